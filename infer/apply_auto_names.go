@@ -0,0 +1,79 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infer
+
+import (
+	"reflect"
+	"unicode/utf8"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/internal/introspect"
+)
+
+// applyAutoNames returns a copy of the new inputs of req with every unset field of I
+// annotated with [Annotator.AutoName] set to a name. The name is taken from the old
+// inputs of req when they hold one, so that a resource keeps its name across updates.
+func applyAutoNames[I any](req p.CheckRequest, tags introspect.TagOptions) (resource.PropertyMap, error) {
+	t := typeFor[I]()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return req.News, nil
+	}
+	autoNames := getAnnotated(t).AutoNames
+	if len(autoNames) == 0 {
+		return req.News, nil
+	}
+
+	news := req.News.Copy()
+	for _, field := range reflect.VisibleFields(t) {
+		tag, err := introspect.ParseTag(field, tags)
+		if err != nil || tag.Internal {
+			continue
+		}
+		maxLen, ok := autoNames[tag.Name]
+		if !ok {
+			continue
+		}
+		key := resource.PropertyKey(tag.Name)
+		if v, ok := news[key]; ok && !v.IsNull() {
+			continue
+		}
+		if v, ok := req.Olds[key]; ok && !v.IsNull() && !v.ContainsUnknowns() {
+			news[key] = v
+			continue
+		}
+		name, err := autoName(req.Urn.Name(), req.RandomSeed, maxLen)
+		if err != nil {
+			return nil, err
+		}
+		news[key] = resource.NewStringProperty(name)
+	}
+	return news, nil
+}
+
+// autoName returns the logical name of a resource followed by a dash and a random suffix
+// derived from seed. The logical name is shortened to keep the name within maxLen.
+func autoName(logicalName string, seed []byte, maxLen int) (string, error) {
+	prefixLen := maxLen - introspect.AutoNameSuffixLen - 1
+	for len(logicalName) > prefixLen {
+		_, size := utf8.DecodeLastRuneInString(logicalName)
+		logicalName = logicalName[:len(logicalName)-size]
+	}
+	return resource.NewUniqueName(seed, logicalName+"-", introspect.AutoNameSuffixLen, maxLen, nil)
+}
@@ -0,0 +1,90 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infer
+
+import (
+	"reflect"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+
+	"github.com/pulumi/pulumi-go-provider/internal/introspect"
+)
+
+// applyConstants returns a copy of inputs with every field annotated with
+// [Annotator.SetConst] set to its constant value, including fields of nested objects.
+func applyConstants[I any](inputs resource.PropertyMap, tags introspect.TagOptions) resource.PropertyMap {
+	return withConstants(typeFor[I](), resource.NewObjectProperty(inputs), tags).ObjectValue()
+}
+
+func withConstants(t reflect.Type, p resource.PropertyValue, tags introspect.TagOptions) resource.PropertyValue {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case p.IsSecret():
+		return resource.MakeSecret(withConstants(t, p.SecretValue().Element, tags))
+	case p.IsOutput():
+		output := p.OutputValue()
+		output.Element = withConstants(t, output.Element, tags)
+		return resource.NewOutputProperty(output)
+	}
+
+	// If the shape of p does not match t, we return p as is and leave it to decoding to
+	// report the mismatch.
+	switch t.Kind() {
+	case reflect.Struct:
+		if !p.IsObject() {
+			return p
+		}
+		consts := getAnnotated(t).Consts
+		obj := p.ObjectValue().Copy()
+		for _, field := range reflect.VisibleFields(t) {
+			tag, err := introspect.ParseTag(field, tags)
+			if err != nil || tag.Internal {
+				continue
+			}
+			key := resource.PropertyKey(tag.Name)
+			if value, ok := consts[tag.Name]; ok {
+				obj[key] = resource.NewPropertyValue(value)
+				continue
+			}
+			if v, ok := obj[key]; ok {
+				obj[key] = withConstants(field.Type, v, tags)
+			}
+		}
+		return resource.NewObjectProperty(obj)
+	case reflect.Slice, reflect.Array:
+		if !p.IsArray() || len(p.ArrayValue()) == 0 {
+			return p
+		}
+		arr := make([]resource.PropertyValue, len(p.ArrayValue()))
+		for i, v := range p.ArrayValue() {
+			arr[i] = withConstants(t.Elem(), v, tags)
+		}
+		return resource.NewArrayProperty(arr)
+	case reflect.Map:
+		if !p.IsObject() || len(p.ObjectValue()) == 0 {
+			return p
+		}
+		obj := make(resource.PropertyMap, len(p.ObjectValue()))
+		for k, v := range p.ObjectValue() {
+			obj[k] = withConstants(t.Elem(), v, tags)
+		}
+		return resource.NewObjectProperty(obj)
+	default:
+		return p
+	}
+}
@@ -15,6 +15,7 @@
 package infer
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"reflect"
@@ -27,8 +28,14 @@ import (
 
 // The object that controls default application.
 type defaultsWalker struct {
+	// ctx is passed to the functions set with [Annotator.SetDefaultFunc].
+	ctx context.Context
+
 	// seen is the stack of types that defaultsWalker has descended into.
 	seen []reflect.Type
+
+	// tags holds the options that the tags of the walked types are parsed with.
+	tags introspect.TagOptions
 }
 
 // Mark that we are visiting a type.
@@ -62,8 +69,9 @@ func (d *defaultsWalker) apply(v reflect.Value) (bool, error) {
 	a := getAnnotated(t)
 	fields := map[string]reflect.Value{}
 	optional := map[string]bool{}
+	var names []string
 	for _, field := range reflect.VisibleFields(v.Type()) {
-		tag, err := introspect.ParseTag(field)
+		tag, err := introspect.ParseTag(field, d.tags)
 		if err != nil {
 			return false, err
 		}
@@ -73,6 +81,7 @@ func (d *defaultsWalker) apply(v reflect.Value) (bool, error) {
 
 		optional[tag.Name] = tag.Optional
 		fields[tag.Name] = v.FieldByIndex(field.Index)
+		names = append(names, tag.Name)
 	}
 
 	// We not apply the defaults we calculated:
@@ -117,6 +126,26 @@ defaultEnvs:
 		didSet = true
 	}
 
+	// Default functions are a fallback for fields that are still unset. They are called
+	// in field order, so each function sees the defaults of the fields before it.
+	for _, k := range names {
+		f, ok := a.DefaultFuncs[k]
+		if !ok || !fields[k].IsZero() {
+			continue
+		}
+		defaultValue, err := f(d.ctx, v.Interface())
+		if err != nil {
+			return false, fmt.Errorf("computing default for %q: %w", k, err)
+		}
+		if defaultValue == nil {
+			continue
+		}
+		if err := setDefaultFromMemory(fields[k], defaultValue); err != nil {
+			return false, err
+		}
+		didSet = true
+	}
+
 	// Default values only apply to primitive types, but this struct could have fields
 	// that itself has default values. We need to traverse those.
 	//
@@ -273,11 +302,11 @@ func (d *defaultsWalker) walk(v reflect.Value) (didSet bool, _ error) {
 }
 
 // applyDefaults recursively applies the default values provided by [introspect.Annotator].
-func applyDefaults[T any](value *T) error {
+func applyDefaults[T any](ctx context.Context, value *T) error {
 	v := reflect.ValueOf(value).Elem()
 	contract.Assertf(v.CanSet(), "Cannot accept an un-editable pointer")
 
-	var walker defaultsWalker
+	walker := defaultsWalker{ctx: ctx, tags: tagOptions(ctx)}
 	_, err := walker.walk(v)
 	return err
 }
@@ -0,0 +1,115 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infer
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+
+	"github.com/pulumi/pulumi-go-provider/internal/introspect"
+)
+
+// normalizeEnums returns a copy of inputs where the value of every enum annotated with
+// [Annotator.SetEnumCaseInsensitive] is replaced by the allowed value it matches
+// regardless of case, including enums in nested objects and the keys of maps.
+func normalizeEnums[I any](inputs resource.PropertyMap, tags introspect.TagOptions) resource.PropertyMap {
+	return withCanonicalEnums(typeFor[I](), resource.NewObjectProperty(inputs), tags).ObjectValue()
+}
+
+func withCanonicalEnums(t reflect.Type, p resource.PropertyValue, tags introspect.TagOptions) resource.PropertyValue {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case p.IsSecret():
+		return resource.MakeSecret(withCanonicalEnums(t, p.SecretValue().Element, tags))
+	case p.IsOutput():
+		output := p.OutputValue()
+		output.Element = withCanonicalEnums(t, output.Element, tags)
+		return resource.NewOutputProperty(output)
+	}
+
+	if e, ok := isEnum(t); ok {
+		if !e.caseInsensitive || !p.IsString() {
+			return p
+		}
+		return resource.NewStringProperty(e.canonical(p.StringValue()))
+	}
+
+	// If the shape of p does not match t, we return p as is and leave it to decoding to
+	// report the mismatch.
+	switch t.Kind() {
+	case reflect.Struct:
+		if !p.IsObject() {
+			return p
+		}
+		obj := p.ObjectValue().Copy()
+		for _, field := range reflect.VisibleFields(t) {
+			tag, err := introspect.ParseTag(field, tags)
+			if err != nil || tag.Internal {
+				continue
+			}
+			key := resource.PropertyKey(tag.Name)
+			if v, ok := obj[key]; ok {
+				obj[key] = withCanonicalEnums(field.Type, v, tags)
+			}
+		}
+		return resource.NewObjectProperty(obj)
+	case reflect.Slice, reflect.Array:
+		if !p.IsArray() || len(p.ArrayValue()) == 0 {
+			return p
+		}
+		arr := make([]resource.PropertyValue, len(p.ArrayValue()))
+		for i, v := range p.ArrayValue() {
+			arr[i] = withCanonicalEnums(t.Elem(), v, tags)
+		}
+		return resource.NewArrayProperty(arr)
+	case reflect.Map:
+		if !p.IsObject() || len(p.ObjectValue()) == 0 {
+			return p
+		}
+		keyEnum, keyIsEnum := isEnum(t.Key())
+		obj := make(resource.PropertyMap, len(p.ObjectValue()))
+		for k, v := range p.ObjectValue() {
+			if keyIsEnum && keyEnum.caseInsensitive {
+				k = resource.PropertyKey(keyEnum.canonical(string(k)))
+			}
+			obj[k] = withCanonicalEnums(t.Elem(), v, tags)
+		}
+		return resource.NewObjectProperty(obj)
+	default:
+		return p
+	}
+}
+
+// canonical returns the allowed value of e that s matches regardless of case. An exact
+// match is preferred. If s matches no allowed value, it is returned as is.
+func (e enum) canonical(s string) string {
+	match := s
+	for _, v := range e.values {
+		allowed, ok := v.Value.(string)
+		switch {
+		case !ok:
+		case allowed == s:
+			return s
+		case match == s && strings.EqualFold(allowed, s):
+			match = allowed
+		}
+	}
+	return match
+}
@@ -0,0 +1,126 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infer
+
+import (
+	"os"
+	"reflect"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+
+	"github.com/pulumi/pulumi-go-provider/internal/introspect"
+)
+
+// applyEnvDefaults returns a copy of inputs where every required field that is missing,
+// and that has a default read from environment variables with [Annotator.SetDefault], is
+// set to the value of the first of them that is set, including fields of nested objects.
+//
+// Other defaults are applied after inputs are decoded, but a missing required field would
+// fail decoding. The engine only fills these fields from the environment when it runs
+// the provider as a plugin, so they are filled here for providers that run embedded too.
+func applyEnvDefaults[I any](inputs resource.PropertyMap, tags introspect.TagOptions) resource.PropertyMap {
+	return withEnvDefaults(typeFor[I](), resource.NewObjectProperty(inputs), tags).ObjectValue()
+}
+
+func withEnvDefaults(t reflect.Type, p resource.PropertyValue, tags introspect.TagOptions) resource.PropertyValue {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case p.IsSecret():
+		return resource.MakeSecret(withEnvDefaults(t, p.SecretValue().Element, tags))
+	case p.IsOutput():
+		output := p.OutputValue()
+		output.Element = withEnvDefaults(t, output.Element, tags)
+		return resource.NewOutputProperty(output)
+	}
+
+	// If the shape of p does not match t, we return p as is and leave it to decoding to
+	// report the mismatch.
+	switch t.Kind() {
+	case reflect.Struct:
+		if !p.IsObject() {
+			return p
+		}
+		envs := getAnnotated(t).DefaultEnvs
+		obj := p.ObjectValue().Copy()
+		for _, field := range reflect.VisibleFields(t) {
+			tag, err := introspect.ParseTag(field, tags)
+			if err != nil || tag.Internal {
+				continue
+			}
+			key := resource.PropertyKey(tag.Name)
+			if v, ok := obj[key]; ok && !v.IsNull() {
+				obj[key] = withEnvDefaults(field.Type, v, tags)
+				continue
+			}
+			if tag.Optional {
+				continue
+			}
+			if v, ok := envDefault(field.Type, envs[tag.Name]); ok {
+				obj[key] = v
+			}
+		}
+		return resource.NewObjectProperty(obj)
+	case reflect.Slice, reflect.Array:
+		if !p.IsArray() || len(p.ArrayValue()) == 0 {
+			return p
+		}
+		arr := make([]resource.PropertyValue, len(p.ArrayValue()))
+		for i, v := range p.ArrayValue() {
+			arr[i] = withEnvDefaults(t.Elem(), v, tags)
+		}
+		return resource.NewArrayProperty(arr)
+	case reflect.Map:
+		if !p.IsObject() || len(p.ObjectValue()) == 0 {
+			return p
+		}
+		obj := make(resource.PropertyMap, len(p.ObjectValue()))
+		for k, v := range p.ObjectValue() {
+			obj[k] = withEnvDefaults(t.Elem(), v, tags)
+		}
+		return resource.NewObjectProperty(obj)
+	default:
+		return p
+	}
+}
+
+// envDefault returns the value of the first of envs that is set, as a property value of
+// type t. Values that can't be parsed as t are left to be reported when defaults are
+// applied.
+func envDefault(t reflect.Type, envs []string) (resource.PropertyValue, bool) {
+	for _, env := range envs {
+		value := os.Getenv(env)
+		if value == "" {
+			continue
+		}
+		v, err := parseScalar(t, value)
+		return v, err == nil
+	}
+	return resource.PropertyValue{}, false
+}
+
+// parseScalar parses value as a property value of the scalar type t, such as an int.
+func parseScalar(t reflect.Type, value string) (resource.PropertyValue, error) {
+	v := reflect.New(t).Elem()
+	if err := setDefaultValueFromEnv(v, value); err != nil {
+		return resource.PropertyValue{}, err
+	}
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	return resource.NewPropertyValue(v.Interface()), nil
+}
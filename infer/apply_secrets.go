@@ -25,8 +25,8 @@ import (
 	"github.com/pulumi/pulumi-go-provider/internal/putil"
 )
 
-func applySecrets[I any](inputs resource.PropertyMap) resource.PropertyMap {
-	var walker secretsWalker
+func applySecrets[I any](inputs resource.PropertyMap, tags introspect.TagOptions) resource.PropertyMap {
+	walker := secretsWalker{tags: tags}
 	result := walker.walk(typeFor[I](), resource.NewProperty(inputs))
 	contract.AssertNoErrorf(errors.Join(walker.errs...),
 		`secretsWalker only produces errors when the type it walks has invalid property tags
@@ -36,7 +36,12 @@ schema generation time already.`)
 }
 
 // The object that controls secrets application.
-type secretsWalker struct{ errs []error }
+type secretsWalker struct {
+	errs []error
+
+	// tags holds the options that the tags of the walked types are parsed with.
+	tags introspect.TagOptions
+}
 
 func (w *secretsWalker) walk(t reflect.Type, p resource.PropertyValue) (out resource.PropertyValue) {
 	// If t is nil, we have no type information, so return.
@@ -48,12 +53,12 @@ func (w *secretsWalker) walk(t reflect.Type, p resource.PropertyValue) (out reso
 
 	if putil.IsSecret(p) {
 		p = putil.MakePublic(p)
-		defer func() { out = putil.MakeSecret(p) }()
+		defer func() { out = putil.MakeSecret(out) }()
 	}
 
 	if putil.IsComputed(p) {
 		p = putil.MakeKnown(p)
-		defer func() { out = putil.MakeComputed(p) }()
+		defer func() { out = putil.MakeComputed(out) }()
 	}
 
 	// Ensure we are working in raw value types for t
@@ -77,7 +82,7 @@ func (w *secretsWalker) walk(t reflect.Type, p resource.PropertyValue) (out reso
 		obj := p.ObjectValue()
 
 		for _, field := range reflect.VisibleFields(t) {
-			info, err := introspect.ParseTag(field)
+			info, err := introspect.ParseTag(field, w.tags)
 			if err != nil {
 				w.errs = append(w.errs, err)
 				continue
@@ -17,6 +17,7 @@ package infer
 import (
 	"context"
 	"fmt"
+	"reflect"
 
 	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
@@ -35,9 +36,30 @@ type ComponentResource[I any, O pulumi.ComponentResource] interface {
 	//
 	// ctx.RegisterResource needs to be called, but ctx.RegisterOutputs does not need to
 	// be called.
+	//
+	// Fields of inputs with input types, such as `pulumi.StringInput`, hold outputs that
+	// keep the dependencies, unknownness and secretness of the values they were given.
 	Construct(ctx *pulumi.Context, name, typ string, inputs I, opts pulumi.ResourceOption) (O, error)
 }
 
+// ComponentDependencies describes a component resource with the dataflow between its
+// inputs (`I`) and outputs (`O`) specified.
+//
+// Plain valued inputs, such as `string` instead of `pulumi.StringInput`, lose their
+// dependencies and unknownness before Construct is called. If a component implements
+// ComponentDependencies, each output that DependsOn an input takes on the dependencies,
+// unknownness and secretness of that input. Only DependsOn is used for components.
+//
+// For example:
+//
+//	func (*Site) WireDependencies(f infer.FieldSelector, args *SiteArgs, state *Site) {
+//		f.OutputField(&state.Url).DependsOn(f.InputField(&args.Domain))
+//	}
+type ComponentDependencies[I any, O pulumi.ComponentResource] interface {
+	// WireDependencies specifies the dependencies between inputs and outputs.
+	WireDependencies(f FieldSelector, args *I, state O)
+}
+
 // InferredComponent is a component resource inferred from code.
 //
 // To create an [InferredComponent], call the [Component] function.
@@ -46,6 +68,14 @@ type InferredComponent interface {
 	schema.Resource
 
 	isInferredComponent()
+	// collectTokens records the tokens used by the component. See [Options.Validate].
+	collectTokens(add addToken, tags introspect.TagOptions)
+	// anyFields describes the fields of the component that are described as any. See
+	// [anyFields].
+	anyFields(tags introspect.TagOptions) []string
+	// getSchema is GetSchema with the options that the tags of the component's types are
+	// parsed with. See [Options.PointersAreOptional].
+	getSchema(reg schema.RegisterDerivativeType, tags introspect.TagOptions) (pschema.ResourceSpec, error)
 }
 
 func (derivedComponentController[R, I, O]) isInferredComponent() {}
@@ -61,14 +91,20 @@ type derivedComponentController[R ComponentResource[I, O], I any, O pulumi.Compo
 
 func (rc *derivedComponentController[R, I, O]) GetSchema(reg schema.RegisterDerivativeType) (
 	pschema.ResourceSpec, error) {
-	r, err := getResourceSchema[R, I, O](true)
+	return rc.getSchema(reg, introspect.TagOptions{})
+}
+
+func (rc *derivedComponentController[R, I, O]) getSchema(
+	reg schema.RegisterDerivativeType, tags introspect.TagOptions,
+) (pschema.ResourceSpec, error) {
+	r, err := getResourceSchema[R, I, O](true, tags)
 	if err := err.ErrorOrNil(); err != nil {
 		return pschema.ResourceSpec{}, err
 	}
-	if err := registerTypes[I](reg); err != nil {
+	if err := registerTypes[I](reg, tags); err != nil {
 		return pschema.ResourceSpec{}, err
 	}
-	if err := registerTypes[O](reg); err != nil {
+	if err := registerTypes[O](reg, tags); err != nil {
 		return pschema.ResourceSpec{}, err
 	}
 	return r, nil
@@ -78,9 +114,20 @@ func (rc *derivedComponentController[R, I, O]) GetToken() (tokens.Type, error) {
 	return getToken[R](nil)
 }
 
+func (rc *derivedComponentController[R, I, O]) collectTokens(add addToken, tags introspect.TagOptions) {
+	collectElementToken[R](add, resourceToken, nil)
+	collectTypeTokens[I](add, tags)
+	collectTypeTokens[O](add, tags)
+}
+
+func (*derivedComponentController[R, I, O]) anyFields(tags introspect.TagOptions) []string {
+	return append(anyFields[I](tags), anyFields[O](tags)...)
+}
+
 func (rc *derivedComponentController[R, I, O]) Construct(
 	ctx context.Context, req p.ConstructRequest,
 ) (p.ConstructResponse, error) {
+	tags := tagOptions(ctx)
 	return req.Construct(ctx,
 		func(
 			ctx *pulumi.Context, inputs pprovider.ConstructInputs, opts pulumi.ResourceOption,
@@ -101,12 +148,128 @@ func (rc *derivedComponentController[R, I, O]) Construct(
 				return nil, err
 			}
 
-			// Register the outputs
-			m := introspect.StructToMap(res)
-			err = ctx.RegisterResourceOutputs(res, pulumi.ToMap(m))
+			if wire, ok := ((interface{})(r)).(ComponentDependencies[I, O]); ok {
+				inputMap, err := inputs.Map()
+				if err != nil {
+					return nil, err
+				}
+				err = wireComponentDependencies(inputMap, &i, res, tags, func(f FieldSelector) {
+					wire.WireDependencies(f, &i, res)
+				})
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			outputs, err := componentOutputs(res, tags)
+			if err != nil {
+				return nil, err
+			}
+			err = ctx.RegisterResourceOutputs(res, outputs)
 			if err != nil {
 				return nil, err
 			}
 			return res, err
 		})
 }
+
+// componentOutputs returns the fields of state to register as the outputs of the component,
+// keyed by their property names.
+//
+// Fields marked secret are registered as secrets. When such a field holds a [pulumi.Output],
+// it is also replaced on state with a secret output, so that it is secret in the response
+// to Construct.
+func componentOutputs(state pulumi.ComponentResource, tags introspect.TagOptions) (pulumi.Map, error) {
+	v := reflect.ValueOf(state)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("component %T must be a pointer to a struct", state)
+	}
+	v = v.Elem()
+	outputs := map[string]any{}
+	for _, f := range reflect.VisibleFields(v.Type()) {
+		tag, err := introspect.ParseTag(f, tags)
+		if err != nil {
+			return nil, err
+		}
+		if tag.Internal {
+			continue
+		}
+		fieldValue := v.FieldByIndex(f.Index)
+		value := fieldValue.Interface()
+		if tag.Secret {
+			secret := pulumi.ToSecret(value)
+			if _, ok := value.(pulumi.Output); ok && reflect.TypeOf(secret).AssignableTo(fieldValue.Type()) {
+				fieldValue.Set(reflect.ValueOf(secret))
+			}
+			value = secret
+		}
+		outputs[tag.Name] = value
+	}
+	return pulumi.ToMap(outputs), nil
+}
+
+// wireComponentDependencies replaces each output field of state that depends on inputs, as
+// specified by wire, with an output that also depends on those inputs.
+func wireComponentDependencies(
+	inputs pulumi.Map, args any, state pulumi.ComponentResource, tags introspect.TagOptions,
+	wire func(FieldSelector),
+) error {
+	fg := newFieldGenerator(args, state, tags)
+	wire(fg)
+	if err := fg.err.ErrorOrNil(); err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(state)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("component %T must be a pointer to a struct", state)
+	}
+	v = v.Elem()
+	for _, f := range reflect.VisibleFields(v.Type()) {
+		tag, err := introspect.ParseTag(f, tags)
+		if err != nil || tag.Internal || !f.IsExported() {
+			continue
+		}
+		field, ok := fg.fields[tag.Name]
+		if !ok || len(field.deps) == 0 {
+			continue
+		}
+		fieldValue := v.FieldByIndex(f.Index)
+		out, ok := fieldValue.Interface().(pulumi.Output)
+		if !ok {
+			return fmt.Errorf("cannot wire dependencies of %q: %s is not a pulumi.Output",
+				tag.Name, fieldValue.Type())
+		}
+		deps := make([]interface{}, 0, len(field.deps))
+		for _, d := range field.deps {
+			if input, ok := inputs[d.name]; ok {
+				deps = append(deps, input)
+			}
+		}
+		wired := reflect.ValueOf(withDependencies(out, deps))
+		if !wired.Type().AssignableTo(fieldValue.Type()) {
+			return fmt.Errorf("cannot wire dependencies of %q: %s is not assignable to %s",
+				tag.Name, wired.Type(), fieldValue.Type())
+		}
+		fieldValue.Set(wired)
+	}
+	return nil
+}
+
+// withDependencies returns an output with the same type and value as out, that also
+// depends on deps.
+func withDependencies(out pulumi.Output, deps []interface{}) pulumi.Output {
+	if len(deps) == 0 {
+		return out
+	}
+	elem := out.ElementType()
+	first := reflect.FuncOf([]reflect.Type{reflect.TypeOf([]interface{}{})}, []reflect.Type{elem}, false)
+	fn := reflect.MakeFunc(first, func(args []reflect.Value) []reflect.Value {
+		v := args[0].Index(0).Elem()
+		if !v.IsValid() {
+			return []reflect.Value{reflect.Zero(elem)}
+		}
+		return []reflect.Value{v}
+	})
+	return pulumi.All(append([]interface{}{out}, deps...)...).ApplyT(fn.Interface())
+}
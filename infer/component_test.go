@@ -0,0 +1,107 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infer
+
+import (
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi/internals"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi-go-provider/internal/introspect"
+)
+
+type siteArgs struct {
+	Domain string `pulumi:"domain"`
+	Port   int    `pulumi:"port"`
+}
+
+type site struct {
+	pulumi.ResourceState
+
+	URL    pulumi.StringOutput `pulumi:"url"`
+	Status pulumi.StringOutput `pulumi:"status"`
+}
+
+type componentMocks struct{}
+
+func (componentMocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	return args.Name + "-id", args.Inputs, nil
+}
+
+func (componentMocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	return args.Args, nil
+}
+
+func TestWireComponentDependencies(t *testing.T) {
+	t.Parallel()
+
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		// dns stands in for the resource that the domain input was computed from.
+		dns := &site{}
+		err := ctx.RegisterComponentResource("test:index:Dns", "dns", dns)
+		require.NoError(t, err)
+		domain := dns.URN().ApplyT(func(pulumi.URN) string { return "example.com" }).(pulumi.StringOutput)
+
+		args := siteArgs{Domain: "example.com", Port: 80}
+		state := &site{
+			URL:    pulumi.String("https://example.com").ToStringOutput(),
+			Status: pulumi.String("ok").ToStringOutput(),
+		}
+		inputs := pulumi.Map{"domain": domain, "port": pulumi.Int(80)}
+		err = wireComponentDependencies(inputs, &args, state, introspect.TagOptions{}, func(f FieldSelector) {
+			f.OutputField(&state.URL).DependsOn(f.InputField(&args.Domain))
+		})
+		require.NoError(t, err)
+
+		url, err := internals.UnsafeAwaitOutput(ctx.Context(), state.URL)
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com", url.Value)
+		assert.True(t, url.Known)
+		assert.Equal(t, []pulumi.Resource{dns}, url.Dependencies)
+
+		status, err := internals.UnsafeAwaitOutput(ctx.Context(), state.Status)
+		require.NoError(t, err)
+		assert.Equal(t, "ok", status.Value)
+		assert.Empty(t, status.Dependencies)
+
+		// Unknown and secret inputs flow to the outputs that depend on them.
+		state = &site{
+			URL:    pulumi.String("https://example.com").ToStringOutput(),
+			Status: pulumi.String("ok").ToStringOutput(),
+		}
+		inputs = pulumi.Map{"domain": pulumi.UnsafeUnknownOutput(nil), "port": pulumi.ToSecret(pulumi.Int(80))}
+		err = wireComponentDependencies(inputs, &args, state, introspect.TagOptions{}, func(f FieldSelector) {
+			f.OutputField(&state.URL).DependsOn(f.InputField(&args.Domain))
+			f.OutputField(&state.Status).DependsOn(f.InputField(&args.Port))
+		})
+		require.NoError(t, err)
+
+		url, err = internals.UnsafeAwaitOutput(ctx.Context(), state.URL)
+		require.NoError(t, err)
+		assert.False(t, url.Known)
+
+		status, err = internals.UnsafeAwaitOutput(ctx.Context(), state.Status)
+		require.NoError(t, err)
+		assert.True(t, status.Known)
+		assert.True(t, status.Secret)
+		assert.Equal(t, "ok", status.Value)
+		return nil
+	}, pulumi.WithMocks("proj", "stack", componentMocks{}))
+	require.NoError(t, err)
+}
@@ -0,0 +1,111 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFile lets users of a provider set its config from a JSON or YAML file, instead of
+// setting each key in the stack configuration. It is embedded in the type passed to
+// [Config]:
+//
+//	type Config struct {
+//		infer.ConfigFile
+//		Endpoint string `pulumi:"endpoint"`
+//		Region   string `pulumi:"region,optional"`
+//	}
+//
+// This adds a configFile property to the config. When it is set, the top level keys of
+// the file it names are read into the config before it is checked and configured. Keys
+// set in the stack configuration take precedence over keys of the file. Files with a
+// ".json" extension are read as JSON, and other files as YAML.
+type ConfigFile struct {
+	// Path is the path of the config file.
+	Path string `pulumi:"configFile,optional"`
+}
+
+// Annotate describes the configFile property.
+func (c *ConfigFile) Annotate(a Annotator) {
+	a.Describe(&c.Path, "The path of a JSON or YAML file to read the configuration from. "+
+		"Keys set in the configuration take precedence over keys of the file.")
+}
+
+const configFileKey resource.PropertyKey = "configFile"
+
+// withConfigFile returns inputs merged with the config file named by their configFile
+// property, if T embeds [ConfigFile]. Keys set in inputs take precedence.
+func withConfigFile[T any](inputs resource.PropertyMap) (resource.PropertyMap, error) {
+	if !embedsConfigFile(typeFor[T]()) {
+		return inputs, nil
+	}
+	path := inputs[configFileKey]
+	for path.IsSecret() || path.IsOutput() && path.OutputValue().Known {
+		if path.IsSecret() {
+			path = path.SecretValue().Element
+		} else {
+			path = path.OutputValue().Element
+		}
+	}
+	if !path.IsString() || path.StringValue() == "" {
+		return inputs, nil
+	}
+
+	b, err := os.ReadFile(path.StringValue())
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]any
+	if strings.EqualFold(filepath.Ext(path.StringValue()), ".json") {
+		err = json.Unmarshal(b, &values)
+	} else {
+		err = yaml.Unmarshal(b, &values)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path.StringValue(), err)
+	}
+
+	merged := resource.NewPropertyMapFromMap(values)
+	for k, v := range inputs {
+		merged[k] = v
+	}
+	return merged, nil
+}
+
+var configFileType = reflect.TypeOf(ConfigFile{})
+
+// embedsConfigFile reports whether t is a struct that embeds [ConfigFile].
+func embedsConfigFile(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for _, f := range reflect.VisibleFields(t) {
+		if f.Anonymous && f.Type == configFileType {
+			return true
+		}
+	}
+	return false
+}
@@ -18,13 +18,16 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
 
 	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/mapper"
 
 	p "github.com/pulumi/pulumi-go-provider"
 	"github.com/pulumi/pulumi-go-provider/infer/internal/ende"
+	"github.com/pulumi/pulumi-go-provider/internal/introspect"
 	"github.com/pulumi/pulumi-go-provider/middleware/schema"
 )
 
@@ -33,7 +36,22 @@ import (
 // `T` has the same properties as an input or output type for a custom resource, and is
 // responsive to the same interfaces.
 //
-// `T` can implement [CustomDiff] and [CustomCheck] and [CustomConfigure] and [Annotated].
+// `T` can implement [CustomDiff] and [CustomCheck] and [CustomConfigure] and
+// [CustomValidate] and [CustomConfigChanged] and [Annotated].
+//
+// Fields of `T` support the same tags and annotations as resource inputs. For example, a
+// field tagged `provider:"secret"` and annotated with a default from the environment
+//
+//	type Config struct {
+//		Token string `pulumi:"apiToken,optional" provider:"secret"`
+//	}
+//
+//	func (c *Config) Annotate(a infer.Annotator) {
+//		a.SetDefault(&c.Token, nil, "MYPKG_TOKEN")
+//	}
+//
+// is marked secret in the schema and is read from MYPKG_TOKEN when not set in the stack
+// configuration. Its checked value is always secret, so it is stored encrypted.
 func Config[T any]() InferredConfig {
 	return &config[T]{}
 }
@@ -44,12 +62,42 @@ type InferredConfig interface {
 	checkConfig(ctx context.Context, req p.CheckRequest) (p.CheckResponse, error)
 	diffConfig(ctx context.Context, req p.DiffRequest) (p.DiffResponse, error)
 	configure(ctx context.Context, req p.ConfigureRequest) error
+	// collectTokens records the tokens used by the config. See [Options.Validate].
+	collectTokens(add addToken, tags introspect.TagOptions)
+	// getSchema is GetSchema with the options that the tags of the config's types are
+	// parsed with. See [Options.PointersAreOptional].
+	getSchema(reg schema.RegisterDerivativeType, tags introspect.TagOptions) (pschema.ResourceSpec, error)
+	// optionalValues describes the optional fields of the config that are not pointers.
+	// See [Options.CheckOptionalValues].
+	optionalValues(tags introspect.TagOptions) []string
+	// anyFields describes the fields of the config that are described as any. See
+	// [anyFields].
+	anyFields(tags introspect.TagOptions) []string
 }
 
 // CustomConfigure describes a provider that requires custom configuration before running.
 //
 // This interface should be implemented by reference to allow setting private fields on
-// its receiver.
+// its receiver. Configure is where clients are built, so that they are built once and
+// shared by every resource and function through [GetConfig]:
+//
+//	type Config struct {
+//		Endpoint string `pulumi:"endpoint"`
+//
+//		client *api.Client
+//	}
+//
+//	func (c *Config) Configure(ctx context.Context) error {
+//		client, err := api.NewClient(c.Endpoint)
+//		c.client = client
+//		return err
+//	}
+//
+//	func (*Bucket) Create(ctx context.Context, name string, args BucketArgs, preview bool) (
+//		string, BucketState, error) {
+//		client := infer.GetConfig[Config](ctx).client
+//		...
+//	}
 type CustomConfigure interface {
 	// Configure the provider.
 	//
@@ -62,6 +110,48 @@ type CustomConfigure interface {
 	Configure(ctx context.Context) error
 }
 
+// CustomValidate describes a config that checks its values beyond what their types and
+// annotations describe, such as an endpoint that must be a valid URL.
+//
+// Validate is called when the config is checked, on the config decoded from the checked
+// inputs, and the failures it returns are reported as check failures of the config. It
+// is called again before [CustomConfigure.Configure], which is then not called if
+// Validate returns any failures.
+type CustomValidate interface {
+	Validate(ctx context.Context) []p.CheckFailure
+}
+
+// validate returns the failures of v, if it or a pointer to it implements [CustomValidate].
+func validate(ctx context.Context, v any) []p.CheckFailure {
+	if v, ok := v.(CustomValidate); ok {
+		return v.Validate(ctx)
+	}
+	if rv := reflect.ValueOf(v); rv.IsValid() && rv.Kind() != reflect.Pointer {
+		ptr := reflect.New(rv.Type())
+		ptr.Elem().Set(rv)
+		if v, ok := ptr.Interface().(CustomValidate); ok {
+			return v.Validate(ctx)
+		}
+	}
+	return nil
+}
+
+// CustomConfigChanged describes a config that is told when its values change between
+// deployments, such as when the region of a provider is changed in the stack config.
+//
+// ConfigChanged is called when the config is diffed and the diff has changes, with the
+// config decoded from the old and new inputs of the provider. It isn't called while the
+// new inputs hold unknown values. An error fails the diff.
+//
+// The resources of a provider are not diffed again when its config changes. A resource
+// that depends on a config value can opt into being replaced when the value changes by
+// tagging the config field `provider:"replaceOnChanges"`, which replaces the provider and
+// with it every resource it manages. A resource can instead compare the config returned
+// by [GetConfig] with its state in [CustomDiff] to decide for itself.
+type CustomConfigChanged[T any] interface {
+	ConfigChanged(ctx context.Context, olds, news T) error
+}
+
 type config[T any] struct{ t *T }
 
 func (*config[T]) underlyingType() reflect.Type {
@@ -70,11 +160,26 @@ func (*config[T]) underlyingType() reflect.Type {
 }
 
 func (*config[T]) GetToken() (tokens.Type, error) { return "pulumi:providers:pkg", nil }
-func (*config[T]) GetSchema(reg schema.RegisterDerivativeType) (pschema.ResourceSpec, error) {
-	if err := registerTypes[T](reg); err != nil {
+
+func (*config[T]) collectTokens(add addToken, tags introspect.TagOptions) {
+	collectTypeTokens[T](add, tags)
+}
+
+func (*config[T]) optionalValues(tags introspect.TagOptions) []string { return optionalValues[T](tags) }
+
+func (*config[T]) anyFields(tags introspect.TagOptions) []string { return anyFields[T](tags) }
+
+func (c *config[T]) GetSchema(reg schema.RegisterDerivativeType) (pschema.ResourceSpec, error) {
+	return c.getSchema(reg, introspect.TagOptions{})
+}
+
+func (*config[T]) getSchema(
+	reg schema.RegisterDerivativeType, tags introspect.TagOptions,
+) (pschema.ResourceSpec, error) {
+	if err := registerTypes[T](reg, tags); err != nil {
 		return pschema.ResourceSpec{}, err
 	}
-	r, errs := getResourceSchema[T, T, T](false)
+	r, errs := getResourceSchema[T, T, T](false, tags)
 	return r, errs.ErrorOrNil()
 }
 
@@ -84,7 +189,16 @@ func (c *config[T]) checkConfig(ctx context.Context, req p.CheckRequest) (p.Chec
 		t = reflect.New(v.Type().Elem()).Interface().(T)
 	}
 
-	encoder, decodeError := ende.DecodeConfig(req.News, &t)
+	tags := tagOptions(ctx)
+	merged, err := withConfigFile[T](req.News)
+	if err != nil {
+		return p.CheckResponse{
+			Inputs:   req.News,
+			Failures: []p.CheckFailure{{Property: string(configFileKey), Reason: err.Error()}},
+		}, nil
+	}
+	req.News = applyEnvDefaults[T](applyConstants[T](merged, tags), tags)
+	encoder, decodeError := ende.DecodeConfig(req.News, &t, tags)
 	if t, ok := ((interface{})(t)).(CustomCheck[T]); ok {
 		// The user implemented check manually, so call that.
 		//
@@ -106,6 +220,9 @@ func (c *config[T]) checkConfig(ctx context.Context, req p.CheckRequest) (p.Chec
 		if err != nil {
 			return p.CheckResponse{}, err
 		}
+		if len(failures) == 0 {
+			failures = validate(ctx, i)
+		}
 		return p.CheckResponse{
 			Inputs:   inputs,
 			Failures: failures,
@@ -116,11 +233,15 @@ func (c *config[T]) checkConfig(ctx context.Context, req p.CheckRequest) (p.Chec
 	if err != nil {
 		return p.CheckResponse{}, err
 	}
+	failures = withRequiredCheckFailures(typeFor[T](), req.News, failures, tags)
 
-	err = applyDefaults(&t)
+	err = applyDefaults(ctx, &t)
 	if err != nil {
 		return p.CheckResponse{}, err
 	}
+	if len(failures) == 0 {
+		failures = validate(ctx, t)
+	}
 
 	news, err := encoder.Encode(t)
 	if err != nil {
@@ -128,21 +249,72 @@ func (c *config[T]) checkConfig(ctx context.Context, req p.CheckRequest) (p.Chec
 	}
 
 	return p.CheckResponse{
-		Inputs:   applySecrets[T](news),
+		Inputs:   applySecrets[T](news, tags),
 		Failures: failures,
 	}, nil
 }
 
 func (c *config[T]) diffConfig(ctx context.Context, req p.DiffRequest) (p.DiffResponse, error) {
 	c.ensure()
-	return diff[T, T, T](ctx, req, c.t, func(string) bool { return true })
+	resp, err := diff[T, T, T](ctx, req, c.t, func(string) bool { return true })
+	if err != nil || !resp.HasChanges || req.News.ContainsUnknowns() {
+		return resp, err
+	}
+	return resp, configChanged[T](ctx, req.Olds, req.News)
+}
+
+// configChanged calls [CustomConfigChanged.ConfigChanged] on the config decoded from news,
+// if T implements it.
+func configChanged[T any](ctx context.Context, olds, news resource.PropertyMap) error {
+	// The hook is found on the type, so the configs are only decoded when it exists.
+	if !reflect.PointerTo(typeFor[T]()).Implements(typeFor[CustomConfigChanged[T]]()) {
+		return nil
+	}
+	decode := func(m resource.PropertyMap) (T, error) {
+		var t T
+		if v := reflect.ValueOf(t); v.Kind() == reflect.Pointer && v.IsNil() {
+			t = reflect.New(v.Type().Elem()).Interface().(T)
+		}
+		_, err := ende.DecodeConfig(m, &t, tagOptions(ctx))
+		if err != nil {
+			return t, err
+		}
+		return t, nil
+	}
+	n, err := decode(news)
+	if err != nil {
+		return err
+	}
+	o, err := decode(olds)
+	if err != nil {
+		return err
+	}
+	return ((interface{})(&n)).(CustomConfigChanged[T]).ConfigChanged(ctx, o, n)
 }
 
 func (c *config[T]) configure(ctx context.Context, req p.ConfigureRequest) error {
 	c.ensure()
-	_, err := ende.DecodeConfig(req.Args, c.t)
+	args, err := withConfigFile[T](req.Args)
 	if err != nil {
-		return c.handleConfigFailures(ctx, err)
+		return err
+	}
+	tags := tagOptions(ctx)
+	_, mErr := ende.DecodeConfig(applyEnvDefaults[T](args, tags), c.t, tags)
+	if mErr != nil {
+		return c.handleConfigFailures(ctx, mErr)
+	}
+	// The config is usually checked before it is configured, but providers that run
+	// embedded may be configured with unchecked values, so defaults are applied again.
+	if err = applyDefaults(ctx, c.t); err != nil {
+		return err
+	}
+
+	if failures := validate(ctx, *c.t); len(failures) > 0 {
+		reasons := make([]string, len(failures))
+		for i, f := range failures {
+			reasons[i] = fmt.Sprintf("%s: %s", f.Property, f.Reason)
+		}
+		return fmt.Errorf("invalid configuration: %s", strings.Join(reasons, "; "))
 	}
 
 	// If we have a custom configure command, call that and return the error if any.
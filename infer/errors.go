@@ -16,6 +16,7 @@ package infer
 
 import (
 	"fmt"
+	"strings"
 )
 
 // ResourceInitFailedError indicates that the resource was created but failed to initialize.
@@ -51,12 +52,51 @@ import (
 // If the the above example errors with [infer.ResourceInitFailedError], the next Update
 // will be called with `state` equal to what was returned alongside
 // [infer.ResourceInitFailedError].
+//
+// To keep the error that Create failed with, return a [PartialError] instead.
 type ResourceInitFailedError struct {
 	Reasons []string
 }
 
 func (err ResourceInitFailedError) Error() string { return "resource failed to initialize" }
 
+// PartialError indicates that Create allocated the resource before failing with Err.
+//
+// Returned by Create alongside the ID of the resource and the state that is known,
+// PartialError records the resource as created with errors, just like a
+// [ResourceInitFailedError] with the message of Err as its reason. The next call will be
+// Update with the returned state, so that the resource isn't leaked.
+//
+//	func (*Server) Create(
+//		ctx context.Context, name string, input ServerArgs, preview bool,
+//	) (string, ServerState, error) {
+//		id, err := GetConfig[Config](ctx).Client.CreateServer(ctx, input.Size)
+//		if err != nil {
+//			return "", ServerState{}, err
+//		}
+//		state := ServerState{ServerArgs: input}
+//		if err := GetConfig[Config](ctx).Client.WaitReady(ctx, id); err != nil {
+//			return id, state, infer.PartialError{Err: err}
+//		}
+//		state.Ready = true
+//		return id, state, nil
+//	}
+//
+// Errors returned by Create alongside an ID that don't wrap PartialError fail the
+// operation without recording the resource.
+type PartialError struct {
+	Err error
+}
+
+func (err PartialError) Error() string {
+	if err.Err == nil {
+		return "resource partially created"
+	}
+	return err.Err.Error()
+}
+
+func (err PartialError) Unwrap() error { return err.Err }
+
 // ProviderError indicates a bug in the provider implementation.
 //
 // When displayed, ProviderError tells the user that the issue was internal and should be
@@ -82,3 +122,59 @@ func (err ProviderError) Error() string {
 	}
 	return prefix + ": " + err.Inner.Error() + suffix
 }
+
+// ResourceError describes an error reported by the API that manages a resource, with the
+// details the API returned alongside it. Returning a ResourceError from the methods of a
+// resource shows its details to the user with the error:
+//
+//	func (*Bucket) Create(
+//		ctx context.Context, name string, inputs BucketArgs, preview bool,
+//	) (string, BucketState, error) {
+//		bucket, err := client.CreateBucket(ctx, inputs.Name)
+//		if apiErr := (api.Error{}); errors.As(err, &apiErr) {
+//			return "", BucketState{}, infer.ResourceError{
+//				Message:   apiErr.Message,
+//				Code:      apiErr.Code,
+//				RequestID: apiErr.RequestID,
+//				HelpURL:   "https://example.com/docs/errors#" + apiErr.Code,
+//			}
+//		}
+//		...
+//	}
+//
+// is shown as
+//
+//	bucket already exists (code: BucketAlreadyExists, request ID: 4f1c8a2); see
+//	https://example.com/docs/errors#BucketAlreadyExists
+//
+// Every field but Message is optional. A ResourceError wrapped with [fmt.Errorf] keeps its
+// details, and can still be found with [errors.As].
+type ResourceError struct {
+	// The message of the error.
+	Message string
+	// The code the API identifies the error with.
+	Code string
+	// The ID of the API request that failed, which the API's maintainers can use to find
+	// the request.
+	RequestID string
+	// A link to documentation that helps the user resolve the error.
+	HelpURL string
+}
+
+func (err ResourceError) Error() string {
+	var details []string
+	if err.Code != "" {
+		details = append(details, "code: "+err.Code)
+	}
+	if err.RequestID != "" {
+		details = append(details, "request ID: "+err.RequestID)
+	}
+	msg := err.Message
+	if len(details) > 0 {
+		msg += " (" + strings.Join(details, ", ") + ")"
+	}
+	if err.HelpURL != "" {
+		msg += "; see " + err.HelpURL
+	}
+	return msg
+}
@@ -24,7 +24,7 @@ import (
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
 
 	p "github.com/pulumi/pulumi-go-provider"
-	"github.com/pulumi/pulumi-go-provider/infer/internal/ende"
+	"github.com/pulumi/pulumi-go-provider/internal/introspect"
 	t "github.com/pulumi/pulumi-go-provider/middleware"
 	"github.com/pulumi/pulumi-go-provider/middleware/schema"
 )
@@ -43,9 +43,27 @@ type InferredFunction interface {
 	schema.Function
 
 	isInferredFunction()
+	// collectTokens records the tokens used by the function. See [Options.Validate].
+	collectTokens(add addToken, tags introspect.TagOptions)
+	// anyFields describes the fields of the function that are described as any. See
+	// [anyFields].
+	anyFields(tags introspect.TagOptions) []string
+	// getSchema is GetSchema with the options that the tags of the function's types are
+	// parsed with. See [Options.PointersAreOptional].
+	getSchema(reg schema.RegisterDerivativeType, tags introspect.TagOptions) (pschema.FunctionSpec, error)
 }
 
 // Function infers a function from `F`, which maps `I` to `O`.
+//
+// The arguments of an invoke are checked like the inputs of a resource that doesn't
+// implement [CustomCheck]: defaults are applied, and missing required fields and values
+// that don't match their annotations are reported as failures of the invoke.
+//
+// Functions are invoked during `pulumi preview` as well as during `pulumi up`, and an
+// invoke isn't told which one it is part of. Neither the schema nor the invoke protocol
+// can mark a function as having side effects, so a function that writes should be safe
+// to call more than once with the same inputs. An operation that must not run during a
+// preview is better modeled as a resource, whose Create is told when it is a preview.
 func Function[F Fn[I, O], I, O any]() InferredFunction {
 	return &derivedInvokeController[F, I, O]{}
 }
@@ -66,6 +84,16 @@ func (*derivedInvokeController[F, I, O]) GetToken() (tokens.Type, error) {
 	return getToken[F](fnToken)
 }
 
+func (*derivedInvokeController[F, I, O]) collectTokens(add addToken, tags introspect.TagOptions) {
+	collectElementToken[F](add, functionToken, fnToken)
+	collectTypeTokens[I](add, tags)
+	collectTypeTokens[O](add, tags)
+}
+
+func (*derivedInvokeController[F, I, O]) anyFields(tags introspect.TagOptions) []string {
+	return append(anyFields[I](tags), anyFields[O](tags)...)
+}
+
 func fnToken(tk tokens.Type) tokens.Type {
 	name := []rune(tk.Name().String())
 	for i, r := range name {
@@ -79,23 +107,29 @@ func fnToken(tk tokens.Type) tokens.Type {
 	return tokens.NewTypeToken(tk.Module(), tokens.TypeName(name))
 }
 
-func (*derivedInvokeController[F, I, O]) GetSchema(reg schema.RegisterDerivativeType) (pschema.FunctionSpec, error) {
+func (r *derivedInvokeController[F, I, O]) GetSchema(reg schema.RegisterDerivativeType) (pschema.FunctionSpec, error) {
+	return r.getSchema(reg, introspect.TagOptions{})
+}
+
+func (*derivedInvokeController[F, I, O]) getSchema(
+	reg schema.RegisterDerivativeType, tags introspect.TagOptions,
+) (pschema.FunctionSpec, error) {
 	var f F
 	descriptions := getAnnotated(reflect.TypeOf(f))
 
-	input, err := objectSchema(reflect.TypeOf(new(I)))
+	input, err := objectSchema(reflect.TypeOf(new(I)), tags)
 	if err != nil {
 		return pschema.FunctionSpec{}, err
 	}
-	output, err := objectSchema(reflect.TypeOf(new(O)))
+	output, err := objectSchema(reflect.TypeOf(new(O)), tags)
 	if err != nil {
 		return pschema.FunctionSpec{}, err
 	}
 
-	if err := registerTypes[I](reg); err != nil {
+	if err := registerTypes[I](reg, tags); err != nil {
 		return pschema.FunctionSpec{}, err
 	}
-	if err := registerTypes[O](reg); err != nil {
+	if err := registerTypes[O](reg, tags); err != nil {
 		return pschema.FunctionSpec{}, err
 	}
 
@@ -106,9 +140,9 @@ func (*derivedInvokeController[F, I, O]) GetSchema(reg schema.RegisterDerivative
 	}, nil
 }
 
-func objectSchema(t reflect.Type) (*pschema.ObjectTypeSpec, error) {
+func objectSchema(t reflect.Type, tags introspect.TagOptions) (*pschema.ObjectTypeSpec, error) {
 	descriptions := getAnnotated(t)
-	props, required, err := propertyListFromType(t, false)
+	props, required, err := propertyListFromType(t, false, tags)
 	if err != nil {
 		return nil, fmt.Errorf("could not serialize input type %s: %w", t, err)
 	}
@@ -124,20 +158,20 @@ func objectSchema(t reflect.Type) (*pschema.ObjectTypeSpec, error) {
 }
 
 func (r *derivedInvokeController[F, I, O]) Invoke(ctx context.Context, req p.InvokeRequest) (p.InvokeResponse, error) {
-	encoder, i, mapErr := ende.Decode[I](req.Args)
-	mapFailures, err := checkFailureFromMapError(mapErr)
+	// Arguments are validated like the inputs of a resource without a custom Check.
+	tags := tagOptions(ctx)
+	encoder, i, failures, err := decodeCheckingMapErrors[I](req.Args, tags)
 	if err != nil {
 		return p.InvokeResponse{}, err
 	}
-	if len(mapFailures) > 0 {
+	if len(failures) > 0 {
 		return p.InvokeResponse{
-			Failures: mapFailures,
+			Failures: failures,
 		}, nil
 	}
 
-	err = applyDefaults(&i)
-	if err != nil {
-		return p.InvokeResponse{}, fmt.Errorf("unable to apply defaults: %w", err)
+	if i, err = defaultCheck(ctx, i); err != nil {
+		return p.InvokeResponse{}, err
 	}
 
 	var f F
@@ -154,6 +188,6 @@ func (r *derivedInvokeController[F, I, O]) Invoke(ctx context.Context, req p.Inv
 		return p.InvokeResponse{}, err
 	}
 	return p.InvokeResponse{
-		Return: applySecrets[O](m),
+		Return: applySecrets[O](m, tags),
 	}, nil
 }
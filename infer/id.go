@@ -0,0 +1,99 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infer
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+
+	"github.com/pulumi/pulumi-go-provider/internal/introspect"
+	"github.com/pulumi/pulumi-go-provider/internal/key"
+)
+
+// NewID returns a new random ID, for resources that don't get an ID from the service they
+// manage.
+//
+// By default, the ID is 16 random hex characters. Tests can make IDs deterministic with
+// [WithIDGenerator], or with [github.com/pulumi/pulumi-go-provider/integration.WithIDGenerator].
+func NewID(ctx context.Context) string {
+	if gen, ok := ctx.Value(key.IDGenerator).(func() string); ok {
+		return gen()
+	}
+	id, err := resource.NewUniqueHex("", 16, 0)
+	contract.AssertNoErrorf(err, "generating a random ID")
+	return id
+}
+
+// WithIDGenerator returns a copy of ctx where [NewID] returns the result of gen.
+func WithIDGenerator(ctx context.Context, gen func() string) context.Context {
+	return context.WithValue(ctx, key.IDGenerator, gen)
+}
+
+// idField returns the field of state that holds the ID of the resource, as set with
+// [Annotator.SetID]. ok is false when no field is set, and err is not nil when the field
+// is not a string.
+func idField[O any](state *O, tags introspect.TagOptions) (field reflect.Value, ok bool, err error) {
+	t := typeFor[O]()
+	name := getAnnotated(t).IDField
+	if name == "" {
+		return reflect.Value{}, false, nil
+	}
+	v := reflect.ValueOf(state).Elem()
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return reflect.Value{}, false, nil
+		}
+		v = v.Elem()
+	}
+	f, _, ok := fieldByTagName(v.Type(), name, tags)
+	if !ok {
+		return reflect.Value{}, false, nil
+	}
+	if f.Type.Kind() != reflect.String {
+		return reflect.Value{}, false, fmt.Errorf("the ID field %q of %s must be a string, not a %s", name, t, f.Type)
+	}
+	field, err = v.FieldByIndexErr(f.Index)
+	return field, err == nil, err
+}
+
+// resolveID returns the ID of a resource whose state was returned with id by op. When the
+// output type of the resource has an ID field, the ID is taken from the field if id is
+// empty, and must match it otherwise.
+func resolveID[O any](op, id string, state O, tags introspect.TagOptions) (string, error) {
+	field, ok, err := idField(&state, tags)
+	if err != nil || !ok || field.String() == "" {
+		return id, err
+	}
+	if id != "" && id != field.String() {
+		return id, fmt.Errorf("%s returned the ID %q, but the ID field of the resource holds %q",
+			op, id, field.String())
+	}
+	return field.String(), nil
+}
+
+// withID sets the ID field of state to id, if the output type of the resource has one and
+// it is empty.
+func withID[O any](state *O, id string, tags introspect.TagOptions) error {
+	field, ok, err := idField(state, tags)
+	if err != nil || !ok || field.String() != "" || !field.CanSet() {
+		return err
+	}
+	field.SetString(id)
+	return nil
+}
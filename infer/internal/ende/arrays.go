@@ -0,0 +1,198 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ende
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/pulumi/pulumi-go-provider/internal/introspect"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/mapper"
+)
+
+// decodeArrays replaces each array in v that is decoded into a fixed-size array of t with
+// a value of that array type, since the mapper can only decode arrays into slices.
+func (e *ende) decodeArrays(md mapper.Mapper, v any, t reflect.Type, path resource.PropertyPath) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch v := v.(type) {
+	case []any:
+		switch t.Kind() {
+		case reflect.Array:
+			return e.decodeArray(md, v, t, path)
+		case reflect.Slice:
+			for i, el := range v {
+				v[i] = e.decodeArrays(md, el, t.Elem(), append(path, i))
+			}
+		}
+	case map[string]any:
+		switch t.Kind() {
+		case reflect.Map:
+			for k, el := range v {
+				v[k] = e.decodeArrays(md, el, t.Elem(), append(path, k))
+			}
+		case reflect.Struct:
+			for _, f := range reflect.VisibleFields(t) {
+				tag, err := introspect.ParseTag(f, e.tags)
+				if err != nil || tag.Internal {
+					continue
+				}
+				if el, ok := v[tag.Name]; ok {
+					v[tag.Name] = e.decodeArrays(md, el, f.Type, append(path, tag.Name))
+				}
+			}
+		}
+	}
+	return v
+}
+
+// decodeArray decodes the elements of v into a value of the array type t. An array of any
+// other length than t is reported as an error, and decodes into the zero value of t.
+func (e *ende) decodeArray(md mapper.Mapper, v []any, t reflect.Type, path resource.PropertyPath) any {
+	arr := reflect.New(t).Elem()
+	if len(v) != t.Len() {
+		e.errs = append(e.errs, mapper.NewFieldError(t.String(), path.String(),
+			fmt.Errorf("expected %d elements, found %d", t.Len(), len(v))))
+		return arr.Interface()
+	}
+	for i, el := range v {
+		path := append(path, i)
+		key := path.String()
+		el = e.decodeArrays(md, el, t.Elem(), path)
+		err := md.DecodeValue(map[string]any{key: el}, t, key, arr.Index(i).Addr().Interface(), true)
+		if err != nil {
+			e.errs = append(e.errs, err)
+		}
+	}
+	return arr.Interface()
+}
+
+// sliceArrays returns v as a value of slicedType(v.Type()), for the mapper to encode. v is
+// returned as is when it holds no fixed-size arrays.
+func sliceArrays(v reflect.Value) reflect.Value {
+	t := slicedType(v.Type(), map[reflect.Type]bool{})
+	if t == v.Type() {
+		return v
+	}
+	return convertSliced(v, t)
+}
+
+// slicedType returns t with each fixed-size array type in it replaced by a slice type,
+// since the mapper can't encode arrays. A struct that holds an array is replaced by a
+// struct of its exported fields, including those of its embedded structs, which the
+// mapper encodes the same way.
+func slicedType(t reflect.Type, visiting map[reflect.Type]bool) reflect.Type {
+	switch t.Kind() {
+	case reflect.Array:
+		return reflect.SliceOf(slicedType(t.Elem(), visiting))
+	case reflect.Slice:
+		if elem := slicedType(t.Elem(), visiting); elem != t.Elem() {
+			return reflect.SliceOf(elem)
+		}
+	case reflect.Map:
+		if elem := slicedType(t.Elem(), visiting); elem != t.Elem() {
+			return reflect.MapOf(t.Key(), elem)
+		}
+	case reflect.Pointer:
+		if elem := slicedType(t.Elem(), visiting); elem != t.Elem() {
+			return reflect.PointerTo(elem)
+		}
+	case reflect.Struct:
+		if visiting[t] {
+			return t
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		var fields []reflect.StructField
+		changed := false
+		for _, f := range encodedFields(t) {
+			if !f.IsExported() {
+				changed = true
+				continue
+			}
+			sliced := slicedType(f.Type, visiting)
+			changed = changed || sliced != f.Type
+			fields = append(fields, reflect.StructField{Name: f.Name, Type: sliced, Tag: f.Tag})
+		}
+		if changed {
+			return reflect.StructOf(fields)
+		}
+	}
+	return t
+}
+
+// encodedFields returns the fields of the struct type t that the mapper encodes: its own
+// fields, and those of the structs it embeds.
+func encodedFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		switch {
+		case !f.Anonymous:
+			fields = append(fields, f)
+		case f.Type.Kind() == reflect.Struct:
+			fields = append(fields, encodedFields(f.Type)...)
+		}
+	}
+	return fields
+}
+
+// convertSliced copies v into a value of t, a type returned by slicedType for the type of
+// v.
+func convertSliced(v reflect.Value, t reflect.Type) reflect.Value {
+	if v.Type() == t {
+		return v
+	}
+	switch v.Kind() {
+	case reflect.Array, reflect.Slice:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return reflect.Zero(t)
+		}
+		s := reflect.MakeSlice(t, v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			s.Index(i).Set(convertSliced(v.Index(i), t.Elem()))
+		}
+		return s
+	case reflect.Map:
+		if v.IsNil() {
+			return reflect.Zero(t)
+		}
+		m := reflect.MakeMapWithSize(t, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m.SetMapIndex(iter.Key(), convertSliced(iter.Value(), t.Elem()))
+		}
+		return m
+	case reflect.Pointer:
+		if v.IsNil() {
+			return reflect.Zero(t)
+		}
+		p := reflect.New(t.Elem())
+		p.Elem().Set(convertSliced(v.Elem(), t.Elem()))
+		return p
+	case reflect.Struct:
+		s := reflect.New(t).Elem()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			s.Field(i).Set(convertSliced(v.FieldByName(f.Name), f.Type))
+		}
+		return s
+	}
+	return v
+}
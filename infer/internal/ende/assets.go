@@ -0,0 +1,135 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ende
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/archive"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/asset"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/sig"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/mapper"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+func isSDKAssetType(t reflect.Type) bool {
+	_, ok := sdkAssetDecoders[t]
+	return ok
+}
+
+// serializedAsset converts an asset or archive into its serialized object form.
+func serializedAsset(v resource.PropertyValue) resource.PropertyValue {
+	var obj map[string]any
+	if v.IsAsset() {
+		obj = v.AssetValue().Serialize()
+	} else {
+		obj = v.ArchiveValue().Serialize()
+	}
+	return resource.NewObjectProperty(resource.NewPropertyMapFromMap(obj))
+}
+
+// sdkAssetDecoders decode serialized assets and archives into the Pulumi Go SDK's
+// pulumi.Asset, pulumi.Archive and pulumi.AssetOrArchive interfaces.
+var sdkAssetDecoders = mapper.Decoders{
+	reflect.TypeOf((*pulumi.Asset)(nil)).Elem(): func(_ mapper.Mapper, obj map[string]any) (any, error) {
+		return decodeSDKAsset(obj)
+	},
+	reflect.TypeOf((*pulumi.Archive)(nil)).Elem(): func(_ mapper.Mapper, obj map[string]any) (any, error) {
+		return decodeSDKArchive(obj)
+	},
+	reflect.TypeOf((*pulumi.AssetOrArchive)(nil)).Elem(): func(_ mapper.Mapper, obj map[string]any) (any, error) {
+		if obj[sig.Key] == sig.ArchiveSig {
+			return decodeSDKArchive(obj)
+		}
+		return decodeSDKAsset(obj)
+	},
+}
+
+func decodeSDKAsset(obj map[string]any) (pulumi.Asset, error) {
+	a, ok, err := asset.Deserialize(obj)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("expected an asset")
+	}
+	return toSDKAsset(a), nil
+}
+
+func decodeSDKArchive(obj map[string]any) (pulumi.Archive, error) {
+	a, ok, err := archive.Deserialize(obj)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("expected an archive")
+	}
+	return toSDKArchive(a), nil
+}
+
+func toSDKAsset(a *asset.Asset) pulumi.Asset {
+	switch {
+	case a.IsPath():
+		return pulumi.NewFileAsset(a.Path)
+	case a.IsURI():
+		return pulumi.NewRemoteAsset(a.URI)
+	default:
+		return pulumi.NewStringAsset(a.Text)
+	}
+}
+
+func toSDKArchive(a *archive.Archive) pulumi.Archive {
+	switch {
+	case a.IsAssets():
+		assets := make(map[string]any, len(a.Assets))
+		for k, v := range a.Assets {
+			switch v := v.(type) {
+			case *asset.Asset:
+				assets[k] = toSDKAsset(v)
+			case *archive.Archive:
+				assets[k] = toSDKArchive(v)
+			}
+		}
+		return pulumi.NewAssetArchive(assets)
+	case a.IsPath():
+		return pulumi.NewFileArchive(a.Path)
+	default:
+		return pulumi.NewRemoteArchive(a.URI)
+	}
+}
+
+// fromSDKAssetOrArchive converts a pulumi.Asset or pulumi.Archive into the equivalent
+// *asset.Asset or *archive.Archive.
+func fromSDKAssetOrArchive(v pulumi.AssetOrArchive) any {
+	switch v := v.(type) {
+	case pulumi.Asset:
+		return &asset.Asset{Sig: sig.AssetSig, Path: v.Path(), Text: v.Text(), URI: v.URI()}
+	case pulumi.Archive:
+		var assets map[string]any
+		if src := v.Assets(); src != nil {
+			assets = make(map[string]any, len(src))
+			for k, a := range src {
+				if a, ok := a.(pulumi.AssetOrArchive); ok {
+					assets[k] = fromSDKAssetOrArchive(a)
+				}
+			}
+		}
+		return &archive.Archive{Sig: sig.ArchiveSig, Assets: assets, Path: v.Path(), URI: v.URI()}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ende
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/mapper"
+)
+
+var (
+	bigIntType   = reflect.TypeOf(big.Int{})
+	bigFloatType = reflect.TypeOf(big.Float{})
+)
+
+// isBigNumber reports whether t is big.Int or big.Float, which are represented as
+// decimal strings so that they keep their precision.
+func isBigNumber(t reflect.Type) bool {
+	return t == bigIntType || t == bigFloatType
+}
+
+// walkBigNumber parses the decimal string that represents a value of typ, a big.Int or a
+// big.Float. Like the values of walkUnmarshaled, the parsed value is held by e and the
+// mapper is passed an object that names it.
+func (e *ende) walkBigNumber(
+	v resource.PropertyValue, path resource.PropertyPath, typ reflect.Type, alignTypes bool,
+) resource.PropertyValue {
+	if v.IsNull() {
+		if alignTypes {
+			return resource.NewObjectProperty(resource.PropertyMap{})
+		}
+		return v
+	}
+	var s string
+	switch {
+	case v.IsString():
+		s = v.StringValue()
+	case v.IsNumber():
+		// Numbers are accepted too, but they have already lost any precision beyond
+		// that of a float64.
+		s = strconv.FormatFloat(v.NumberValue(), 'f', -1, 64)
+	default:
+		err := fmt.Errorf("expected a decimal string, found %s", v.TypeString())
+		e.errs = append(e.errs, mapper.NewFieldError(typ.String(), path.String(), err))
+		return resource.NewObjectProperty(resource.PropertyMap{})
+	}
+	n, err := parseBigNumber(s, typ)
+	if err != nil {
+		e.errs = append(e.errs, mapper.NewFieldError(typ.String(), path.String(), err))
+		return resource.NewObjectProperty(resource.PropertyMap{})
+	}
+	e.unmarshaled = append(e.unmarshaled, n)
+	return resource.NewObjectProperty(resource.PropertyMap{
+		unmarshaledKey: resource.NewNumberProperty(float64(len(e.unmarshaled) - 1)),
+	})
+}
+
+// parseBigNumber parses s as a value of typ, a big.Int or a big.Float.
+//
+// A big.Float is given enough precision to hold every digit of s, so that it is encoded
+// back into the same number.
+func parseBigNumber(s string, typ reflect.Type) (reflect.Value, error) {
+	if typ == bigIntType {
+		n, ok := new(big.Int).SetString(s, 10)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("%q is not a decimal integer", s)
+		}
+		return reflect.ValueOf(n).Elem(), nil
+	}
+	// Each decimal digit takes less than 4 bits.
+	prec := max(uint(len(s))*4, 64)
+	n, _, err := big.ParseFloat(s, 10, prec, big.ToNearestEven)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("%q is not a decimal number", s)
+	}
+	return reflect.ValueOf(n).Elem(), nil
+}
+
+// encodeBigNumber returns the decimal string of v, a big.Int or a big.Float. A big.Float
+// is written with the fewest digits that parse back into it, and without an exponent.
+func encodeBigNumber(v reflect.Value) string {
+	if !v.CanAddr() {
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		v = ptr.Elem()
+	}
+	switch n := v.Addr().Interface().(type) {
+	case *big.Int:
+		return n.String()
+	case *big.Float:
+		return n.Text('f', -1)
+	default:
+		panic(fmt.Sprintf("encodeBigNumber: unexpected type %s", v.Type()))
+	}
+}
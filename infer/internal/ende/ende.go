@@ -16,7 +16,10 @@
 package ende
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"reflect"
+	"time"
 
 	"github.com/pulumi/pulumi-go-provider/infer/types"
 	"github.com/pulumi/pulumi-go-provider/internal/introspect"
@@ -26,6 +29,7 @@ import (
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/sig"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/mapper"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
 // AssetSignature is a unique key for use for assets in the AssetOrArchive union type.
@@ -49,42 +53,123 @@ type Encoder struct{ *ende }
 //
 //	encoder, value, _ := Decode(m)
 //	m, _ = encoder.Encode(value)
-func Decode[T any](m resource.PropertyMap) (Encoder, T, mapper.MappingError) {
+func Decode[T any](m resource.PropertyMap, opts introspect.TagOptions) (Encoder, T, mapper.MappingError) {
 	var dst T
-	enc, err := decode(m, &dst, false, false)
+	enc, err := decode(m, &dst, false, false, opts)
 	return enc, dst, err
 }
 
 // DecodeTolerateMissing is like Decode, but doesn't return an error for a missing value.
-func DecodeTolerateMissing[T any](m resource.PropertyMap, dst T) (Encoder, mapper.MappingError) {
-	return decode(m, dst, false, true)
+func DecodeTolerateMissing[T any](
+	m resource.PropertyMap, dst T, opts introspect.TagOptions,
+) (Encoder, mapper.MappingError) {
+	return decode(m, dst, false, true, opts)
 }
 
-func DecodeConfig[T any](m resource.PropertyMap, dst T) (Encoder, mapper.MappingError) {
-	return decode(m, dst, true, false)
+func DecodeConfig[T any](m resource.PropertyMap, dst T, opts introspect.TagOptions) (Encoder, mapper.MappingError) {
+	return decode(m, dst, true, false, opts)
 }
 
+// optionalTags are the `pulumi` tag options that mark a field as not required. Computed
+// fields are set by the provider, so they are never required as inputs.
+var optionalTags = []string{"omitempty", "optional", "computed"}
+
 func decode(
-	m resource.PropertyMap, dst any, ignoreUnrecognized, allowMissing bool,
+	m resource.PropertyMap, dst any, ignoreUnrecognized, allowMissing bool, opts introspect.TagOptions,
 ) (Encoder, mapper.MappingError) {
-	e := new(ende)
+	e := &ende{tags: opts}
 	target := reflect.ValueOf(dst)
 	for target.Type().Kind() == reflect.Pointer && !target.IsNil() {
 		target = target.Elem()
 	}
 	m = e.simplify(m, target.Type())
-	return Encoder{e}, mapper.New(&mapper.Opts{
+	d := decoders(target.Type())
+	e.addUnmarshalDecoders(target.Type(), d, map[reflect.Type]struct{}{})
+	// When some pointer fields are optional without being tagged optional, missing fields
+	// are reported by missingFields instead of the mapper.
+	checkMissing := !allowMissing && opts.PointersOptional &&
+		e.hasOptionalPointers(target.Type(), map[reflect.Type]bool{})
+	md := mapper.New(&mapper.Opts{
 		IgnoreUnrecognized: ignoreUnrecognized,
-		IgnoreMissing:      allowMissing,
-	}).Decode(m.Mappable(), target.Addr().Interface())
+		IgnoreMissing:      allowMissing || checkMissing,
+		OptionalTags:       optionalTags,
+		CustomDecoders:     d,
+	})
+	obj := e.decodeArrays(md, e.withTypedNils(m.Mappable(), target.Type()), target.Type(), resource.PropertyPath{})
+	if checkMissing {
+		e.missingFields(obj, target.Type(), resource.PropertyPath{})
+	}
+	err := md.Decode(obj.(map[string]any), target.Addr().Interface())
+	if len(e.errs) > 0 {
+		errs := e.errs
+		if err != nil {
+			errs = append(errs, err.Failures()...)
+		}
+		err = mapper.NewMappingError(errs)
+	}
+	return Encoder{e}, err
 }
 
-func DecodeAny(m resource.PropertyMap, dst any) (Encoder, mapper.MappingError) {
-	return decode(m, dst, false, false)
+// withTypedNils replaces each null element of a slice or map of pointers in v, which is
+// decoded into t, with a nil pointer of the element type. The mapper can't assign an
+// untyped nil to a pointer element.
+func (e *ende) withTypedNils(v any, t reflect.Type) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	elem := func(el any, t reflect.Type) any {
+		if el == nil && t.Kind() == reflect.Pointer {
+			return reflect.Zero(t).Interface()
+		}
+		return e.withTypedNils(el, t)
+	}
+	switch v := v.(type) {
+	case []any:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i, el := range v {
+				v[i] = elem(el, t.Elem())
+			}
+		}
+	case map[string]any:
+		switch t.Kind() {
+		case reflect.Map:
+			for k, el := range v {
+				v[k] = elem(el, t.Elem())
+			}
+		case reflect.Struct:
+			for _, f := range reflect.VisibleFields(t) {
+				tag, err := introspect.ParseTag(f, e.tags)
+				if err != nil || tag.Internal {
+					continue
+				}
+				if el, ok := v[tag.Name]; ok {
+					v[tag.Name] = e.withTypedNils(el, f.Type)
+				}
+			}
+		}
+	}
+	return v
+}
+
+func DecodeAny(m resource.PropertyMap, dst any, opts introspect.TagOptions) (Encoder, mapper.MappingError) {
+	return decode(m, dst, false, false, opts)
 }
 
 // An ENcoder DEcoder.
-type ende struct{ changes []change }
+type ende struct {
+	changes []change
+
+	// tags holds the options that the tags of the decoded types are parsed with.
+	tags introspect.TagOptions
+
+	// errs holds errors found while simplifying values that the mapper would not be
+	// able to describe, such as malformed duration strings.
+	errs []error
+
+	// unmarshaled holds the values decoded by their UnmarshalProperty method or by
+	// walkBigNumber, for the decoders added by addUnmarshalDecoders.
+	unmarshaled []reflect.Value
+}
 
 type change struct {
 	path        resource.PropertyPath
@@ -186,6 +271,30 @@ func (e *ende) walk(
 		return el
 	}
 
+	if typ == rawMessageType {
+		return e.walkRawMessage(v, path)
+	}
+	if typ == bytesType {
+		return e.walkBytes(v, path, alignTypes)
+	}
+	if typ != nil && typ.Implements(resourceReferenceType) {
+		return walkResourceReference(v, alignTypes)
+	}
+	if typ != nil && isUnmarshaler(typ) {
+		return e.walkUnmarshaled(v, path, typ, alignTypes)
+	}
+	if typ != nil && isBigNumber(typ) {
+		return e.walkBigNumber(v, path, typ, alignTypes)
+	}
+	if typ == durationStringType {
+		return e.walkDuration(v, path, alignTypes)
+	}
+
+	if c, ok := unionCase(v, typ); ok {
+		// Walk union values as the case named by their discriminator.
+		typ = c
+	}
+
 	var elemType reflect.Type
 	if typ != nil {
 		switch typ.Kind() {
@@ -214,6 +323,10 @@ func (e *ende) walk(
 				aa = types.AssetOrArchive{Archive: v.ArchiveValue()}
 			}
 			return resource.NewPropertyValue(aa)
+		case isSDKAssetType(typ) && (v.IsAsset() || v.IsArchive()):
+			// Pass the SDK's asset types to the mapper as objects, so they are
+			// decoded by sdkAssetDecoders.
+			return serializedAsset(v)
 		// This is a scalar value, so we can return it as is.
 		default:
 			return v
@@ -224,8 +337,27 @@ func (e *ende) walk(
 	contract.Assertf(!putil.IsSecret(v), "failed to strip secrets")
 	contract.Assertf(!v.IsOutput(), "failed to strip outputs")
 
+	if typ == timeType {
+		if v.IsString() {
+			return v
+		}
+		return resource.NewStringProperty(time.Time{}.Format(time.RFC3339Nano))
+	}
+
 	switch typ.Kind() {
-	case reflect.Array, reflect.Slice:
+	case reflect.Array:
+		// Unknown values stand in for an array of exactly typ.Len() elements.
+		arr := e.walkArray(v, path, elemType, alignTypes).ArrayValue()
+		aligned := make([]resource.PropertyValue, typ.Len())
+		for i := range aligned {
+			if i < len(arr) {
+				aligned[i] = arr[i]
+			} else {
+				aligned[i] = e.walk(resource.NewNullProperty(), append(path, i), elemType, alignTypes)
+			}
+		}
+		return resource.NewArrayProperty(aligned)
+	case reflect.Slice:
 		return e.walkArray(v, path, elemType, alignTypes)
 	case reflect.Map:
 		return e.walkMap(v, path, elemType, alignTypes)
@@ -235,21 +367,25 @@ func (e *ende) walk(
 			result = v.ObjectValue().Copy()
 		}
 		for _, field := range reflect.VisibleFields(typ) {
-			tag, err := introspect.ParseTag(field)
+			tag, err := introspect.ParseTag(field, e.tags)
 			if err != nil || tag.Internal {
 				continue
 			}
 			pName := resource.PropertyKey(tag.Name)
 			path := append(path, tag.Name)
+			fieldType := field.Type
+			if tag.Duration {
+				fieldType = durationStrings(fieldType)
+			}
 			if vInner, ok := result[pName]; ok {
-				result[pName] = e.walk(vInner, path, field.Type, alignTypes)
+				result[pName] = e.walk(vInner, path, fieldType, alignTypes)
 			} else {
-				if tag.Optional || !alignTypes {
+				if tag.Optional || tag.Computed || !alignTypes {
 					continue
 				}
 				// Create a new unknown output, which we will then type
 				result[pName] = e.walk(resource.NewNullProperty(),
-					path, field.Type, true)
+					path, fieldType, true)
 			}
 		}
 		return resource.NewObjectProperty(result)
@@ -263,7 +399,9 @@ func (e *ende) walk(
 			return v
 		}
 		return resource.NewBoolProperty(false)
-	case reflect.Int, reflect.Int64, reflect.Float32, reflect.Float64:
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
 		if v.IsNumber() {
 			return v
 		}
@@ -273,6 +411,103 @@ func (e *ende) walk(
 	}
 }
 
+// durationString stands in for the time.Duration values of fields tagged with
+// `provider:"duration"`, so that walk can tell them apart from numbers.
+type durationString time.Duration
+
+var durationStringType = reflect.TypeOf(durationString(0))
+
+// durationStrings returns typ, the type of a field tagged with `provider:"duration"`, with
+// each time.Duration it holds replaced by durationString.
+func durationStrings(typ reflect.Type) reflect.Type {
+	switch typ.Kind() {
+	case reflect.Pointer:
+		return reflect.PointerTo(durationStrings(typ.Elem()))
+	case reflect.Slice:
+		return reflect.SliceOf(durationStrings(typ.Elem()))
+	case reflect.Array:
+		return reflect.ArrayOf(typ.Len(), durationStrings(typ.Elem()))
+	case reflect.Map:
+		return reflect.MapOf(typ.Key(), durationStrings(typ.Elem()))
+	default:
+		return durationStringType
+	}
+}
+
+// walkDuration converts a duration string, such as "5m30s", into the number of
+// nanoseconds that the mapper can decode into a time.Duration.
+func (e *ende) walkDuration(
+	v resource.PropertyValue, path resource.PropertyPath, alignTypes bool,
+) resource.PropertyValue {
+	if !v.IsString() {
+		if alignTypes && !v.IsNumber() {
+			return resource.NewNumberProperty(0)
+		}
+		return v
+	}
+	d, err := time.ParseDuration(v.StringValue())
+	if err != nil {
+		e.errs = append(e.errs, mapper.NewFieldError(durationType.String(), path.String(), err))
+		return resource.NewNumberProperty(0)
+	}
+	return resource.NewNumberProperty(float64(d))
+}
+
+// walkRawMessage converts v into its JSON encoding, which the mapper decodes into a
+// json.RawMessage. Secrets nested within v mark the whole value as secret, since they
+// cannot be represented within the raw JSON.
+func (e *ende) walkRawMessage(v resource.PropertyValue, path resource.PropertyPath) resource.PropertyValue {
+	if v.ContainsUnknowns() {
+		e.mark(change{path: path, computed: true})
+		return resource.NewStringProperty("null")
+	}
+	if v.ContainsSecrets() {
+		e.mark(change{path: path, secret: true})
+	}
+	if v.IsNull() {
+		return v
+	}
+	b, err := json.Marshal(plainValue(v))
+	if err != nil {
+		e.errs = append(e.errs, mapper.NewFieldError(rawMessageType.String(), path.String(), err))
+		return resource.NewNullProperty()
+	}
+	return resource.NewStringProperty(string(b))
+}
+
+// walkBytes decodes the base64 string that represents a []byte value. The decoded bytes
+// are passed to the mapper as a string, which it converts into a []byte.
+func (e *ende) walkBytes(
+	v resource.PropertyValue, path resource.PropertyPath, alignTypes bool,
+) resource.PropertyValue {
+	if !v.IsString() {
+		if alignTypes {
+			return resource.NewStringProperty("")
+		}
+		// Arrays of numbers are decoded by the mapper as they always have been.
+		return v
+	}
+	b, err := base64.StdEncoding.DecodeString(v.StringValue())
+	if err != nil {
+		e.errs = append(e.errs, mapper.NewFieldError(bytesType.String(), path.String(), err))
+		return resource.NewStringProperty("")
+	}
+	return resource.NewStringProperty(string(b))
+}
+
+// plainValue returns the mappable form of v, with any secrets removed.
+func plainValue(v resource.PropertyValue) any {
+	return v.MapRepl(nil, func(v resource.PropertyValue) (any, bool) {
+		switch {
+		case v.IsSecret():
+			return plainValue(v.SecretValue().Element), true
+		case v.IsOutput():
+			return plainValue(v.OutputValue().Element), true
+		}
+		return nil, false
+	})
+}
+
 func (e *ende) walkArray(
 	v resource.PropertyValue, path resource.PropertyPath,
 	elemType reflect.Type, alignTypes bool,
@@ -319,13 +554,46 @@ func (e *ende) walkMap(
 	return resource.NewObjectProperty(result)
 }
 
+// walkResourceReference passes a resource reference to the mapper as an object, so it is
+// decoded into a [types.ResourceReference].
+func walkResourceReference(v resource.PropertyValue, alignTypes bool) resource.PropertyValue {
+	if !v.IsResourceReference() {
+		if alignTypes {
+			return resource.NewObjectProperty(resource.PropertyMap{"urn": resource.NewStringProperty("")})
+		}
+		return v
+	}
+	ref := v.ResourceReferenceValue()
+	obj := resource.PropertyMap{"urn": resource.NewStringProperty(string(ref.URN))}
+	if id, ok := ref.IDString(); ok {
+		obj["id"] = resource.NewStringProperty(id)
+	}
+	return resource.NewObjectProperty(obj)
+}
+
 func (e *ende) Encode(src any) (resource.PropertyMap, mapper.MappingError) {
+	mappable := src
+	if src != nil {
+		mappable = sliceArrays(reflect.ValueOf(src)).Interface()
+	}
 	props, err := mapper.New(&mapper.Opts{
 		IgnoreMissing: true,
-	}).Encode(src)
+		OptionalTags:  optionalTags,
+	}).Encode(mappable)
 	if err != nil {
 		return nil, err
 	}
+	if props != nil {
+		var tags introspect.TagOptions
+		if e != nil {
+			tags = e.tags
+		}
+		var errs []error
+		props = encodeScalars(reflect.ValueOf(src), props, tags, &errs).(map[string]any)
+		if len(errs) > 0 {
+			return nil, mapper.NewMappingError(errs)
+		}
+	}
 
 	m := resource.NewPropertyValueRepl(props,
 		nil, // keys are not changed
@@ -367,6 +635,170 @@ const (
 	isEmptyArr = iota
 )
 
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	durationType   = reflect.TypeOf(time.Duration(0))
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	bytesType      = reflect.TypeOf([]byte{})
+
+	resourceReferenceType = reflect.TypeOf((*types.AnyResourceReference)(nil)).Elem()
+)
+
+// encodeScalars rewrites values that the mapper encodes as structs, but which Pulumi
+// represents as scalars. v is the Go value that was encoded into encoded.
+//
+// time.Time values are encoded as RFC 3339 strings, the time.Duration values of fields tagged with
+// `provider:"duration"` are encoded as duration strings and the SDK's pulumi.Asset and
+// pulumi.Archive values are encoded as assets and archives. Union values are encoded with
+// their discriminator. json.RawMessage values are encoded as the JSON value they hold,
+// []byte values are encoded as base64 strings and big.Int and big.Float values are encoded
+// as decimal strings. Values with a MarshalProperty method are encoded as the property
+// value it returns, and its errors are added to errs.
+func encodeScalars(v reflect.Value, encoded any, tags introspect.TagOptions, errs *[]error) any {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return encoded
+		}
+		if !v.CanInterface() {
+			return encoded
+		}
+		if a, ok := v.Interface().(pulumi.AssetOrArchive); ok {
+			return fromSDKAssetOrArchive(a)
+		}
+		if r, ok := v.Interface().(types.AnyResourceReference); ok {
+			return r.Reference()
+		}
+		if u, ok := introspect.GetUnion(v.Type()); ok {
+			return encodeUnion(u, v.Elem(), encoded, tags, errs)
+		}
+		v = v.Elem()
+	}
+	if pv, ok, err := marshalProperty(v); ok {
+		if err != nil {
+			*errs = append(*errs, err)
+			return encoded
+		}
+		return plainValue(pv)
+	}
+	if v.Type() == timeType {
+		return v.Interface().(time.Time).Format(time.RFC3339Nano)
+	}
+	if isBigNumber(v.Type()) {
+		return encodeBigNumber(v)
+	}
+	if v.Type().Implements(resourceReferenceType) && v.CanInterface() {
+		return v.Interface().(types.AnyResourceReference).Reference()
+	}
+	if v.Type() == rawMessageType {
+		return encodeRawMessage(v.Bytes(), encoded)
+	}
+	if v.Type() == bytesType {
+		if v.IsNil() {
+			return encoded
+		}
+		return base64.StdEncoding.EncodeToString(v.Bytes())
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		obj, ok := encoded.(map[string]any)
+		if !ok {
+			return encoded
+		}
+		for _, field := range reflect.VisibleFields(v.Type()) {
+			tag, err := introspect.ParseTag(field, tags)
+			if err != nil || tag.Internal {
+				continue
+			}
+			inner, ok := obj[tag.Name]
+			if !ok {
+				continue
+			}
+			fieldV, err := v.FieldByIndexErr(field.Index)
+			if err != nil {
+				continue
+			}
+			if tag.Duration {
+				obj[tag.Name] = encodeDuration(fieldV, inner)
+				continue
+			}
+			obj[tag.Name] = encodeScalars(fieldV, inner, tags, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		arr, ok := encoded.([]any)
+		if !ok {
+			return encoded
+		}
+		for i := range arr {
+			arr[i] = encodeScalars(v.Index(i), arr[i], tags, errs)
+		}
+	case reflect.Map:
+		obj, ok := encoded.(map[string]any)
+		if !ok {
+			return encoded
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			k := iter.Key().String()
+			if inner, ok := obj[k]; ok {
+				obj[k] = encodeScalars(iter.Value(), inner, tags, errs)
+			}
+		}
+	}
+	return encoded
+}
+
+func encodeRawMessage(raw json.RawMessage, encoded any) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		// The raw message isn't valid JSON, so we leave it as the mapper encoded it.
+		return encoded
+	}
+	return value
+}
+
+// encodeDuration encodes the time.Duration values held by v, the value of a field tagged
+// with `provider:"duration"`, as duration strings.
+func encodeDuration(v reflect.Value, encoded any) any {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return encoded
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		arr, ok := encoded.([]any)
+		if !ok {
+			return encoded
+		}
+		for i := range arr {
+			arr[i] = encodeDuration(v.Index(i), arr[i])
+		}
+		return arr
+	case reflect.Map:
+		obj, ok := encoded.(map[string]any)
+		if !ok {
+			return encoded
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			k := iter.Key().String()
+			if inner, ok := obj[k]; ok {
+				obj[k] = encodeDuration(iter.Value(), inner)
+			}
+		}
+		return obj
+	case reflect.Int64:
+		return time.Duration(v.Int()).String()
+	default:
+		return encoded
+	}
+}
+
 // flattenAssets pulls out assets and archives from AssetOrArchive objects.
 // See #237 for more background.
 // From:
@@ -430,5 +862,5 @@ func (e *ende) AllowUnknown(allowUnknowns bool) Encoder {
 		changes = append(changes, v)
 	}
 
-	return Encoder{&ende{changes}}
+	return Encoder{&ende{changes: changes, tags: e.tags}}
 }
@@ -15,18 +15,27 @@
 package ende
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
 	"reflect"
 	"testing"
+	"time"
 
 	r "github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/archive"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/asset"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/sig"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/mapper"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"pgregory.net/rapid"
 
 	"github.com/pulumi/pulumi-go-provider/infer/types"
+	"github.com/pulumi/pulumi-go-provider/internal/introspect"
 	rType "github.com/pulumi/pulumi-go-provider/internal/rapid/reflect"
 	rResource "github.com/pulumi/pulumi-go-provider/internal/rapid/resource"
 )
@@ -37,7 +46,7 @@ func testRoundTrip[T any](t *testing.T, pMap func() r.PropertyMap) {
 	t.Run("", func(t *testing.T) {
 		t.Parallel()
 		toDecode := pMap()
-		encoder, typeInfo, err := Decode[T](toDecode)
+		encoder, typeInfo, err := Decode[T](toDecode, introspect.TagOptions{})
 		require.NoError(t, err)
 
 		assert.Equalf(t, pMap(), toDecode, "mutated decode map")
@@ -57,7 +66,7 @@ func TestRapidRoundTrip(t *testing.T) {
 
 		toDecode := pMap()
 		encoder, err := decode(toDecode, goValue,
-			false /*ignoreUnrecognized*/, false /*allowMissing*/)
+			false /*ignoreUnrecognized*/, false /*allowMissing*/, introspect.TagOptions{})
 		require.NoError(t, err)
 
 		assert.Equalf(t, pMap(), toDecode, "mutated decode map")
@@ -269,3 +278,553 @@ func TestEncodeAsset(t *testing.T) {
 		})
 	})
 }
+
+func TestRoundtripTime(t *testing.T) {
+	t.Parallel()
+
+	testRoundTrip[struct {
+		Created  time.Time   `pulumi:"created"`
+		Updated  *time.Time  `pulumi:"updated,optional"`
+		History  []time.Time `pulumi:"history"`
+		Optional *time.Time  `pulumi:"optional,optional"`
+	}](t, func() r.PropertyMap {
+		return r.PropertyMap{
+			"created": r.NewStringProperty("2024-01-02T03:04:05Z"),
+			"updated": r.MakeSecret(r.NewStringProperty("2024-01-02T03:04:05.123456789+01:00")),
+			"history": r.NewArrayProperty([]r.PropertyValue{
+				r.NewStringProperty("2023-06-07T08:09:10Z"),
+			}),
+		}
+	})
+}
+
+func TestEncodeTime(t *testing.T) {
+	t.Parallel()
+
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	properties, err := Encoder{}.Encode(struct {
+		Created time.Time            `pulumi:"created"`
+		ByName  map[string]time.Time `pulumi:"byName"`
+	}{
+		Created: created,
+		ByName:  map[string]time.Time{"a": created},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, r.PropertyMap{
+		"created": r.NewStringProperty("2024-01-02T03:04:05Z"),
+		"byName": r.NewObjectProperty(r.PropertyMap{
+			"a": r.NewStringProperty("2024-01-02T03:04:05Z"),
+		}),
+	}, properties)
+}
+
+func TestRoundtripDuration(t *testing.T) {
+	t.Parallel()
+
+	testRoundTrip[struct {
+		Timeout  time.Duration  `pulumi:"timeout" provider:"duration"`
+		Interval *time.Duration `pulumi:"interval,optional" provider:"duration"`
+		Legacy   time.Duration  `pulumi:"legacy"`
+	}](t, func() r.PropertyMap {
+		return r.PropertyMap{
+			"timeout":  r.NewStringProperty("5m30s"),
+			"interval": r.MakeSecret(r.NewStringProperty("1h0m0s")),
+			"legacy":   r.NewNumberProperty(1e9),
+		}
+	})
+}
+
+func TestRoundtripDurationCollections(t *testing.T) {
+	t.Parallel()
+
+	testRoundTrip[struct {
+		Backoff []time.Duration          `pulumi:"backoff" provider:"duration"`
+		ByStage map[string]time.Duration `pulumi:"byStage" provider:"duration"`
+		Windows [2]*time.Duration        `pulumi:"windows" provider:"duration"`
+	}](t, func() r.PropertyMap {
+		return r.PropertyMap{
+			"backoff": r.NewArrayProperty([]r.PropertyValue{
+				r.NewStringProperty("1s"),
+				r.MakeSecret(r.NewStringProperty("2m0s")),
+			}),
+			"byStage": r.NewObjectProperty(r.PropertyMap{
+				"build":  r.NewStringProperty("10m0s"),
+				"deploy": r.NewStringProperty("1h30m0s"),
+			}),
+			"windows": r.NewArrayProperty([]r.PropertyValue{
+				r.NewStringProperty("5s"),
+				r.NewStringProperty("10s"),
+			}),
+		}
+	})
+}
+
+func TestDecodeDurationInUnknownOutput(t *testing.T) {
+	t.Parallel()
+
+	type policy struct {
+		Timeout time.Duration   `pulumi:"timeout" provider:"duration"`
+		Backoff []time.Duration `pulumi:"backoff" provider:"duration"`
+	}
+	type args struct {
+		Policy policy `pulumi:"policy"`
+	}
+
+	// The types of unknown outputs are aligned, which must not discard the known
+	// durations that they hold.
+	_, typed, err := Decode[args](r.PropertyMap{
+		"policy": r.NewOutputProperty(r.Output{
+			Element: r.NewObjectProperty(r.PropertyMap{
+				"timeout": r.NewStringProperty("5m"),
+				"backoff": r.NewArrayProperty([]r.PropertyValue{r.NewStringProperty("1s")}),
+			}),
+		}),
+	}, introspect.TagOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, typed.Policy.Timeout)
+	assert.Equal(t, []time.Duration{time.Second}, typed.Policy.Backoff)
+}
+
+func TestDecodeInvalidDuration(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		Timeout time.Duration `pulumi:"timeout" provider:"duration"`
+	}
+
+	_, _, err := Decode[args](r.PropertyMap{
+		"timeout": r.NewStringProperty("five minutes"),
+	}, introspect.TagOptions{})
+	require.Error(t, err)
+	require.Len(t, err.Failures(), 1)
+	var fieldErr mapper.FieldError
+	require.ErrorAs(t, err.Failures()[0], &fieldErr)
+	assert.Equal(t, "timeout", fieldErr.Field())
+
+	type backoff struct {
+		Backoff []time.Duration `pulumi:"backoff" provider:"duration"`
+	}
+
+	_, _, err = Decode[backoff](r.PropertyMap{
+		"backoff": r.NewArrayProperty([]r.PropertyValue{
+			r.NewStringProperty("1s"),
+			r.NewStringProperty("two seconds"),
+		}),
+	}, introspect.TagOptions{})
+	require.Error(t, err)
+	require.Len(t, err.Failures(), 1)
+	require.ErrorAs(t, err.Failures()[0], &fieldErr)
+	assert.Equal(t, "backoff[1]", fieldErr.Field())
+}
+
+func TestRoundtripSDKAssets(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		Asset   pulumi.Asset          `pulumi:"asset"`
+		Archive pulumi.Archive        `pulumi:"archive"`
+		Either  pulumi.AssetOrArchive `pulumi:"either"`
+		Absent  pulumi.Asset          `pulumi:"absent,optional"`
+	}
+
+	textAsset := &asset.Asset{Sig: sig.AssetSig, Text: "pulumi"}
+	pathAsset := &asset.Asset{Sig: sig.AssetSig, Path: "a/b/c.txt"}
+	archive := &archive.Archive{Sig: sig.ArchiveSig, Assets: map[string]any{
+		"text": textAsset,
+	}}
+
+	encoder, value, err := Decode[args](r.PropertyMap{
+		"asset":   r.NewAssetProperty(textAsset),
+		"archive": r.NewArchiveProperty(archive),
+		"either":  r.NewAssetProperty(pathAsset),
+	}, introspect.TagOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "pulumi", value.Asset.Text())
+	assert.Equal(t, "a/b/c.txt", value.Either.(pulumi.Asset).Path())
+	assert.Equal(t, "pulumi", value.Archive.Assets()["text"].(pulumi.Asset).Text())
+	assert.Nil(t, value.Absent)
+
+	properties, err := encoder.Encode(value)
+	require.NoError(t, err)
+	assert.Equal(t, r.PropertyMap{
+		"asset":   r.NewAssetProperty(textAsset),
+		"archive": r.NewArchiveProperty(archive),
+		"either":  r.NewAssetProperty(pathAsset),
+	}, properties)
+}
+
+func TestRoundtripRawMessage(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		Config  json.RawMessage            `pulumi:"config"`
+		ByName  map[string]json.RawMessage `pulumi:"byName"`
+		List    []json.RawMessage          `pulumi:"list"`
+		Blobs   map[string]any             `pulumi:"blobs"`
+		Items   []any                      `pulumi:"items"`
+		Missing json.RawMessage            `pulumi:"missing,optional"`
+	}
+
+	object := func() r.PropertyValue {
+		return r.NewObjectProperty(r.PropertyMap{
+			"enabled": r.NewBoolProperty(true),
+			"nested": r.NewArrayProperty([]r.PropertyValue{
+				r.NewNumberProperty(1), r.NewStringProperty("two"), r.NewNullProperty(),
+			}),
+		})
+	}
+
+	testRoundTrip[args](t, func() r.PropertyMap {
+		return r.PropertyMap{
+			"config": object(),
+			"byName": r.NewObjectProperty(r.PropertyMap{
+				"a": object(),
+				"b": r.NewStringProperty("plain"),
+			}),
+			"list": r.NewArrayProperty([]r.PropertyValue{object(), r.NewNumberProperty(3)}),
+			"blobs": r.NewObjectProperty(r.PropertyMap{
+				"a": object(),
+			}),
+			"items": r.NewArrayProperty([]r.PropertyValue{object()}),
+		}
+	})
+
+	_, value, err := Decode[args](r.PropertyMap{
+		"config": object(),
+		"byName": r.NewObjectProperty(r.PropertyMap{"a": r.NewNumberProperty(1)}),
+		"list":   r.NewArrayProperty([]r.PropertyValue{r.NewStringProperty("x")}),
+		"blobs":  r.NewObjectProperty(r.PropertyMap{}),
+		"items":  r.NewArrayProperty([]r.PropertyValue{}),
+	}, introspect.TagOptions{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"enabled":true,"nested":[1,"two",null]}`, string(value.Config))
+	assert.JSONEq(t, `1`, string(value.ByName["a"]))
+	assert.JSONEq(t, `"x"`, string(value.List[0]))
+	assert.Nil(t, value.Missing)
+}
+
+func TestRawMessageSecretsAndUnknowns(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		Config json.RawMessage `pulumi:"config"`
+	}
+
+	encoder, value, err := Decode[args](r.PropertyMap{
+		"config": r.NewObjectProperty(r.PropertyMap{
+			"token": r.MakeSecret(r.NewStringProperty("hunter2")),
+		}),
+	}, introspect.TagOptions{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"token":"hunter2"}`, string(value.Config))
+
+	// A nested secret can't be represented in the raw JSON, so the whole value becomes
+	// secret.
+	encoded, err := encoder.Encode(value)
+	require.NoError(t, err)
+	assert.Equal(t, r.PropertyMap{
+		"config": r.MakeSecret(r.NewObjectProperty(r.PropertyMap{
+			"token": r.NewStringProperty("hunter2"),
+		})),
+	}, encoded)
+
+	encoder, value, err = Decode[args](r.PropertyMap{
+		"config": r.NewObjectProperty(r.PropertyMap{
+			"id": r.MakeComputed(r.NewStringProperty("")),
+		}),
+	}, introspect.TagOptions{})
+	require.NoError(t, err)
+	encoded, err = encoder.Encode(value)
+	require.NoError(t, err)
+	assert.True(t, encoded["config"].ContainsUnknowns())
+}
+
+func TestRoundtripBytes(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		Payload  []byte            `pulumi:"payload"`
+		Optional []byte            `pulumi:"optional,optional"`
+		ByName   map[string][]byte `pulumi:"byName"`
+		Octets   octets            `pulumi:"octets"`
+	}
+
+	binary := []byte{0x00, 0xff, 'a', 0x00, 0x80, '\n', 0x00}
+	encoded := base64.StdEncoding.EncodeToString(binary)
+
+	testRoundTrip[args](t, func() r.PropertyMap {
+		return r.PropertyMap{
+			"payload": r.NewStringProperty(encoded),
+			"byName": r.NewObjectProperty(r.PropertyMap{
+				"a": r.MakeSecret(r.NewStringProperty(encoded)),
+				"b": r.NewStringProperty(""),
+			}),
+			"octets": r.NewArrayProperty([]r.PropertyValue{r.NewNumberProperty(1), r.NewNumberProperty(2)}),
+		}
+	})
+
+	_, value, err := Decode[args](r.PropertyMap{
+		"payload": r.NewStringProperty(encoded),
+		"byName":  r.NewObjectProperty(r.PropertyMap{}),
+		"octets":  r.NewArrayProperty([]r.PropertyValue{}),
+	}, introspect.TagOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, binary, value.Payload)
+	assert.Nil(t, value.Optional)
+
+	_, _, err = Decode[args](r.PropertyMap{
+		"payload": r.NewStringProperty("not base64!"),
+		"byName":  r.NewObjectProperty(r.PropertyMap{}),
+		"octets":  r.NewArrayProperty([]r.PropertyValue{}),
+	}, introspect.TagOptions{})
+	require.Error(t, err)
+	require.Len(t, err.Failures(), 1)
+	var fieldErr mapper.FieldError
+	require.ErrorAs(t, err.Failures()[0], &fieldErr)
+	assert.Equal(t, "payload", fieldErr.Field())
+}
+
+// octets is a named byte slice, which is represented as an array of integers.
+type octets []uint8
+
+func TestRoundtripNilPointerElements(t *testing.T) {
+	t.Parallel()
+
+	type item struct {
+		Name string `pulumi:"name"`
+	}
+	type args struct {
+		Strings []*string          `pulumi:"strings"`
+		ByName  map[string]*item   `pulumi:"byName"`
+		Items   []*item            `pulumi:"items"`
+		Nested  []map[string]*item `pulumi:"nested"`
+	}
+
+	named := func(name string) r.PropertyValue {
+		return r.NewObjectProperty(r.PropertyMap{"name": r.NewStringProperty(name)})
+	}
+	pMap := func() r.PropertyMap {
+		return r.PropertyMap{
+			"strings": r.NewArrayProperty([]r.PropertyValue{r.NewNullProperty(), r.NewStringProperty("a")}),
+			"byName":  r.NewObjectProperty(r.PropertyMap{"a": named("a"), "b": r.NewNullProperty()}),
+			"items":   r.NewArrayProperty([]r.PropertyValue{named("a"), r.NewNullProperty()}),
+			"nested": r.NewArrayProperty([]r.PropertyValue{
+				r.NewObjectProperty(r.PropertyMap{"b": r.NewNullProperty()}),
+			}),
+		}
+	}
+	testRoundTrip[args](t, pMap)
+
+	_, value, err := Decode[args](pMap(), introspect.TagOptions{})
+	require.NoError(t, err)
+	a := "a"
+	assert.Equal(t, args{
+		Strings: []*string{nil, &a},
+		ByName:  map[string]*item{"a": {Name: "a"}, "b": nil},
+		Items:   []*item{{Name: "a"}, nil},
+		Nested:  []map[string]*item{{"b": nil}},
+	}, value)
+}
+
+func TestRoundtripArrays(t *testing.T) {
+	t.Parallel()
+
+	type point struct {
+		Coords [2]int `pulumi:"coords"`
+	}
+	type args struct {
+		Names  [3]string            `pulumi:"names"`
+		Key    [4]byte              `pulumi:"key"`
+		Points []point              `pulumi:"points"`
+		ByName map[string]*[1]point `pulumi:"byName"`
+	}
+
+	num := r.NewNumberProperty
+	coords := func(x, y float64) r.PropertyValue {
+		return r.NewObjectProperty(r.PropertyMap{"coords": r.NewArrayProperty([]r.PropertyValue{num(x), num(y)})})
+	}
+	pMap := func() r.PropertyMap {
+		return r.PropertyMap{
+			"names": r.NewArrayProperty([]r.PropertyValue{
+				r.NewStringProperty("a"), r.MakeSecret(r.NewStringProperty("b")), r.NewStringProperty("c"),
+			}),
+			"key":    r.NewArrayProperty([]r.PropertyValue{num(1), num(2), num(3), num(4)}),
+			"points": r.NewArrayProperty([]r.PropertyValue{coords(1, 2), coords(3, 4)}),
+			"byName": r.NewObjectProperty(r.PropertyMap{
+				"a": r.NewArrayProperty([]r.PropertyValue{coords(5, 6)}),
+			}),
+		}
+	}
+	testRoundTrip[args](t, pMap)
+
+	_, value, err := Decode[args](pMap(), introspect.TagOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, args{
+		Names:  [3]string{"a", "b", "c"},
+		Key:    [4]byte{1, 2, 3, 4},
+		Points: []point{{Coords: [2]int{1, 2}}, {Coords: [2]int{3, 4}}},
+		ByName: map[string]*[1]point{"a": {{Coords: [2]int{5, 6}}}},
+	}, value)
+
+	short := pMap()
+	short["points"] = r.NewArrayProperty([]r.PropertyValue{coords(1, 2), r.NewObjectProperty(r.PropertyMap{
+		"coords": r.NewArrayProperty([]r.PropertyValue{num(3)}),
+	})})
+	_, _, err = Decode[args](short, introspect.TagOptions{})
+	require.Error(t, err)
+	require.Len(t, err.Failures(), 1)
+	var fieldErr mapper.FieldError
+	require.ErrorAs(t, err.Failures()[0], &fieldErr)
+	assert.Equal(t, "points[1].coords", fieldErr.Field())
+	assert.Contains(t, fieldErr.Reason(), "expected 2 elements, found 1")
+}
+
+func TestDecodeOptionalPointers(t *testing.T) {
+	t.Parallel()
+
+	type inner struct {
+		Low  *int `pulumi:"low"`
+		High int  `pulumi:"high"`
+	}
+	type args struct {
+		Size  *int    `pulumi:"size"`
+		Label *string `pulumi:"label" provider:"required"`
+		Inner []inner `pulumi:"inner"`
+	}
+	opts := introspect.TagOptions{PointersOptional: true}
+
+	m := r.PropertyMap{
+		"label": r.NewStringProperty("l"),
+		"inner": r.NewArrayProperty([]r.PropertyValue{
+			r.NewObjectProperty(r.PropertyMap{"high": r.NewNumberProperty(2)}),
+		}),
+	}
+	encoder, value, err := Decode[args](m, opts)
+	require.NoError(t, err)
+	reEncoded, err := encoder.Encode(value)
+	require.NoError(t, err)
+	assert.Equal(t, m, reEncoded)
+
+	// Without the option, the pointer fields are required.
+	_, _, err = Decode[args](m, introspect.TagOptions{})
+	assert.ErrorContains(t, err, "size")
+
+	_, _, err = Decode[args](r.PropertyMap{
+		"inner": r.NewArrayProperty([]r.PropertyValue{r.NewObjectProperty(r.PropertyMap{})}),
+	}, opts)
+	require.Error(t, err)
+	fields := make([]string, len(err.Failures()))
+	for i, f := range err.Failures() {
+		var fieldErr mapper.FieldError
+		require.ErrorAs(t, f, &fieldErr)
+		fields[i] = fieldErr.Field()
+	}
+	assert.ElementsMatch(t, []string{"label", "inner[0].high"}, fields)
+}
+
+type cidr struct{ net.IPNet }
+
+func (c cidr) MarshalProperty() (r.PropertyValue, error) {
+	if c.IP == nil {
+		return r.NewNullProperty(), nil
+	}
+	return r.NewStringProperty(c.String()), nil
+}
+
+func (c *cidr) UnmarshalProperty(v r.PropertyValue) error {
+	if !v.IsString() {
+		return fmt.Errorf("expected a string, found a %s", v.TypeString())
+	}
+	_, n, err := net.ParseCIDR(v.StringValue())
+	if err != nil {
+		return err
+	}
+	c.IPNet = *n
+	return nil
+}
+
+func TestRoundtripPropertyMarshaler(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		Block    cidr            `pulumi:"block"`
+		Optional *cidr           `pulumi:"optional,optional"`
+		Blocks   []cidr          `pulumi:"blocks"`
+		ByName   map[string]cidr `pulumi:"byName"`
+	}
+
+	pMap := func() r.PropertyMap {
+		return r.PropertyMap{
+			"block":    r.MakeSecret(r.NewStringProperty("10.0.0.0/8")),
+			"optional": r.NewStringProperty("10.1.0.0/16"),
+			"blocks":   r.NewArrayProperty([]r.PropertyValue{r.NewStringProperty("192.168.0.0/16")}),
+			"byName":   r.NewObjectProperty(r.PropertyMap{"a": r.NewStringProperty("172.16.0.0/12")}),
+		}
+	}
+	testRoundTrip[args](t, pMap)
+
+	_, value, err := Decode[args](pMap(), introspect.TagOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.0/8", value.Block.String())
+	assert.Equal(t, "10.1.0.0/16", value.Optional.String())
+	assert.Equal(t, "192.168.0.0/16", value.Blocks[0].String())
+	byName := value.ByName["a"]
+	assert.Equal(t, "172.16.0.0/12", byName.String())
+
+	_, _, err = Decode[args](r.PropertyMap{
+		"block":  r.NewStringProperty("not a cidr"),
+		"blocks": r.NewArrayProperty(nil),
+		"byName": r.NewObjectProperty(r.PropertyMap{}),
+	}, introspect.TagOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid CIDR address: not a cidr")
+}
+
+func TestRoundtripBigNumbers(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		Supply   big.Int              `pulumi:"supply"`
+		Rate     *big.Float           `pulumi:"rate,optional"`
+		Balances []*big.Int           `pulumi:"balances"`
+		Prices   map[string]big.Float `pulumi:"prices"`
+	}
+
+	const (
+		supply = "123456789012345678901234567890123456789"
+		rate   = "3.1415926535897932384626433832795028841971693993751"
+	)
+	pMap := func() r.PropertyMap {
+		return r.PropertyMap{
+			"supply":   r.NewStringProperty(supply),
+			"rate":     r.MakeSecret(r.NewStringProperty(rate)),
+			"balances": r.NewArrayProperty([]r.PropertyValue{r.NewStringProperty("-" + supply)}),
+			"prices":   r.NewObjectProperty(r.PropertyMap{"a": r.NewStringProperty("0.000000000000000000000001")}),
+		}
+	}
+	testRoundTrip[args](t, pMap)
+
+	_, value, err := Decode[args](pMap(), introspect.TagOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, supply, value.Supply.String())
+	assert.Equal(t, rate, value.Rate.Text('f', 49))
+	assert.Equal(t, "-"+supply, value.Balances[0].String())
+	price := value.Prices["a"]
+	assert.Equal(t, "1e-24", price.Text('g', -1))
+
+	_, _, err = Decode[args](r.PropertyMap{
+		"supply":   r.NewStringProperty("1.5"),
+		"rate":     r.NewStringProperty("pi"),
+		"balances": r.NewArrayProperty(nil),
+		"prices":   r.NewObjectProperty(r.PropertyMap{}),
+	}, introspect.TagOptions{})
+	require.Error(t, err)
+	fields := make([]string, len(err.Failures()))
+	for i, f := range err.Failures() {
+		var fieldErr mapper.FieldError
+		require.ErrorAs(t, f, &fieldErr)
+		fields[i] = fieldErr.Field()
+	}
+	assert.ElementsMatch(t, []string{"supply", "rate"}, fields)
+}
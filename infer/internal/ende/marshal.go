@@ -0,0 +1,146 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ende
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/mapper"
+
+	"github.com/pulumi/pulumi-go-provider/internal/introspect"
+)
+
+// propertyMarshaler and propertyUnmarshaler match infer.PropertyMarshaler and
+// infer.PropertyUnmarshaler, which types use to choose their own property value.
+type (
+	propertyMarshaler interface {
+		MarshalProperty() (resource.PropertyValue, error)
+	}
+	propertyUnmarshaler interface {
+		UnmarshalProperty(v resource.PropertyValue) error
+	}
+)
+
+var (
+	propertyMarshalerType   = reflect.TypeOf((*propertyMarshaler)(nil)).Elem()
+	propertyUnmarshalerType = reflect.TypeOf((*propertyUnmarshaler)(nil)).Elem()
+)
+
+// unmarshaledKey names the index of a value unmarshaled by walkUnmarshaled in the
+// object that is passed to the mapper in its place.
+const unmarshaledKey = "__unmarshaled"
+
+// isUnmarshaler reports whether values of the non-pointer type t are decoded by their
+// UnmarshalProperty method.
+func isUnmarshaler(t reflect.Type) bool {
+	return t.Kind() != reflect.Pointer && t.Kind() != reflect.Interface &&
+		reflect.PointerTo(t).Implements(propertyUnmarshalerType)
+}
+
+// walkUnmarshaled unmarshals v into a new value of typ. The value is held by e, and the
+// mapper is passed an object that names it, which the decoders added by
+// addUnmarshalDecoders turn back into the value.
+func (e *ende) walkUnmarshaled(
+	v resource.PropertyValue, path resource.PropertyPath, typ reflect.Type, alignTypes bool,
+) resource.PropertyValue {
+	if v.IsNull() {
+		if alignTypes {
+			return resource.NewObjectProperty(resource.PropertyMap{})
+		}
+		return v
+	}
+	dst := reflect.New(typ)
+	if err := dst.Interface().(propertyUnmarshaler).UnmarshalProperty(v); err != nil {
+		e.errs = append(e.errs, mapper.NewFieldError(typ.String(), path.String(), err))
+		return resource.NewObjectProperty(resource.PropertyMap{})
+	}
+	e.unmarshaled = append(e.unmarshaled, dst.Elem())
+	return resource.NewObjectProperty(resource.PropertyMap{
+		unmarshaledKey: resource.NewNumberProperty(float64(len(e.unmarshaled) - 1)),
+	})
+}
+
+// addUnmarshalDecoders adds a decoder to d for each type found in t that is decoded by
+// its UnmarshalProperty method or by walkBigNumber, and for pointers to it.
+func (e *ende) addUnmarshalDecoders(t reflect.Type, d mapper.Decoders, visited map[reflect.Type]struct{}) {
+	if _, ok := visited[t]; ok {
+		return
+	}
+	visited[t] = struct{}{}
+	if isUnmarshaler(t) || isBigNumber(t) {
+		d[t] = e.unmarshaledDecoder(t, false)
+		d[reflect.PointerTo(t)] = e.unmarshaledDecoder(t, true)
+		return
+	}
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		e.addUnmarshalDecoders(t.Elem(), d, visited)
+	case reflect.Struct:
+		for _, f := range reflect.VisibleFields(t) {
+			e.addUnmarshalDecoders(f.Type, d, visited)
+		}
+	case reflect.Interface:
+		if u, ok := introspect.GetUnion(t); ok {
+			for _, c := range u.Cases {
+				e.addUnmarshalDecoders(c, d, visited)
+			}
+		}
+	}
+}
+
+// unmarshaledDecoder returns the value of t named by an object from walkUnmarshaled, or
+// a pointer to it if ptr is true. Objects that name no value decode into the zero value.
+func (e *ende) unmarshaledDecoder(t reflect.Type, ptr bool) mapper.Decoder {
+	return func(_ mapper.Mapper, obj map[string]any) (any, error) {
+		v := reflect.New(t)
+		if i, ok := obj[unmarshaledKey].(float64); ok {
+			if int(i) < 0 || int(i) >= len(e.unmarshaled) {
+				return nil, fmt.Errorf("no unmarshaled %s value at index %v", t, i)
+			}
+			v.Elem().Set(e.unmarshaled[int(i)])
+		}
+		if ptr {
+			return v.Interface(), nil
+		}
+		return v.Elem().Interface(), nil
+	}
+}
+
+// marshalProperty returns the property value chosen by the MarshalProperty method of v,
+// if v or a pointer to it has one.
+func marshalProperty(v reflect.Value) (resource.PropertyValue, bool, error) {
+	if !v.IsValid() || !v.CanInterface() || v.Kind() == reflect.Interface {
+		return resource.PropertyValue{}, false, nil
+	}
+	if !v.Type().Implements(propertyMarshalerType) {
+		if !reflect.PointerTo(v.Type()).Implements(propertyMarshalerType) {
+			return resource.PropertyValue{}, false, nil
+		}
+		if v.CanAddr() {
+			v = v.Addr()
+		} else {
+			ptr := reflect.New(v.Type())
+			ptr.Elem().Set(v)
+			v = ptr
+		}
+	}
+	pv, err := v.Interface().(propertyMarshaler).MarshalProperty()
+	if err != nil {
+		return resource.PropertyValue{}, true, fmt.Errorf("%s: %w", v.Type(), err)
+	}
+	return pv, true, nil
+}
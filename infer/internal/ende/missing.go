@@ -0,0 +1,102 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ende
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/pulumi/pulumi-go-provider/internal/introspect"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/mapper"
+)
+
+// hasOptionalPointers reports if t, or a type that t refers to, is a struct type with
+// pointer fields that e.tags makes optional without them being tagged optional. The
+// mapper doesn't know of these fields, so it reports them as missing.
+func (e *ende) hasOptionalPointers(t reflect.Type, visited map[reflect.Type]bool) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if visited[t] {
+		return false
+	}
+	visited[t] = true
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return e.hasOptionalPointers(t.Elem(), visited)
+	case reflect.Struct:
+		for _, f := range reflect.VisibleFields(t) {
+			tag, err := introspect.ParseTag(f, e.tags)
+			if err != nil || tag.Internal {
+				continue
+			}
+			if tag.Optional && !hasOptionalTag(f) || e.hasOptionalPointers(f.Type, visited) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// missingFields reports each required field of the structs in v, which is decoded into t,
+// that v doesn't set. It stands in for the check of required fields of the mapper, which
+// only treats fields tagged optional as optional.
+func (e *ende) missingFields(v any, t reflect.Type, path resource.PropertyPath) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch v := v.(type) {
+	case []any:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i, el := range v {
+				e.missingFields(el, t.Elem(), append(path, i))
+			}
+		}
+	case map[string]any:
+		switch t.Kind() {
+		case reflect.Map:
+			for k, el := range v {
+				e.missingFields(el, t.Elem(), append(path, k))
+			}
+		case reflect.Struct:
+			for _, f := range reflect.VisibleFields(t) {
+				tag, err := introspect.ParseTag(f, e.tags)
+				if err != nil || tag.Internal {
+					continue
+				}
+				path := append(path, tag.Name)
+				if el := v[tag.Name]; el != nil {
+					e.missingFields(el, f.Type, path)
+				} else if !tag.Optional && !hasOptionalTag(f) {
+					e.errs = append(e.errs, mapper.NewMissingError(t, path.String()))
+				}
+			}
+		}
+	}
+}
+
+// hasOptionalTag reports if the mapper treats the field f as optional.
+func hasOptionalTag(f reflect.StructField) bool {
+	parts := strings.Split(f.Tag.Get("pulumi"), ",")
+	for _, part := range parts[1:] {
+		if slices.Contains(optionalTags, part) {
+			return true
+		}
+	}
+	return false
+}
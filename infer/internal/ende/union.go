@@ -0,0 +1,133 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ende
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/mapper"
+
+	"github.com/pulumi/pulumi-go-provider/internal/introspect"
+)
+
+// decoders returns the custom decoders used to decode into t types that the mapper doesn't
+// understand natively.
+func decoders(t reflect.Type) mapper.Decoders {
+	d := make(mapper.Decoders, len(sdkAssetDecoders))
+	for k, v := range sdkAssetDecoders {
+		d[k] = v
+	}
+	introspect.RangeUnions(func(iface reflect.Type, u introspect.Union) {
+		d[iface] = unionDecoder(iface, u)
+	})
+	addPointerDecoders(t, d, map[reflect.Type]struct{}{})
+	return d
+}
+
+// addPointerDecoders adds a decoder to d for each pointer to a pointer to a struct found
+// in t. The mapper allocates a single pointer when it decodes an object held by a slice
+// or a map, so it can't decode into elements such as **T on its own.
+func addPointerDecoders(t reflect.Type, d mapper.Decoders, visited map[reflect.Type]struct{}) {
+	if _, ok := visited[t]; ok {
+		return
+	}
+	visited[t] = struct{}{}
+	switch t.Kind() {
+	case reflect.Pointer:
+		if t.Elem().Kind() == reflect.Pointer {
+			base := t.Elem()
+			for base.Kind() == reflect.Pointer {
+				base = base.Elem()
+			}
+			if base.Kind() == reflect.Struct {
+				d[t] = pointerDecoder(t, base)
+			}
+		}
+		addPointerDecoders(t.Elem(), d, visited)
+	case reflect.Slice, reflect.Array, reflect.Map:
+		addPointerDecoders(t.Elem(), d, visited)
+	case reflect.Struct:
+		for _, f := range reflect.VisibleFields(t) {
+			addPointerDecoders(f.Type, d, visited)
+		}
+	}
+}
+
+// pointerDecoder decodes an object into the struct base, returning it behind as many
+// pointers as t has.
+func pointerDecoder(t, base reflect.Type) mapper.Decoder {
+	return func(m mapper.Mapper, obj map[string]any) (any, error) {
+		v := reflect.New(base)
+		if err := m.Decode(obj, v.Interface()); err != nil {
+			return nil, err
+		}
+		for v.Type() != t {
+			ptr := reflect.New(v.Type())
+			ptr.Elem().Set(v)
+			v = ptr
+		}
+		return v.Interface(), nil
+	}
+}
+
+// unionDecoder decodes an object into the case of u named by its discriminator.
+func unionDecoder(iface reflect.Type, u introspect.Union) mapper.Decoder {
+	return func(m mapper.Mapper, obj map[string]any) (any, error) {
+		value, _ := obj[u.Discriminator].(string)
+		c, ok := u.Cases[value]
+		if !ok {
+			return nil, fmt.Errorf("%q is not a valid %s, expected one of %q",
+				value, u.Discriminator, u.Values())
+		}
+		dst := reflect.New(c)
+		if err := m.Decode(obj, dst.Interface()); err != nil {
+			return nil, err
+		}
+		if c.Implements(iface) {
+			return dst.Elem().Interface(), nil
+		}
+		return dst.Interface(), nil
+	}
+}
+
+// unionCase returns the case type of v, if typ is a union and v names one of its cases.
+func unionCase(v resource.PropertyValue, typ reflect.Type) (reflect.Type, bool) {
+	if typ == nil || !v.IsObject() {
+		return nil, false
+	}
+	u, ok := introspect.GetUnion(typ)
+	if !ok {
+		return nil, false
+	}
+	d := v.ObjectValue()[resource.PropertyKey(u.Discriminator)]
+	if !d.IsString() {
+		return nil, false
+	}
+	c, ok := u.Cases[d.StringValue()]
+	return c, ok
+}
+
+// encodeUnion encodes a union value v, ensuring that the encoded object names its case.
+func encodeUnion(u introspect.Union, v reflect.Value, encoded any, tags introspect.TagOptions, errs *[]error) any {
+	encoded = encodeScalars(v, encoded, tags, errs)
+	if obj, ok := encoded.(map[string]any); ok {
+		if value, ok := u.ValueOf(v.Type()); ok {
+			obj[u.Discriminator] = value
+		}
+	}
+	return encoded
+}
@@ -0,0 +1,85 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infer
+
+import (
+	"reflect"
+
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// PropertyMarshaler is implemented by types that are represented by a single property
+// value instead of an object of their fields, such as a CIDR block represented by the
+// string "10.0.0.0/8".
+//
+// A type that implements PropertyMarshaler must implement [PropertyUnmarshaler] on its
+// pointer type, so that it can be read from inputs and state. For example:
+//
+//	type CIDR struct{ net.IPNet }
+//
+//	func (CIDR) PropertyType() schema.TypeSpec { return schema.TypeSpec{Type: "string"} }
+//
+//	func (c CIDR) MarshalProperty() (resource.PropertyValue, error) {
+//		return resource.NewStringProperty(c.String()), nil
+//	}
+//
+//	func (c *CIDR) UnmarshalProperty(v resource.PropertyValue) error {
+//		if !v.IsString() {
+//			return fmt.Errorf("expected a string, found a %s", v.TypeString())
+//		}
+//		_, n, err := net.ParseCIDR(v.StringValue())
+//		if err != nil {
+//			return err
+//		}
+//		c.IPNet = *n
+//		return nil
+//	}
+type PropertyMarshaler interface {
+	// PropertyType returns the type of the property in the schema. It is called on the
+	// zero value of the type.
+	PropertyType() pschema.TypeSpec
+	// MarshalProperty returns the property value that represents the receiver.
+	MarshalProperty() (resource.PropertyValue, error)
+}
+
+// PropertyUnmarshaler is implemented by pointers to types that implement
+// [PropertyMarshaler].
+type PropertyUnmarshaler interface {
+	// UnmarshalProperty sets the receiver to the value represented by v. Secrets and
+	// outputs are removed from v before UnmarshalProperty is called, and unknown values
+	// are left as the zero value of the type.
+	UnmarshalProperty(v resource.PropertyValue) error
+}
+
+// propertyMarshaler returns the zero value of t as a [PropertyMarshaler], if t or a
+// pointer to t implements it.
+func propertyMarshaler(t reflect.Type) (PropertyMarshaler, bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Interface {
+		return nil, false
+	}
+	marshaler := reflect.TypeOf((*PropertyMarshaler)(nil)).Elem()
+	switch {
+	case t.Implements(marshaler):
+		return reflect.New(t).Elem().Interface().(PropertyMarshaler), true
+	case reflect.PointerTo(t).Implements(marshaler):
+		return reflect.New(t).Interface().(PropertyMarshaler), true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infer
+
+import (
+	"context"
+	"fmt"
+)
+
+// PageArgs are the inputs of a paginated function. Embed PageArgs in the function's
+// inputs to let users page through its results:
+//
+//	type ListBucketsArgs struct {
+//		infer.PageArgs
+//		Prefix string `pulumi:"prefix,optional"`
+//	}
+type PageArgs struct {
+	// The cursor returned by the previous page. When Cursor is nil, the first page is
+	// returned.
+	Cursor *string `pulumi:"cursor,optional"`
+	// The maximum number of items to return.
+	PageSize *int `pulumi:"pageSize,optional"`
+}
+
+// PageResult is the output of a paginated function. Embed PageResult in the function's
+// outputs, with T the type of the items listed:
+//
+//	type ListBucketsResult struct {
+//		infer.PageResult[Bucket]
+//	}
+type PageResult[T any] struct {
+	Items []T `pulumi:"items"`
+	// The cursor to pass to get the next page. NextCursor is nil on the last page.
+	NextCursor *string `pulumi:"nextCursor,optional"`
+}
+
+// Paginate calls fetch for each page of a paginated API, and returns the items of every
+// page. The first call is passed a nil cursor. Each following call is passed the
+// NextCursor of the previous page, until a page has no NextCursor.
+//
+// Paginate returns an error if ctx is canceled, if fetch fails or if fetch returns a
+// cursor that it already returned, which would page through the same results forever.
+func Paginate[T any](
+	ctx context.Context, fetch func(ctx context.Context, cursor *string) (PageResult[T], error),
+) ([]T, error) {
+	var items []T
+	var cursor *string
+	seen := map[string]bool{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		page, err := fetch(ctx, cursor)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page.Items...)
+		if page.NextCursor == nil {
+			return items, nil
+		}
+		if seen[*page.NextCursor] {
+			return nil, fmt.Errorf("pagination returned cursor %q more than once", *page.NextCursor)
+		}
+		seen[*page.NextCursor] = true
+		cursor = page.NextCursor
+	}
+}
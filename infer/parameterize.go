@@ -0,0 +1,159 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infer
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+
+	"github.com/blang/semver"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/internal/key"
+	"github.com/pulumi/pulumi-go-provider/middleware/dispatch"
+	"github.com/pulumi/pulumi-go-provider/middleware/schema"
+)
+
+// Parameterization describes the package that a parameterized provider serves, as
+// returned by [Options.Parameterize].
+type Parameterization struct {
+	// The name and version of the parameterized package.
+	Name    string
+	Version semver.Version
+
+	// Value is embedded in the schema of the parameterized package, and is passed back to
+	// [Options.Parameterize] as [p.ParameterizeRequestValue.Value] when a program uses
+	// an SDK generated from that schema. It should hold everything needed to recreate
+	// this Parameterization.
+	Value []byte
+
+	// The custom resources, components and functions served by the parameterized package.
+	Resources  []InferredResource
+	Components []InferredComponent
+	Functions  []InferredFunction
+}
+
+// parameterized wraps provider so that once Parameterize has been called, resource and
+// function requests are served by the package that opts.Parameterize returned.
+func parameterized(provider p.Provider, opts Options) p.Provider {
+	type current struct {
+		p.Provider
+		info p.RunInfo
+	}
+	var active atomic.Pointer[current]
+
+	provider.Parameterize = func(ctx context.Context, req p.ParameterizeRequest) (p.ParameterizeResponse, error) {
+		param, err := opts.Parameterize(ctx, req)
+		if err != nil {
+			return p.ParameterizeResponse{}, err
+		}
+
+		info := p.GetRunInfo(ctx)
+		// The parameterized package keeps every option of the base provider, and only
+		// serves other resources, components and functions.
+		inner := opts
+		inner.Resources = param.Resources
+		inner.Components = param.Components
+		inner.Functions = param.Functions
+		inner.Parameterize = nil
+		if err := inner.Validate(); err != nil {
+			return p.ParameterizeResponse{}, err
+		}
+		lower := p.Provider{
+			// The parameterization is merged into the schema generated for the package.
+			GetSchema: func(context.Context, p.GetSchemaRequest) (p.GetSchemaResponse, error) {
+				b, err := json.Marshal(pschema.PackageSpec{
+					Name: param.Name,
+					Parameterization: &pschema.ParameterizationSpec{
+						BaseProvider: pschema.BaseProviderSpec{Name: info.PackageName, Version: info.Version},
+						Parameter:    param.Value,
+					},
+				})
+				return p.GetSchemaResponse{Schema: string(b)}, err
+			},
+		}
+		lower.Cancel = cancelResources(nil, param.Resources)
+		served := schema.Wrap(dispatch.Wrap(lower, inner.dispatch()), inner.schema())
+		if fields := inner.anyFields(); len(fields) > 0 {
+			served.GetSchema = logAnyFields(served.GetSchema, fields)
+		}
+		if inner.CheckOptionalValues && inner.Strict {
+			if values := inner.optionalValues(); len(values) > 0 {
+				served.GetSchema = rejectOptionalValues(served.GetSchema, values)
+			}
+		}
+		served = served.WithDefaults()
+		active.Store(&current{
+			Provider: served,
+			info:     p.RunInfo{PackageName: param.Name, Version: param.Version.String()},
+		})
+		return p.ParameterizeResponse{Name: param.Name, Version: param.Version}, nil
+	}
+
+	// route returns the provider that should serve a request, and the context to serve it
+	// with.
+	base := provider.WithDefaults()
+	route := func(ctx context.Context) (context.Context, p.Provider) {
+		if c := active.Load(); c != nil {
+			return context.WithValue(ctx, key.RuntimeInfo, c.info), c.Provider
+		}
+		return ctx, base
+	}
+
+	wrapped := provider
+	wrapped.GetSchema = func(ctx context.Context, req p.GetSchemaRequest) (p.GetSchemaResponse, error) {
+		ctx, r := route(ctx)
+		return r.GetSchema(ctx, req)
+	}
+	wrapped.Invoke = func(ctx context.Context, req p.InvokeRequest) (p.InvokeResponse, error) {
+		ctx, r := route(ctx)
+		return r.Invoke(ctx, req)
+	}
+	wrapped.Check = func(ctx context.Context, req p.CheckRequest) (p.CheckResponse, error) {
+		ctx, r := route(ctx)
+		return r.Check(ctx, req)
+	}
+	wrapped.Diff = func(ctx context.Context, req p.DiffRequest) (p.DiffResponse, error) {
+		ctx, r := route(ctx)
+		return r.Diff(ctx, req)
+	}
+	wrapped.Create = func(ctx context.Context, req p.CreateRequest) (p.CreateResponse, error) {
+		ctx, r := route(ctx)
+		return r.Create(ctx, req)
+	}
+	wrapped.Read = func(ctx context.Context, req p.ReadRequest) (p.ReadResponse, error) {
+		ctx, r := route(ctx)
+		return r.Read(ctx, req)
+	}
+	wrapped.Update = func(ctx context.Context, req p.UpdateRequest) (p.UpdateResponse, error) {
+		ctx, r := route(ctx)
+		return r.Update(ctx, req)
+	}
+	wrapped.Delete = func(ctx context.Context, req p.DeleteRequest) error {
+		ctx, r := route(ctx)
+		return r.Delete(ctx, req)
+	}
+	wrapped.Construct = func(ctx context.Context, req p.ConstructRequest) (p.ConstructResponse, error) {
+		ctx, r := route(ctx)
+		return r.Construct(ctx, req)
+	}
+	wrapped.Cancel = func(ctx context.Context) error {
+		ctx, r := route(ctx)
+		return r.Cancel(ctx)
+	}
+	return wrapped
+}
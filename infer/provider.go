@@ -17,14 +17,18 @@ package infer
 import (
 	"context"
 	"fmt"
+	"reflect"
 
+	"github.com/hashicorp/hcl/v2"
 	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/internal/introspect"
 	t "github.com/pulumi/pulumi-go-provider/middleware"
 	"github.com/pulumi/pulumi-go-provider/middleware/cancel"
 	"github.com/pulumi/pulumi-go-provider/middleware/complexconfig" //nolint:staticcheck
 	mContext "github.com/pulumi/pulumi-go-provider/middleware/context"
 	"github.com/pulumi/pulumi-go-provider/middleware/dispatch"
 	"github.com/pulumi/pulumi-go-provider/middleware/schema"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
 	"google.golang.org/grpc/codes"
@@ -35,6 +39,18 @@ type configKeyType struct{}
 
 var configKey configKeyType
 
+type explainDiffsKeyType struct{}
+
+var explainDiffsKey explainDiffsKeyType
+
+type reportDriftKeyType struct{}
+
+var reportDriftKey reportDriftKeyType
+
+type tagOptionsKeyType struct{}
+
+var tagOptionsKey tagOptionsKeyType
+
 // Options to configure an inferred provider.
 //
 // See [Provider] to turn a set of Options into a [p.Provider].
@@ -61,6 +77,11 @@ type Options struct {
 	// To create an [InferredFunction], use [Function].
 	Functions []InferredFunction
 
+	// The set of union types used by the provider's resources and functions.
+	//
+	// To create an [InferredUnion], use [Union].
+	Unions []InferredUnion
+
 	// The config used by the provider, if any.
 	//
 	// To create an [InferredConfig], use [Config].
@@ -77,6 +98,60 @@ type Options struct {
 	// will instead result in exposing the same resources at `pkg:bar:Foo`, `pkg:bar:Bar` and
 	// `pkg:fizz:Buzz`.
 	ModuleMap map[tokens.ModuleName]tokens.ModuleName
+
+	// TokenStrategy derives the tokens of resources, components, functions and types
+	// that don't set their token with [Annotator.SetToken]. It is applied before
+	// ModuleMap.
+	//
+	// When TokenStrategy is nil, tokens are derived with [DefaultTokenStrategy].
+	TokenStrategy TokenStrategy
+
+	// Strict makes GetSchema fail when a resource, function or property has no
+	// description. It is opt-in, and is meant to keep published providers fully
+	// documented. See [schema.Options.Strict].
+	//
+	// With CheckOptionalValues, Strict also makes GetSchema fail when an optional field is
+	// not a pointer. Strict also logs a warning when the metadata is missing fields that
+	// publishing requires.
+	Strict bool
+
+	// CheckOptionalValues reports the optional fields of resources and of the config whose
+	// type is a scalar, such as int or string, rather than a pointer to one. An unset value
+	// of such a field can't be told apart from its zero value, which shows up as a
+	// spurious diff.
+	//
+	// The fields are logged as warnings the first time the provider is configured, or
+	// make GetSchema fail when Strict is set.
+	CheckOptionalValues bool
+
+	// ExplainDiffs makes the diff of each resource log which of its properties changed,
+	// and which of those changes replace it, as an info diagnostic. It helps to find out
+	// why a resource is updated or replaced on every deployment.
+	ExplainDiffs bool
+
+	// PointersAreOptional makes the pointer fields of the inputs, outputs and config of the
+	// provider optional, as if they were tagged `pulumi:"name,optional"`. A pointer field
+	// tagged `provider:"required"` stays required. Fields that are not pointers are
+	// required unless they are tagged optional, as without PointersAreOptional.
+	//
+	// The option applies to the struct types used by the provider's resources,
+	// components, functions and config, and to the struct types they refer to.
+	PointersAreOptional bool
+
+	// ReportDrift makes the Read of each resource that implements [CustomRead] log the
+	// properties whose live values differ from the recorded state, as an info diagnostic,
+	// when the resource is refreshed.
+	ReportDrift bool
+
+	// Parameterize makes the provider a parameterized provider: a single provider that
+	// serves a different package for each set of parameters it is given.
+	//
+	// Parameterize is called when the engine parameterizes the provider, either with the
+	// arguments given to `pulumi package add` or with the [Parameterization.Value] of an
+	// SDK that was generated earlier. The resources, components and functions of the
+	// returned Parameterization then replace those of Options for every following
+	// request, and GetSchema returns the schema of the parameterized package.
+	Parameterize func(context.Context, p.ParameterizeRequest) (Parameterization, error)
 }
 
 func (o Options) dispatch() dispatch.Options {
@@ -84,19 +159,28 @@ func (o Options) dispatch() dispatch.Options {
 	for _, r := range o.Functions {
 		typ, err := r.GetToken()
 		contract.AssertNoErrorf(err, "failed to get token for function %v", r)
-		functions[typ] = r
+		functions[o.token(typ)] = r
 	}
 	customs := map[tokens.Type]t.CustomResource{}
 	for _, r := range o.Resources {
 		typ, err := r.GetToken()
 		contract.AssertNoErrorf(err, "failed to get token for resource %v", r)
-		customs[typ] = r
+		customs[o.token(typ)] = recoveringResource{r}
+	}
+	// State written under an aliased token is still served by the aliasing resource, so
+	// existing stacks can upgrade without replacing it. Current tokens take precedence.
+	for _, r := range o.Resources {
+		for _, alias := range r.getAliases() {
+			if _, ok := customs[alias]; !ok {
+				customs[alias] = recoveringResource{r}
+			}
+		}
 	}
 	components := map[tokens.Type]t.ComponentResource{}
 	for _, r := range o.Components {
 		typ, err := r.GetToken()
 		contract.AssertNoErrorf(err, "failed to get token for component %v", r)
-		components[typ] = r
+		components[o.token(typ)] = r
 	}
 	return dispatch.Options{
 		Customs:    customs,
@@ -107,31 +191,80 @@ func (o Options) dispatch() dispatch.Options {
 }
 
 func (o Options) schema() schema.Options {
+	// Anonymous structs are named after the first field they are found in. Collecting the
+	// tokens names them in the order of o, before the schemas of the elements are
+	// generated concurrently.
+	o.collectTokens(func(tokenKind, tokens.Type, reflect.Type) {})
+	tags := o.tagOptions()
 	resources := make([]schema.Resource, len(o.Resources)+len(o.Components))
 	for i, r := range o.Resources {
-		resources[i] = r
+		resources[i] = withTagOptions[pschema.ResourceSpec]{r, tags}
 	}
 	for i, c := range o.Components {
-		resources[i+len(o.Resources)] = c
+		resources[i+len(o.Resources)] = withTagOptions[pschema.ResourceSpec]{c, tags}
 	}
 	functions := make([]schema.Function, len(o.Functions))
 	for i, f := range o.Functions {
-		functions[i] = f
+		functions[i] = withTagOptions[pschema.FunctionSpec]{f, tags}
+	}
+	var config schema.Resource
+	if o.Config != nil {
+		config = withTagOptions[pschema.ResourceSpec]{o.Config, tags}
 	}
 
 	return schema.Options{
-		Resources: resources,
-		Invokes:   functions,
-		Provider:  o.Config,
-		Metadata:  o.Metadata,
-		ModuleMap: o.ModuleMap,
+		Resources:   resources,
+		Invokes:     functions,
+		Provider:    config,
+		Metadata:    o.Metadata,
+		ModuleMap:   o.ModuleMap,
+		RenameToken: o.token,
+		Strict:      o.Strict,
+	}
+}
+
+// SchemaForToken generates the schema of the resource or function tok, along with the
+// types it references, without generating the rest of the package. tok is a token of the
+// generated schema, such as "pkg:index:Bucket".
+func (o Options) SchemaForToken(tok tokens.Type) (pschema.PackageSpec, error) {
+	return schema.Subset(string(tok.Package()), o.schema(), func(t tokens.Type) bool {
+		return t == tok
+	})
+}
+
+// SchemaForModule generates the schema of the resources and functions of the module mod,
+// such as "pkg:index", along with the types they reference, without generating the rest
+// of the package.
+func (o Options) SchemaForModule(mod tokens.Module) (pschema.PackageSpec, error) {
+	return schema.Subset(string(mod.Package()), o.schema(), func(t tokens.Type) bool {
+		return t.Module() == mod
+	})
+}
+
+// ValidateSchema generates the schema of the package name described by opts, and returns
+// the diagnostics that Pulumi's schema validator reports for it. It lets providers catch
+// schema errors in their tests, before SDKs are generated from the schema. No plugins are
+// loaded, so it fails on references to the types and resources of other packages:
+//
+//	diags, err := infer.ValidateSchema("mypkg", opts)
+//	require.NoError(t, err)
+//	assert.False(t, diags.HasErrors(), diags.Error())
+func ValidateSchema(name string, opts Options) (hcl.Diagnostics, error) {
+	spec, err := schema.Generate(name, opts.schema())
+	if err != nil {
+		return nil, err
 	}
+	return schema.Validate(spec, nil)
 }
 
 // Provider creates a new inferred provider from `opts`.
 //
 // To customize the resulting provider, including setting resources, functions, config options and other
 // schema metadata, look at the [Options] struct.
+//
+// The returned provider can be wrapped with other middleware, such as
+// [github.com/pulumi/pulumi-go-provider/middleware/cancel.Wrap] or middleware of your own.
+// See [github.com/pulumi/pulumi-go-provider/middleware] for how to write one.
 func Provider(opts Options) p.Provider {
 	return Wrap(p.Provider{}, opts)
 }
@@ -141,8 +274,21 @@ func Provider(opts Options) p.Provider {
 // The resulting provider will respond to resources and functions that are described in `opts`, delegating
 // unknown calls to the underlying provider.
 func Wrap(provider p.Provider, opts Options) p.Provider {
+	if err := opts.Validate(); err != nil {
+		return invalidOptions(provider, err)
+	}
+	for _, u := range opts.Unions {
+		u.register()
+	}
 	provider = dispatch.Wrap(provider, opts.dispatch())
+	provider.Cancel = cancelResources(provider.Cancel, opts.Resources)
 	provider = schema.Wrap(provider, opts.schema())
+	if fields := opts.anyFields(); len(fields) > 0 {
+		provider.GetSchema = logAnyFields(provider.GetSchema, fields)
+	}
+	if opts.Parameterize != nil {
+		provider = parameterized(provider, opts)
+	}
 
 	config := opts.Config
 	if config != nil {
@@ -168,10 +314,63 @@ func Wrap(provider p.Provider, opts Options) p.Provider {
 		})
 	}
 
+	if opts.ExplainDiffs || opts.ReportDrift {
+		provider = mContext.Wrap(provider, func(ctx context.Context) context.Context {
+			ctx = context.WithValue(ctx, explainDiffsKey, opts.ExplainDiffs)
+			return context.WithValue(ctx, reportDriftKey, opts.ReportDrift)
+		})
+	}
+
+	if opts.PointersAreOptional {
+		provider = mContext.Wrap(provider, func(ctx context.Context) context.Context {
+			return context.WithValue(ctx, tagOptionsKey, opts.tagOptions())
+		})
+	}
+
+	if opts.CheckOptionalValues {
+		if values := opts.optionalValues(); len(values) > 0 && opts.Strict {
+			provider.GetSchema = rejectOptionalValues(provider.GetSchema, values)
+		} else if len(values) > 0 {
+			provider.Configure = warnOptionalValues(provider.Configure, values)
+		}
+	}
+
 	provider = complexconfig.Wrap(provider)
 	return cancel.Wrap(provider)
 }
 
+// tagOptions returns the options that the provider parses the tags of its types with.
+func (o Options) tagOptions() introspect.TagOptions {
+	return introspect.TagOptions{PointersOptional: o.PointersAreOptional}
+}
+
+// tagOptions returns the options that the tags of types are parsed with while serving a
+// request with ctx. See [Options.tagOptions].
+func tagOptions(ctx context.Context) introspect.TagOptions {
+	tags, _ := ctx.Value(tagOptionsKey).(introspect.TagOptions)
+	return tags
+}
+
+// schemaElement is a resource, component, function or config whose schema depends on the
+// options that the tags of its types are parsed with.
+type schemaElement[S any] interface {
+	GetToken() (tokens.Type, error)
+	getSchema(reg schema.RegisterDerivativeType, tags introspect.TagOptions) (S, error)
+}
+
+// withTagOptions presents el to the schema middleware, which generates its schema with
+// the tag options of the provider.
+type withTagOptions[S any] struct {
+	el   schemaElement[S]
+	tags introspect.TagOptions
+}
+
+func (w withTagOptions[S]) GetToken() (tokens.Type, error) { return w.el.GetToken() }
+
+func (w withTagOptions[S]) GetSchema(reg schema.RegisterDerivativeType) (S, error) {
+	return w.el.getSchema(reg, w.tags)
+}
+
 // GetConfig retrieves the configuration of this provider.
 //
 // Note: GetConfig will panic if the type of T does not match the type of the config or if
@@ -0,0 +1,76 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infer
+
+import (
+	"context"
+	"runtime/debug"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	t "github.com/pulumi/pulumi-go-provider/middleware"
+)
+
+// recoveringResource serves a custom resource, converting the panics of its methods into
+// errors. A bug in one resource then fails the operation on that resource, instead of
+// crashing the provider and with it the whole deployment.
+type recoveringResource struct{ t.CustomResource }
+
+// recoverPanic converts a panic of the method of the resource urn into an error, which is
+// stored in err. The stack of the panic is logged as a debug message, which is shown when
+// Pulumi runs with verbose logging.
+//
+// recoverPanic must be called with defer.
+func recoverPanic(ctx context.Context, method string, urn resource.URN, err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	p.GetLogger(ctx).Debugf("%s of %s panicked: %v\n%s", method, urn, r, debug.Stack())
+	*err = status.Errorf(codes.Internal, "%s of %s (%s) panicked: %v", method, urn.Name(), urn.Type(), r)
+}
+
+func (r recoveringResource) Check(ctx context.Context, req p.CheckRequest) (_ p.CheckResponse, err error) {
+	defer recoverPanic(ctx, "Check", req.Urn, &err)
+	return r.CustomResource.Check(ctx, req)
+}
+
+func (r recoveringResource) Diff(ctx context.Context, req p.DiffRequest) (_ p.DiffResponse, err error) {
+	defer recoverPanic(ctx, "Diff", req.Urn, &err)
+	return r.CustomResource.Diff(ctx, req)
+}
+
+func (r recoveringResource) Create(ctx context.Context, req p.CreateRequest) (_ p.CreateResponse, err error) {
+	defer recoverPanic(ctx, "Create", req.Urn, &err)
+	return r.CustomResource.Create(ctx, req)
+}
+
+func (r recoveringResource) Read(ctx context.Context, req p.ReadRequest) (_ p.ReadResponse, err error) {
+	defer recoverPanic(ctx, "Read", req.Urn, &err)
+	return r.CustomResource.Read(ctx, req)
+}
+
+func (r recoveringResource) Update(ctx context.Context, req p.UpdateRequest) (_ p.UpdateResponse, err error) {
+	defer recoverPanic(ctx, "Update", req.Urn, &err)
+	return r.CustomResource.Update(ctx, req)
+}
+
+func (r recoveringResource) Delete(ctx context.Context, req p.DeleteRequest) (err error) {
+	defer recoverPanic(ctx, "Delete", req.Urn, &err)
+	return r.CustomResource.Delete(ctx, req)
+}
@@ -19,6 +19,12 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
@@ -34,6 +40,7 @@ import (
 	"github.com/pulumi/pulumi-go-provider/infer/internal/ende"
 	"github.com/pulumi/pulumi-go-provider/internal"
 	"github.com/pulumi/pulumi-go-provider/internal/introspect"
+	"github.com/pulumi/pulumi-go-provider/internal/key"
 	"github.com/pulumi/pulumi-go-provider/internal/putil"
 	t "github.com/pulumi/pulumi-go-provider/middleware"
 	"github.com/pulumi/pulumi-go-provider/middleware/schema"
@@ -46,7 +53,28 @@ import (
 // inputs and `O` the full set of resource fields. It is recommended that `O` is a
 // superset of `I`, but it is not strictly required. The fields of `I` and `O` should
 // consist of non-pulumi types i.e. `string` and `int` instead of `pulumi.StringInput` and
-// `pulumi.IntOutput`.
+// `pulumi.IntOutput`. Fields of type `any` or [encoding/json.RawMessage] accept arbitrary
+// values and are typed as `pulumi.json#/Any` in the schema. A json.RawMessage field holds
+// the JSON encoding of its value. A `[]byte` field is typed as a string, holding its
+// bytes encoded as base64. A fixed-size array field such as `[3]string` is typed as an
+// array of its elements, and Check rejects inputs that don't have exactly its number of
+// elements. `[N]byte` is such an array, of integers rather than a base64 string.
+//
+// A [math/big.Int] or [math/big.Float] field is typed as a string, holding the number in
+// decimal so that it keeps its precision. Check reports strings that don't parse as a
+// number of the field's type. A big.Float is given enough precision for every digit of
+// its string.
+//
+// A field of I tagged `pulumi:"name,computed"` is set by the provider: it is left out of
+// the resource's inputs in the schema and Check rejects user supplied values for it. This
+// allows I and O to share a single state struct.
+//
+// A field tagged `provider:"secret"` is marked secret in the schema. The value of a
+// secret field of O is always returned to the engine as a secret, even when it is
+// computed by the provider, such as a generated password.
+//
+// A panic in a method of the resource fails that operation with an error, instead of
+// crashing the provider. The stack of the panic is logged at the debug level.
 //
 // The behavior of a CustomResource resource can be extended by implementing any of the
 // following interfaces on the resource controller:
@@ -57,6 +85,8 @@ import (
 // - [CustomRead]
 // - [CustomDelete]
 // - [CustomStateMigrations]
+// - [CustomMigrateState]
+// - [Cancellable]
 // - [Annotated]
 //
 // Example:
@@ -93,10 +123,26 @@ type CustomResource[I, O any] interface {
 	CustomCreate[I, O]
 }
 
+// CustomCreate describes a resource that can be created.
+//
+// When Create fails after it has allocated the resource, it should return the ID of the
+// resource and the state it knows alongside a [PartialError]. The resource is then
+// recorded as created with errors, so that it can be updated or deleted instead of being
+// leaked. Any other error means that no resource was created, even if it is returned with
+// an ID.
 type CustomCreate[I, O any] interface {
 	Create(ctx context.Context, name string, inputs I, preview bool) (id string, output O, err error)
 }
 
+// IsPreview reports whether ctx belongs to a Create or Update that is part of `pulumi
+// preview`. It matches the preview argument passed to Create and Update, and allows
+// helpers that only receive ctx to avoid making changes during a preview.
+//
+// IsPreview is the same as [p.IsDryRun].
+func IsPreview(ctx context.Context) bool {
+	return p.IsDryRun(ctx)
+}
+
 // CustomCheck describes a resource that understands how to check its inputs.
 //
 // By default, infer handles checks by ensuring that a inputs de-serialize correctly,
@@ -106,9 +152,31 @@ type CustomCreate[I, O any] interface {
 // This is where you can extend that behavior. The
 // returned input is given to subsequent calls to `Create` and `Update`.
 //
+// CustomCheck is only called once the inputs have been checked to decode into I, so it
+// is a good place for validation that spans several fields. Annotation driven defaults
+// and secrets are not applied to the inputs of a CustomCheck. To keep them, call
+// [DefaultCheck] first and work with its result.
+//
 // Example:
-// TODO - Maybe a resource that has a regex. We could reject invalid regex before the up
-// actually happens.
+//
+//	func (*Pool) Check(ctx context.Context, name string, oldInputs, newInputs resource.PropertyMap) (
+//		PoolArgs, []p.CheckFailure, error) {
+//		args, failures, err := infer.DefaultCheck[PoolArgs](ctx, newInputs)
+//		if err != nil || len(failures) > 0 {
+//			return args, failures, err
+//		}
+//		if args.MaxSize < args.MinSize {
+//			failures = append(failures, p.CheckFailure{
+//				Property: "maxSize",
+//				Reason:   "maxSize must be at least minSize",
+//			})
+//		}
+//		if args.DesiredSize == nil {
+//			// Default the desired size from the other fields.
+//			args.DesiredSize = &args.MinSize
+//		}
+//		return args, failures, nil
+//	}
 type CustomCheck[I any] interface {
 	// Maybe oldInputs can be of type I
 	Check(
@@ -122,25 +190,56 @@ type CustomCheck[I any] interface {
 // By default, infer handles diffs by structural equality among inputs. If CustomUpdate is
 // implemented, changes will result in updates. Otherwise changes will result in replaces.
 //
+// A CustomDiff reports each changed property in DetailedDiff, along with whether changing
+// it requires a replacement. If DetailedDiff contains any changes, HasChanges is implied.
+//
 // Example:
-// TODO - Indicate replacements for certain changes but not others.
+//
+//	func (*Bucket) Diff(ctx context.Context, id string, olds BucketState, news BucketArgs) (p.DiffResponse, error) {
+//		diff := map[string]p.PropertyDiff{}
+//		if olds.Name != news.Name {
+//			// Buckets cannot be renamed in place.
+//			diff["name"] = p.PropertyDiff{Kind: p.UpdateReplace}
+//		}
+//		if !maps.Equal(olds.Tags, news.Tags) {
+//			diff["tags"] = p.PropertyDiff{Kind: p.Update}
+//		}
+//		return p.DiffResponse{
+//			DeleteBeforeReplace: true,
+//			DetailedDiff:        diff,
+//		}, nil
+//	}
 type CustomDiff[I, O any] interface {
 	// Maybe oldInputs can be of type I
 	Diff(ctx context.Context, id string, olds O, news I) (p.DiffResponse, error)
 }
 
-// CustomUpdate descibes a resource that can adapt to new inputs with a delete and
-// replace.
+// CustomUpdate describes a resource that can adapt to new inputs in place, without a
+// delete and replace.
 //
-// There is no default behavior for CustomUpdate.
+// There is no default behavior for CustomUpdate. Resources that don't implement it are
+// replaced whenever their inputs change. Resources that do implement it are updated in
+// place, except when a field tagged `provider:"replaceOnChanges"` or annotated with
+// [Annotator.SetReplaceOnChanges] changes, or a [CustomDiff] asks for a replacement.
 //
 // Here the old state (as returned by Create or Update) as well as the new inputs are
-// passed. Update should return the new state of the resource. If preview is true, then
-// the update is part of `pulumi preview` and no changes should be made.
+// passed. Update should return the new state of the resource, which replaces the old
+// state. If preview is true, then the update is part of `pulumi preview` and no changes
+// should be made: Update should return the state it expects the resource to have after
+// the update.
 //
 // Example:
 //
-//	TODO
+//	func (*Volume) Update(
+//		ctx context.Context, id string, olds VolumeState, news VolumeArgs, preview bool,
+//	) (VolumeState, error) {
+//		state := VolumeState{VolumeArgs: news, Zone: olds.Zone}
+//		if preview || olds.Size == news.Size {
+//			return state, nil
+//		}
+//		// Volumes can be resized in place.
+//		return state, resizeVolume(ctx, id, news.Size)
+//	}
 type CustomUpdate[I, O any] interface {
 	Update(ctx context.Context, id string, olds O, news I, preview bool) (O, error)
 }
@@ -151,8 +250,28 @@ type CustomUpdate[I, O any] interface {
 // fit into I and O respectively. If they do, then the values will be returned as is.
 // Otherwise an error will be returned.
 //
+// On `pulumi refresh`, Read is called with the inputs and state recorded for the
+// resource, and returns them reconciled with the live resource: fields that drifted take
+// their live values, and the returned state replaces the recorded state. Returning an
+// empty canonicalID tells the engine that the resource no longer exists. With
+// [Options.ReportDrift], the fields of the state that Read changed are logged.
+//
+// CustomRead is also how resources are imported with `pulumi import`. On import, only the
+// ID is known: Read is called with zero valued inputs and state, and is expected to
+// reconstruct both from the live resource. Resources that don't implement CustomRead
+// cannot be imported.
+//
 // Example:
-// TODO - Probably something to do with the file system.
+//
+//	func (*File) Read(ctx context.Context, id string, inputs FileArgs, state FileState) (
+//		string, FileArgs, FileState, error) {
+//		content, err := os.ReadFile(id)
+//		if err != nil {
+//			return "", FileArgs{}, FileState{}, err
+//		}
+//		args := FileArgs{Path: id, Content: string(content)}
+//		return id, args, FileState{args}, nil
+//	}
 type CustomRead[I, O any] interface {
 	// Read accepts a resource id, and a best guess of the input and output state. It returns
 	// a normalized version of each, assuming it can be recovered.
@@ -168,6 +287,20 @@ type CustomDelete[O any] interface {
 	Delete(ctx context.Context, id string, props O) error
 }
 
+// Cancellable describes a resource that holds external handles, such as connections or
+// locks, that must be released when the engine cancels the current operation (for
+// example, when the user interrupts `pulumi up`).
+//
+// Each Create, Read, Update and Delete runs on a new instance of the resource. When the
+// provider is canceled, Cancel is called on the instance of each of these operations that
+// is still in flight, after the contexts of all in-flight operations have been canceled.
+// A handle that an operation stores in a field of its receiver, such as a connection
+// opened by Create, can so be released by Cancel. The resource must be a pointer type,
+// such as *Database, for its operations and Cancel to share fields.
+type Cancellable interface {
+	Cancel(ctx context.Context) error
+}
+
 // StateMigrationFunc represents a stateless mapping from an old state shape to a new
 // state shape. Each StateMigrationFunc is parameterized by the shape of the type it
 // produces, ensuring that all successful migrations end up in a valid state.
@@ -249,6 +382,30 @@ type CustomStateMigrations[O any] interface {
 	StateMigrations(ctx context.Context) []StateMigrationFunc[O]
 }
 
+// CustomMigrateState upgrades the recorded state of a resource whose version is set with
+// [Annotator.SetVersion], such as when the type of a property changes shape.
+//
+// When the state of the resource was recorded with an older version, MigrateState is
+// called once for each version up to the current one, with the state at oldVersion. It
+// returns the state at oldVersion+1. Migrations run before the state is diffed, read,
+// updated or deleted, and before [CustomStateMigrations]. When the resource is read, the
+// inputs recorded with the state are migrated with MigrateState too.
+//
+//	func (*Bucket) MigrateState(
+//		ctx context.Context, oldVersion int, old resource.PropertyMap,
+//	) (resource.PropertyMap, error) {
+//		switch oldVersion {
+//		case 1:
+//			// v2 replaced the tag string with a list of tags.
+//			old["tags"] = resource.NewArrayProperty([]resource.PropertyValue{old["tag"]})
+//			delete(old, "tag")
+//		}
+//		return old, nil
+//	}
+type CustomMigrateState interface {
+	MigrateState(ctx context.Context, oldVersion int, old resource.PropertyMap) (resource.PropertyMap, error)
+}
+
 // Annotator is used as part of [Annotated] to describe schema metadata for a resource or
 // type.
 //
@@ -268,8 +425,31 @@ type Annotator interface {
 
 	// Annotate a struct field with a default value. The default value must be a primitive
 	// type in the pulumi type system.
+	//
+	// If env is provided, the first non-empty environment variable in env takes precedence
+	// over defaultValue. To default a field only from the environment, pass a nil
+	// defaultValue:
+	//
+	//	a.SetDefault(&c.Token, nil, "MYPKG_TOKEN")
 	SetDefault(i any, defaultValue any, env ...string)
 
+	// Annotate a struct field with a function that computes its default value, such as a
+	// name derived from another input.
+	//
+	// f is called when the inputs are checked and the field is unset, after the defaults
+	// set with SetDefault have been applied, so it only acts as a fallback. args is a copy
+	// of the struct that holds the field, with its other inputs decoded. If f returns a
+	// nil value, the field is left unset.
+	//
+	//	a.SetDefaultFunc(&r.Name, func(ctx context.Context, args any) (any, error) {
+	//		return args.(BucketArgs).Prefix + "-bucket", nil
+	//	})
+	SetDefaultFunc(i any, f func(ctx context.Context, args any) (any, error))
+
+	// Annotate a struct field with the format of its value, such as "date-time" for
+	// time.Time fields. The format is recorded in the generated schema.
+	SetFieldFormat(i any, format string)
+
 	// Set the token of the annotated type.
 	//
 	// module and name should be valid Pulumi token segments. The package name will be
@@ -283,6 +463,7 @@ type Annotator interface {
 	//
 	//	mypkg:mymodule:MyResource
 	//
+	// A token set with SetToken takes precedence over [Options.TokenStrategy].
 	SetToken(module tokens.ModuleName, name tokens.TypeName)
 
 	// Add a type [alias](https://www.pulumi.com/docs/using-pulumi/pulumi-packages/schema/#alias) for
@@ -290,10 +471,179 @@ type Annotator interface {
 	//
 	// The module and the name will be assembled into a type specifier of the form
 	// `mypkg:mymodule:MyResource`, in the same way `SetToken` does.
+	//
+	// Requests made against an alias are served by this resource, so state written under
+	// the old token continues to work after the rename.
 	AddAlias(module tokens.ModuleName, name tokens.TypeName)
 
 	// Set a deprecation message for the resource, which officially marks it as deprecated.
 	SetResourceDeprecationMessage(message string)
+
+	// Add a previous name for a struct field, such as after renaming an input property.
+	//
+	// Properties set under alias are treated as if they were set under the field's
+	// current name, so existing stacks don't see a diff. The alias is kept in the schema
+	// as a deprecated property.
+	//
+	//	a.AddPropertyAlias(&r.Bucket, "bucketName")
+	AddPropertyAlias(i any, alias string)
+
+	// Mark a struct field as deprecated, with a message explaining what to use instead.
+	// The field continues to work as before.
+	//
+	// Deprecating the annotated struct itself is equivalent to
+	// SetResourceDeprecationMessage:
+	//
+	//	a.Deprecate(&r.OldField, "use NewField instead")
+	//	a.Deprecate(&r, "use NewResource instead")
+	Deprecate(i any, message string)
+
+	// Mark the annotated type as a case of a union registered with [Union]. The field i
+	// is the discriminator property, and value identifies this case.
+	//
+	// For example:
+	//
+	//	func (s *GitSource) Annotate(a infer.Annotator) {
+	//		a.SetDiscriminator(&s.Kind, "git")
+	//	}
+	SetDiscriminator(i any, value string)
+
+	// Fix a struct field to a constant value, such as the API version implied by the
+	// resource type. The value must be a primitive type in the pulumi type system.
+	//
+	// Constant resource inputs are omitted from the schema's input properties, and Check
+	// always sets them to value:
+	//
+	//	a.SetConst(&r.APIVersion, "v1")
+	SetConst(i any, value any)
+
+	// Set the smallest value a numeric struct field may hold. Check reports a failure for
+	// smaller values.
+	//
+	//	a.SetMinimum(&r.Port, 1)
+	SetMinimum(i any, minimum float64)
+
+	// Set the largest value a numeric struct field may hold. Check reports a failure for
+	// larger values.
+	//
+	//	a.SetMaximum(&r.Port, 65535)
+	SetMaximum(i any, maximum float64)
+
+	// Set a regular expression that the value of a string struct field must match. Check
+	// reports a failure for values that don't match.
+	//
+	//	a.SetPattern(&r.Name, "^[a-z0-9-]+$")
+	SetPattern(i any, pattern string)
+
+	// Override the name of a struct field in the SDK generated for language, such as
+	// "csharp". The override is recorded in the language section of the property's
+	// schema, and is applied by SDK generators that support renaming properties.
+	//
+	//	a.SetLanguageName(&r.ID, "csharp", "ResourceId")
+	SetLanguageName(i any, language, name string)
+
+	// Set the default timeout for creating the resource. A timeout set by the user with
+	// the customTimeouts resource option takes precedence.
+	//
+	// When Create times out after allocating an ID, the resource is recorded as partially
+	// initialized so that it isn't leaked.
+	SetCreateTimeout(timeout time.Duration)
+
+	// Set the default timeout for updating the resource. A timeout set by the user with
+	// the customTimeouts resource option takes precedence.
+	SetUpdateTimeout(timeout time.Duration)
+
+	// Set the default timeout for deleting the resource. A timeout set by the user with
+	// the customTimeouts resource option takes precedence.
+	SetDeleteTimeout(timeout time.Duration)
+
+	// Require replacements of the resource to delete the old resource before creating the
+	// new one, such as when two instances can't coexist because their names must be
+	// unique. By default, the new resource is created first.
+	//
+	// This sets DeleteBeforeReplace on every diff of the resource, including diffs
+	// returned by [CustomDiff].
+	SetDeleteBeforeReplace(deleteBeforeReplace bool)
+
+	// Return the outputs that Create and Update set during a preview as known values. By
+	// default, every output of a previewed Create that doesn't mirror an input is unknown.
+	// With partial values, only the outputs that are left as zero values are unknown, so
+	// resources that depend on the outputs computed during the preview see their values.
+	//
+	// An output that depends on an unknown input is unknown either way, see
+	// [ExplicitDependencies]. Use a pointer to return a zero value as known, such as a
+	// *bool set to false.
+	SetSupportsPartialValues(supportsPartialValues bool)
+
+	// Annotate a struct field to replace the resource when its value changes, as with the
+	// `provider:"replaceOnChanges"` tag. Changes to values nested in the field also
+	// replace the resource, so annotating the field spec has the effect of the path
+	// "spec.*".
+	//
+	// Like the tag, this only applies to diffs computed without a [CustomDiff].
+	SetReplaceOnChanges(i any)
+
+	// Accept the values of the annotated string enum in any case. Check replaces a value
+	// that matches an allowed value regardless of case with the allowed value, so that
+	// only canonical values reach the provider. The schema still lists canonical values.
+	//
+	//	func (*Tier) Annotate(a infer.Annotator) {
+	//		a.SetEnumCaseInsensitive(true)
+	//	}
+	SetEnumCaseInsensitive(caseInsensitive bool)
+
+	// Designate a string field of the output type of a resource as its ID, such as its
+	// ARN. The field must be set by Create, which may then return an empty ID:
+	//
+	//	func (s *QueueState) Annotate(a infer.Annotator) {
+	//		a.SetID(&s.Arn)
+	//	}
+	//
+	// The ID of the resource is taken from the field, and the state passed to [CustomRead]
+	// holds the ID in the field when it is read for an import.
+	SetID(i any)
+
+	// Set the format of the IDs that the resource is imported with, for resources whose
+	// ID is made of several properties. Each property is named in braces, and is
+	// separated from the next one by literal text:
+	//
+	//	func (*Instance) Annotate(a infer.Annotator) {
+	//		a.SetImportIDFormat("{project}/{zone}/{instance}")
+	//	}
+	//
+	// When the resource is imported, the properties are parsed from the ID and set in the
+	// inputs and state passed to [CustomRead]. IDs that don't match the format fail the
+	// import.
+	SetImportIDFormat(format string)
+
+	// Set the version of the state of the resource, starting at 1, and record it in the
+	// state of the resource. When the version is raised, [CustomMigrateState] upgrades
+	// state that was recorded with an older version. State recorded before the resource
+	// set a version is at version 1.
+	//
+	//	func (*Bucket) Annotate(a infer.Annotator) {
+	//		a.SetVersion(2)
+	//	}
+	SetVersion(version int)
+
+	// Name the resource automatically when the string struct field is unset, as in the
+	// aws provider. Check sets the field to the logical name of the resource, followed by
+	// a dash and a random suffix of 7 characters, such as "bucket-4f1c8a2". The logical
+	// name is shortened so that the name is at most maxLen characters long.
+	//
+	//	func (b *BucketArgs) Annotate(a infer.Annotator) {
+	//		a.AutoName(&b.Name, 63)
+	//	}
+	//
+	// The suffix is derived from the random seed the engine sends with Check, so a
+	// preview and the update that follows it choose the same name. Once named, the
+	// resource keeps its name: updates that leave the field unset reuse the name from the
+	// prior inputs of the resource.
+	//
+	// The field should be optional, so that users may leave it unset. AutoName only
+	// applies to the input type of a resource, and panics if maxLen is shorter than 9
+	// characters.
+	AutoName(i any, maxLen int)
 }
 
 // Annotated is used to describe the fields of an object or a resource. Annotated can be
@@ -382,6 +732,10 @@ type fieldGenerator struct {
 	err          multierror.Error
 
 	fields map[string]*field
+
+	// zeroOutputs holds the outputs that were left as zero values, when the resource
+	// supports partial values during a preview. See [Annotator.SetSupportsPartialValues].
+	zeroOutputs map[string]bool
 }
 
 func (g *fieldGenerator) getField(name string) *field {
@@ -419,16 +773,24 @@ func (d dependency) has(kind inputKind) bool {
 // computedness and secretness as appropriate.
 func (g *fieldGenerator) MarkMap(isCreate, isPreview bool) func(oldInputs, inputs, m resource.PropertyMap) {
 	return func(oldInputs, inputs, m resource.PropertyMap) {
+		if isPreview {
+			// Outputs left unset are left out of m, so they are added as unknowns.
+			for k := range g.zeroOutputs {
+				if _, ok := m[resource.PropertyKey(k)]; !ok && !g.getField(k).known {
+					m[resource.PropertyKey(k)] = resource.MakeComputed(resource.NewNullProperty())
+				}
+			}
+		}
 		// Flow secretness and computedness
 		for k, v := range m {
-			m[k] = markField(g.getField(string(k)), k, v, oldInputs, inputs, isCreate, isPreview)
+			m[k] = markField(g.getField(string(k)), k, v, oldInputs, inputs, isCreate, isPreview, g.zeroOutputs)
 		}
 	}
 }
 
 func markComputed(
 	field *field, key resource.PropertyKey, prop resource.PropertyValue,
-	oldInputs, inputs resource.PropertyMap, isCreate bool,
+	oldInputs, inputs resource.PropertyMap, isCreate bool, zeroOutputs map[string]bool,
 ) resource.PropertyValue {
 	// If the value is already computed or if it is guaranteed to be known, we don't need to do anything
 	if field.known || putil.IsComputed(prop) {
@@ -441,8 +803,14 @@ func markComputed(
 		return prop
 	}
 
-	// If this is during a create and the value is not explicitly marked as known, we mark it computed.
-	if isCreate {
+	if zeroOutputs != nil {
+		// The resource supports partial values, so only the outputs it left unset are
+		// computed, along with those whose dependencies are.
+		if zeroOutputs[string(key)] {
+			return putil.MakeComputed(prop)
+		}
+	} else if isCreate {
+		// If this is during a create and the value is not explicitly marked as known, we mark it computed.
 		return putil.MakeComputed(prop)
 	}
 
@@ -515,11 +883,11 @@ func markSecret(
 
 func markField(
 	field *field, key resource.PropertyKey, prop resource.PropertyValue,
-	oldInputs, inputs resource.PropertyMap, isCreate, isPreview bool,
+	oldInputs, inputs resource.PropertyMap, isCreate, isPreview bool, zeroOutputs map[string]bool,
 ) resource.PropertyValue {
 	// Fields can only be computed during preview. They must be known by when the resource is actually created.
 	if isPreview {
-		prop = markComputed(field, key, prop, oldInputs, inputs, isCreate)
+		prop = markComputed(field, key, prop, oldInputs, inputs, isCreate, zeroOutputs)
 	}
 
 	return markSecret(field, key, prop, inputs)
@@ -579,11 +947,11 @@ func (g *fieldGenerator) OutputField(a any) OutputField {
 	return &errField{}
 }
 
-func newFieldGenerator(i, o any) *fieldGenerator {
+func newFieldGenerator(i, o any, tags introspect.TagOptions) *fieldGenerator {
 	return &fieldGenerator{
 		args: i, state: o,
-		argsMatcher:  introspect.NewFieldMatcher(i),
-		stateMatcher: introspect.NewFieldMatcher(o),
+		argsMatcher:  introspect.NewFieldMatcher(i, tags),
+		stateMatcher: introspect.NewFieldMatcher(o, tags),
 		err: multierror.Error{
 			ErrorFormat: func(es []error) string {
 				return "wiring error: " + multierror.ListFormatFunc(es)
@@ -812,6 +1180,22 @@ type InferredResource interface {
 	schema.Resource
 
 	isInferredResource()
+	// getAliases returns the previous tokens of the resource, as registered with
+	// [Annotator.AddAlias].
+	getAliases() []tokens.Type
+	// cancel calls the Cancel hook of the resource, if it implements [Cancellable].
+	cancel(ctx context.Context) error
+	// collectTokens records the tokens used by the resource. See [Options.Validate].
+	collectTokens(add addToken, tags introspect.TagOptions)
+	// getSchema is GetSchema with the options that the tags of the resource's types are
+	// parsed with. See [Options.PointersAreOptional].
+	getSchema(reg schema.RegisterDerivativeType, tags introspect.TagOptions) (pschema.ResourceSpec, error)
+	// optionalValues describes the optional fields of the resource that are not
+	// pointers. See [Options.CheckOptionalValues].
+	optionalValues(tags introspect.TagOptions) []string
+	// anyFields describes the fields of the resource that are described as any. See
+	// [anyFields].
+	anyFields(tags introspect.TagOptions) []string
 }
 
 // Resource creates a new InferredResource, where `R` is the resource controller, `I` is
@@ -820,22 +1204,47 @@ func Resource[R CustomResource[I, O], I, O any]() InferredResource {
 	return &derivedResourceController[R, I, O]{}
 }
 
-type derivedResourceController[R CustomResource[I, O], I, O any] struct{}
+type derivedResourceController[R CustomResource[I, O], I, O any] struct {
+	// inFlight holds the instances of the Create, Read, Update and Delete calls that are
+	// in flight, when R implements [Cancellable].
+	m        sync.Mutex
+	inFlight map[*R]struct{}
+}
 
 func (*derivedResourceController[R, I, O]) isInferredResource() {}
 
-func (*derivedResourceController[R, I, O]) GetSchema(reg schema.RegisterDerivativeType) (
+func (rc *derivedResourceController[R, I, O]) GetSchema(reg schema.RegisterDerivativeType) (
 	pschema.ResourceSpec, error) {
-	if err := registerTypes[I](reg); err != nil {
+	return rc.getSchema(reg, introspect.TagOptions{})
+}
+
+func (*derivedResourceController[R, I, O]) getSchema(
+	reg schema.RegisterDerivativeType, tags introspect.TagOptions,
+) (pschema.ResourceSpec, error) {
+	if err := registerTypes[I](reg, tags); err != nil {
 		return pschema.ResourceSpec{}, err
 	}
-	if err := registerTypes[O](reg); err != nil {
+	if err := registerTypes[O](reg, tags); err != nil {
 		return pschema.ResourceSpec{}, err
 	}
-	r, errs := getResourceSchema[R, I, O](false)
+	r, errs := getResourceSchema[R, I, O](false, tags)
 	return r, errs.ErrorOrNil()
 }
 
+func (*derivedResourceController[R, I, O]) collectTokens(add addToken, tags introspect.TagOptions) {
+	collectElementToken[R](add, resourceToken, nil)
+	collectTypeTokens[I](add, tags)
+	collectTypeTokens[O](add, tags)
+}
+
+func (*derivedResourceController[R, I, O]) optionalValues(tags introspect.TagOptions) []string {
+	return append(optionalValues[I](tags), optionalValues[O](tags)...)
+}
+
+func (*derivedResourceController[R, I, O]) anyFields(tags introspect.TagOptions) []string {
+	return append(anyFields[I](tags), anyFields[O](tags)...)
+}
+
 func getToken[R any](transform func(tokens.Type) tokens.Type) (tokens.Type, error) {
 	var r R
 	return getTokenOf(reflect.TypeOf(r), transform)
@@ -846,26 +1255,68 @@ func getTokenOf(t reflect.Type, transform func(tokens.Type) tokens.Type) (tokens
 	if annotator.Token != "" {
 		return tokens.Type(annotator.Token), nil
 	}
-
-	tk, err := introspect.GetToken("pkg", t)
-	if transform == nil || err != nil {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if tk, ok, err := anonymousStructToken(t, transform); ok {
 		return tk, err
 	}
 
-	return transform(tk), nil
+	return deriveToken(t, transform)
 }
 
 func (*derivedResourceController[R, I, O]) GetToken() (tokens.Type, error) {
 	return getToken[R](nil)
 }
 
+func (*derivedResourceController[R, I, O]) getAliases() []tokens.Type {
+	var r R
+	var aliases []tokens.Type
+	for _, alias := range getAnnotated(reflect.TypeOf(r)).Aliases {
+		aliases = append(aliases, tokens.Type(alias))
+	}
+	return aliases
+}
+
+// getInstance returns a new instance of R. When R is a pointer, it points to a new zero
+// value, so that an operation can hold state in the fields of its receiver.
 func (*derivedResourceController[R, I, O]) getInstance() *R {
 	var r R
+	if v := reflect.ValueOf(&r).Elem(); v.Kind() == reflect.Pointer {
+		v.Set(reflect.New(v.Type().Elem()))
+	}
 	return &r
 }
 
+// track records r as the instance of an in-flight operation until the returned function
+// is called, so that the provider's Cancel reaches it. See [Cancellable].
+func (rc *derivedResourceController[R, I, O]) track(r *R) func() {
+	if _, ok := ((interface{})(*r)).(Cancellable); !ok {
+		return func() {}
+	}
+	rc.m.Lock()
+	defer rc.m.Unlock()
+	if rc.inFlight == nil {
+		rc.inFlight = map[*R]struct{}{}
+	}
+	rc.inFlight[r] = struct{}{}
+	return func() {
+		rc.m.Lock()
+		defer rc.m.Unlock()
+		delete(rc.inFlight, r)
+	}
+}
+
 func (rc *derivedResourceController[R, I, O]) Check(ctx context.Context, req p.CheckRequest) (p.CheckResponse, error) {
-	encoder, i, failures, err := decodeCheckingMapErrors[I](req.News)
+	req.Olds = renamePropertyAliases(req.Olds, typeFor[I]())
+	req.News = renamePropertyAliases(req.News, typeFor[I]())
+	tags := tagOptions(ctx)
+	news, err := applyAutoNames[I](req, tags)
+	if err != nil {
+		return p.CheckResponse{}, err
+	}
+	req.News = news
+	encoder, i, failures, err := decodeCheckingMapErrors[I](req.News, tags)
 	if err != nil {
 		return p.CheckResponse{}, err
 	}
@@ -873,7 +1324,7 @@ func (rc *derivedResourceController[R, I, O]) Check(ctx context.Context, req p.C
 		return p.CheckResponse{
 			// If we failed to decode, we apply secrets pro-actively to ensure
 			// that they don't leak into previews.
-			Inputs:   applySecrets[I](req.News),
+			Inputs:   applySecrets[I](req.News, tags),
 			Failures: failures,
 		}, nil
 	}
@@ -895,18 +1346,18 @@ func (rc *derivedResourceController[R, I, O]) Check(ctx context.Context, req p.C
 
 		inputs, err := encoder.Encode(i)
 		return p.CheckResponse{
-			Inputs:   inputs,
+			Inputs:   withoutComputedInputs(typeFor[I](), inputs, tags),
 			Failures: failures,
 		}, err
 	}
 
-	if i, err = defaultCheck(i); err != nil {
+	if i, err = defaultCheck(ctx, i); err != nil {
 		return p.CheckResponse{}, fmt.Errorf("unable to apply defaults: %w", err)
 	}
 
 	inputs, err := encoder.Encode(i)
 
-	return p.CheckResponse{Inputs: applySecrets[I](inputs)}, err
+	return p.CheckResponse{Inputs: applySecrets[I](withoutComputedInputs(typeFor[I](), inputs, tags), tags)}, err
 }
 
 // This (key,value) pair provide a mechanism for [DefaultCheck] to silently return the
@@ -931,10 +1382,12 @@ func callCustomCheck[T any](
 // DefaultCheck verifies that inputs can deserialize cleanly into I. This is the default
 // validation that is performed when leaving Check unimplemented.
 //
-// It also adds defaults to inputs as necessary, as defined by [Annotator.SetDefault].
+// It also adds defaults to inputs as necessary, as defined by [Annotator.SetDefault] and
+// [Annotator.SetDefaultFunc], and sets constant inputs, as defined by [Annotator.SetConst].
 func DefaultCheck[I any](ctx context.Context, inputs resource.PropertyMap) (I, []p.CheckFailure, error) {
-	inputs = applySecrets[I](inputs)
-	enc, i, failures, err := decodeCheckingMapErrors[I](inputs)
+	tags := tagOptions(ctx)
+	inputs = applySecrets[I](inputs, tags)
+	enc, i, failures, err := decodeCheckingMapErrors[I](inputs, tags)
 
 	if v, ok := ctx.Value(defaultCheckEncoderKey{}).(*defaultCheckEncoderValue); ok {
 		v.enc = &enc
@@ -944,25 +1397,33 @@ func DefaultCheck[I any](ctx context.Context, inputs resource.PropertyMap) (I, [
 		return i, failures, err
 	}
 
-	i, err = defaultCheck(i)
+	i, err = defaultCheck(ctx, i)
 	return i, nil, err
 }
 
-func defaultCheck[I any](i I) (I, error) {
-	if err := applyDefaults(&i); err != nil {
+func defaultCheck[I any](ctx context.Context, i I) (I, error) {
+	if err := applyDefaults(ctx, &i); err != nil {
 		return i, fmt.Errorf("unable to apply defaults: %w", err)
 	}
 	return i, nil
 }
 
-func decodeCheckingMapErrors[I any](inputs resource.PropertyMap) (ende.Encoder, I, []p.CheckFailure, error) {
-	encoder, i, err := ende.Decode[I](inputs)
+func decodeCheckingMapErrors[I any](
+	inputs resource.PropertyMap, tags introspect.TagOptions,
+) (ende.Encoder, I, []p.CheckFailure, error) {
+	inputs = applyEnvDefaults[I](applyConstants[I](normalizeEnums[I](inputs, tags), tags), tags)
+	computed := computedCheckFailures(typeFor[I](), inputs, tags)
+	encoder, i, err := ende.Decode[I](inputs, tags)
 	if err != nil {
 		failures, e := checkFailureFromMapError(err)
-		return encoder, i, failures, e
+		if e != nil {
+			return encoder, i, failures, e
+		}
+		return encoder, i, append(computed, withRequiredCheckFailures(typeFor[I](), inputs, failures, tags)...), nil
 	}
 
-	return encoder, i, nil, nil
+	failures := valueCheckFailures(reflect.ValueOf(i), resource.NewObjectProperty(inputs), "", tags)
+	return encoder, i, append(computed, failures...), nil
 }
 
 // checkFailureFromMapError converts from a [mapper.MappingError] to a [p.CheckFailure]:
@@ -979,7 +1440,7 @@ func checkFailureFromMapError(err mapper.MappingError) ([]p.CheckFailure, error)
 		switch err := err.(type) {
 		case mapper.FieldError:
 			failures = append(failures, p.CheckFailure{
-				Property: err.Field(),
+				Property: propertyPathOfField(err.Field()),
 				Reason:   err.Reason(),
 			})
 		default:
@@ -989,26 +1450,155 @@ func checkFailureFromMapError(err mapper.MappingError) ([]p.CheckFailure, error)
 	return failures, nil
 }
 
+// mapValueField matches the name the mapper gives to the value of a map, such as
+// `tags[name] value`.
+var mapValueField = regexp.MustCompile(`\[([^\]]*)\] value`)
+
+// propertyPathOfField converts the name of a field reported by the mapper into the
+// property path used by the other check failures, such as `tags["name"]` for the value
+// `tags[name] value` of a map.
+func propertyPathOfField(field string) string {
+	return mapValueField.ReplaceAllStringFunc(field, func(m string) string {
+		return "[" + strconv.Quote(mapValueField.FindStringSubmatch(m)[1]) + "]"
+	})
+}
+
 func (rc *derivedResourceController[R, I, O]) Diff(ctx context.Context, req p.DiffRequest) (p.DiffResponse, error) {
+	migrated, _, err := migrateStateVersion[R](ctx, req.Olds, nil)
+	if err != nil {
+		return p.DiffResponse{}, err
+	}
+	req.Olds = renamePropertyAliases(migrated, typeFor[I](), typeFor[O]())
+	req.News = renamePropertyAliases(req.News, typeFor[I]())
 	r := rc.getInstance()
 	_, hasUpdate := ((interface{})(*r)).(CustomUpdate[I, O])
 	var forceReplace func(string) bool
 	if hasUpdate {
-		schema, err := rc.GetSchema(func(tokens.Type, pschema.ComplexTypeSpec) bool { return false })
-		if err != nil {
-			return p.DiffResponse{}, err
+		tags := tagOptions(ctx)
+		forceReplace = func(s string) bool { return replaceOnChanges(typeFor[I](), s, tags) }
+	} else {
+		// No update => every change is a replace
+		forceReplace = func(string) bool { return true }
+	}
+	resp, err := diff[R, I, O](ctx, req, r, forceReplace)
+	if err != nil {
+		return p.DiffResponse{}, err
+	}
+	if getAnnotated(typeFor[R]()).DeleteBeforeReplace {
+		resp.DeleteBeforeReplace = true
+	}
+	explainDiff(ctx, resp)
+	return resp, nil
+}
+
+// explainDiff logs the properties changed by resp, and whether each change replaces the
+// resource, when the provider was created with [Options.ExplainDiffs].
+func explainDiff(ctx context.Context, resp p.DiffResponse) {
+	if explain, _ := ctx.Value(explainDiffsKey).(bool); !explain || !resp.HasChanges {
+		return
+	}
+	props := make([]string, 0, len(resp.DetailedDiff))
+	replaces := false
+	for k, d := range resp.DetailedDiff {
+		if d.Kind == p.Stable {
+			continue
+		}
+		props = append(props, k)
+		replaces = replaces || strings.HasSuffix(string(d.Kind), "&replace")
+	}
+	sort.Strings(props)
+
+	var b strings.Builder
+	switch {
+	case replaces && resp.DeleteBeforeReplace:
+		b.WriteString("the resource will be deleted and then replaced")
+	case replaces:
+		b.WriteString("the resource will be replaced")
+	default:
+		b.WriteString("the resource will be updated")
+	}
+	if len(props) == 0 {
+		b.WriteString(", but the diff does not say which properties changed")
+	} else {
+		b.WriteString(" because these properties changed:")
+	}
+	for _, k := range props {
+		kind := string(resp.DetailedDiff[k].Kind)
+		if base, ok := strings.CutSuffix(kind, "&replace"); ok {
+			kind = base + ", forces replacement"
+		}
+		fmt.Fprintf(&b, "\n  %s (%s)", k, kind)
+	}
+	p.GetLogger(ctx).Info(b.String())
+}
+
+// replaceOnChanges reports if a change to the property at path, such as "spec.image", in a
+// value of type t replaces the resource. This is the case when path or any of its parents
+// is a field tagged `provider:"replaceOnChanges"` or annotated with
+// [Annotator.SetReplaceOnChanges].
+func replaceOnChanges(t reflect.Type, path string, tags introspect.TagOptions) bool {
+	parsed, err := resource.ParsePropertyPath(path)
+	if err != nil {
+		return false
+	}
+	for _, elem := range parsed {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
 		}
-		forceReplace = func(s string) bool {
-			if schema.InputProperties == nil {
+		switch t.Kind() {
+		case reflect.Struct:
+			name, ok := elem.(string)
+			if !ok {
 				return false
 			}
-			return schema.InputProperties[s].ReplaceOnChanges
+			field, tag, ok := fieldByTagName(t, name, tags)
+			if !ok {
+				return false
+			}
+			if tag.ReplaceOnChanges || getAnnotated(t).ReplaceOnChanges[name] {
+				return true
+			}
+			t = field.Type
+		case reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+		default:
+			return false
 		}
-	} else {
-		// No update => every change is a replace
-		forceReplace = func(string) bool { return true }
 	}
-	return diff[R, I, O](ctx, req, r, forceReplace)
+	return false
+}
+
+// fieldByTagName returns the field of the struct type t named name in the Pulumi type system.
+func fieldByTagName(
+	t reflect.Type, name string, tags introspect.TagOptions,
+) (reflect.StructField, introspect.FieldTag, bool) {
+	for _, field := range reflect.VisibleFields(t) {
+		tag, err := introspect.ParseTag(field, tags)
+		if err == nil && !tag.Internal && tag.Name == name {
+			return field, tag, true
+		}
+	}
+	return reflect.StructField{}, introspect.FieldTag{}, false
+}
+
+// ignoreChanges returns a copy of news where the properties at paths, such as
+// "spec.replicas", are reset to their values in olds, so that changes to them are neither
+// diffed nor cause a replacement.
+func ignoreChanges(olds, news resource.PropertyMap, paths []resource.PropertyKey) (resource.PropertyMap, error) {
+	if len(paths) == 0 {
+		return news, nil
+	}
+	news = putil.DeepCopy(resource.NewObjectProperty(news)).ObjectValue()
+	for _, s := range paths {
+		path, err := resource.ParsePropertyPath(string(s))
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignoreChanges path %q: %w", s, err)
+		}
+		// The engine has already checked that paths are valid for the inputs, so paths that
+		// can't be reset against the old state are left as they are.
+		path.Reset(olds, news)
+	}
+	return news, nil
 }
 
 // Compute a diff request.
@@ -1016,16 +1606,18 @@ func diff[R, I, O any](
 	ctx context.Context, req p.DiffRequest, r *R, forceReplace func(string) bool,
 ) (p.DiffResponse, error) {
 
-	for _, ignoredChange := range req.IgnoreChanges {
-		req.News[ignoredChange] = req.Olds[ignoredChange]
+	news, err := ignoreChanges(req.Olds, req.News, req.IgnoreChanges)
+	if err != nil {
+		return p.DiffResponse{}, err
 	}
+	req.News = news
 
 	if r, ok := ((interface{})(*r)).(CustomDiff[I, O]); ok {
 		_, olds, err := hydrateFromState[R, I, O](ctx, req.Olds) // TODO
 		if err != nil {
 			return p.DiffResponse{}, err
 		}
-		_, news, err := ende.Decode[I](req.News)
+		_, news, err := ende.Decode[I](req.News, tagOptions(ctx))
 		if err != nil {
 			return p.DiffResponse{}, err
 		}
@@ -1033,21 +1625,33 @@ func diff[R, I, O any](
 		if err != nil {
 			return p.DiffResponse{}, err
 		}
+		for _, v := range diff.DetailedDiff {
+			if v.Kind != p.Stable {
+				diff.HasChanges = true
+				break
+			}
+		}
 		return diff, nil
 	}
 
-	inputProps, err := introspect.FindProperties(typeFor[I]())
+	inputProps, err := introspect.FindProperties(typeFor[I](), tagOptions(ctx))
 	if err != nil {
 		return p.DiffResponse{}, err
 	}
 	// Olds is an Output, but news is an Input. Output should be a superset of Input,
 	// so we need to filter out fields that are in Output but not Input.
 	oldInputs := resource.PropertyMap{}
-	for k := range inputProps {
+	for k, tag := range inputProps {
+		if tag.Computed {
+			// Computed fields are never inputs, so they can't have changed.
+			continue
+		}
 		key := resource.PropertyKey(k)
 		oldInputs[key] = req.Olds[key]
 	}
-	objDiff := oldInputs.Diff(req.News)
+	// A null value and a missing one both mean that the property is unset, so they are
+	// dropped before the diff. Going from unset to a zero value is still a change.
+	objDiff := withoutUnset(oldInputs).Diff(withoutUnset(req.News))
 	pluginDiff := plugin.NewDetailedDiffFromObjectDiff(objDiff, false)
 	diff := map[string]p.PropertyDiff{}
 
@@ -1078,25 +1682,89 @@ func diff[R, I, O any](
 		}
 	}
 	return p.DiffResponse{
-		// TODO: how shoould we set this?
-		// DeleteBeforeReplace: ???,
 		HasChanges:   objDiff.AnyChanges(),
 		DetailedDiff: diff,
 	}, nil
 }
 
+// withoutUnset returns a copy of m without the properties that are null, including null
+// values that are secret or known outputs and properties of nested objects.
+func withoutUnset(m resource.PropertyMap) resource.PropertyMap {
+	var drop func(v resource.PropertyValue) (resource.PropertyValue, bool)
+	drop = func(v resource.PropertyValue) (resource.PropertyValue, bool) {
+		switch {
+		case v.IsNull():
+			return v, false
+		case v.IsSecret():
+			e, ok := drop(v.SecretValue().Element)
+			return resource.MakeSecret(e), ok
+		case v.IsOutput():
+			o := v.OutputValue()
+			if !o.Known {
+				return v, true
+			}
+			e, ok := drop(o.Element)
+			o.Element = e
+			return resource.NewOutputProperty(o), ok
+		case v.IsObject():
+			return resource.NewObjectProperty(withoutUnset(v.ObjectValue())), true
+		case v.IsArray():
+			arr := make([]resource.PropertyValue, len(v.ArrayValue()))
+			for i, e := range v.ArrayValue() {
+				// Elements of arrays keep their position, so they are not dropped.
+				if e, ok := drop(e); ok {
+					arr[i] = e
+				}
+			}
+			return resource.NewArrayProperty(arr), true
+		default:
+			return v, true
+		}
+	}
+	result := make(resource.PropertyMap, len(m))
+	for k, v := range m {
+		if v, ok := drop(v); ok {
+			result[k] = v
+		}
+	}
+	return result
+}
+
 func (rc *derivedResourceController[R, I, O]) Create(
 	ctx context.Context, req p.CreateRequest,
 ) (resp p.CreateResponse, retError error) {
+	req.Properties = renamePropertyAliases(req.Properties, typeFor[I]())
 	r := rc.getInstance()
+	defer rc.track(r)()
+	tags := tagOptions(ctx)
 
 	var err error
-	encoder, input, err := ende.Decode[I](req.Properties)
+	encoder, input, err := ende.Decode[I](req.Properties, tags)
 	if err != nil {
 		return p.CreateResponse{}, fmt.Errorf("invalid inputs: %w", err)
 	}
 
+	ctx = context.WithValue(ctx, key.DryRun, req.Preview)
+	ctx, cancel := withTimeout(ctx, req.Timeout, getAnnotated(typeFor[R]()).CreateTimeout)
+	defer cancel()
 	id, o, err := (*r).Create(ctx, req.Urn.Name(), input, req.Preview)
+	id, idErr := resolveID("Create", id, o, tags)
+	if err == nil {
+		err = idErr
+	}
+	if err != nil && id != "" && !req.Preview && !errors.As(err, &ResourceInitFailedError{}) {
+		// The resource was allocated before Create failed, so we record it as partially
+		// initialized instead of leaking it.
+		var reason string
+		if partial := (PartialError{}); errors.As(err, &partial) {
+			reason = partial.Error()
+		} else if errors.Is(err, context.DeadlineExceeded) {
+			reason = "timed out while creating the resource"
+		}
+		if reason != "" {
+			err = fmt.Errorf("%w: %w", ResourceInitFailedError{Reasons: []string{reason}}, err)
+		}
+	}
 	if initFailed := (ResourceInitFailedError{}); errors.As(err, &initFailed) {
 		defer func(createErr error) {
 			// If there was an error, it indicates a problem with serializing
@@ -1124,13 +1792,14 @@ func (rc *derivedResourceController[R, I, O]) Create(
 	if id == "" && !req.Preview {
 		return p.CreateResponse{}, ProviderErrorf("'%s' was created without an id", req.Urn)
 	}
+	warnUnsafeIntegers(ctx, o)
 
 	m, err := encoder.AllowUnknown(req.Preview).Encode(o)
 	if err != nil {
 		return p.CreateResponse{}, fmt.Errorf("encoding resource properties: %w", err)
 	}
 
-	setDeps, err := getDependencies(r, &input, &o, true /* isCreate */, req.Preview)
+	setDeps, err := getDependencies(r, &input, &o, true /* isCreate */, req.Preview, tags)
 	if err != nil {
 		return p.CreateResponse{}, err
 	}
@@ -1138,17 +1807,31 @@ func (rc *derivedResourceController[R, I, O]) Create(
 
 	return p.CreateResponse{
 		ID:         id,
-		Properties: m,
+		Properties: withStateVersion[R](applySecrets[O](m, tags)),
 	}, err
 }
 
 func (rc *derivedResourceController[R, I, O]) Read(
 	ctx context.Context, req p.ReadRequest,
 ) (resp p.ReadResponse, retError error) {
+	migrated, migratedInputs, err := migrateStateVersion[R](ctx, req.Properties, req.Inputs)
+	if err != nil {
+		return p.ReadResponse{}, err
+	}
+	req.Inputs = renamePropertyAliases(migratedInputs, typeFor[I]())
+	req.Properties = renamePropertyAliases(migrated, typeFor[I](), typeFor[O]())
 	r := rc.getInstance()
+	defer rc.track(r)()
+	tags := tagOptions(ctx)
+	importing := isImport(req)
+	if importing {
+		req.Inputs, req.Properties, err = importIDProperties[R, I, O](req.ID, tags)
+		if err != nil {
+			return p.ReadResponse{}, err
+		}
+	}
 	var inputs I
-	var err error
-	inputEncoder, err := ende.DecodeTolerateMissing(req.Inputs, &inputs)
+	inputEncoder, err := ende.DecodeTolerateMissing(req.Inputs, &inputs, tags)
 	if err != nil {
 		return p.ReadResponse{}, err
 	}
@@ -1173,7 +1856,7 @@ func (rc *derivedResourceController[R, I, O]) Read(
 	} else {
 		// That didn't work, so maybe we can get by decoding without state migration but by tolerating
 		// missing fields.
-		stateEncoder, err = ende.DecodeTolerateMissing(req.Properties, &state)
+		stateEncoder, err = ende.DecodeTolerateMissing(req.Properties, &state, tags)
 		if err != nil {
 			return p.ReadResponse{}, err
 		}
@@ -1181,17 +1864,28 @@ func (rc *derivedResourceController[R, I, O]) Read(
 
 	read, ok := ((interface{})(*r)).(CustomRead[I, O])
 	if !ok {
+		if importing {
+			return p.ReadResponse{}, status.Errorf(codes.Unimplemented,
+				"Import is not implemented for resource %s: it must implement CustomRead", req.Urn)
+		}
 		// Default read implementation:
 		//
 		// We have already confirmed that we deserialize state and properties correctly.
 		// We now just return them as is.
 		return p.ReadResponse{
 			ID:         req.ID,
-			Properties: req.Properties,
-			Inputs:     req.Inputs,
+			Properties: withStateVersion[R](applySecrets[O](req.Properties, tags)),
+			Inputs:     applySecrets[I](req.Inputs, tags),
 		}, nil
 	}
+	if err := withID(&state, req.ID, tags); err != nil {
+		return p.ReadResponse{}, err
+	}
 	id, inputs, state, err := read.Read(ctx, req.ID, inputs, state)
+	if id != "" && err == nil {
+		// An empty ID means that the resource was deleted, so it is kept as is.
+		id, err = resolveID("Read", id, state, tags)
+	}
 	if initFailed := (ResourceInitFailedError{}); errors.As(err, &initFailed) {
 		defer func(readErr error) {
 			// If there was an error, it indicates a problem with serializing
@@ -1224,35 +1918,78 @@ func (rc *derivedResourceController[R, I, O]) Read(
 	if err != nil {
 		return p.ReadResponse{}, err
 	}
+	if !importing && id != "" {
+		reportDrift(ctx, req.Properties, s)
+	}
 
+	// Values read from the provider are not secret, so mark secret fields as secret
+	// again. Otherwise an import would leak them into the state.
 	return p.ReadResponse{
 		ID:         id,
-		Properties: s,
-		Inputs:     i,
+		Properties: withStateVersion[R](applySecrets[O](s, tags)),
+		Inputs:     applySecrets[I](i, tags),
 	}, nil
 }
 
+// reportDrift logs the properties of the recorded state olds that differ in the state
+// news returned by Read, when the provider was created with [Options.ReportDrift].
+// Secrets are compared by their values, since Read does not return them as secret.
+func reportDrift(ctx context.Context, olds, news resource.PropertyMap) {
+	if report, _ := ctx.Value(reportDriftKey).(bool); !report {
+		return
+	}
+	var drifted []string
+	for k := range olds {
+		if _, ok := news[k]; !ok {
+			drifted = append(drifted, string(k))
+		}
+	}
+	for k, v := range news {
+		if !putil.DeepEquals(putil.MakePublic(olds[k]), putil.MakePublic(v)) {
+			drifted = append(drifted, string(k))
+		}
+	}
+	if len(drifted) == 0 {
+		return
+	}
+	sort.Strings(drifted)
+	p.GetLogger(ctx).Infof("the resource drifted from its recorded state in these properties: %s",
+		strings.Join(drifted, ", "))
+}
+
 func (rc *derivedResourceController[R, I, O]) Update(
 	ctx context.Context, req p.UpdateRequest,
 ) (resp p.UpdateResponse, retError error) {
+	migrated, _, err := migrateStateVersion[R](ctx, req.Olds, nil)
+	if err != nil {
+		return p.UpdateResponse{}, err
+	}
+	req.Olds = renamePropertyAliases(migrated, typeFor[I](), typeFor[O]())
+	req.News = renamePropertyAliases(req.News, typeFor[I]())
 	r := rc.getInstance()
+	defer rc.track(r)()
 	update, ok := ((interface{})(*r)).(CustomUpdate[I, O])
 	if !ok {
 		return p.UpdateResponse{}, status.Errorf(codes.Unimplemented,
 			"Update is not implemented for resource %s", req.Urn)
 	}
-	for _, ignoredChange := range req.IgnoreChanges {
-		req.News[ignoredChange] = req.Olds[ignoredChange]
+	req.News, err = ignoreChanges(req.Olds, req.News, req.IgnoreChanges)
+	if err != nil {
+		return p.UpdateResponse{}, err
 	}
 
 	_, olds, err := hydrateFromState[R, I, O](ctx, req.Olds)
 	if err != nil {
 		return p.UpdateResponse{}, err
 	}
-	encoder, news, err := ende.Decode[I](req.News)
+	tags := tagOptions(ctx)
+	encoder, news, err := ende.Decode[I](req.News, tags)
 	if err != nil {
 		return p.UpdateResponse{}, err
 	}
+	ctx = context.WithValue(ctx, key.DryRun, req.Preview)
+	ctx, cancel := withTimeout(ctx, req.Timeout, getAnnotated(typeFor[R]()).UpdateTimeout)
+	defer cancel()
 	o, err := update.Update(ctx, req.ID, olds, news, req.Preview)
 	if initFailed := (ResourceInitFailedError{}); errors.As(err, &initFailed) {
 		defer func(updateErr error) {
@@ -1279,41 +2016,84 @@ func (rc *derivedResourceController[R, I, O]) Update(
 	if err != nil {
 		return p.UpdateResponse{}, err
 	}
+	warnUnsafeIntegers(ctx, o)
 	m, err := encoder.AllowUnknown(req.Preview).Encode(o)
 	if err != nil {
 		return p.UpdateResponse{}, err
 	}
-	setDeps, err := getDependencies(r, &news, &o, false /* isCreate */, req.Preview)
+	setDeps, err := getDependencies(r, &news, &o, false /* isCreate */, req.Preview, tags)
 	if err != nil {
 		return p.UpdateResponse{}, err
 	}
 	setDeps(req.Olds, req.News, m)
 
 	return p.UpdateResponse{
-		Properties: m,
+		Properties: withStateVersion[R](applySecrets[O](m, tags)),
 	}, nil
 }
 
 func (rc *derivedResourceController[R, I, O]) Delete(ctx context.Context, req p.DeleteRequest) error {
+	migrated, _, err := migrateStateVersion[R](ctx, req.Properties, nil)
+	if err != nil {
+		return err
+	}
+	req.Properties = renamePropertyAliases(migrated, typeFor[I](), typeFor[O]())
 	r := rc.getInstance()
+	defer rc.track(r)()
 	del, ok := ((interface{})(*r)).(CustomDelete[O])
 	if ok {
 		_, olds, err := hydrateFromState[R, I, O](ctx, req.Properties)
 		if err != nil {
 			return err
 		}
+		ctx, cancel := withTimeout(ctx, req.Timeout, getAnnotated(typeFor[R]()).DeleteTimeout)
+		defer cancel()
 		return del.Delete(ctx, req.ID, olds)
 	}
 	return nil
 }
 
+// cancel calls the Cancel hook of the instance of each in-flight operation. See
+// [Cancellable].
+func (rc *derivedResourceController[R, I, O]) cancel(ctx context.Context) error {
+	rc.m.Lock()
+	instances := make([]*R, 0, len(rc.inFlight))
+	for r := range rc.inFlight {
+		instances = append(instances, r)
+	}
+	rc.m.Unlock()
+
+	var errs []error
+	for _, r := range instances {
+		errs = append(errs, ((interface{})(*r)).(Cancellable).Cancel(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// cancelResources returns a Cancel method that calls the Cancel hook of each resource that
+// implements [Cancellable], and then next.
+func cancelResources(next func(context.Context) error, resources []InferredResource) func(context.Context) error {
+	return func(ctx context.Context) error {
+		var errs []error
+		for _, r := range resources {
+			errs = append(errs, r.cancel(ctx))
+		}
+		if next != nil {
+			if err := next(ctx); status.Code(err) != codes.Unimplemented {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+}
+
 // Apply dependencies to a property map, flowing secretness and computedness from input to
 // output.
 type setDeps func(oldInputs, input, output resource.PropertyMap)
 
 // Get the decency mapping between inputs and outputs of a resource.
 func getDependencies[R, I, O any](
-	r *R, input *I, output *O, isCreate, isPreview bool,
+	r *R, input *I, output *O, isCreate, isPreview bool, tags introspect.TagOptions,
 ) (setDeps, error) {
 	var wire func(FieldSelector)
 
@@ -1322,14 +2102,19 @@ func getDependencies[R, I, O any](
 			r.WireDependencies(fg, input, output)
 		}
 	}
-	return getDependenciesRaw(input, output, wire, isCreate, isPreview)
+	partialValues := isPreview && getAnnotated(typeFor[R]()).SupportsPartialValues
+	return getDependenciesRaw(input, output, wire, isCreate, isPreview, partialValues, tags)
 }
 
 // getDependenciesRaw is the untyped implementation of getDependencies.
 func getDependenciesRaw(
-	input, output any, wire func(FieldSelector), isCreate, isPreview bool,
+	input, output any, wire func(FieldSelector), isCreate, isPreview, partialValues bool,
+	tags introspect.TagOptions,
 ) (setDeps, error) {
-	fg := newFieldGenerator(input, output)
+	fg := newFieldGenerator(input, output, tags)
+	if partialValues {
+		fg.zeroOutputs = zeroFields(output, tags)
+	}
 	if wire != nil {
 		wire(fg)
 		if err := fg.err.ErrorOrNil(); err != nil {
@@ -1348,6 +2133,128 @@ func getDependenciesRaw(
 	return fg.MarkMap(isCreate, isPreview), nil
 }
 
+// zeroFields returns the names of the properties of v, a pointer to a struct, whose
+// fields hold zero values. A nil pointer is a zero value, but a pointer to one is not.
+func zeroFields(v any, tags introspect.TagOptions) map[string]bool {
+	zero := map[string]bool{}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return zero
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return zero
+	}
+	for _, f := range reflect.VisibleFields(rv.Type()) {
+		tag, err := introspect.ParseTag(f, tags)
+		if err != nil || tag.Internal {
+			continue
+		}
+		fv, err := rv.FieldByIndexErr(f.Index)
+		if err != nil || fv.IsZero() {
+			zero[tag.Name] = true
+		}
+	}
+	return zero
+}
+
+// withTimeout bounds ctx by the timeout requested by the engine, in seconds. If the
+// engine didn't request a timeout, the resource's default timeout is used instead.
+func withTimeout(ctx context.Context, requested float64, fallback time.Duration) (context.Context, context.CancelFunc) {
+	timeout := fallback
+	if requested > 0 {
+		timeout = time.Duration(requested * float64(time.Second))
+	}
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// renamePropertyAliases returns m with properties set under an alias registered with
+// [Annotator.AddPropertyAlias] on any of types moved to their current name. When a
+// property is set under both names, the current name wins.
+func renamePropertyAliases(m resource.PropertyMap, types ...reflect.Type) resource.PropertyMap {
+	copied := false
+	for _, t := range types {
+		for name, aliases := range getAnnotated(t).PropertyAliases {
+			for _, alias := range aliases {
+				v, ok := m[resource.PropertyKey(alias)]
+				if !ok {
+					continue
+				}
+				if !copied {
+					m = m.Copy()
+					copied = true
+				}
+				if _, ok := m[resource.PropertyKey(name)]; !ok {
+					m[resource.PropertyKey(name)] = v
+				}
+				delete(m, resource.PropertyKey(alias))
+			}
+		}
+	}
+	return m
+}
+
+// isImport reports if req is reading a resource that is not yet in the stack state, as
+// with `pulumi import`. Only the ID of the resource is known.
+func isImport(req p.ReadRequest) bool {
+	return req.ID != "" && len(req.Inputs) == 0 && len(req.Properties) == 0
+}
+
+// importIDProperties returns the inputs and state parsed from the ID of a resource that is
+// imported, when R sets the format of its import IDs with [Annotator.SetImportIDFormat].
+func importIDProperties[R, I, O any](
+	id string, tags introspect.TagOptions,
+) (inputs, state resource.PropertyMap, err error) {
+	format := getAnnotated(typeFor[R]()).ImportIDFormat
+	if format == "" {
+		return nil, nil, nil
+	}
+	parts, err := introspect.ParseImportIDFormat(format)
+	if err != nil {
+		return nil, nil, err
+	}
+	values, ok := introspect.ParseImportID(parts, id)
+	if !ok {
+		return nil, nil, status.Errorf(codes.InvalidArgument,
+			"invalid import ID %q: expected an ID of the form %q", id, format)
+	}
+
+	inputs, state = resource.PropertyMap{}, resource.PropertyMap{}
+	for name, value := range values {
+		found := false
+		for _, target := range []struct {
+			t     reflect.Type
+			props resource.PropertyMap
+		}{{typeFor[I](), inputs}, {typeFor[O](), state}} {
+			t := target.t
+			for t.Kind() == reflect.Pointer {
+				t = t.Elem()
+			}
+			field, _, ok := fieldByTagName(t, name, tags)
+			if !ok {
+				continue
+			}
+			v, err := parseScalar(field.Type, value)
+			if err != nil {
+				return nil, nil, status.Errorf(codes.InvalidArgument,
+					"invalid import ID %q: %s: %s", id, name, err)
+			}
+			target.props[resource.PropertyKey(name)] = v
+			found = true
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("the import ID format %q names %q, which is not a property of %s",
+				format, name, typeFor[R]())
+		}
+	}
+	return inputs, state, nil
+}
+
 // hydrateFromState takes a blob from state and hydrates it for user consumption, running any relevant state
 // migrations.
 func hydrateFromState[R, I, O any](
@@ -1361,7 +2268,7 @@ func hydrateFromState[R, I, O any](
 		}
 	}
 
-	return ende.Decode[O](state)
+	return ende.Decode[O](state, tagOptions(ctx))
 }
 
 func migrateState[O any](
@@ -1385,7 +2292,7 @@ func migrateState[O any](
 			oldValue := reflect.New(oldType)
 
 			var err error
-			enc, err = ende.DecodeAny(state, oldValue.Interface())
+			enc, err = ende.DecodeAny(state, oldValue.Interface(), tagOptions(ctx))
 			if err != nil {
 				// If we couldn't encode cleanly, then state doesn't fit into the migrator.
 				continue
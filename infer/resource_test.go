@@ -30,6 +30,7 @@ import (
 
 	p "github.com/pulumi/pulumi-go-provider"
 	"github.com/pulumi/pulumi-go-provider/infer/types"
+	"github.com/pulumi/pulumi-go-provider/internal/introspect"
 	"github.com/pulumi/pulumi-go-provider/internal/putil"
 	rRapid "github.com/pulumi/pulumi-go-provider/internal/rapid/resource"
 )
@@ -50,7 +51,8 @@ func testGetDependencies[I any, O any](t *testing.T,
 	setDeps, err := getDependenciesRaw(
 		&i, &o, wireDeps,
 		false, /*isCreate*/
-		true /*isPreview*/)
+		true,  /*isPreview*/
+		false /*partialValues*/, introspect.TagOptions{})
 	require.NoError(t, err)
 
 	inputT := rapid.Just(reflect.TypeOf(i))
@@ -207,7 +209,7 @@ func TestFieldGenerator(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 			i, o := &args{}, &state{}
-			fm := newFieldGenerator(i, o)
+			fm := newFieldGenerator(i, o, introspect.TagOptions{})
 			tt.wire(fm, i, o)
 			tt.assert(t, *fm)
 		})
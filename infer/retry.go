@@ -0,0 +1,92 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infer
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	p "github.com/pulumi/pulumi-go-provider"
+)
+
+// RetryPolicy configures how [WithRetry] retries an operation.
+type RetryPolicy struct {
+	// The maximum number of attempts, including the first one. If zero, 3 attempts are
+	// made.
+	MaxAttempts int
+	// The delay before the first retry, which doubles after each attempt. If zero, the
+	// delay starts at one second.
+	BaseDelay time.Duration
+	// The upper bound on the delay between attempts. If zero, the delay is not bounded.
+	MaxDelay time.Duration
+	// Retryable reports if an error is transient and the operation should be retried. If
+	// nil, all errors are retried.
+	Retryable func(error) bool
+}
+
+// WithRetry calls fn until it succeeds, returns an error that isn't retryable or the
+// attempts allowed by policy are exhausted. The last error returned by fn is returned.
+//
+// Retries are delayed with exponential backoff and jitter. If ctx is cancelled while
+// waiting to retry, the returned error wraps both ctx.Err() and the last error from fn.
+// Each retry is logged as a warning on the resource being operated on.
+//
+// Example:
+//
+//	func (*Widget) Create(ctx context.Context, name string, args WidgetArgs, preview bool) (
+//		id string, state WidgetState, err error) {
+//		err = infer.WithRetry(ctx, infer.RetryPolicy{Retryable: isThrottled}, func(ctx context.Context) error {
+//			id, err = client.CreateWidget(ctx, args)
+//			return err
+//		})
+//		return id, WidgetState{args}, err
+//	}
+func WithRetry(ctx context.Context, policy RetryPolicy, fn func(context.Context) error) error {
+	attempts := policy.MaxAttempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+	delay := policy.BaseDelay
+	if delay <= 0 {
+		delay = time.Second
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt >= attempts ||
+			(policy.Retryable != nil && !policy.Retryable(err)) {
+			return err
+		}
+
+		if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+			delay = policy.MaxDelay
+		}
+		// Wait between half and all of the delay, so that concurrent retries spread out.
+		wait := delay/2 + rand.N(delay/2+1)
+		p.GetLogger(ctx).Warningf("Attempt %d of %d failed, retrying in %s: %s",
+			attempt, attempts, wait.Round(time.Millisecond), err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(ctx.Err(), err)
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
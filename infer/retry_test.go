@@ -0,0 +1,115 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi-go-provider/internal/key"
+)
+
+type recordingSink struct {
+	m        sync.Mutex
+	messages []string
+}
+
+func (s *recordingSink) Log(_ context.Context, _ resource.URN, _ diag.Severity, msg string) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.messages = append(s.messages, msg)
+}
+
+func (s *recordingSink) LogStatus(ctx context.Context, urn resource.URN, sev diag.Severity, msg string) {
+	s.Log(ctx, urn, sev, msg)
+}
+
+var errTransient = errors.New("transient")
+
+func TestWithRetry(t *testing.T) {
+	t.Parallel()
+
+	policy := RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    2 * time.Millisecond,
+		Retryable:   func(err error) bool { return errors.Is(err, errTransient) },
+	}
+
+	t.Run("succeeds-after-failures", func(t *testing.T) {
+		t.Parallel()
+		sink := &recordingSink{}
+		ctx := context.WithValue(context.Background(), key.Logger, sink)
+
+		calls := 0
+		err := WithRetry(ctx, policy, func(context.Context) error {
+			calls++
+			if calls <= 2 {
+				return errTransient
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, calls)
+		require.Len(t, sink.messages, 2)
+		assert.Contains(t, sink.messages[0], "Attempt 1 of 4 failed")
+		assert.Contains(t, sink.messages[1], "Attempt 2 of 4 failed")
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		t.Parallel()
+		calls := 0
+		err := WithRetry(context.Background(), policy, func(context.Context) error {
+			calls++
+			return errTransient
+		})
+		assert.ErrorIs(t, err, errTransient)
+		assert.Equal(t, 4, calls)
+	})
+
+	t.Run("not-retryable", func(t *testing.T) {
+		t.Parallel()
+		fatal := errors.New("fatal")
+		calls := 0
+		err := WithRetry(context.Background(), policy, func(context.Context) error {
+			calls++
+			return fatal
+		})
+		assert.Equal(t, fatal, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithCancel(context.Background())
+		calls := 0
+		err := WithRetry(ctx, RetryPolicy{BaseDelay: time.Hour}, func(context.Context) error {
+			calls++
+			cancel()
+			return errTransient
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.ErrorIs(t, err, errTransient)
+		assert.Equal(t, 1, calls)
+	})
+}
@@ -0,0 +1,105 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infer
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+
+	p "github.com/pulumi/pulumi-go-provider"
+)
+
+var scalars sync.Map // map[reflect.Type]scalar
+
+// scalar is a named scalar type registered with [RegisterScalar].
+type scalar struct {
+	spec schema.TypeSpec
+	// validate returns the errors of the validators of the type for v, a value of the type.
+	validate func(v reflect.Value) []error
+}
+
+// RegisterScalar describes T, a named scalar type such as `type Port uint16`, as spec
+// wherever it appears in the schema, and validates the values of T with validators in
+// Check. This keeps the description of domain types consistent across every field that
+// uses them, instead of annotating each field.
+//
+// For example:
+//
+//	type Port uint16
+//
+//	func init() {
+//		infer.RegisterScalar[Port](schema.TypeSpec{Type: "integer"}, func(p Port) error {
+//			if p == 0 {
+//				return errors.New("ports range from 1 to 65535")
+//			}
+//			return nil
+//		})
+//	}
+//
+// A validator reports an invalid value by returning an error, which is reported as a
+// check failure of the property that holds it. The errors of secret values are not
+// reported, as they may reveal the value.
+//
+// Types must be registered before the provider is served. RegisterScalar panics if T is
+// not a named boolean, number or string type, or if spec has neither a type nor a
+// reference.
+func RegisterScalar[T any](spec schema.TypeSpec, validators ...func(T) error) {
+	t := typeFor[T]()
+	if t.Name() == "" || t.PkgPath() == "" || !isScalar(t) {
+		panic(fmt.Sprintf("RegisterScalar[%s]: %s is not a named scalar type", t, t))
+	}
+	if spec.Type == "" && spec.Ref == "" {
+		panic(fmt.Sprintf("RegisterScalar[%s]: the schema type must have a type or a reference", t))
+	}
+	scalars.Store(t, scalar{
+		spec: spec,
+		validate: func(v reflect.Value) []error {
+			value := v.Convert(t).Interface().(T)
+			var errs []error
+			for _, validate := range validators {
+				if err := validate(value); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			return errs
+		},
+	})
+}
+
+// registeredScalar returns the scalar registered for t, if any.
+func registeredScalar(t reflect.Type) (scalar, bool) {
+	s, ok := scalars.Load(t)
+	if !ok {
+		return scalar{}, false
+	}
+	return s.(scalar), true
+}
+
+// checkFailures returns a check failure for each validator of s that rejects v. secret
+// reports if v is secret, and path is its property path.
+func (s scalar) checkFailures(v reflect.Value, secret bool, path string) []p.CheckFailure {
+	var failures []p.CheckFailure
+	for _, err := range s.validate(v) {
+		reason := fmt.Sprintf("%v is not a valid %s: %v", v.Interface(), v.Type().Name(), err)
+		if secret {
+			reason = fmt.Sprintf("[secret] is not a valid %s", v.Type().Name())
+		}
+		failures = append(failures, p.CheckFailure{Property: path, Reason: reason})
+	}
+	return failures
+}
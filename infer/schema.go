@@ -15,13 +15,21 @@
 package infer
 
 import (
+	"encoding/json"
 	"fmt"
+	"math/big"
 	"reflect"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 
 	"github.com/pulumi/pulumi-go-provider/infer/types"
@@ -29,7 +37,24 @@ import (
 	sch "github.com/pulumi/pulumi-go-provider/middleware/schema"
 )
 
+// annotatedCache memoizes [getAnnotated], since annotations are fixed for a given type
+// and are needed on every request.
+var annotatedCache sync.Map // map[reflect.Type]introspect.Annotator
+
+// getAnnotated returns the merged annotations of t and the structs it embeds.
+//
+// The returned Annotator is shared between callers, which may run concurrently, and must
+// not be modified. Annotators computed concurrently for the same type are equal, and only
+// the first one stored is returned.
 func getAnnotated(t reflect.Type) introspect.Annotator {
+	if a, ok := annotatedCache.Load(t); ok {
+		return a.(introspect.Annotator)
+	}
+	a, _ := annotatedCache.LoadOrStore(t, computeAnnotated(t))
+	return a.(introspect.Annotator)
+}
+
+func computeAnnotated(t reflect.Type) introspect.Annotator {
 	// If we have type *R with value(i) = nil, NewAnnotator will fail. We need to get
 	// value(i) = *R{}, so we reinflate the underlying value
 	for t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Pointer {
@@ -55,17 +80,99 @@ func getAnnotated(t reflect.Type) introspect.Annotator {
 			(*dst).Defaults[k] = v
 		}
 		for k, v := range src.DefaultEnvs {
-			(*dst).DefaultEnvs[k] = v
+			// Slices are copied, so that merged Annotators never share memory with the
+			// cached Annotators of embedded types.
+			(*dst).DefaultEnvs[k] = slices.Clone(v)
+		}
+		for k, v := range src.DefaultFuncs {
+			(*dst).DefaultFuncs[k] = v
+		}
+		for k, v := range src.Formats {
+			(*dst).Formats[k] = v
+		}
+		for k, v := range src.Deprecations {
+			(*dst).Deprecations[k] = v
+		}
+		for k, v := range src.PropertyAliases {
+			(*dst).PropertyAliases[k] = append((*dst).PropertyAliases[k], v...)
+		}
+		for k, v := range src.Consts {
+			(*dst).Consts[k] = v
+		}
+		for k, v := range src.Minimums {
+			(*dst).Minimums[k] = v
+		}
+		for k, v := range src.Maximums {
+			(*dst).Maximums[k] = v
+		}
+		for k, v := range src.Patterns {
+			(*dst).Patterns[k] = v
+		}
+		for k, names := range src.LanguageNames {
+			if (*dst).LanguageNames[k] == nil {
+				(*dst).LanguageNames[k] = map[string]string{}
+			}
+			for lang, name := range names {
+				(*dst).LanguageNames[k][lang] = name
+			}
 		}
 		dst.Token = src.Token
 		dst.Aliases = append(dst.Aliases, src.Aliases...)
 		dst.DeprecationMessage = src.DeprecationMessage
+		if src.Discriminator != "" {
+			dst.Discriminator = src.Discriminator
+			dst.DiscriminatorValue = src.DiscriminatorValue
+		}
+		if src.CreateTimeout != 0 {
+			dst.CreateTimeout = src.CreateTimeout
+		}
+		if src.UpdateTimeout != 0 {
+			dst.UpdateTimeout = src.UpdateTimeout
+		}
+		if src.DeleteTimeout != 0 {
+			dst.DeleteTimeout = src.DeleteTimeout
+		}
+		if src.DeleteBeforeReplace {
+			dst.DeleteBeforeReplace = true
+		}
+		if src.SupportsPartialValues {
+			dst.SupportsPartialValues = true
+		}
+		for k, v := range src.ReplaceOnChanges {
+			(*dst).ReplaceOnChanges[k] = v
+		}
+		for k, v := range src.AutoNames {
+			(*dst).AutoNames[k] = v
+		}
+		if src.EnumCaseInsensitive {
+			dst.EnumCaseInsensitive = true
+		}
+		if src.IDField != "" {
+			dst.IDField = src.IDField
+		}
+		if src.ImportIDFormat != "" {
+			dst.ImportIDFormat = src.ImportIDFormat
+		}
+		if src.Version != 0 {
+			dst.Version = src.Version
+		}
 	}
 
 	ret := introspect.Annotator{
-		Descriptions: map[string]string{},
-		Defaults:     map[string]any{},
-		DefaultEnvs:  map[string][]string{},
+		Descriptions:     map[string]string{},
+		Defaults:         map[string]any{},
+		DefaultEnvs:      map[string][]string{},
+		DefaultFuncs:     map[string]introspect.DefaultFunc{},
+		Formats:          map[string]string{},
+		Deprecations:     map[string]string{},
+		PropertyAliases:  map[string][]string{},
+		Consts:           map[string]any{},
+		Minimums:         map[string]float64{},
+		Maximums:         map[string]float64{},
+		Patterns:         map[string]*regexp.Regexp{},
+		LanguageNames:    map[string]map[string]string{},
+		ReplaceOnChanges: map[string]bool{},
+		AutoNames:        map[string]int{},
 	}
 	if t.Elem().Kind() == reflect.Struct {
 		for _, f := range reflect.VisibleFields(t.Elem()) {
@@ -74,6 +181,10 @@ func getAnnotated(t reflect.Type) introspect.Annotator {
 				merge(&ret, r)
 			}
 		}
+		// Only the annotations of embedded fields are promoted. The description and
+		// aliases of an embedded type describe that type, not t.
+		delete(ret.Descriptions, "")
+		ret.Aliases = nil
 	}
 
 	if r, ok := i.Interface().(Annotated); ok {
@@ -85,23 +196,56 @@ func getAnnotated(t reflect.Type) introspect.Annotator {
 	return ret
 }
 
-func getResourceSchema[R, I, O any](isComponent bool) (schema.ResourceSpec, multierror.Error) {
+func getResourceSchema[R, I, O any](
+	isComponent bool, tags introspect.TagOptions,
+) (schema.ResourceSpec, multierror.Error) {
 	var r R
 	var errs multierror.Error
 	annotations := getAnnotated(reflect.TypeOf(r))
 
-	properties, required, err := propertyListFromType(reflect.TypeOf(new(O)), isComponent)
+	properties, required, err := propertyListFromType(reflect.TypeOf(new(O)), isComponent, tags)
 	if err != nil {
 		var o O
 		errs.Errors = append(errs.Errors, fmt.Errorf("could not serialize output type %T: %w", o, err))
 	}
 
-	inputProperties, requiredInputs, err := propertyListFromType(reflect.TypeOf(new(I)), isComponent)
+	inputProperties, requiredInputs, err := propertyListFromType(reflect.TypeOf(new(I)), isComponent, tags)
 	if err != nil {
 		var i I
 		errs.Errors = append(errs.Errors, fmt.Errorf("could not serialize input type %T: %w", i, err))
 	}
 
+	// Aliased input properties are still accepted under their old names, so we keep
+	// them in the schema as deprecated properties.
+	for name, aliases := range getAnnotated(reflect.TypeOf(new(I))).PropertyAliases {
+		prop, ok := inputProperties[name]
+		if !ok {
+			continue
+		}
+		prop.DeprecationMessage = fmt.Sprintf("Use `%s` instead.", name)
+		for _, alias := range aliases {
+			inputProperties[alias] = prop
+		}
+	}
+
+	if !isComponent {
+		// Constant inputs are set by Check, so users don't need to see them.
+		for name := range getAnnotated(reflect.TypeOf(new(I))).Consts {
+			delete(inputProperties, name)
+		}
+		// Computed fields are set by the provider, so they are only outputs.
+		inputTags, err := introspect.FindProperties(reflect.TypeOf(new(I)), tags)
+		if err != nil {
+			errs.Errors = append(errs.Errors, err)
+		}
+		for name, tag := range inputTags {
+			if tag.Computed {
+				delete(inputProperties, name)
+				requiredInputs = slices.DeleteFunc(requiredInputs, func(s string) bool { return s == name })
+			}
+		}
+	}
+
 	var aliases []schema.AliasSpec
 	for _, alias := range annotations.Aliases {
 		a := alias
@@ -128,21 +272,39 @@ func serializeTypeAsPropertyType(
 	for t.Kind() == reflect.Pointer {
 		t = t.Elem()
 	}
-	if t == reflect.TypeOf(resource.Asset{}) {
-		// Provider authors should not be using resource.Asset directly, but rather types.AssetOrArchive. #243
-		return schema.TypeSpec{
-			Ref: "pulumi.json#/Asset",
-		}, nil
+	if m, ok := propertyMarshaler(t); ok {
+		spec := m.PropertyType()
+		spec.Plain = spec.Plain || indicatePlain
+		return spec, nil
 	}
-	if t == reflect.TypeOf(resource.Archive{}) {
-		return schema.TypeSpec{
-			Ref: "pulumi.json#/Archive",
-		}, nil
+	if s, ok := registeredScalar(t); ok {
+		spec := s.spec
+		spec.Plain = spec.Plain || indicatePlain
+		return spec, nil
 	}
-	if t == reflect.TypeOf(types.AssetOrArchive{}) {
-		return schema.TypeSpec{
-			Ref: "pulumi.json#/Asset",
-		}, nil
+	if spec, ok := assetTypeSpec(t); ok {
+		return spec, nil
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		// Timestamps are serialized as RFC 3339 strings.
+		return schema.TypeSpec{Type: "string", Plain: indicatePlain}, nil
+	}
+	if t == reflect.TypeOf(big.Int{}) || t == reflect.TypeOf(big.Float{}) {
+		// Arbitrary-precision numbers are serialized as decimal strings, which don't lose
+		// precision as a number would.
+		return schema.TypeSpec{Type: "string", Plain: indicatePlain}, nil
+	}
+	if t == reflect.TypeOf([]byte{}) {
+		// Binary data is serialized as a base64 encoded string. Named byte slices and
+		// byte arrays are left as arrays of integers.
+		return schema.TypeSpec{Type: "string", Plain: indicatePlain}, nil
+	}
+	if t == reflect.TypeOf(json.RawMessage{}) {
+		// Raw JSON holds an arbitrary value, just like fields of type any.
+		return schema.TypeSpec{Ref: "pulumi.json#/Any"}, nil
+	}
+	if spec, ok := externalTypeReference(t, extType); ok {
+		return spec, nil
 	}
 	if enum, ok := isEnum(t); ok {
 		return schema.TypeSpec{
@@ -153,6 +315,10 @@ func serializeTypeAsPropertyType(
 	if err != nil {
 		return schema.TypeSpec{}, err
 	}
+	if spec, ok := assetTypeSpec(t); ok {
+		// Input types such as pulumi.AssetInput resolve to the SDK's asset types.
+		return spec, nil
+	}
 	if tk, ok, err := resourceReferenceToken(t, extType, false); ok {
 		if err != nil {
 			return schema.TypeSpec{}, err
@@ -176,7 +342,8 @@ func serializeTypeAsPropertyType(
 		if t.Key().Kind() != reflect.String {
 			return schema.TypeSpec{}, fmt.Errorf("map keys must be strings, found %s", t.Key().String())
 		}
-		el, err := serializeTypeAsPropertyType(t.Elem(), indicatePlain, extType)
+		// The elements of an input container such as pulumi.StringMapInput are inputs too.
+		el, err := serializeTypeAsPropertyType(t.Elem(), indicatePlain && !inputy, extType)
 		if err != nil {
 			return schema.TypeSpec{}, err
 		}
@@ -185,7 +352,7 @@ func serializeTypeAsPropertyType(
 			AdditionalProperties: &el,
 		}, nil
 	case reflect.Array, reflect.Slice:
-		el, err := serializeTypeAsPropertyType(t.Elem(), indicatePlain, extType)
+		el, err := serializeTypeAsPropertyType(t.Elem(), indicatePlain && !inputy, extType)
 		if err != nil {
 			return schema.TypeSpec{}, err
 		}
@@ -195,13 +362,20 @@ func serializeTypeAsPropertyType(
 		}, nil
 	case reflect.Bool:
 		return primitive("boolean")
-	case reflect.Int, reflect.Int64, reflect.Int32:
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		return primitive("integer")
-	case reflect.Float64:
+	case reflect.Float32, reflect.Float64:
 		return primitive("number")
 	case reflect.String:
 		return primitive("string")
 	case reflect.Interface:
+		// Asset, archive and resource interfaces have already been handled above.
+		if u, ok := introspect.GetUnion(t); ok {
+			return unionTypeSpec(u)
+		}
+		// Other interfaces are described as any. The fields of interfaces that may have
+		// been meant as unions are logged by GetSchema, see anyFields.
 		return schema.TypeSpec{
 			Ref: "pulumi.json#/Any",
 		}, nil
@@ -210,12 +384,91 @@ func serializeTypeAsPropertyType(
 	}
 }
 
+// anyFields describes each field of T, and of the types it refers to, whose type is an
+// interface with methods that is not a registered union, and so is described as any.
+func anyFields[T any](tags introspect.TagOptions) []string {
+	var fields []string
+	// Invalid types are reported when the schema is generated, so errors are ignored here.
+	_ = crawlTypes[T](tags, func(
+		t reflect.Type, _ bool, info *introspect.FieldTag, parent, field string,
+	) (bool, error) {
+		if t.Kind() != reflect.Interface || t.NumMethod() == 0 || parent == "" {
+			return true, nil
+		}
+		if spec, err := serializeTypeAsPropertyType(t, false, info.ExplicitRef); err == nil &&
+			spec.Ref == "pulumi.json#/Any" {
+			fields = append(fields, fmt.Sprintf("%s.%s has type %s, an interface that is not a registered "+
+				"union, so it is described as any: register its cases with infer.Union to describe them",
+				parent, field, t))
+		}
+		return true, nil
+	})
+	return fields
+}
+
+// durationTypeSpec returns spec, the type of a field of time.Durations, with each
+// time.Duration it holds serialized as a duration string.
+func durationTypeSpec(spec schema.TypeSpec) schema.TypeSpec {
+	switch {
+	case spec.Items != nil:
+		items := durationTypeSpec(*spec.Items)
+		spec.Items = &items
+	case spec.AdditionalProperties != nil:
+		elem := durationTypeSpec(*spec.AdditionalProperties)
+		spec.AdditionalProperties = &elem
+	default:
+		spec = schema.TypeSpec{Type: "string", Plain: spec.Plain}
+	}
+	return spec
+}
+
+// unionTypeSpec returns a oneOf type over the cases of u.
+func unionTypeSpec(u introspect.Union) (schema.TypeSpec, error) {
+	spec := schema.TypeSpec{
+		Discriminator: &schema.DiscriminatorSpec{
+			PropertyName: u.Discriminator,
+			Mapping:      map[string]string{},
+		},
+	}
+	for _, v := range u.Values() {
+		tk, _, err := structReferenceToken(u.Cases[v], nil)
+		if err != nil {
+			return schema.TypeSpec{}, err
+		}
+		spec.OneOf = append(spec.OneOf, tk)
+		spec.Discriminator.Mapping[v] = tk.Ref
+	}
+	return spec, nil
+}
+
+// assetTypeSpec returns the schema reference for asset and archive types, both from this
+// library and from the Pulumi Go SDK.
+func assetTypeSpec(t reflect.Type) (schema.TypeSpec, bool) {
+	switch t {
+	// Provider authors should not be using resource.Asset directly, but rather types.AssetOrArchive. #243
+	case reflect.TypeOf(resource.Asset{}), reflect.TypeOf((*pulumi.Asset)(nil)).Elem():
+		return schema.TypeSpec{Ref: "pulumi.json#/Asset"}, true
+	case reflect.TypeOf(resource.Archive{}), reflect.TypeOf((*pulumi.Archive)(nil)).Elem():
+		return schema.TypeSpec{Ref: "pulumi.json#/Archive"}, true
+	// pulumi.json#/Asset accepts both assets and archives.
+	case reflect.TypeOf(types.AssetOrArchive{}), reflect.TypeOf((*pulumi.AssetOrArchive)(nil)).Elem():
+		return schema.TypeSpec{Ref: "pulumi.json#/Asset"}, true
+	default:
+		return schema.TypeSpec{}, false
+	}
+}
+
 // underlyingType find the non-inputty, non-ptr type of t. It returns the underlying type
 // and if t was an Inputty or Outputty type.
 func underlyingType(t reflect.Type) (reflect.Type, bool, error) {
 	for t != nil && t.Kind() == reflect.Pointer {
 		t = t.Elem()
 	}
+	// pulumi.Input and pulumi.Output don't know the type they hold, so they are described
+	// as any.
+	if t == reflect.TypeOf((*pulumi.Input)(nil)).Elem() || t == reflect.TypeOf((*pulumi.Output)(nil)).Elem() {
+		return t, true, nil
+	}
 	isInputType := t.Implements(reflect.TypeOf(new(pulumi.Input)).Elem())
 	isOutputType := t.Implements(reflect.TypeOf(new(pulumi.Output)).Elem())
 
@@ -229,17 +482,16 @@ func underlyingType(t reflect.Type) (reflect.Type, bool, error) {
 		for t.Kind() == reflect.Pointer {
 			t = t.Elem()
 		}
+		// Input interfaces such as pulumi.StringArrayInput and the types that implement
+		// them such as pulumi.StringArray both convert to outputs with a To<T>Output
+		// method, where T is the name of the type without its Input suffix.
 		T := strings.TrimSuffix(t.Name(), "Input")
-		switch t.Kind() {
-		case reflect.Map:
-			T = t.Elem().Name() + "Map"
-		case reflect.Array, reflect.Slice:
-			T = t.Elem().Name() + "Array"
-		}
 
 		toOutMethod, ok := t.MethodByName("To" + T + "Output")
 		if !ok {
-			return nil, false, fmt.Errorf("%v is an input type, but does not have a To%vOutput method", t.Name(), T)
+			return nil, false, fmt.Errorf("%[1]v is an input type, but does not have a To%[2]vOutput method: "+
+				"add a To%[2]vOutput method returning a pulumi.Output, or use a type that is not a pulumi.Input",
+				t.Name(), T)
 		}
 		outputT := toOutMethod.Type.Out(0)
 		//create new object of type outputT
@@ -258,39 +510,84 @@ func underlyingType(t reflect.Type) (reflect.Type, bool, error) {
 	return t, isOutputType || isInputType, nil
 }
 
-func propertyListFromType(typ reflect.Type, indicatePlain bool) (
+func propertyListFromType(typ reflect.Type, indicatePlain bool, opts introspect.TagOptions) (
 	props map[string]schema.PropertySpec, required []string, err error) {
 	for typ.Kind() == reflect.Pointer {
 		typ = typ.Elem()
 	}
 	props = map[string]schema.PropertySpec{}
 	annotations := getAnnotated(typ)
+	// fieldNames maps each property name to the Go field that declared it, so fields
+	// promoted from embedded structs cannot silently collide.
+	fieldNames := map[string]string{}
 
 	for _, field := range reflect.VisibleFields(typ) {
 		fieldType := field.Type
 		for fieldType.Kind() == reflect.Pointer {
 			fieldType = fieldType.Elem()
 		}
-		tags, err := introspect.ParseTag(field)
+		tags, err := introspect.ParseTag(field, opts)
 		if err != nil {
 			return nil, nil, fmt.Errorf("invalid fields '%s' on '%s': %w", field.Name, typ, err)
 		}
 		if tags.Internal {
 			continue
 		}
+		nameAnonymousStruct(typ, field)
+		if other, ok := fieldNames[tags.Name]; ok {
+			return nil, nil, fmt.Errorf("ambiguous property '%s' on '%s': declared by both '%s' and '%s'",
+				tags.Name, typ, other, fieldPath(typ, field))
+		}
+		fieldNames[tags.Name] = fieldPath(typ, field)
 		serialized, err := serializeTypeAsPropertyType(fieldType, indicatePlain, tags.ExplicitRef)
 		if err != nil {
 			return nil, nil, fmt.Errorf("invalid type '%s' on '%s.%s': %w", fieldType, typ, field.Name, err)
 		}
-		if !tags.Optional {
+		if tags.Duration {
+			// Durations opt into being serialized as strings, such as "5m30s".
+			serialized = durationTypeSpec(serialized)
+		}
+		constValue, isConst := annotations.Consts[tags.Name]
+		if !tags.Optional && !isConst {
 			required = append(required, tags.Name)
 		}
 		spec := &schema.PropertySpec{
-			TypeSpec:         serialized,
-			Secret:           tags.Secret,
-			ReplaceOnChanges: tags.ReplaceOnChanges,
-			Description:      annotations.Descriptions[tags.Name],
-			Default:          annotations.Defaults[tags.Name],
+			TypeSpec:           serialized,
+			Secret:             tags.Secret,
+			ReplaceOnChanges:   tags.ReplaceOnChanges || annotations.ReplaceOnChanges[tags.Name],
+			Description:        annotations.Descriptions[tags.Name],
+			Default:            annotations.Defaults[tags.Name],
+			DeprecationMessage: annotations.Deprecations[tags.Name],
+		}
+		if annotations.Discriminator == tags.Name {
+			spec.Const = annotations.DiscriminatorValue
+		}
+		if isConst {
+			spec.Const = constValue
+		}
+		if format, ok := annotations.Formats[tags.Name]; ok {
+			spec.Description = describeFormat(spec.Description, format)
+		}
+		if minimum, ok := annotations.Minimums[tags.Name]; ok {
+			spec.Description = describeNote(spec.Description, fmt.Sprintf("Minimum: `%v`.", minimum))
+		}
+		if maximum, ok := annotations.Maximums[tags.Name]; ok {
+			spec.Description = describeNote(spec.Description, fmt.Sprintf("Maximum: `%v`.", maximum))
+		}
+		if pattern, ok := annotations.Patterns[tags.Name]; ok {
+			spec.Description = describeNote(spec.Description, fmt.Sprintf("Pattern: `%s`.", pattern.String()))
+		}
+		if fieldType.Kind() == reflect.Map {
+			if e, ok := isEnum(fieldType.Key()); ok {
+				keys := make([]string, len(e.values))
+				for i, v := range e.values {
+					keys[i] = fmt.Sprintf("`%v`", v.Value)
+				}
+				spec.Description = describeNote(spec.Description, "Keys: "+strings.Join(keys, ", ")+".")
+			}
+		}
+		if names := annotations.LanguageNames[tags.Name]; len(names) > 0 {
+			spec.Language = languageNames(names)
 		}
 		if envs := annotations.DefaultEnvs[tags.Name]; len(envs) > 0 {
 			spec.DefaultInfo = &schema.DefaultSpec{
@@ -299,20 +596,83 @@ func propertyListFromType(typ reflect.Type, indicatePlain bool) (
 		}
 		props[tags.Name] = *spec
 	}
+	// Properties are serialized from a map, and so in sorted order. Sorting required keeps
+	// the generated schema stable when fields are reordered.
+	sort.Strings(required)
 	return props, required, nil
 }
 
+// fieldPath returns the Go selector path to field within typ, including the names of any
+// embedded structs the field was promoted from.
+func fieldPath(typ reflect.Type, field reflect.StructField) string {
+	names := make([]string, len(field.Index))
+	for i := range field.Index {
+		f := typ.FieldByIndex(field.Index[:i+1])
+		names[i] = f.Name
+	}
+	return strings.Join(names, ".")
+}
+
+// languageNames returns the language section of a property spec that renames the
+// property to names[language] in the SDK for each language.
+func languageNames(names map[string]string) map[string]schema.RawMessage {
+	language := make(map[string]schema.RawMessage, len(names))
+	for lang, name := range names {
+		info, err := json.Marshal(struct {
+			Name string `json:"name"`
+		}{name})
+		contract.AssertNoErrorf(err, "failed to marshal language name %q", name)
+		language[lang] = info
+	}
+	return language
+}
+
+// describeFormat records format in a property description, since the Pulumi schema has
+// no dedicated field for it.
+func describeFormat(description, format string) string {
+	return describeNote(description, fmt.Sprintf("Format: `%s`.", format))
+}
+
+// describeNote appends note to a property description as its own paragraph. It records
+// metadata that the Pulumi schema has no dedicated field for, such as validation
+// constraints.
+func describeNote(description, note string) string {
+	if description == "" {
+		return note
+	}
+	return description + "\n\n" + note
+}
+
 func resourceReferenceToken(
 	t reflect.Type, extTag *introspect.ExplicitType, allowMissingExtType bool,
 ) (schema.TypeSpec, bool, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
 	ptrT := reflect.PointerTo(t)
 	implements := func(typ reflect.Type) bool {
 		return t.Implements(typ) || ptrT.Implements(typ)
 	}
+	// instance returns a non-nil value of t, or of *t when only *t implements typ, so
+	// that methods of typ can be called on it.
+	instance := func(typ reflect.Type) any {
+		if t.Implements(typ) {
+			return reflect.New(t).Elem().Interface()
+		}
+		return reflect.New(t).Interface()
+	}
+	anyResourceReference := reflect.TypeOf(new(types.AnyResourceReference)).Elem()
+	schemaResource := reflect.TypeOf(new(sch.Resource)).Elem()
 	switch {
+	case implements(anyResourceReference):
+		// A reference to a resource of this provider.
+		tk, err := getTokenOf(instance(anyResourceReference).(types.AnyResourceReference).ResourceType(), nil)
+		return schema.TypeSpec{
+			Ref: "#/resources/" + tk.String(),
+		}, true, err
 	// This handles both components and resources
-	case implements(reflect.TypeOf(new(sch.Resource)).Elem()):
-		tk, err := reflect.New(t).Elem().Interface().(sch.Resource).GetToken()
+	case implements(schemaResource):
+		tk, err := instance(schemaResource).(sch.Resource).GetToken()
 		return schema.TypeSpec{
 			Ref: "#/resources/" + tk.String(),
 		}, true, err
@@ -333,19 +693,50 @@ func resourceReferenceToken(
 	}
 }
 
+// explicitTypeReference returns a reference to the type named by extTag.
+func explicitTypeReference(extTag *introspect.ExplicitType) schema.TypeSpec {
+	if extTag.Pkg != "" {
+		return schema.TypeSpec{
+			Ref: fmt.Sprintf("/%s/%s/schema.json#/types/%s:%s:%s",
+				extTag.Pkg, extTag.Version,
+				extTag.Pkg, extTag.Module, extTag.Name,
+			),
+		}
+	}
+	return schema.TypeSpec{
+		Ref: fmt.Sprintf("#/types/pkg:%s:%s", extTag.Module, extTag.Name),
+	}
+}
+
+// externalTypeReference returns a reference to the type of another package named by
+// extTag, for a field of type t that is neither a resource nor a container of other
+// types. This lets fields of any Go type, such as a string, hold an object or an enum of
+// another package.
+func externalTypeReference(t reflect.Type, extTag *introspect.ExplicitType) (schema.TypeSpec, bool) {
+	if extTag == nil || extTag.Pkg == "" {
+		return schema.TypeSpec{}, false
+	}
+	t, _, err := underlyingType(t)
+	if err != nil {
+		return schema.TypeSpec{}, false
+	}
+	switch t.Kind() {
+	case reflect.Array, reflect.Slice, reflect.Map, reflect.Interface:
+		// The tag applies to the elements, which are serialized on their own.
+		return schema.TypeSpec{}, false
+	}
+	if _, ok, _ := resourceReferenceToken(t, extTag, true); ok {
+		return schema.TypeSpec{}, false
+	}
+	return explicitTypeReference(extTag), true
+}
+
 func structReferenceToken(t reflect.Type, extTag *introspect.ExplicitType) (schema.TypeSpec, bool, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
 	if t.Kind() == reflect.Struct && extTag != nil {
-		if extTag.Pkg != "" {
-			return schema.TypeSpec{
-				Ref: fmt.Sprintf("/%s/%s/schema.json#/types/%s:%s:%s",
-					extTag.Pkg, extTag.Version,
-					extTag.Pkg, extTag.Module, extTag.Name,
-				),
-			}, true, nil
-		}
-		return schema.TypeSpec{
-			Ref: fmt.Sprintf("#/types/pkg:%s:%s", extTag.Module, extTag.Name),
-		}, true, nil
+		return explicitTypeReference(extTag), true, nil
 	}
 	if t.Kind() != reflect.Struct ||
 		t.Implements(reflect.TypeOf(new(pulumi.Output)).Elem()) {
@@ -368,9 +759,10 @@ func schemaNameForType(t reflect.Kind) string {
 		return "string"
 	case reflect.Bool:
 		return "boolean"
-	case reflect.Float64:
+	case reflect.Float32, reflect.Float64:
 		return "number"
-	case reflect.Int:
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		return "integer"
 	default:
 		panic(fmt.Sprintf("unknown primitive type: %s", t))
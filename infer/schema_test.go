@@ -15,10 +15,22 @@
 package infer
 
 import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
 	"testing"
+	"time"
 
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi-go-provider/infer/types"
+	"github.com/pulumi/pulumi-go-provider/internal/introspect"
 )
 
 type TestResource struct {
@@ -34,7 +46,8 @@ func (r *TestResource) Annotate(a Annotator) {
 func TestResourceAnnotations(t *testing.T) {
 	t.Parallel()
 
-	spec, err := getResourceSchema[TestResource, TestResource, TestResource](false /* isComponent */)
+	spec, err := getResourceSchema[TestResource, TestResource, TestResource](
+		false /* isComponent */, introspect.TagOptions{})
 	require.NoError(t, err.ErrorOrNil())
 
 	require.Len(t, spec.Aliases, 1)
@@ -44,3 +57,455 @@ func TestResourceAnnotations(t *testing.T) {
 
 	require.Equal(t, "This resource is deprecated.", spec.DeprecationMessage)
 }
+
+func TestNumericPropertyTypes(t *testing.T) {
+	t.Parallel()
+
+	type numbers struct {
+		Int8    int8    `pulumi:"int8"`
+		Int16   int16   `pulumi:"int16"`
+		Uint    uint    `pulumi:"uint"`
+		Uint8   uint8   `pulumi:"uint8"`
+		Uint16  *uint16 `pulumi:"uint16,optional"`
+		Uint32  uint32  `pulumi:"uint32"`
+		Uint64  uint64  `pulumi:"uint64"`
+		Float32 float32 `pulumi:"float32"`
+	}
+
+	props, _, err := propertyListFromType(reflect.TypeOf(numbers{}), false, introspect.TagOptions{})
+	require.NoError(t, err)
+
+	for _, name := range []string{"int8", "int16", "uint", "uint8", "uint16", "uint32", "uint64"} {
+		assert.Equal(t, "integer", props[name].Type, name)
+	}
+	assert.Equal(t, "number", props["float32"].Type)
+}
+
+func TestUnsafeIntegerPaths(t *testing.T) {
+	t.Parallel()
+
+	type inner struct {
+		Count uint64 `pulumi:"count"`
+	}
+	type outer struct {
+		Safe   uint64            `pulumi:"safe"`
+		Unsafe uint64            `pulumi:"unsafe"`
+		Signed int64             `pulumi:"signed"`
+		Nested *inner            `pulumi:"nested"`
+		List   []inner           `pulumi:"list"`
+		Map    map[string]uint64 `pulumi:"map"`
+	}
+
+	paths := unsafeIntegerPaths(reflect.ValueOf(outer{
+		Safe:   maxSafeInteger,
+		Unsafe: maxSafeInteger + 1,
+		Signed: -maxSafeInteger - 1,
+		Nested: &inner{Count: math.MaxUint64},
+		List:   []inner{{Count: 1}, {Count: math.MaxUint64}},
+		Map:    map[string]uint64{"k": math.MaxUint64},
+	}), "", introspect.TagOptions{})
+
+	assert.ElementsMatch(t, []string{
+		"unsafe", "signed", "nested.count", `list[1].count`, `map["k"]`,
+	}, paths)
+}
+
+type timestamped struct {
+	Created time.Time  `pulumi:"created"`
+	Expires *time.Time `pulumi:"expires,optional"`
+}
+
+func (t *timestamped) Annotate(a Annotator) {
+	a.Describe(&t.Created, "When the resource was created.")
+	a.SetFieldFormat(&t.Created, "date-time")
+	a.SetFieldFormat(&t.Expires, "date-time")
+}
+
+func TestTimePropertyTypes(t *testing.T) {
+	t.Parallel()
+
+	props, required, err := propertyListFromType(reflect.TypeOf(timestamped{}), false, introspect.TagOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"created"}, required)
+
+	assert.Equal(t, "string", props["created"].Type)
+	assert.Equal(t, "When the resource was created.\n\nFormat: `date-time`.", props["created"].Description)
+	assert.Equal(t, "string", props["expires"].Type)
+	assert.Equal(t, "Format: `date-time`.", props["expires"].Description)
+}
+
+func TestDurationPropertyTypes(t *testing.T) {
+	t.Parallel()
+
+	type timeouts struct {
+		Timeout *time.Duration           `pulumi:"timeout,optional" provider:"duration"`
+		Backoff []time.Duration          `pulumi:"backoff" provider:"duration"`
+		ByStage map[string]time.Duration `pulumi:"byStage" provider:"duration"`
+		Legacy  time.Duration            `pulumi:"legacy"`
+	}
+
+	props, _, err := propertyListFromType(reflect.TypeOf(timeouts{}), false, introspect.TagOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "string", props["timeout"].Type)
+	assert.Equal(t, "array", props["backoff"].Type)
+	assert.Equal(t, "string", props["backoff"].Items.Type)
+	assert.Equal(t, "object", props["byStage"].Type)
+	assert.Equal(t, "string", props["byStage"].AdditionalProperties.Type)
+	assert.Equal(t, "integer", props["legacy"].Type)
+}
+
+func TestBytesPropertyTypes(t *testing.T) {
+	t.Parallel()
+
+	type pixels []uint8
+	type payload struct {
+		Data   []byte   `pulumi:"data"`
+		Chunks [][]byte `pulumi:"chunks"`
+		Pixels pixels   `pulumi:"pixels"`
+	}
+
+	props, _, err := propertyListFromType(reflect.TypeOf(payload{}), false, introspect.TagOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "string", props["data"].Type)
+	assert.Equal(t, "string", props["chunks"].Items.Type)
+	assert.Equal(t, "array", props["pixels"].Type)
+	assert.Equal(t, "integer", props["pixels"].Items.Type)
+}
+
+func TestContainerInputPropertyTypes(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		Names      pulumi.StringArrayInput      `pulumi:"names"`
+		Labels     pulumi.StringMapInput        `pulumi:"labels"`
+		NameArray  pulumi.StringArray           `pulumi:"nameArray"`
+		LabelMap   pulumi.StringMap             `pulumi:"labelMap"`
+		NameGroups pulumi.StringArrayArrayInput `pulumi:"nameGroups"`
+	}
+
+	str := pschema.TypeSpec{Type: "string"}
+	stringArray := pschema.TypeSpec{Type: "array", Items: &str}
+	props, _, err := propertyListFromType(reflect.TypeOf(args{}), true, introspect.TagOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, stringArray, props["names"].TypeSpec)
+	assert.Equal(t, pschema.TypeSpec{Type: "object", AdditionalProperties: &str}, props["labels"].TypeSpec)
+	assert.Equal(t, stringArray, props["nameArray"].TypeSpec)
+	assert.Equal(t, pschema.TypeSpec{Type: "object", AdditionalProperties: &str}, props["labelMap"].TypeSpec)
+	assert.Equal(t, pschema.TypeSpec{Type: "array", Items: &stringArray}, props["nameGroups"].TypeSpec)
+}
+
+func TestAnyPropertyTypes(t *testing.T) {
+	t.Parallel()
+
+	type blobs struct {
+		Raw    json.RawMessage            `pulumi:"raw"`
+		RawPtr *json.RawMessage           `pulumi:"rawPtr,optional"`
+		RawMap map[string]json.RawMessage `pulumi:"rawMap"`
+		RawArr []json.RawMessage          `pulumi:"rawArr"`
+		Any    any                        `pulumi:"any"`
+	}
+
+	props, _, err := propertyListFromType(reflect.TypeOf(blobs{}), false, introspect.TagOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "pulumi.json#/Any", props["raw"].Ref)
+	assert.Equal(t, "pulumi.json#/Any", props["rawPtr"].Ref)
+	assert.Equal(t, "pulumi.json#/Any", props["rawMap"].AdditionalProperties.Ref)
+	assert.Equal(t, "pulumi.json#/Any", props["rawArr"].Items.Ref)
+	assert.Equal(t, "pulumi.json#/Any", props["any"].Ref)
+}
+
+func TestAssetPropertyTypes(t *testing.T) {
+	t.Parallel()
+
+	type assets struct {
+		Asset          pulumi.Asset               `pulumi:"asset"`
+		Archive        pulumi.Archive             `pulumi:"archive"`
+		AssetOrArchive pulumi.AssetOrArchive      `pulumi:"assetOrArchive"`
+		AssetInput     pulumi.AssetInput          `pulumi:"assetInput"`
+		ArchiveInput   pulumi.ArchiveInput        `pulumi:"archiveInput"`
+		EitherInput    pulumi.AssetOrArchiveInput `pulumi:"eitherInput"`
+		Local          *resource.Asset            `pulumi:"local"`
+		Union          types.AssetOrArchive       `pulumi:"union"`
+	}
+
+	props, _, err := propertyListFromType(reflect.TypeOf(assets{}), false, introspect.TagOptions{})
+	require.NoError(t, err)
+
+	for name, ref := range map[string]string{
+		"asset":          "pulumi.json#/Asset",
+		"archive":        "pulumi.json#/Archive",
+		"assetOrArchive": "pulumi.json#/Asset",
+		"assetInput":     "pulumi.json#/Asset",
+		"archiveInput":   "pulumi.json#/Archive",
+		"eitherInput":    "pulumi.json#/Asset",
+		"local":          "pulumi.json#/Asset",
+		"union":          "pulumi.json#/Asset",
+	} {
+		assert.Equal(t, ref, props[name].Ref, name)
+	}
+}
+
+type pointerElem struct {
+	Name string `pulumi:"name"`
+}
+
+func TestPointerElementPropertyTypes(t *testing.T) {
+	t.Parallel()
+
+	type fields struct {
+		Strings []*string               `pulumi:"strings"`
+		Fixed   [2]*int                 `pulumi:"fixed"`
+		ByName  map[string]*pointerElem `pulumi:"byName"`
+		List    []*pointerElem          `pulumi:"list"`
+		Nested  []map[string]*string    `pulumi:"nested"`
+	}
+
+	props, _, err := propertyListFromType(reflect.TypeOf(fields{}), false, introspect.TagOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "array", props["strings"].Type)
+	assert.Equal(t, &pschema.TypeSpec{Type: "string"}, props["strings"].Items)
+	assert.Equal(t, &pschema.TypeSpec{Type: "integer"}, props["fixed"].Items)
+	assert.Equal(t, "object", props["byName"].Type)
+	assert.Equal(t, &pschema.TypeSpec{Ref: "#/types/pkg:infer:pointerElem"}, props["byName"].AdditionalProperties)
+	assert.Equal(t, &pschema.TypeSpec{Ref: "#/types/pkg:infer:pointerElem"}, props["list"].Items)
+	assert.Equal(t, &pschema.TypeSpec{Type: "string"}, props["nested"].Items.AdditionalProperties)
+
+	registered := map[tokens.Type]pschema.ComplexTypeSpec{}
+	err = registerTypes[fields](func(tk tokens.Type, spec pschema.ComplexTypeSpec) bool {
+		_, known := registered[tk]
+		registered[tk] = spec
+		return !known
+	}, introspect.TagOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, registered, tokens.Type("pkg:infer:pointerElem"))
+}
+
+type vehicle interface{ isVehicle() }
+
+type car struct {
+	Kind  string `pulumi:"kind"`
+	Seats int    `pulumi:"seats"`
+}
+
+func (car) isVehicle() {}
+
+func (c *car) Annotate(a Annotator) { a.SetDiscriminator(&c.Kind, "car") }
+
+type truck struct {
+	Kind    string  `pulumi:"kind"`
+	Payload float64 `pulumi:"payload"`
+}
+
+func (truck) isVehicle() {}
+
+func (tr *truck) Annotate(a Annotator) { a.SetDiscriminator(&tr.Kind, "truck") }
+
+func TestInterfacePropertyTypes(t *testing.T) {
+	t.Parallel()
+
+	Union[vehicle](car{}, truck{}).(derivedUnion).register()
+
+	type fields struct {
+		Any      any             `pulumi:"any"`
+		Input    pulumi.Input    `pulumi:"input"`
+		Output   pulumi.Output   `pulumi:"output"`
+		Asset    pulumi.Asset    `pulumi:"asset"`
+		Resource pulumi.Resource `pulumi:"resource" provider:"type=aws@6.0.0:s3/bucket:Bucket"`
+		Vehicle  vehicle         `pulumi:"vehicle"`
+		Stringer fmt.Stringer    `pulumi:"stringer"`
+	}
+
+	props, _, err := propertyListFromType(reflect.TypeOf(fields{}), false, introspect.TagOptions{})
+	require.NoError(t, err)
+
+	for name, ref := range map[string]string{
+		"any":      "pulumi.json#/Any",
+		"input":    "pulumi.json#/Any",
+		"output":   "pulumi.json#/Any",
+		"asset":    "pulumi.json#/Asset",
+		"resource": "/aws/v6.0.0/schema.json#/resources/aws:s3/bucket:Bucket",
+		"stringer": "pulumi.json#/Any",
+	} {
+		assert.Equal(t, ref, props[name].Ref, name)
+	}
+	require.Len(t, props["vehicle"].OneOf, 2)
+	assert.Equal(t, "kind", props["vehicle"].Discriminator.PropertyName)
+
+	t.Run("resource without type", func(t *testing.T) {
+		t.Parallel()
+		type fields struct {
+			Resource pulumi.Resource `pulumi:"resource"`
+		}
+		_, _, err := propertyListFromType(reflect.TypeOf(fields{}), false, introspect.TagOptions{})
+		assert.ErrorContains(t, err, "missing type= tag on foreign resource")
+	})
+}
+
+type regionalBase struct {
+	Region string  `pulumi:"region"`
+	Zone   *string `pulumi:"zone,optional"`
+}
+
+func (b *regionalBase) Annotate(a Annotator) {
+	a.Describe(&b.Region, "The region to deploy into.")
+}
+
+func TestEmbeddedPropertyTypes(t *testing.T) {
+	t.Parallel()
+
+	type bucketArgs struct {
+		regionalBase
+		Name string `pulumi:"name"`
+	}
+
+	props, required, err := propertyListFromType(reflect.TypeOf(bucketArgs{}), false, introspect.TagOptions{})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"region", "name"}, required)
+	assert.Len(t, props, 3)
+	assert.Equal(t, "The region to deploy into.", props["region"].Description)
+	assert.Equal(t, "string", props["zone"].Type)
+
+	type ambiguousArgs struct {
+		regionalBase
+		Location string `pulumi:"region"`
+	}
+
+	_, _, err = propertyListFromType(reflect.TypeOf(ambiguousArgs{}), false, introspect.TagOptions{})
+	assert.ErrorContains(t, err,
+		"ambiguous property 'region' on 'infer.ambiguousArgs': declared by both 'regionalBase.Region' and 'Location'")
+}
+
+type deprecatedArgs struct {
+	BucketName *string `pulumi:"bucketName,optional"`
+	Bucket     *string `pulumi:"bucket,optional"`
+}
+
+func (d *deprecatedArgs) Annotate(a Annotator) {
+	a.Deprecate(&d.BucketName, "Use bucket instead.")
+}
+
+func TestDeprecatedProperties(t *testing.T) {
+	t.Parallel()
+
+	props, _, err := propertyListFromType(reflect.TypeOf(deprecatedArgs{}), false, introspect.TagOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "Use bucket instead.", props["bucketName"].DeprecationMessage)
+	assert.Empty(t, props["bucket"].DeprecationMessage)
+}
+
+func TestRequiredPropertiesSorted(t *testing.T) {
+	t.Parallel()
+
+	type before struct {
+		Zone   string `pulumi:"zone"`
+		Name   string `pulumi:"name"`
+		Region string `pulumi:"region"`
+	}
+	type after struct {
+		Region string `pulumi:"region"`
+		Zone   string `pulumi:"zone"`
+		Name   string `pulumi:"name"`
+	}
+
+	_, required, err := propertyListFromType(reflect.TypeOf(before{}), false, introspect.TagOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"name", "region", "zone"}, required)
+
+	_, reordered, err := propertyListFromType(reflect.TypeOf(after{}), false, introspect.TagOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, required, reordered)
+}
+
+type versionedArgs struct {
+	APIVersion string `pulumi:"apiVersion"`
+	Name       string `pulumi:"name"`
+}
+
+func (v *versionedArgs) Annotate(a Annotator) {
+	a.SetConst(&v.APIVersion, "v1")
+}
+
+func TestConstProperties(t *testing.T) {
+	t.Parallel()
+
+	spec, errs := getResourceSchema[TestResource, versionedArgs, versionedArgs](
+		false /* isComponent */, introspect.TagOptions{})
+	require.NoError(t, errs.ErrorOrNil())
+
+	assert.Equal(t, "v1", spec.Properties["apiVersion"].Const)
+	assert.Equal(t, []string{"name"}, spec.Required)
+	assert.NotContains(t, spec.InputProperties, "apiVersion")
+	assert.Equal(t, []string{"name"}, spec.RequiredInputs)
+}
+
+type storedState struct {
+	Name string `pulumi:"name"`
+	Arn  string `pulumi:"arn,computed"`
+}
+
+func TestComputedProperties(t *testing.T) {
+	t.Parallel()
+
+	spec, errs := getResourceSchema[TestResource, storedState, storedState](
+		false /* isComponent */, introspect.TagOptions{})
+	require.NoError(t, errs.ErrorOrNil())
+
+	assert.Contains(t, spec.Properties, "arn")
+	assert.Equal(t, []string{"arn", "name"}, spec.Required)
+	assert.NotContains(t, spec.InputProperties, "arn")
+	assert.Equal(t, []string{"name"}, spec.RequiredInputs)
+}
+
+type constrainedArgs struct {
+	Port int    `pulumi:"port"`
+	Name string `pulumi:"name"`
+}
+
+func (c *constrainedArgs) Annotate(a Annotator) {
+	a.Describe(&c.Port, "The port to listen on.")
+	a.SetMinimum(&c.Port, 1)
+	a.SetMaximum(&c.Port, 65535)
+	a.SetPattern(&c.Name, "^[a-z]+$")
+}
+
+func TestConstraintProperties(t *testing.T) {
+	t.Parallel()
+
+	props, _, err := propertyListFromType(reflect.TypeOf(constrainedArgs{}), false, introspect.TagOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "The port to listen on.\n\nMinimum: `1`.\n\nMaximum: `65535`.", props["port"].Description)
+	assert.Equal(t, "Pattern: `^[a-z]+$`.", props["name"].Description)
+}
+
+type renamedArgs struct {
+	ID   string `pulumi:"id"`
+	Name string `pulumi:"name"`
+}
+
+func (r *renamedArgs) Annotate(a Annotator) {
+	a.SetLanguageName(&r.ID, "csharp", "ResourceId")
+	a.SetLanguageName(&r.ID, "python", "resource_id")
+}
+
+func TestLanguageNames(t *testing.T) {
+	t.Parallel()
+
+	props, _, err := propertyListFromType(reflect.TypeOf(renamedArgs{}), false, introspect.TagOptions{})
+	require.NoError(t, err)
+	require.Len(t, props["id"].Language, 2)
+	assert.JSONEq(t, `{"name": "ResourceId"}`, string(props["id"].Language["csharp"]))
+	assert.JSONEq(t, `{"name": "resource_id"}`, string(props["id"].Language["python"]))
+	assert.Nil(t, props["name"].Language)
+
+	// The override is carried through to the serialized schema.
+	spec, errs := getResourceSchema[TestResource, renamedArgs, renamedArgs](
+		false /* isComponent */, introspect.TagOptions{})
+	require.NoError(t, errs.ErrorOrNil())
+	b, err := json.Marshal(spec.InputProperties["id"])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "string",
+		"language": {"csharp": {"name": "ResourceId"}, "python": {"name": "resource_id"}}
+	}`, string(b))
+}
@@ -0,0 +1,87 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// stateVersionKey holds the version of the state of a resource that sets one with
+// [Annotator.SetVersion].
+const stateVersionKey resource.PropertyKey = "__version"
+
+// withStateVersion returns state with the version of the resource R recorded in it, if R
+// sets one.
+func withStateVersion[R any](state resource.PropertyMap) resource.PropertyMap {
+	version := getAnnotated(typeFor[R]()).Version
+	if version == 0 || state == nil {
+		return state
+	}
+	state = state.Copy()
+	state[stateVersionKey] = resource.NewNumberProperty(float64(version))
+	return state
+}
+
+// migrateStateVersion upgrades state to the version of the resource R with
+// [CustomMigrateState], along with the inputs recorded with it, if any. State that holds
+// no version is at version 1. The version is removed from the returned state, so that it
+// can be decoded, and is recorded again by withStateVersion.
+func migrateStateVersion[R any](
+	ctx context.Context, state, inputs resource.PropertyMap,
+) (resource.PropertyMap, resource.PropertyMap, error) {
+	current := getAnnotated(typeFor[R]()).Version
+	if current == 0 || len(state) == 0 {
+		return state, inputs, nil
+	}
+	version := 1
+	if v := state[stateVersionKey]; v.IsNumber() {
+		version = int(v.NumberValue())
+	}
+	state = state.Copy()
+	delete(state, stateVersionKey)
+	if version > current {
+		return nil, nil, fmt.Errorf("the state of the resource is at version %d, "+
+			"which is newer than version %d of the provider", version, current)
+	}
+	if version == current {
+		return state, inputs, nil
+	}
+
+	var r R
+	migrate, ok := ((interface{})(r)).(CustomMigrateState)
+	if !ok {
+		return nil, nil, fmt.Errorf("the state of the resource is at version %d, but the resource "+
+			"does not implement CustomMigrateState to upgrade it to version %d", version, current)
+	}
+	if inputs != nil {
+		inputs = inputs.Copy()
+	}
+	for ; version < current; version++ {
+		var err error
+		if state, err = migrate.MigrateState(ctx, version, state); err != nil {
+			return nil, nil, fmt.Errorf("migrating state from version %d: %w", version, err)
+		}
+		if len(inputs) == 0 {
+			continue
+		}
+		if inputs, err = migrate.MigrateState(ctx, version, inputs); err != nil {
+			return nil, nil, fmt.Errorf("migrating inputs from version %d: %w", version, err)
+		}
+	}
+	return state, inputs, nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infer
+
+import (
+	"context"
+	"fmt"
+
+	p "github.com/pulumi/pulumi-go-provider"
+)
+
+// ReportStatus updates the status line of the resource that ctx belongs to, as shown by
+// `pulumi up` while the resource is being created, updated or deleted. Each message
+// replaces the previous one, and status messages are not kept in the final output.
+//
+// ReportStatus is intended for long running operations:
+//
+//	func (*Cluster) Create(
+//		ctx context.Context, name string, inputs ClusterArgs, preview bool,
+//	) (string, ClusterState, error) {
+//		infer.ReportStatus(ctx, "requesting cluster")
+//		id, err := requestCluster(ctx, inputs)
+//		...
+//		infer.ReportStatus(ctx, "waiting for cluster to become ready")
+//		...
+//	}
+//
+// When there is no engine to report to, ReportStatus logs msg with [log/slog] instead.
+func ReportStatus(ctx context.Context, msg string) {
+	p.GetLogger(ctx).InfoStatus(msg)
+}
+
+// ReportStatusf is like [ReportStatus], formatting msg with [fmt.Sprintf].
+func ReportStatusf(ctx context.Context, msg string, a ...any) {
+	ReportStatus(ctx, fmt.Sprintf(msg, a...))
+}
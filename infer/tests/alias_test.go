@@ -0,0 +1,162 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/blang/semver"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pgp "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
+)
+
+type Bucket struct{}
+
+func (*Bucket) Annotate(a infer.Annotator) {
+	a.AddAlias("legacy", "Bucket")
+}
+
+type BucketArgs struct {
+	Bucket string `pulumi:"bucket"`
+}
+
+func (args *BucketArgs) Annotate(a infer.Annotator) {
+	a.AddPropertyAlias(&args.Bucket, "bucketName")
+}
+
+type BucketState struct{ BucketArgs }
+
+func (*Bucket) Create(ctx context.Context, name string, inputs BucketArgs, preview bool) (string, BucketState, error) {
+	return inputs.Bucket, BucketState{inputs}, nil
+}
+
+func aliasProvider() integration.Server {
+	p := infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[*Bucket, BucketArgs, BucketState]()},
+	})
+	return integration.NewServer("test", semver.MustParse("1.0.0"), p)
+}
+
+func TestPropertyAliasSchema(t *testing.T) {
+	t.Parallel()
+
+	resp, err := aliasProvider().GetSchema(pgp.GetSchemaRequest{Version: 1})
+	require.NoError(t, err)
+
+	var spec pschema.PackageSpec
+	require.NoError(t, json.Unmarshal([]byte(resp.Schema), &spec))
+
+	bucket := spec.Resources["test:tests:Bucket"]
+	require.Contains(t, bucket.InputProperties, "bucketName")
+	assert.Equal(t, "Use `bucket` instead.", bucket.InputProperties["bucketName"].DeprecationMessage)
+	assert.Equal(t, []string{"bucket"}, bucket.RequiredInputs)
+	assert.NotContains(t, bucket.Properties, "bucketName")
+	require.Len(t, bucket.Aliases, 1)
+	assert.Equal(t, "test:legacy:Bucket", *bucket.Aliases[0].Type)
+}
+
+func TestPropertyAliasUpgrade(t *testing.T) {
+	t.Parallel()
+
+	urn := resource.NewURN("stack", "proj", "", "test:tests:Bucket", "bucket")
+	s := resource.NewStringProperty
+	prov := aliasProvider()
+
+	// A program written against the old schema still checks, and its inputs are
+	// normalized to the current property name.
+	check, err := prov.Check(pgp.CheckRequest{
+		Urn:  urn,
+		Olds: resource.PropertyMap{"bucketName": s("my-bucket")},
+		News: resource.PropertyMap{"bucketName": s("my-bucket")},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, check.Failures)
+	assert.Equal(t, resource.PropertyMap{"bucket": s("my-bucket")}, check.Inputs)
+
+	// State written under the old name doesn't produce a diff, let alone a replacement.
+	diff, err := prov.Diff(pgp.DiffRequest{
+		Urn:  urn,
+		ID:   "my-bucket",
+		Olds: resource.PropertyMap{"bucketName": s("my-bucket")},
+		News: check.Inputs,
+	})
+	require.NoError(t, err)
+	assert.False(t, diff.HasChanges)
+	assert.False(t, diff.DeleteBeforeReplace)
+	assert.Empty(t, diff.DetailedDiff)
+
+	// An actual change is still reported against the current name.
+	diff, err = prov.Diff(pgp.DiffRequest{
+		Urn:  urn,
+		ID:   "my-bucket",
+		Olds: resource.PropertyMap{"bucketName": s("my-bucket")},
+		News: resource.PropertyMap{"bucket": s("other-bucket")},
+	})
+	require.NoError(t, err)
+	assert.True(t, diff.HasChanges)
+	assert.Equal(t, map[string]pgp.PropertyDiff{
+		"bucket": {Kind: pgp.UpdateReplace},
+	}, diff.DetailedDiff)
+}
+
+func TestResourceAliasRouting(t *testing.T) {
+	t.Parallel()
+
+	urn := resource.NewURN("stack", "proj", "", "test:legacy:Bucket", "bucket")
+	s := resource.NewStringProperty
+	state := resource.PropertyMap{"bucket": s("my-bucket")}
+	prov := aliasProvider()
+
+	read, err := prov.Read(pgp.ReadRequest{
+		Urn:        urn,
+		ID:         "my-bucket",
+		Inputs:     state,
+		Properties: state,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, pgp.ReadResponse{
+		ID:         "my-bucket",
+		Inputs:     state,
+		Properties: state,
+	}, read)
+
+	diff, err := prov.Diff(pgp.DiffRequest{
+		Urn:  urn,
+		ID:   "my-bucket",
+		Olds: state,
+		News: state,
+	})
+	require.NoError(t, err)
+	assert.False(t, diff.HasChanges)
+
+	_, err = prov.Update(pgp.UpdateRequest{
+		Urn:  urn,
+		ID:   "my-bucket",
+		Olds: state,
+		News: resource.PropertyMap{"bucket": s("other-bucket")},
+	})
+	// Bucket doesn't implement Update, so reaching the resource is enough to show that
+	// the old token was routed to it.
+	assert.ErrorContains(t, err, "Update")
+	assert.NotContains(t, err.Error(), "not found")
+}
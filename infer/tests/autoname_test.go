@@ -0,0 +1,130 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
+)
+
+type Vault struct{}
+
+type VaultArgs struct {
+	Name string `pulumi:"name,optional"`
+	Size int    `pulumi:"size"`
+}
+
+func (v *VaultArgs) Annotate(a infer.Annotator) {
+	a.AutoName(&v.Name, 16)
+}
+
+func (*Vault) Create(ctx context.Context, name string, args VaultArgs, preview bool) (string, VaultArgs, error) {
+	return args.Name, args, nil
+}
+
+func (*Vault) Update(
+	ctx context.Context, id string, olds VaultArgs, news VaultArgs, preview bool,
+) (VaultArgs, error) {
+	return news, nil
+}
+
+func vaultProvider() integration.Server {
+	return integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[*Vault, VaultArgs, VaultArgs]()},
+	}))
+}
+
+func TestAutoNameStableAcrossUpdates(t *testing.T) {
+	t.Parallel()
+
+	var name string
+	size := func(n int) resource.PropertyMap {
+		return resource.PropertyMap{"size": resource.NewNumberProperty(float64(n))}
+	}
+	integration.LifeCycleTest{
+		Resource: "test:tests:Vault",
+		Create: integration.Operation{
+			Inputs: size(1),
+			Hook: func(inputs, output resource.PropertyMap) {
+				name = output["name"].StringValue()
+				assert.Regexp(t, "^test-[0-9a-f]{7}$", name)
+				assert.Equal(t, inputs["name"], output["name"])
+			},
+		},
+		Updates: []integration.Operation{{
+			Inputs: size(2),
+			Hook: func(inputs, output resource.PropertyMap) {
+				assert.Equal(t, name, output["name"].StringValue())
+			},
+			ExpectedDiff: map[string]p.PropertyDiff{"size": {Kind: p.Update}},
+		}, {
+			Inputs: size(3),
+			Hook: func(inputs, output resource.PropertyMap) {
+				assert.Equal(t, name, output["name"].StringValue())
+			},
+		}},
+	}.Run(t, vaultProvider())
+}
+
+func TestAutoName(t *testing.T) {
+	t.Parallel()
+
+	prov := vaultProvider()
+	check := func(t *testing.T, logicalName string, news resource.PropertyMap, seed []byte) string {
+		resp, err := prov.Check(p.CheckRequest{
+			Urn:        resource.NewURN("stack", "proj", "", "test:tests:Vault", logicalName),
+			News:       news,
+			RandomSeed: seed,
+		})
+		require.NoError(t, err)
+		require.Empty(t, resp.Failures)
+		return resp.Inputs["name"].StringValue()
+	}
+	size := resource.PropertyMap{"size": resource.NewNumberProperty(1)}
+
+	t.Run("deterministic", func(t *testing.T) {
+		t.Parallel()
+		seed := []byte("seed")
+		name := check(t, "vault", size, seed)
+		assert.Regexp(t, "^vault-[0-9a-f]{7}$", name)
+		// A preview and the update that follows it are checked with the same seed.
+		assert.Equal(t, name, check(t, "vault", size, seed))
+		assert.NotEqual(t, name, check(t, "vault", size, []byte("other seed")))
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		t.Parallel()
+		name := check(t, "a-very-long-logical-name", size, nil)
+		assert.Len(t, name, 16)
+		assert.True(t, strings.HasPrefix(name, "a-very-l-"), name)
+	})
+
+	t.Run("explicit", func(t *testing.T) {
+		t.Parallel()
+		news := size.Copy()
+		news["name"] = resource.NewStringProperty("my-vault")
+		assert.Equal(t, "my-vault", check(t, "vault", news, nil))
+	})
+}
@@ -0,0 +1,117 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/blang/semver"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
+)
+
+// Ledger holds arbitrary-precision numbers.
+type Ledger struct{}
+
+type LedgerArgs struct {
+	Supply *big.Int   `pulumi:"supply"`
+	Rate   big.Float  `pulumi:"rate"`
+	Limits []*big.Int `pulumi:"limits,optional"`
+}
+
+type LedgerState struct {
+	LedgerArgs
+	// Total is Supply times Rate, truncated to an integer.
+	Total big.Int `pulumi:"total"`
+}
+
+func (*Ledger) Create(ctx context.Context, name string, args LedgerArgs, preview bool) (string, LedgerState, error) {
+	state := LedgerState{LedgerArgs: args}
+	total := new(big.Float).SetPrec(args.Rate.Prec()).SetInt(args.Supply)
+	total.Mul(total, &args.Rate).Int(&state.Total)
+	return name, state, nil
+}
+
+func TestBigNumbers(t *testing.T) {
+	t.Parallel()
+
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[*Ledger, LedgerArgs, LedgerState]()},
+	}))
+	urn := resource.NewURN("stack", "proj", "", "test:tests:Ledger", "ledger")
+	const (
+		supply = "340282366920938463463374607431768211457"
+		rate   = "2.5000000000000000000000000000000000000001"
+	)
+	inputs := resource.PropertyMap{
+		"supply": resource.NewStringProperty(supply),
+		"rate":   resource.NewStringProperty(rate),
+		"limits": resource.NewArrayProperty([]resource.PropertyValue{
+			resource.NewStringProperty("18446744073709551617"),
+		}),
+	}
+
+	t.Run("schema", func(t *testing.T) {
+		t.Parallel()
+		resp, err := prov.GetSchema(p.GetSchemaRequest{Version: 1})
+		require.NoError(t, err)
+		var spec pschema.PackageSpec
+		require.NoError(t, json.Unmarshal([]byte(resp.Schema), &spec))
+
+		res := spec.Resources["test:tests:Ledger"]
+		assert.Equal(t, "string", res.InputProperties["supply"].Type)
+		assert.Equal(t, "string", res.InputProperties["rate"].Type)
+		assert.Equal(t, "string", res.InputProperties["limits"].Items.Type)
+		assert.Equal(t, "string", res.Properties["total"].Type)
+		assert.Len(t, spec.Types, 0)
+	})
+
+	t.Run("create", func(t *testing.T) {
+		t.Parallel()
+		check, err := prov.Check(p.CheckRequest{Urn: urn, News: inputs})
+		require.NoError(t, err)
+		require.Empty(t, check.Failures)
+		assert.Equal(t, inputs, check.Inputs)
+
+		create, err := prov.Create(p.CreateRequest{Urn: urn, Properties: check.Inputs})
+		require.NoError(t, err)
+		expected := inputs.Copy()
+		expected["total"] = resource.NewStringProperty("850705917302346158658436518579420528642")
+		assert.Equal(t, expected, create.Properties)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		news := inputs.Copy()
+		news["supply"] = resource.NewStringProperty("12e3")
+		news["rate"] = resource.NewStringProperty("one")
+		check, err := prov.Check(p.CheckRequest{Urn: urn, News: news})
+		require.NoError(t, err)
+		properties := make([]string, len(check.Failures))
+		for i, f := range check.Failures {
+			properties[i] = f.Property
+		}
+		assert.ElementsMatch(t, []string{"supply", "rate"}, properties)
+	})
+}
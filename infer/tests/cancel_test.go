@@ -0,0 +1,56 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	p "github.com/pulumi/pulumi-go-provider"
+)
+
+func TestCancelHook(t *testing.T) {
+	t.Parallel()
+
+	prov := provider()
+
+	created := make(chan error)
+	go func() {
+		_, err := prov.Create(p.CreateRequest{
+			Urn:        urn("Lease", "l"),
+			Properties: resource.PropertyMap{"holder": resource.NewStringProperty("cancel-hook")},
+		})
+		created <- err
+	}()
+	var held any
+	require.Eventually(t, func() bool {
+		held, _ = leases.Load("cancel-hook")
+		return held != nil
+	}, time.Second, time.Millisecond)
+
+	// The Create is in flight until the Cancel hook releases the lease it took.
+	require.NoError(t, prov.Cancel())
+	assert.ErrorIs(t, <-created, context.Canceled)
+	select {
+	case <-held.(*lease).released:
+	default:
+		assert.Fail(t, "the Cancel hook should release the lease")
+	}
+}
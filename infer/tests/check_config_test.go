@@ -15,13 +15,20 @@
 package tests
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/blang/semver"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
 )
 
 func TestCheckConfig(t *testing.T) {
@@ -74,3 +81,150 @@ func TestCheckConfigCustom(t *testing.T) {
 		pMap{"number": pNumber(42)},
 		pMap{"number": pNumber(42.5)}))
 }
+
+func TestCheckConfigSecretFromEnv(t *testing.T) {
+	t.Setenv("TEST_API_TOKEN", "hunter2")
+
+	prov := providerWithConfig[SecretConfig]()
+
+	resp, err := prov.CheckConfig(p.CheckRequest{
+		Urn:  urn("provider", "provider"),
+		News: resource.PropertyMap{},
+	})
+	require.NoError(t, err)
+	require.Empty(t, resp.Failures)
+	assert.Equal(t, resource.PropertyMap{
+		"apiToken": resource.MakeSecret(resource.NewStringProperty("hunter2")),
+	}, resp.Inputs)
+
+	schemaResp, err := prov.GetSchema(p.GetSchemaRequest{Version: 1})
+	require.NoError(t, err)
+	var spec pschema.PackageSpec
+	require.NoError(t, json.Unmarshal([]byte(schemaResp.Schema), &spec))
+
+	for _, prop := range []pschema.PropertySpec{
+		spec.Config.Variables["apiToken"],
+		spec.Provider.InputProperties["apiToken"],
+	} {
+		assert.True(t, prop.Secret)
+		assert.Equal(t, "The token used to authenticate with the API.", prop.Description)
+		require.NotNil(t, prop.DefaultInfo)
+		assert.Equal(t, []string{"TEST_API_TOKEN"}, prop.DefaultInfo.Environment)
+	}
+}
+
+type FileConfig struct {
+	infer.ConfigFile
+
+	Endpoint string            `pulumi:"endpoint"`
+	Region   string            `pulumi:"region,optional"`
+	Retries  int               `pulumi:"retries,optional"`
+	Labels   map[string]string `pulumi:"labels,optional"`
+}
+
+func TestCheckConfigFromFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+	yamlFile := write("config.yaml", "endpoint: https://file.example.com\nregion: eu-west\nretries: 3\n"+
+		"labels:\n  team: infra\n")
+	jsonFile := write("config.json", `{"endpoint": "https://file.example.com", "retries": 5}`)
+	badFile := write("bad.yaml", "endpoint: [unterminated\n")
+
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Config: infer.Config[FileConfig](),
+	}))
+	checkConfig := func(news resource.PropertyMap) p.CheckResponse {
+		resp, err := prov.CheckConfig(p.CheckRequest{Urn: urn("provider", "provider"), News: news})
+		require.NoError(t, err)
+		return resp
+	}
+	str := resource.NewStringProperty
+	num := resource.NewNumberProperty
+
+	t.Run("yaml", func(t *testing.T) {
+		t.Parallel()
+		resp := checkConfig(resource.PropertyMap{
+			"configFile": str(yamlFile),
+			"region":     str("us-east"),
+		})
+		assert.Empty(t, resp.Failures)
+		assert.Equal(t, resource.PropertyMap{
+			"configFile": str(yamlFile),
+			"endpoint":   str("https://file.example.com"),
+			// The key set in the configuration takes precedence over the file.
+			"region":  str("us-east"),
+			"retries": num(3),
+			"labels":  resource.NewObjectProperty(resource.PropertyMap{"team": str("infra")}),
+		}, resp.Inputs)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+		resp := checkConfig(resource.PropertyMap{
+			"configFile": str(jsonFile),
+			"endpoint":   str("https://config.example.com"),
+		})
+		assert.Empty(t, resp.Failures)
+		assert.Equal(t, resource.PropertyMap{
+			"configFile": str(jsonFile),
+			"endpoint":   str("https://config.example.com"),
+			"region":     str(""),
+			"retries":    num(5),
+		}, resp.Inputs)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		resp := checkConfig(resource.PropertyMap{"configFile": str(badFile)})
+		require.Len(t, resp.Failures, 1)
+		assert.Equal(t, "configFile", resp.Failures[0].Property)
+		assert.Contains(t, resp.Failures[0].Reason, "reading "+badFile)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		t.Parallel()
+		resp := checkConfig(resource.PropertyMap{"configFile": str(filepath.Join(dir, "missing.yaml"))})
+		require.Len(t, resp.Failures, 1)
+		assert.Equal(t, "configFile", resp.Failures[0].Property)
+	})
+
+	t.Run("schema", func(t *testing.T) {
+		t.Parallel()
+		resp, err := prov.GetSchema(p.GetSchemaRequest{Version: 1})
+		require.NoError(t, err)
+		var spec pschema.PackageSpec
+		require.NoError(t, json.Unmarshal([]byte(resp.Schema), &spec))
+		configFile := spec.Config.Variables["configFile"]
+		assert.Equal(t, "string", configFile.Type)
+		assert.Contains(t, configFile.Description, "JSON or YAML file")
+		assert.Equal(t, []string{"endpoint"}, spec.Config.Required)
+	})
+}
+
+func TestConfigureFromFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("endpoint: https://file.example.com\nretries: 3\n"), 0o600))
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Config:    infer.Config[FileConfig](),
+		Resources: []infer.InferredResource{infer.Resource[*ReadFileConfig, ReadConfigArgs, ReadConfigOutput]()},
+	}))
+
+	// The provider is configured without the checked config, as it is when it runs embedded.
+	require.NoError(t, prov.Configure(p.ConfigureRequest{Args: resource.PropertyMap{
+		"configFile": resource.NewStringProperty(path),
+		"retries":    resource.NewNumberProperty(4),
+	}}))
+	resp, err := prov.Create(p.CreateRequest{
+		Urn: resource.NewURN("stack", "proj", "", "test:tests:ReadFileConfig", "config"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, resource.NewStringProperty("https://file.example.com x4"), resp.Properties["config"])
+}
@@ -15,13 +15,17 @@
 package tests
 
 import (
+	"context"
 	"testing"
 
+	"github.com/blang/semver"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
 )
 
 func TestCheckDefaults(t *testing.T) {
@@ -257,3 +261,389 @@ func TestCheckDefaultsRecursive(t *testing.T) {
 	}, resp.Inputs)
 
 }
+
+func TestCheckRequired(t *testing.T) {
+	t.Parallel()
+	pString := resource.NewStringProperty
+	pNumber := resource.NewNumberProperty
+	type pMap = resource.PropertyMap
+	type pValue = resource.PropertyValue
+
+	prov := provider()
+	resp, err := prov.Check(p.CheckRequest{
+		Urn: urn("Required", "check-required"),
+		News: pMap{
+			"network": resource.NewObjectProperty(pMap{
+				"cidr": pString("10.0.0.0/16"),
+			}),
+			"rules": resource.NewArrayProperty([]pValue{
+				resource.NewObjectProperty(pMap{"port": pNumber(80)}),
+				resource.NewObjectProperty(pMap{}),
+			}),
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []p.CheckFailure{
+		{Property: "bucket", Reason: "missing required property 'bucket'"},
+		{Property: "network.subnet", Reason: "missing required property 'network.subnet'"},
+		{Property: "rules[1].port", Reason: "missing required property 'rules[1].port'"},
+	}, resp.Failures)
+}
+
+func TestCheckCrossField(t *testing.T) {
+	t.Parallel()
+	pNumber := resource.NewNumberProperty
+	pString := resource.NewStringProperty
+	type pMap = resource.PropertyMap
+
+	check := func(news pMap) p.CheckResponse {
+		resp, err := provider().Check(p.CheckRequest{
+			Urn:  urn("Pool", "check-pool"),
+			News: news,
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		t.Parallel()
+		resp := check(pMap{"minSize": pNumber(2), "maxSize": pNumber(5)})
+		assert.Empty(t, resp.Failures)
+		// Both the annotated default and the computed default are applied.
+		assert.Equal(t, pMap{
+			"minSize":     pNumber(2),
+			"maxSize":     pNumber(5),
+			"desiredSize": pNumber(2),
+			"zone":        pString("us-east-1a"),
+		}, resp.Inputs)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		resp := check(pMap{"minSize": pNumber(5), "maxSize": pNumber(2)})
+		assert.Equal(t, []p.CheckFailure{
+			{Property: "maxSize", Reason: "maxSize (2) must be at least minSize (5)"},
+		}, resp.Failures)
+	})
+
+	t.Run("structural", func(t *testing.T) {
+		t.Parallel()
+		// Structural failures are reported before the custom check runs.
+		resp := check(pMap{"minSize": pNumber(5)})
+		assert.Equal(t, []p.CheckFailure{
+			{Property: "maxSize", Reason: "missing required property 'maxSize'"},
+		}, resp.Failures)
+	})
+}
+
+func TestCheckConst(t *testing.T) {
+	t.Parallel()
+	pString := resource.NewStringProperty
+	pNumber := resource.NewNumberProperty
+	type pMap = resource.PropertyMap
+	type pValue = resource.PropertyValue
+
+	resp, err := provider().Check(p.CheckRequest{
+		Urn: urn("Deployment", "check-const"),
+		News: pMap{
+			"apiVersion": pString("apps/v1beta1"),
+			"replicas":   pNumber(2),
+			"containers": resource.NewArrayProperty([]pValue{
+				resource.NewObjectProperty(pMap{"image": pString("nginx")}),
+			}),
+		},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Failures)
+	assert.Equal(t, pMap{
+		"apiVersion": pString("apps/v1"),
+		"replicas":   pNumber(2),
+		"containers": resource.NewArrayProperty([]pValue{
+			resource.NewObjectProperty(pMap{
+				"kind":  pString("Container"),
+				"image": pString("nginx"),
+			}),
+		}),
+	}, resp.Inputs)
+}
+
+func TestCheckDefaultFunc(t *testing.T) {
+	t.Parallel()
+	pString := resource.NewStringProperty
+	type pMap = resource.PropertyMap
+
+	check := func(news pMap) (p.CheckResponse, error) {
+		return provider().Check(p.CheckRequest{Urn: urn("Topic", "check-default-func"), News: news})
+	}
+
+	t.Run("derived", func(t *testing.T) {
+		t.Parallel()
+		resp, err := check(pMap{"prefix": pString("events")})
+		require.NoError(t, err)
+		assert.Empty(t, resp.Failures)
+		// The name is derived after the region is defaulted.
+		assert.Equal(t, pMap{
+			"prefix": pString("events"),
+			"region": pString("us-east-1"),
+			"name":   pString("events-us-east-1"),
+		}, resp.Inputs)
+	})
+
+	t.Run("other-defaults", func(t *testing.T) {
+		t.Parallel()
+		resp, err := check(pMap{"prefix": pString("events"), "region": pString("eu-west-1")})
+		require.NoError(t, err)
+		assert.Equal(t, pString("events-eu-west-1"), resp.Inputs["name"])
+	})
+
+	t.Run("set", func(t *testing.T) {
+		t.Parallel()
+		resp, err := check(pMap{"prefix": pString("events"), "name": pString("custom")})
+		require.NoError(t, err)
+		assert.Equal(t, pString("custom"), resp.Inputs["name"])
+	})
+
+	t.Run("error", func(t *testing.T) {
+		t.Parallel()
+		_, err := check(pMap{"prefix": pString("")})
+		assert.ErrorContains(t, err, "cannot derive a name without a prefix")
+	})
+}
+
+func TestCheckConstraints(t *testing.T) {
+	t.Parallel()
+	pString := resource.NewStringProperty
+	pNumber := resource.NewNumberProperty
+	type pMap = resource.PropertyMap
+	type pValue = resource.PropertyValue
+
+	check := func(news pMap) p.CheckResponse {
+		resp, err := provider().Check(p.CheckRequest{
+			Urn:  urn("Listener", "check-constraints"),
+			News: news,
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := check(pMap{
+		"name": pString("web-1"),
+		"port": pNumber(443),
+		"backends": resource.NewArrayProperty([]pValue{
+			resource.NewObjectProperty(pMap{"host": pString("a.example"), "weight": pNumber(0.5)}),
+			resource.NewObjectProperty(pMap{"host": pString("b.example")}),
+		}),
+	})
+	assert.Empty(t, resp.Failures)
+
+	resp = check(pMap{
+		"name": resource.MakeSecret(pString("Web_1")),
+		"port": pNumber(0),
+		"backends": resource.NewArrayProperty([]pValue{
+			resource.NewObjectProperty(pMap{"host": pString("a.example"), "weight": pNumber(1.5)}),
+			resource.NewObjectProperty(pMap{"host": pString("B.EXAMPLE"), "weight": pNumber(-1)}),
+		}),
+	})
+	assert.ElementsMatch(t, []p.CheckFailure{
+		{Property: "name", Reason: `[secret] does not match the pattern "^[a-z0-9-]+$"`},
+		{Property: "port", Reason: "0 is less than the minimum of 1"},
+		{Property: "backends[0].weight", Reason: "1.5 is greater than the maximum of 1"},
+		{Property: "backends[1].host", Reason: `"B.EXAMPLE" does not match the pattern "^[a-z.]+$"`},
+		{Property: "backends[1].weight", Reason: "-1 is less than the minimum of 0"},
+	}, resp.Failures)
+
+	resp = check(pMap{
+		"name": pString("web"),
+		"port": pNumber(70000),
+	})
+	assert.Equal(t, []p.CheckFailure{
+		{Property: "port", Reason: "70000 is greater than the maximum of 65535"},
+	}, resp.Failures)
+
+	// Unknown values can't be checked until they are known.
+	resp = check(pMap{
+		"name": resource.MakeComputed(pString("")),
+		"port": resource.MakeComputed(pNumber(0)),
+	})
+	assert.Empty(t, resp.Failures)
+}
+
+func TestCheckComputed(t *testing.T) {
+	t.Parallel()
+	prov := provider()
+
+	resp, err := prov.Check(p.CheckRequest{
+		Urn:  urn("Object", "check-computed"),
+		News: resource.PropertyMap{"name": resource.NewStringProperty("logs")},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Failures)
+	assert.Equal(t, resource.PropertyMap{"name": resource.NewStringProperty("logs")}, resp.Inputs)
+
+	create, err := prov.Create(p.CreateRequest{
+		Urn:        urn("Object", "check-computed"),
+		Properties: resp.Inputs,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, resource.NewStringProperty("arn:object:logs"), create.Properties["arn"])
+
+	resp, err = prov.Check(p.CheckRequest{
+		Urn: urn("Object", "check-computed"),
+		News: resource.PropertyMap{
+			"name": resource.NewStringProperty("logs"),
+			"arn":  resource.NewStringProperty("arn:object:other"),
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []p.CheckFailure{
+		{Property: "arn", Reason: "'arn' is computed by the provider and cannot be set"},
+	}, resp.Failures)
+}
+
+type PlacementConfig struct {
+	Region string `pulumi:"region"`
+}
+
+func (c *PlacementConfig) Annotate(a infer.Annotator) {
+	a.SetDefault(&c.Region, nil, "TEST_PLACEMENT_REGION")
+}
+
+// Placement places a resource in a zone of the region of the provider.
+type Placement struct{}
+
+type PlacementArgs struct {
+	Zone string `pulumi:"zone"`
+}
+
+func (a *PlacementArgs) Annotate(an infer.Annotator) {
+	an.SetDefault(&a.Zone, nil, "TEST_PLACEMENT_ZONE")
+}
+
+type PlacementState struct {
+	PlacementArgs
+	Location string `pulumi:"location"`
+}
+
+func (*Placement) Create(
+	ctx context.Context, name string, args PlacementArgs, preview bool,
+) (string, PlacementState, error) {
+	region := infer.GetConfig[PlacementConfig](ctx).Region
+	return name, PlacementState{PlacementArgs: args, Location: region + "/" + args.Zone}, nil
+}
+
+// TestRequiredEnvDefaults checks that required fields are read from their environment
+// variables by the provider itself, as they are when it runs embedded, without the
+// engine applying the defaults from the schema.
+func TestRequiredEnvDefaults(t *testing.T) {
+	t.Setenv("TEST_PLACEMENT_REGION", "us-west-2")
+	t.Setenv("TEST_PLACEMENT_ZONE", "b")
+
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Config:    infer.Config[PlacementConfig](),
+		Resources: []infer.InferredResource{infer.Resource[*Placement, PlacementArgs, PlacementState]()},
+	}))
+
+	config, err := prov.CheckConfig(p.CheckRequest{
+		Urn: resource.NewURN("stack", "proj", "", "pulumi:providers:test", "provider"),
+	})
+	require.NoError(t, err)
+	assert.Empty(t, config.Failures)
+	assert.Equal(t, resource.PropertyMap{"region": resource.NewStringProperty("us-west-2")}, config.Inputs)
+
+	// The provider is configured without the checked config.
+	require.NoError(t, prov.Configure(p.ConfigureRequest{}))
+
+	urn := resource.NewURN("stack", "proj", "", "test:tests:Placement", "placement")
+	check, err := prov.Check(p.CheckRequest{Urn: urn})
+	require.NoError(t, err)
+	assert.Empty(t, check.Failures)
+	assert.Equal(t, resource.PropertyMap{"zone": resource.NewStringProperty("b")}, check.Inputs)
+
+	create, err := prov.Create(p.CreateRequest{Urn: urn, Properties: check.Inputs})
+	require.NoError(t, err)
+	assert.Equal(t, resource.NewStringProperty("us-west-2/b"), create.Properties["location"])
+
+	t.Run("explicit", func(t *testing.T) {
+		check, err := prov.Check(p.CheckRequest{
+			Urn:  urn,
+			News: resource.PropertyMap{"zone": resource.NewStringProperty("c")},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, resource.PropertyMap{"zone": resource.NewStringProperty("c")}, check.Inputs)
+	})
+}
+
+// Beacon broadcasts a fixed number of messages, signed with a fixed-size key.
+type Beacon struct{}
+
+type BeaconArgs struct {
+	Messages [3]string `pulumi:"messages"`
+	Key      [4]byte   `pulumi:"key"`
+}
+
+func (*Beacon) Create(ctx context.Context, name string, args BeaconArgs, preview bool) (string, BeaconArgs, error) {
+	return name, args, nil
+}
+
+func TestCheckFixedLengthArrays(t *testing.T) {
+	t.Parallel()
+
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[*Beacon, BeaconArgs, BeaconArgs]()},
+	}))
+	urn := resource.NewURN("stack", "proj", "", "test:tests:Beacon", "beacon")
+
+	stringArray := func(elems ...string) resource.PropertyValue {
+		arr := make([]resource.PropertyValue, len(elems))
+		for i, el := range elems {
+			arr[i] = resource.NewStringProperty(el)
+		}
+		return resource.NewArrayProperty(arr)
+	}
+	numberArray := func(elems ...float64) resource.PropertyValue {
+		arr := make([]resource.PropertyValue, len(elems))
+		for i, el := range elems {
+			arr[i] = resource.NewNumberProperty(el)
+		}
+		return resource.NewArrayProperty(arr)
+	}
+
+	inputs := resource.PropertyMap{
+		"messages": stringArray("a", "b", "c"),
+		"key":      numberArray(1, 2, 3, 4),
+	}
+	check, err := prov.Check(p.CheckRequest{Urn: urn, News: inputs})
+	require.NoError(t, err)
+	assert.Empty(t, check.Failures)
+	assert.Equal(t, inputs, check.Inputs)
+
+	create, err := prov.Create(p.CreateRequest{Urn: urn, Properties: check.Inputs})
+	require.NoError(t, err)
+	assert.Equal(t, inputs, create.Properties)
+
+	check, err = prov.Check(p.CheckRequest{Urn: urn, News: resource.PropertyMap{
+		"messages": stringArray("a", "b"),
+		"key":      numberArray(1, 2, 3, 4, 5),
+	}})
+	require.NoError(t, err)
+	require.Len(t, check.Failures, 2)
+	failures := map[string]string{}
+	for _, f := range check.Failures {
+		failures[f.Property] = f.Reason
+	}
+	assert.Contains(t, failures["messages"], "expected 3 elements, found 2")
+	assert.Contains(t, failures["key"], "expected 4 elements, found 5")
+
+	// Unknown arrays are accepted during previews.
+	unknown := resource.PropertyMap{
+		"messages": resource.MakeComputed(resource.NewStringProperty("")),
+		"key":      numberArray(1, 2, 3, 4),
+	}
+	check, err = prov.Check(p.CheckRequest{Urn: urn, News: unknown})
+	require.NoError(t, err)
+	assert.Empty(t, check.Failures)
+	preview, err := prov.Create(p.CreateRequest{Urn: urn, Properties: unknown, Preview: true})
+	require.NoError(t, err)
+	assert.True(t, preview.Properties["messages"].ContainsUnknowns())
+}
@@ -0,0 +1,90 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	p "github.com/pulumi/pulumi-go-provider"
+)
+
+// regionChanges receives the old and new regions of each call to
+// [RegionConfig.ConfigChanged].
+var regionChanges = make(chan [2]string, 1)
+
+type RegionConfig struct {
+	Region string `pulumi:"region"`
+}
+
+func (*RegionConfig) ConfigChanged(ctx context.Context, olds, news RegionConfig) error {
+	if news.Region == "nowhere" {
+		return errors.New("unknown region")
+	}
+	regionChanges <- [2]string{olds.Region, news.Region}
+	return nil
+}
+
+//nolint:paralleltest // The subtests share regionChanges, so they run one at a time.
+func TestConfigChanged(t *testing.T) {
+	t.Parallel()
+
+	prov := providerWithConfig[RegionConfig]()
+	region := func(r string) resource.PropertyMap {
+		return resource.PropertyMap{"region": resource.NewStringProperty(r)}
+	}
+	diff := func(olds, news resource.PropertyMap) (p.DiffResponse, error) {
+		return prov.DiffConfig(p.DiffRequest{
+			Urn:  resource.NewURN("dev", "proj", "", "pulumi:providers:test", "provider"),
+			Olds: olds,
+			News: news,
+		})
+	}
+
+	resp, err := diff(region("us-west-1"), region("us-east-1"))
+	require.NoError(t, err)
+	assert.True(t, resp.HasChanges)
+	select {
+	case change := <-regionChanges:
+		assert.Equal(t, [2]string{"us-west-1", "us-east-1"}, change)
+	default:
+		assert.Fail(t, "ConfigChanged was not called")
+	}
+
+	t.Run("unchanged", func(t *testing.T) {
+		resp, err := diff(region("us-east-1"), region("us-east-1"))
+		require.NoError(t, err)
+		assert.False(t, resp.HasChanges)
+		assert.Empty(t, regionChanges)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		news := resource.PropertyMap{"region": resource.MakeComputed(resource.NewStringProperty(""))}
+		resp, err := diff(region("us-east-1"), news)
+		require.NoError(t, err)
+		assert.True(t, resp.HasChanges)
+		assert.Empty(t, regionChanges)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := diff(region("us-east-1"), region("nowhere"))
+		assert.ErrorContains(t, err, "unknown region")
+	})
+}
@@ -15,13 +15,20 @@
 package tests
 
 import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
 	"testing"
 
+	"github.com/blang/semver"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
 )
 
 func TestConfigure(t *testing.T) {
@@ -47,6 +54,30 @@ func TestConfigure(t *testing.T) {
 	}, resp.Properties)
 }
 
+func TestConfigureWithConfig(t *testing.T) {
+	t.Parallel()
+	pString := resource.NewStringProperty
+	type pMap = resource.PropertyMap
+
+	prov := providerWithConfig[Config](integration.WithConfig(pMap{
+		"value": pString("my-token"),
+	}))
+
+	resp, err := prov.Create(p.CreateRequest{
+		Urn: urn("ReadConfig", "config"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, pMap{
+		"config": pString("{\"Value\":\"my-token\"}"),
+	}, resp.Properties)
+
+	assert.Panics(t, func() {
+		providerWithConfig[Config](integration.WithConfig(pMap{
+			"value": resource.NewNumberProperty(42),
+		}))
+	}, "invalid config should fail server setup")
+}
+
 func TestConfigureCustom(t *testing.T) {
 	t.Parallel()
 	pString := resource.NewStringProperty
@@ -80,3 +111,101 @@ func TestConfigureCustom(t *testing.T) {
 		pMap{"number": pNumber(42)},
 		pMap{"config": pString(`{"Number":42,"Squared":1764}`)}))
 }
+
+// apiClient stands in for the client of a cloud API. It counts the clients built, so that
+// tests can check that a single client is shared.
+type apiClient struct{ endpoint string }
+
+var apiClients atomic.Int32
+
+func (c *apiClient) put(key string) string { return c.endpoint + "/" + key }
+
+type ClientConfig struct {
+	Endpoint string `pulumi:"endpoint"`
+
+	client *apiClient
+}
+
+var (
+	_ infer.CustomConfigure = (*ClientConfig)(nil)
+	_ infer.CustomValidate  = (*ClientConfig)(nil)
+)
+
+func (c *ClientConfig) Validate(context.Context) []p.CheckFailure {
+	if !strings.HasPrefix(c.Endpoint, "https://") {
+		return []p.CheckFailure{{Property: "endpoint", Reason: "the endpoint must use https"}}
+	}
+	return nil
+}
+
+func (c *ClientConfig) Configure(context.Context) error {
+	apiClients.Add(1)
+	c.client = &apiClient{endpoint: c.Endpoint}
+	return nil
+}
+
+// Upload puts an object with the client built by ClientConfig.
+type Upload struct{}
+
+type UploadArgs struct {
+	Key string `pulumi:"key"`
+}
+
+type UploadState struct {
+	UploadArgs
+	URL string `pulumi:"url"`
+}
+
+func (*Upload) Create(ctx context.Context, name string, args UploadArgs, preview bool) (string, UploadState, error) {
+	client := infer.GetConfig[*ClientConfig](ctx).client
+	if client == nil {
+		return "", UploadState{}, fmt.Errorf("the provider is not configured")
+	}
+	return args.Key, UploadState{UploadArgs: args, URL: client.put(args.Key)}, nil
+}
+
+//nolint:paralleltest // apiClients counts the clients built by every test.
+func TestConfigureClient(t *testing.T) {
+	newServer := func() integration.Server {
+		return integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+			Config:    infer.Config[*ClientConfig](),
+			Resources: []infer.InferredResource{infer.Resource[*Upload, UploadArgs, UploadState]()},
+		}))
+	}
+	endpoint := func(e string) resource.PropertyMap {
+		return resource.PropertyMap{"endpoint": resource.NewStringProperty(e)}
+	}
+
+	t.Run("check", func(t *testing.T) {
+		resp, err := newServer().CheckConfig(p.CheckRequest{
+			Urn:  resource.NewURN("stack", "proj", "", "pulumi:providers:test", "provider"),
+			News: endpoint("http://api.example.com"),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []p.CheckFailure{
+			{Property: "endpoint", Reason: "the endpoint must use https"},
+		}, resp.Failures)
+	})
+
+	t.Run("configure-invalid", func(t *testing.T) {
+		before := apiClients.Load()
+		err := newServer().Configure(p.ConfigureRequest{Args: endpoint("http://api.example.com")})
+		assert.ErrorContains(t, err, "invalid configuration: endpoint: the endpoint must use https")
+		assert.Equal(t, before, apiClients.Load(), "Configure should not be called")
+	})
+
+	t.Run("create", func(t *testing.T) {
+		before := apiClients.Load()
+		prov := newServer()
+		require.NoError(t, prov.Configure(p.ConfigureRequest{Args: endpoint("https://api.example.com")}))
+		for _, key := range []string{"a", "b"} {
+			resp, err := prov.Create(p.CreateRequest{
+				Urn:        resource.NewURN("stack", "proj", "", "test:tests:Upload", key),
+				Properties: resource.PropertyMap{"key": resource.NewStringProperty(key)},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, resource.NewStringProperty("https://api.example.com/"+key), resp.Properties["url"])
+		}
+		assert.Equal(t, before+1, apiClients.Load(), "a single client should be built")
+	})
+}
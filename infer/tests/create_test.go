@@ -15,12 +15,23 @@
 package tests
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/blang/semver"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
 	"github.com/pulumi/pulumi-go-provider/internal/putil"
 )
 
@@ -206,3 +217,291 @@ func TestCreate(t *testing.T) {
 		}, resp.Properties)
 	})
 }
+
+func TestCreatePreview(t *testing.T) {
+	t.Parallel()
+	s := resource.NewStringProperty
+
+	prov := provider()
+	resp, err := prov.Create(p.CreateRequest{
+		Urn:        urn("Certificate", "preview"),
+		Properties: resource.PropertyMap{"domain": s("preview.example")},
+		Preview:    true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, resource.PropertyMap{
+		"domain": s("preview.example"),
+		"serial": resource.MakeComputed(s("")),
+	}, resp.Properties)
+	_, issued := issuedCertificates.Load("preview.example")
+	assert.False(t, issued, "a preview must not issue a certificate")
+
+	resp, err = prov.Create(p.CreateRequest{
+		Urn:        urn("Certificate", "preview"),
+		Properties: resource.PropertyMap{"domain": s("preview.example")},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, resource.PropertyMap{
+		"domain": s("preview.example"),
+		"serial": s("serial-preview.example"),
+	}, resp.Properties)
+	_, issued = issuedCertificates.Load("preview.example")
+	assert.True(t, issued)
+}
+
+func TestCreateReportStatus(t *testing.T) {
+	t.Parallel()
+
+	prov := provider()
+	_, err := prov.Create(p.CreateRequest{
+		Urn:        urn("Cluster", "status"),
+		Properties: resource.PropertyMap{"nodes": resource.NewNumberProperty(3)},
+	})
+	require.NoError(t, err)
+
+	status := func(msg string) integration.LogMessage {
+		return integration.LogMessage{Severity: diag.Info, URN: urn("Cluster", "status"), Message: msg, Status: true}
+	}
+	assert.Equal(t, []integration.LogMessage{
+		status("requesting cluster"),
+		status("starting 3 nodes"),
+		status("waiting for cluster to become ready"),
+	}, prov.Logs())
+}
+
+func TestCreateFixedID(t *testing.T) {
+	t.Parallel()
+
+	create := func() []string {
+		var n int
+		prov := provider(integration.WithIDGenerator(func() string {
+			n++
+			return fmt.Sprintf("fixed-%d", n)
+		}))
+		var ids []string
+		for _, title := range []string{"first", "second"} {
+			resp, err := prov.Create(p.CreateRequest{
+				Urn:        urn("Invoice", title),
+				Properties: resource.PropertyMap{"title": resource.NewStringProperty(title)},
+			})
+			require.NoError(t, err)
+			ids = append(ids, resp.ID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []string{"invoice-fixed-1", "invoice-fixed-2"}, create())
+	assert.Equal(t, create(), create(), "IDs should be the same across runs")
+
+	// Without a generator, IDs are random.
+	resp, err := provider().Create(p.CreateRequest{
+		Urn:        urn("Invoice", "random"),
+		Properties: resource.PropertyMap{"title": resource.NewStringProperty("random")},
+	})
+	require.NoError(t, err)
+	assert.Regexp(t, "^invoice-[0-9a-f]{16}$", resp.ID)
+}
+
+// Disk is allocated before it is formatted, which fails for unknown filesystems.
+type Disk struct{}
+
+type DiskArgs struct {
+	Filesystem string `pulumi:"filesystem"`
+}
+
+type DiskState struct {
+	DiskArgs
+	Formatted bool `pulumi:"formatted"`
+}
+
+func (*Disk) Create(ctx context.Context, name string, inputs DiskArgs, preview bool) (string, DiskState, error) {
+	if inputs.Filesystem == "" {
+		return "", DiskState{}, errors.New("a filesystem is required")
+	}
+	state := DiskState{DiskArgs: inputs}
+	switch inputs.Filesystem {
+	case "ext4":
+	case "ntfs":
+		// An ID returned with a plain error doesn't record the disk.
+		return "disk-1", state, fmt.Errorf("%s is not supported", inputs.Filesystem)
+	default:
+		return "disk-1", state, infer.PartialError{
+			Err: fmt.Errorf("cannot format disk-1 as %s", inputs.Filesystem),
+		}
+	}
+	state.Formatted = true
+	return "disk-1", state, nil
+}
+
+func TestCreatePartialFailure(t *testing.T) {
+	t.Parallel()
+
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[*Disk, DiskArgs, DiskState]()},
+	}))
+	create := func(filesystem string) (p.CreateResponse, error) {
+		return prov.Create(p.CreateRequest{
+			Urn:        resource.NewURN("stack", "proj", "", "test:tests:Disk", "disk"),
+			Properties: resource.PropertyMap{"filesystem": resource.NewStringProperty(filesystem)},
+		})
+	}
+
+	t.Run("allocated", func(t *testing.T) {
+		t.Parallel()
+		resp, err := create("zfs")
+		assert.ErrorContains(t, err, "cannot format disk-1 as zfs")
+		assert.Equal(t, "disk-1", resp.ID)
+		assert.Equal(t, resource.PropertyMap{
+			"filesystem": resource.NewStringProperty("zfs"),
+			"formatted":  resource.NewBoolProperty(false),
+		}, resp.Properties)
+		require.NotNil(t, resp.PartialState)
+		assert.Equal(t, []string{"cannot format disk-1 as zfs"}, resp.PartialState.Reasons)
+	})
+
+	t.Run("not-allocated", func(t *testing.T) {
+		t.Parallel()
+		resp, err := create("")
+		assert.ErrorContains(t, err, "a filesystem is required")
+		assert.Empty(t, resp.ID)
+		assert.Nil(t, resp.PartialState)
+	})
+
+	t.Run("failed-with-id", func(t *testing.T) {
+		t.Parallel()
+		resp, err := create("ntfs")
+		assert.ErrorContains(t, err, "ntfs is not supported")
+		assert.False(t, errors.As(err, &infer.ResourceInitFailedError{}))
+		assert.Empty(t, resp.ID)
+		assert.Nil(t, resp.PartialState)
+	})
+
+	t.Run("succeeded", func(t *testing.T) {
+		t.Parallel()
+		resp, err := create("ext4")
+		require.NoError(t, err)
+		assert.Nil(t, resp.PartialState)
+		assert.Equal(t, resource.NewBoolProperty(true), resp.Properties["formatted"])
+	})
+}
+
+// Queue is identified by its ARN, which Create returns in its state instead of as an ID.
+type Queue struct{}
+
+type QueueArgs struct {
+	Name string `pulumi:"name"`
+}
+
+type QueueState struct {
+	QueueArgs
+	Arn string `pulumi:"arn"`
+}
+
+func (s *QueueState) Annotate(a infer.Annotator) { a.SetID(&s.Arn) }
+
+func (*Queue) Create(ctx context.Context, name string, inputs QueueArgs, preview bool) (string, QueueState, error) {
+	if inputs.Name == "mismatch" {
+		return "other", QueueState{QueueArgs: inputs, Arn: "arn:queue:" + inputs.Name}, nil
+	}
+	return "", QueueState{QueueArgs: inputs, Arn: "arn:queue:" + inputs.Name}, nil
+}
+
+func (*Queue) Read(
+	ctx context.Context, id string, inputs QueueArgs, state QueueState,
+) (string, QueueArgs, QueueState, error) {
+	// The ARN is looked up from the state, which holds the ID when the queue is imported.
+	name, ok := strings.CutPrefix(state.Arn, "arn:queue:")
+	if !ok {
+		return "", QueueArgs{}, QueueState{}, fmt.Errorf("unknown queue %q", state.Arn)
+	}
+	return state.Arn, QueueArgs{Name: name}, QueueState{QueueArgs: QueueArgs{Name: name}, Arn: state.Arn}, nil
+}
+
+func TestCreateIDField(t *testing.T) {
+	t.Parallel()
+
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[*Queue, QueueArgs, QueueState]()},
+	}))
+	urn := resource.NewURN("stack", "proj", "", "test:tests:Queue", "queue")
+	str := resource.NewStringProperty
+
+	t.Run("create", func(t *testing.T) {
+		t.Parallel()
+		resp, err := prov.Create(p.CreateRequest{
+			Urn:        urn,
+			Properties: resource.PropertyMap{"name": str("jobs")},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "arn:queue:jobs", resp.ID)
+		assert.Equal(t, str("arn:queue:jobs"), resp.Properties["arn"])
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		t.Parallel()
+		_, err := prov.Create(p.CreateRequest{
+			Urn:        urn,
+			Properties: resource.PropertyMap{"name": str("mismatch")},
+		})
+		assert.ErrorContains(t, err,
+			`Create returned the ID "other", but the ID field of the resource holds "arn:queue:mismatch"`)
+	})
+
+	t.Run("import", func(t *testing.T) {
+		t.Parallel()
+		resp, err := prov.Read(p.ReadRequest{Urn: urn, ID: "arn:queue:mail"})
+		require.NoError(t, err)
+		assert.Equal(t, "arn:queue:mail", resp.ID)
+		assert.Equal(t, resource.PropertyMap{
+			"name": str("mail"),
+			"arn":  str("arn:queue:mail"),
+		}, resp.Properties)
+		assert.Equal(t, resource.PropertyMap{"name": str("mail")}, resp.Inputs)
+	})
+}
+
+// Fragile panics when it is created with a negative size.
+type Fragile struct{}
+
+type FragileArgs struct {
+	Size int `pulumi:"size"`
+}
+
+func (*Fragile) Create(ctx context.Context, name string, args FragileArgs, preview bool) (string, FragileArgs, error) {
+	if args.Size < 0 {
+		var sizes []int
+		_ = sizes[args.Size]
+	}
+	return name, args, nil
+}
+
+func TestCreatePanics(t *testing.T) {
+	t.Parallel()
+
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[*Fragile, FragileArgs, FragileArgs]()},
+	}))
+	urn := resource.NewURN("stack", "proj", "", "test:tests:Fragile", "fragile")
+
+	_, err := prov.Create(p.CreateRequest{
+		Urn:        urn,
+		Properties: resource.PropertyMap{"size": resource.NewNumberProperty(-1)},
+	})
+	require.Error(t, err)
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Contains(t, err.Error(), "Create of fragile (test:tests:Fragile) panicked: runtime error: index out of range")
+
+	// The stack of the panic is only logged for verbose output.
+	logs := prov.Logs()
+	require.Len(t, logs, 1)
+	assert.Equal(t, diag.Debug, logs[0].Severity)
+	assert.Contains(t, logs[0].Message, "tests.(*Fragile).Create")
+
+	// The provider keeps serving requests.
+	resp, err := prov.Create(p.CreateRequest{
+		Urn:        urn,
+		Properties: resource.PropertyMap{"size": resource.NewNumberProperty(1)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "fragile", resp.ID)
+}
@@ -0,0 +1,546 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"sync"
+	"testing"
+
+	"github.com/blang/semver"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
+)
+
+type Repo struct{}
+
+type RepoArgs struct {
+	Name string            `pulumi:"name"`
+	Tags map[string]string `pulumi:"tags,optional"`
+}
+
+type RepoState struct{ RepoArgs }
+
+func (*Repo) Create(ctx context.Context, name string, inputs RepoArgs, preview bool) (string, RepoState, error) {
+	return inputs.Name, RepoState{inputs}, nil
+}
+
+func (*Repo) Diff(ctx context.Context, id string, olds RepoState, news RepoArgs) (p.DiffResponse, error) {
+	diff := map[string]p.PropertyDiff{}
+	if olds.Name != news.Name {
+		diff["name"] = p.PropertyDiff{Kind: p.UpdateReplace}
+	}
+	if !maps.Equal(olds.Tags, news.Tags) {
+		diff["tags"] = p.PropertyDiff{Kind: p.Update}
+	}
+	return p.DiffResponse{
+		DeleteBeforeReplace: diff["name"].Kind == p.UpdateReplace,
+		DetailedDiff:        diff,
+	}, nil
+}
+
+func TestCustomDiff(t *testing.T) {
+	t.Parallel()
+
+	s := resource.NewStringProperty
+	repo := func(name string, tags map[string]string) resource.PropertyMap {
+		m := resource.PropertyMap{"name": s(name)}
+		if tags != nil {
+			obj := resource.PropertyMap{}
+			for k, v := range tags {
+				obj[resource.PropertyKey(k)] = s(v)
+			}
+			m["tags"] = resource.NewObjectProperty(obj)
+		}
+		return m
+	}
+
+	diff := func(t *testing.T, olds, news resource.PropertyMap) p.DiffResponse {
+		prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+			Resources: []infer.InferredResource{infer.Resource[*Repo, RepoArgs, RepoState]()},
+		}))
+		resp, err := prov.Diff(p.DiffRequest{
+			Urn:  resource.NewURN("stack", "proj", "", "test:tests:Repo", "repo"),
+			ID:   "repo",
+			Olds: olds,
+			News: news,
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("no-changes", func(t *testing.T) {
+		t.Parallel()
+		resp := diff(t, repo("a", map[string]string{"env": "dev"}), repo("a", map[string]string{"env": "dev"}))
+		assert.Equal(t, p.DiffResponse{DetailedDiff: map[string]p.PropertyDiff{}}, resp)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		t.Parallel()
+		resp := diff(t, repo("a", map[string]string{"env": "dev"}), repo("a", map[string]string{"env": "prod"}))
+		assert.Equal(t, p.DiffResponse{
+			HasChanges: true,
+			DetailedDiff: map[string]p.PropertyDiff{
+				"tags": {Kind: p.Update},
+			},
+		}, resp)
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		t.Parallel()
+		resp := diff(t, repo("a", nil), repo("b", map[string]string{"env": "prod"}))
+		assert.Equal(t, p.DiffResponse{
+			HasChanges:          true,
+			DeleteBeforeReplace: true,
+			DetailedDiff: map[string]p.PropertyDiff{
+				"name": {Kind: p.UpdateReplace},
+				"tags": {Kind: p.Update},
+			},
+		}, resp)
+	})
+}
+
+// Endpoint binds a port, which only one endpoint may bind at a time.
+type Endpoint struct{}
+
+type EndpointArgs struct {
+	Port int    `pulumi:"port"`
+	Path string `pulumi:"path"`
+}
+
+func (*Endpoint) Annotate(a infer.Annotator) { a.SetDeleteBeforeReplace(true) }
+
+// endpoints records which path each port is bound to, and the order of operations on
+// endpoints.
+var endpoints = struct {
+	sync.Mutex
+	bound  map[int]string
+	events []string
+}{bound: map[int]string{}}
+
+func (*Endpoint) Create(
+	ctx context.Context, name string, args EndpointArgs, preview bool,
+) (string, EndpointArgs, error) {
+	if preview {
+		return "", args, nil
+	}
+	endpoints.Lock()
+	defer endpoints.Unlock()
+	if path, ok := endpoints.bound[args.Port]; ok {
+		return "", args, fmt.Errorf("port %d is already bound to %s", args.Port, path)
+	}
+	endpoints.bound[args.Port] = args.Path
+	endpoints.events = append(endpoints.events, "create "+args.Path)
+	return args.Path, args, nil
+}
+
+func (*Endpoint) Delete(ctx context.Context, id string, props EndpointArgs) error {
+	endpoints.Lock()
+	defer endpoints.Unlock()
+	delete(endpoints.bound, props.Port)
+	endpoints.events = append(endpoints.events, "delete "+props.Path)
+	return nil
+}
+
+func TestDeleteBeforeReplace(t *testing.T) {
+	t.Parallel()
+
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[*Endpoint, EndpointArgs, EndpointArgs]()},
+	}))
+
+	endpoint := func(port int, path string) resource.PropertyMap {
+		return resource.PropertyMap{
+			"port": resource.NewNumberProperty(float64(port)),
+			"path": resource.NewStringProperty(path),
+		}
+	}
+
+	resp, err := prov.Diff(p.DiffRequest{
+		Urn:  resource.NewURN("stack", "proj", "", "test:tests:Endpoint", "e"),
+		ID:   "/a",
+		Olds: endpoint(80, "/a"),
+		News: endpoint(80, "/b"),
+	})
+	require.NoError(t, err)
+	assert.True(t, resp.DeleteBeforeReplace)
+
+	integration.LifeCycleTest{
+		Resource: "test:tests:Endpoint",
+		Create: integration.Operation{
+			Inputs:         endpoint(80, "/a"),
+			ExpectedOutput: endpoint(80, "/a"),
+		},
+		Updates: []integration.Operation{{
+			Inputs:         endpoint(80, "/b"),
+			ExpectedOutput: endpoint(80, "/b"),
+			ExpectedDiff:   map[string]p.PropertyDiff{"path": {Kind: p.UpdateReplace}},
+		}},
+	}.Run(t, prov)
+
+	// The old endpoint must release the port before the new endpoint can bind it.
+	assert.Equal(t, []string{"create /a", "delete /a", "create /b", "delete /b"}, endpoints.events)
+}
+
+type Workload struct{}
+
+type WorkloadArgs struct {
+	Name string       `pulumi:"name"`
+	Spec WorkloadSpec `pulumi:"spec"`
+}
+
+type WorkloadSpec struct {
+	Image    string `pulumi:"image"`
+	Replicas int    `pulumi:"replicas"`
+}
+
+func (*Workload) Create(
+	ctx context.Context, name string, args WorkloadArgs, preview bool,
+) (string, WorkloadArgs, error) {
+	return args.Name, args, nil
+}
+
+func TestDiffIgnoreChanges(t *testing.T) {
+	t.Parallel()
+
+	workload := func(name, image string, replicas int) resource.PropertyMap {
+		return resource.PropertyMap{
+			"name": resource.NewStringProperty(name),
+			"spec": resource.NewObjectProperty(resource.PropertyMap{
+				"image":    resource.NewStringProperty(image),
+				"replicas": resource.NewNumberProperty(float64(replicas)),
+			}),
+		}
+	}
+
+	diff := func(t *testing.T, olds, news resource.PropertyMap, ignoreChanges ...resource.PropertyKey) p.DiffResponse {
+		prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+			Resources: []infer.InferredResource{infer.Resource[*Workload, WorkloadArgs, WorkloadArgs]()},
+		}))
+		resp, err := prov.Diff(p.DiffRequest{
+			Urn:           resource.NewURN("stack", "proj", "", "test:tests:Workload", "w"),
+			ID:            "w",
+			Olds:          olds,
+			News:          news,
+			IgnoreChanges: ignoreChanges,
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("nested", func(t *testing.T) {
+		t.Parallel()
+		news := workload("w", "nginx", 5)
+		resp := diff(t, workload("w", "nginx", 3), news, "spec.replicas")
+		assert.False(t, resp.HasChanges)
+		assert.Empty(t, resp.DetailedDiff)
+		// The request is not modified.
+		assert.Equal(t, workload("w", "nginx", 5), news)
+	})
+
+	t.Run("top-level", func(t *testing.T) {
+		t.Parallel()
+		resp := diff(t, workload("w", "nginx", 3), workload("v", "nginx", 3), "name")
+		assert.False(t, resp.HasChanges)
+		assert.Empty(t, resp.DetailedDiff)
+	})
+
+	t.Run("other-changes", func(t *testing.T) {
+		t.Parallel()
+		resp := diff(t, workload("w", "nginx", 3), workload("w", "httpd", 5), "spec.replicas")
+		assert.True(t, resp.HasChanges)
+		assert.Equal(t, map[string]p.PropertyDiff{
+			"spec.image": {Kind: p.UpdateReplace},
+		}, resp.DetailedDiff)
+	})
+}
+
+type Server struct{}
+
+type ServerArgs struct {
+	Zone    string        `pulumi:"zone" provider:"replaceOnChanges"`
+	Image   string        `pulumi:"image"`
+	Size    int           `pulumi:"size"`
+	Network ServerNetwork `pulumi:"network"`
+}
+
+type ServerNetwork struct {
+	Subnet string `pulumi:"subnet"`
+	Ports  []int  `pulumi:"ports,optional"`
+}
+
+func (s *ServerArgs) Annotate(a infer.Annotator) {
+	a.SetReplaceOnChanges(&s.Image)
+	a.SetReplaceOnChanges(&s.Network)
+}
+
+func (*Server) Create(ctx context.Context, name string, args ServerArgs, preview bool) (string, ServerArgs, error) {
+	return name, args, nil
+}
+
+func (*Server) Update(
+	ctx context.Context, id string, olds ServerArgs, news ServerArgs, preview bool,
+) (ServerArgs, error) {
+	return news, nil
+}
+
+func TestReplaceOnChanges(t *testing.T) {
+	t.Parallel()
+
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[*Server, ServerArgs, ServerArgs]()},
+	}))
+
+	t.Run("schema", func(t *testing.T) {
+		t.Parallel()
+		resp, err := prov.GetSchema(p.GetSchemaRequest{Version: 1})
+		require.NoError(t, err)
+		var spec pschema.PackageSpec
+		require.NoError(t, json.Unmarshal([]byte(resp.Schema), &spec))
+		replace := map[string]bool{}
+		for name, prop := range spec.Resources["test:tests:Server"].InputProperties {
+			replace[name] = prop.ReplaceOnChanges
+		}
+		assert.Equal(t, map[string]bool{
+			"zone":    true,
+			"image":   true,
+			"size":    false,
+			"network": true,
+		}, replace)
+	})
+
+	server := func(zone, image string, size int, subnet string, ports ...float64) resource.PropertyMap {
+		portValues := make([]resource.PropertyValue, len(ports))
+		for i, port := range ports {
+			portValues[i] = resource.NewNumberProperty(port)
+		}
+		return resource.PropertyMap{
+			"zone":  resource.NewStringProperty(zone),
+			"image": resource.NewStringProperty(image),
+			"size":  resource.NewNumberProperty(float64(size)),
+			"network": resource.NewObjectProperty(resource.PropertyMap{
+				"subnet": resource.NewStringProperty(subnet),
+				"ports":  resource.NewArrayProperty(portValues),
+			}),
+		}
+	}
+	olds := server("a", "nginx", 1, "10.0.0.0/24", 80)
+
+	tests := []struct {
+		name     string
+		news     resource.PropertyMap
+		expected map[string]p.PropertyDiff
+	}{
+		{
+			name:     "untagged",
+			news:     server("a", "nginx", 2, "10.0.0.0/24", 80),
+			expected: map[string]p.PropertyDiff{"size": {Kind: p.Update}},
+		},
+		{
+			name:     "tagged",
+			news:     server("b", "nginx", 1, "10.0.0.0/24", 80),
+			expected: map[string]p.PropertyDiff{"zone": {Kind: p.UpdateReplace}},
+		},
+		{
+			name:     "annotated",
+			news:     server("a", "httpd", 1, "10.0.0.0/24", 80),
+			expected: map[string]p.PropertyDiff{"image": {Kind: p.UpdateReplace}},
+		},
+		{
+			name: "annotated-nested",
+			news: server("a", "nginx", 1, "10.0.1.0/24", 80, 443),
+			expected: map[string]p.PropertyDiff{
+				"network.subnet":   {Kind: p.UpdateReplace},
+				"network.ports[1]": {Kind: p.AddReplace},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			resp, err := prov.Diff(p.DiffRequest{
+				Urn:  resource.NewURN("stack", "proj", "", "test:tests:Server", "s"),
+				ID:   "s",
+				Olds: olds,
+				News: tt.news,
+			})
+			require.NoError(t, err)
+			assert.True(t, resp.HasChanges)
+			assert.Equal(t, tt.expected, resp.DetailedDiff)
+		})
+	}
+}
+
+func TestExplainDiffs(t *testing.T) {
+	t.Parallel()
+
+	newServer := func(explain bool) integration.Server {
+		return integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+			Resources:    []infer.InferredResource{infer.Resource[*Server, ServerArgs, ServerArgs]()},
+			ExplainDiffs: explain,
+		}))
+	}
+	server := func(zone string, size int) resource.PropertyMap {
+		return resource.PropertyMap{
+			"zone":  resource.NewStringProperty(zone),
+			"image": resource.NewStringProperty("nginx"),
+			"size":  resource.NewNumberProperty(float64(size)),
+			"network": resource.NewObjectProperty(resource.PropertyMap{
+				"subnet": resource.NewStringProperty("10.0.0.0/24"),
+			}),
+		}
+	}
+	urn := resource.NewURN("stack", "proj", "", "test:tests:Server", "s")
+	diff := func(t *testing.T, prov integration.Server, news resource.PropertyMap) {
+		_, err := prov.Diff(p.DiffRequest{Urn: urn, ID: "s", Olds: server("a", 1), News: news})
+		require.NoError(t, err)
+	}
+
+	t.Run("replace", func(t *testing.T) {
+		t.Parallel()
+		prov := newServer(true)
+		diff(t, prov, server("b", 2))
+		assert.Equal(t, []integration.LogMessage{{
+			Severity: diag.Info,
+			URN:      urn,
+			Message: "the resource will be replaced because these properties changed:\n" +
+				"  size (update)\n" +
+				"  zone (update, forces replacement)",
+		}}, prov.Logs())
+	})
+
+	t.Run("update", func(t *testing.T) {
+		t.Parallel()
+		prov := newServer(true)
+		diff(t, prov, server("a", 2))
+		assert.Equal(t, []integration.LogMessage{{
+			Severity: diag.Info,
+			URN:      urn,
+			Message:  "the resource will be updated because these properties changed:\n  size (update)",
+		}}, prov.Logs())
+	})
+
+	t.Run("no-changes", func(t *testing.T) {
+		t.Parallel()
+		prov := newServer(true)
+		diff(t, prov, server("a", 1))
+		assert.Empty(t, prov.Logs())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+		prov := newServer(false)
+		diff(t, prov, server("b", 2))
+		assert.Empty(t, prov.Logs())
+	})
+}
+
+type Meter struct{}
+
+type MeterArgs struct {
+	Limit *int        `pulumi:"limit,optional"`
+	Token *string     `pulumi:"token,optional" provider:"secret"`
+	Scale *MeterScale `pulumi:"scale,optional"`
+}
+
+type MeterScale struct {
+	Factor *float64 `pulumi:"factor,optional"`
+}
+
+func (*Meter) Create(ctx context.Context, name string, args MeterArgs, preview bool) (string, MeterArgs, error) {
+	return name, args, nil
+}
+
+func (*Meter) Read(
+	ctx context.Context, id string, inputs MeterArgs, state MeterArgs,
+) (string, MeterArgs, MeterArgs, error) {
+	return id, inputs, state, nil
+}
+
+func (*Meter) Update(ctx context.Context, id string, olds, news MeterArgs, preview bool) (MeterArgs, error) {
+	return news, nil
+}
+
+func TestDiffUnsetOptionals(t *testing.T) {
+	t.Parallel()
+
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[*Meter, MeterArgs, MeterArgs]()},
+	}))
+	urn := resource.NewURN("stack", "proj", "", "test:tests:Meter", "meter")
+	null := resource.NewNullProperty()
+	scale := resource.NewObjectProperty(resource.PropertyMap{})
+
+	created, err := prov.Create(p.CreateRequest{
+		Urn:        urn,
+		Properties: resource.PropertyMap{"scale": scale},
+	})
+	require.NoError(t, err)
+	// Refreshing the resource keeps its unset fields unset.
+	refreshed, err := prov.Read(p.ReadRequest{
+		Urn:        urn,
+		ID:         created.ID,
+		Properties: created.Properties,
+		Inputs:     resource.PropertyMap{"scale": scale},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, created.Properties, refreshed.Properties)
+
+	diff := func(olds, news resource.PropertyMap) p.DiffResponse {
+		resp, err := prov.Diff(p.DiffRequest{Urn: urn, ID: created.ID, Olds: olds, News: news})
+		require.NoError(t, err)
+		return resp
+	}
+
+	for _, news := range []resource.PropertyMap{
+		{"scale": scale},
+		{"scale": scale, "limit": null},
+		{"scale": scale, "token": resource.MakeSecret(null)},
+		{"scale": resource.NewObjectProperty(resource.PropertyMap{"factor": null})},
+	} {
+		resp := diff(refreshed.Properties, news)
+		assert.False(t, resp.HasChanges, "diff against %v", news)
+		assert.Empty(t, resp.DetailedDiff, "diff against %v", news)
+	}
+
+	// Unset olds that were recorded as null don't diff either.
+	resp := diff(resource.PropertyMap{
+		"scale": scale,
+		"limit": null,
+		"token": resource.MakeSecret(null),
+	}, resource.PropertyMap{"scale": scale})
+	assert.False(t, resp.HasChanges)
+
+	// Setting a field to its zero value is a change.
+	resp = diff(refreshed.Properties, resource.PropertyMap{
+		"scale": scale,
+		"limit": resource.NewNumberProperty(0),
+	})
+	assert.True(t, resp.HasChanges)
+	assert.Equal(t, map[string]p.PropertyDiff{
+		"limit": {Kind: p.Add},
+	}, resp.DetailedDiff)
+}
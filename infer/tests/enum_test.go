@@ -0,0 +1,305 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/blang/semver"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pgp "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
+)
+
+type Priority int32
+
+const (
+	Low  Priority = 1
+	High Priority = 10
+)
+
+func (Priority) Values() []infer.EnumValue[Priority] {
+	return []infer.EnumValue[Priority]{
+		{Name: "Low", Value: Low, Description: "Handle eventually."},
+		{Name: "High", Value: High, Description: "Handle immediately."},
+	}
+}
+
+type Ticket struct{}
+
+type TicketArgs struct {
+	Priority   Priority   `pulumi:"priority"`
+	Escalation []Priority `pulumi:"escalation,optional"`
+}
+
+type TicketState struct{ TicketArgs }
+
+func (*Ticket) Create(ctx context.Context, name string, inputs TicketArgs, preview bool) (string, TicketState, error) {
+	return "id", TicketState{inputs}, nil
+}
+
+func enumProvider() integration.Server {
+	p := infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[*Ticket, TicketArgs, TicketState]()},
+	})
+	return integration.NewServer("test", semver.MustParse("1.0.0"), p)
+}
+
+func TestIntegerEnumSchema(t *testing.T) {
+	t.Parallel()
+
+	resp, err := enumProvider().GetSchema(pgp.GetSchemaRequest{Version: 1})
+	require.NoError(t, err)
+
+	var spec pschema.PackageSpec
+	require.NoError(t, json.Unmarshal([]byte(resp.Schema), &spec))
+
+	require.Contains(t, spec.Types, "test:tests:Priority")
+	priority := spec.Types["test:tests:Priority"]
+	assert.Equal(t, "integer", priority.Type)
+	assert.Equal(t, []pschema.EnumValueSpec{
+		{Value: 1.0, Description: "Handle eventually."},
+		{Value: 10.0, Description: "Handle immediately."},
+	}, priority.Enum)
+}
+
+func TestIntegerEnumCheck(t *testing.T) {
+	t.Parallel()
+
+	check := func(news resource.PropertyMap) pgp.CheckResponse {
+		resp, err := enumProvider().Check(pgp.CheckRequest{
+			Urn:  resource.NewURN("stack", "proj", "", "test:tests:Ticket", "ticket"),
+			News: news,
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		t.Parallel()
+		news := resource.PropertyMap{
+			"priority": resource.NewNumberProperty(10),
+			"escalation": resource.NewArrayProperty([]resource.PropertyValue{
+				resource.NewNumberProperty(1),
+			}),
+		}
+		resp := check(news)
+		assert.Empty(t, resp.Failures)
+		assert.Equal(t, news, resp.Inputs)
+	})
+
+	t.Run("out-of-range", func(t *testing.T) {
+		t.Parallel()
+		resp := check(resource.PropertyMap{
+			"priority": resource.NewNumberProperty(5),
+			"escalation": resource.NewArrayProperty([]resource.PropertyValue{
+				resource.NewNumberProperty(1),
+				resource.MakeSecret(resource.NewNumberProperty(3)),
+			}),
+		})
+		assert.Equal(t, []pgp.CheckFailure{
+			{Property: "priority", Reason: "5 is not a valid value for Priority, expected one of 1, 10"},
+			{Property: "escalation[1]", Reason: "[secret] is not a valid value for Priority, expected one of 1, 10"},
+		}, resp.Failures)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		t.Parallel()
+		resp := check(resource.PropertyMap{
+			"priority": resource.MakeComputed(resource.NewNumberProperty(0)),
+		})
+		assert.Empty(t, resp.Failures)
+	})
+}
+
+type Tier string
+
+const (
+	Standard Tier = "Standard"
+	Premium  Tier = "Premium"
+)
+
+func (Tier) Values() []infer.EnumValue[Tier] {
+	return []infer.EnumValue[Tier]{
+		{Name: "Standard", Value: Standard},
+		{Name: "Premium", Value: Premium},
+	}
+}
+
+func (*Tier) Annotate(a infer.Annotator) {
+	a.SetEnumCaseInsensitive(true)
+}
+
+type Channel string
+
+func (Channel) Values() []infer.EnumValue[Channel] {
+	return []infer.EnumValue[Channel]{
+		{Name: "Stable", Value: "Stable"},
+		{Name: "Beta", Value: "Beta"},
+	}
+}
+
+type Plan struct{}
+
+type PlanArgs struct {
+	Tier      Tier     `pulumi:"tier"`
+	Fallbacks []Tier   `pulumi:"fallbacks,optional"`
+	Channel   *Channel `pulumi:"channel,optional"`
+}
+
+func (*Plan) Create(ctx context.Context, name string, inputs PlanArgs, preview bool) (string, PlanArgs, error) {
+	return "id", inputs, nil
+}
+
+func TestCaseInsensitiveEnum(t *testing.T) {
+	t.Parallel()
+
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[*Plan, PlanArgs, PlanArgs]()},
+	}))
+	check := func(news resource.PropertyMap) pgp.CheckResponse {
+		resp, err := prov.Check(pgp.CheckRequest{
+			Urn:  resource.NewURN("stack", "proj", "", "test:tests:Plan", "plan"),
+			News: news,
+		})
+		require.NoError(t, err)
+		return resp
+	}
+	str := resource.NewStringProperty
+
+	t.Run("schema", func(t *testing.T) {
+		t.Parallel()
+		resp, err := prov.GetSchema(pgp.GetSchemaRequest{Version: 1})
+		require.NoError(t, err)
+		var spec pschema.PackageSpec
+		require.NoError(t, json.Unmarshal([]byte(resp.Schema), &spec))
+		assert.Equal(t, []pschema.EnumValueSpec{
+			{Value: "Standard"},
+			{Value: "Premium"},
+		}, spec.Types["test:tests:Tier"].Enum)
+	})
+
+	t.Run("normalized", func(t *testing.T) {
+		t.Parallel()
+		resp := check(resource.PropertyMap{
+			"tier": str("standard"),
+			"fallbacks": resource.NewArrayProperty([]resource.PropertyValue{
+				str("PREMIUM"),
+				resource.MakeSecret(str("sTaNdArD")),
+			}),
+		})
+		assert.Empty(t, resp.Failures)
+		assert.Equal(t, resource.PropertyMap{
+			"tier": str("Standard"),
+			"fallbacks": resource.NewArrayProperty([]resource.PropertyValue{
+				str("Premium"),
+				resource.MakeSecret(str("Standard")),
+			}),
+		}, resp.Inputs)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		resp := check(resource.PropertyMap{
+			"tier": str("basic"),
+			// Channel is not case insensitive.
+			"channel": str("stable"),
+		})
+		assert.ElementsMatch(t, []pgp.CheckFailure{
+			{Property: "tier", Reason: `"basic" is not a valid value for Tier, expected one of "Standard", "Premium"`},
+			{Property: "channel", Reason: `"stable" is not a valid value for Channel, expected one of "Stable", "Beta"`},
+		}, resp.Failures)
+	})
+}
+
+type Region string
+
+func (Region) Values() []infer.EnumValue[Region] {
+	return []infer.EnumValue[Region]{
+		{Value: "us-east"},
+		{Value: "eu-west"},
+	}
+}
+
+// Quota limits the number of instances in each region.
+type Quota struct{}
+
+type QuotaArgs struct {
+	Limits map[Region]int `pulumi:"limits"`
+	// Tier is case insensitive, so its keys are normalized.
+	Seats map[Tier]int `pulumi:"seats,optional"`
+}
+
+func (*Quota) Create(ctx context.Context, name string, inputs QuotaArgs, preview bool) (string, QuotaArgs, error) {
+	return name, inputs, nil
+}
+
+func TestEnumMapKeys(t *testing.T) {
+	t.Parallel()
+
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[*Quota, QuotaArgs, QuotaArgs]()},
+	}))
+	check := func(news resource.PropertyMap) pgp.CheckResponse {
+		resp, err := prov.Check(pgp.CheckRequest{
+			Urn:  resource.NewURN("stack", "proj", "", "test:tests:Quota", "quota"),
+			News: news,
+		})
+		require.NoError(t, err)
+		return resp
+	}
+	num := resource.NewNumberProperty
+	obj := resource.NewObjectProperty
+
+	t.Run("schema", func(t *testing.T) {
+		t.Parallel()
+		resp, err := prov.GetSchema(pgp.GetSchemaRequest{Version: 1})
+		require.NoError(t, err)
+		var spec pschema.PackageSpec
+		require.NoError(t, json.Unmarshal([]byte(resp.Schema), &spec))
+		limits := spec.Resources["test:tests:Quota"].InputProperties["limits"]
+		assert.Equal(t, "object", limits.Type)
+		assert.Equal(t, "integer", limits.AdditionalProperties.Type)
+		assert.Equal(t, "Keys: `us-east`, `eu-west`.", limits.Description)
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		t.Parallel()
+		resp := check(resource.PropertyMap{
+			"limits": obj(resource.PropertyMap{"us-east": num(2), "eu-west": num(1)}),
+			"seats":  obj(resource.PropertyMap{"premium": num(3)}),
+		})
+		assert.Empty(t, resp.Failures)
+		assert.Equal(t, obj(resource.PropertyMap{"Premium": num(3)}), resp.Inputs["seats"])
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		resp := check(resource.PropertyMap{
+			"limits": obj(resource.PropertyMap{"us-east": num(2), "ap-south": num(1)}),
+		})
+		assert.Equal(t, []pgp.CheckFailure{{
+			Property: `limits["ap-south"]`,
+			Reason:   `"ap-south" is not a valid key for Region, expected one of "us-east", "eu-west"`,
+		}}, resp.Failures)
+	})
+}
@@ -0,0 +1,108 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	rpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/status"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
+)
+
+// Allotment fails to be created with the details of an upstream API error.
+type Allotment struct{}
+
+type AllotmentArgs struct {
+	// Partial makes Create fail after the allotment was allocated.
+	Partial bool `pulumi:"partial,optional"`
+}
+
+var errQuotaExceeded = infer.ResourceError{
+	Message:   "quota exceeded",
+	Code:      "QuotaExceeded",
+	RequestID: "4f1c8a2",
+	HelpURL:   "https://example.com/docs/errors#QuotaExceeded",
+}
+
+func (*Allotment) Create(
+	ctx context.Context, name string, args AllotmentArgs, preview bool,
+) (string, AllotmentArgs, error) {
+	if args.Partial {
+		return "allotment", args, infer.PartialError{Err: fmt.Errorf("setting limits: %w", errQuotaExceeded)}
+	}
+	return "", args, fmt.Errorf("allocating quota: %w", errQuotaExceeded)
+}
+
+func allotmentProvider() p.Provider {
+	return infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[*Allotment, AllotmentArgs, AllotmentArgs]()},
+	})
+}
+
+func TestResourceError(t *testing.T) {
+	t.Parallel()
+
+	const expected = "quota exceeded (code: QuotaExceeded, request ID: 4f1c8a2); " +
+		"see https://example.com/docs/errors#QuotaExceeded"
+	urn := resource.NewURN("stack", "proj", "", "test:tests:Allotment", "allotment")
+
+	t.Run("format", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, expected, errQuotaExceeded.Error())
+		assert.Equal(t, "quota exceeded; see https://example.com",
+			infer.ResourceError{Message: "quota exceeded", HelpURL: "https://example.com"}.Error())
+		assert.Equal(t, "quota exceeded", infer.ResourceError{Message: "quota exceeded"}.Error())
+	})
+
+	t.Run("create", func(t *testing.T) {
+		t.Parallel()
+		prov := integration.NewServer("test", semver.MustParse("1.0.0"), allotmentProvider())
+		_, err := prov.Create(p.CreateRequest{Urn: urn})
+		require.Error(t, err)
+		assert.Equal(t, "allocating quota: "+expected, err.Error())
+		var resourceErr infer.ResourceError
+		require.ErrorAs(t, err, &resourceErr)
+		assert.Equal(t, errQuotaExceeded, resourceErr)
+
+		resp, err := prov.Create(p.CreateRequest{
+			Urn:        urn,
+			Properties: resource.PropertyMap{"partial": resource.NewBoolProperty(true)},
+		})
+		require.Error(t, err)
+		require.NotNil(t, resp.PartialState)
+		assert.Equal(t, []string{"setting limits: " + expected}, resp.PartialState.Reasons)
+	})
+
+	// The engine shows the message of the gRPC error as the diagnostic of the resource.
+	t.Run("grpc", func(t *testing.T) {
+		t.Parallel()
+		s, err := p.RawServer("test", "1.0.0", allotmentProvider())(nil)
+		require.NoError(t, err)
+		_, err = s.Create(context.Background(), &rpc.CreateRequest{Urn: string(urn)})
+		require.Error(t, err)
+		// gRPC converts the error into a status as status.Convert does.
+		assert.Equal(t, "allocating quota: "+expected, status.Convert(err).Message())
+	})
+}
@@ -0,0 +1,224 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
+)
+
+// liveWidgets stands in for the cloud API that owns widgets.
+var liveWidgets = map[string]WidgetArgs{
+	"abc-123": {Color: "red", Size: 3},
+	// The color of this widget was changed outside of Pulumi.
+	"ghi-789": {Color: "blue", Size: 3},
+}
+
+type Widget struct{}
+
+type WidgetArgs struct {
+	Color string `pulumi:"color"`
+	Size  int    `pulumi:"size"`
+}
+
+type WidgetState struct {
+	WidgetArgs
+	Serial string `pulumi:"serial"`
+}
+
+func (*Widget) Create(ctx context.Context, name string, inputs WidgetArgs, preview bool) (string, WidgetState, error) {
+	return name, WidgetState{WidgetArgs: inputs, Serial: "W-" + name}, nil
+}
+
+func (*Widget) Read(
+	ctx context.Context, id string, inputs WidgetArgs, state WidgetState,
+) (string, WidgetArgs, WidgetState, error) {
+	live, ok := liveWidgets[id]
+	if !ok {
+		return "", WidgetArgs{}, WidgetState{}, fmt.Errorf("widget %q not found", id)
+	}
+	return id, live, WidgetState{WidgetArgs: live, Serial: "W-" + id}, nil
+}
+
+func TestImportFromID(t *testing.T) {
+	t.Parallel()
+
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{
+			infer.Resource[*Widget, WidgetArgs, WidgetState](),
+			infer.Resource[*Ticket, TicketArgs, TicketState](),
+		},
+	}))
+
+	t.Run("widget", func(t *testing.T) {
+		t.Parallel()
+		resp, err := prov.Read(p.ReadRequest{
+			ID:  "abc-123",
+			Urn: resource.NewURN("stack", "proj", "", "test:tests:Widget", "myWidget"),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, p.ReadResponse{
+			ID: "abc-123",
+			Inputs: resource.PropertyMap{
+				"color": resource.NewStringProperty("red"),
+				"size":  resource.NewNumberProperty(3),
+			},
+			Properties: resource.PropertyMap{
+				"color":  resource.NewStringProperty("red"),
+				"size":   resource.NewNumberProperty(3),
+				"serial": resource.NewStringProperty("W-abc-123"),
+			},
+		}, resp)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		t.Parallel()
+		_, err := prov.Read(p.ReadRequest{
+			ID:  "def-456",
+			Urn: resource.NewURN("stack", "proj", "", "test:tests:Widget", "myWidget"),
+		})
+		assert.ErrorContains(t, err, `widget "def-456" not found`)
+	})
+
+	t.Run("no-custom-read", func(t *testing.T) {
+		t.Parallel()
+		_, err := prov.Read(p.ReadRequest{
+			ID:  "ticket-1",
+			Urn: resource.NewURN("stack", "proj", "", "test:tests:Ticket", "myTicket"),
+		})
+		assert.ErrorContains(t, err, "Import is not implemented for resource")
+	})
+}
+
+func TestRefreshDrift(t *testing.T) {
+	t.Parallel()
+
+	urn := resource.NewURN("stack", "proj", "", "test:tests:Widget", "myWidget")
+	widget := func(color string, size int) resource.PropertyMap {
+		return resource.PropertyMap{
+			"color": resource.NewStringProperty(color),
+			"size":  resource.NewNumberProperty(float64(size)),
+		}
+	}
+	refresh := func(t *testing.T, reportDrift bool, id string) (p.ReadResponse, []integration.LogMessage) {
+		prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+			Resources:   []infer.InferredResource{infer.Resource[*Widget, WidgetArgs, WidgetState]()},
+			ReportDrift: reportDrift,
+		}))
+		state := widget("red", 3)
+		state["serial"] = resource.NewStringProperty("W-" + id)
+		resp, err := prov.Read(p.ReadRequest{ID: id, Urn: urn, Inputs: widget("red", 3), Properties: state})
+		require.NoError(t, err)
+		return resp, prov.Logs()
+	}
+
+	t.Run("drifted", func(t *testing.T) {
+		t.Parallel()
+		resp, logs := refresh(t, true, "ghi-789")
+		assert.Equal(t, resource.NewStringProperty("blue"), resp.Properties["color"])
+		assert.Equal(t, resource.NewStringProperty("blue"), resp.Inputs["color"])
+		assert.Equal(t, []integration.LogMessage{{
+			Severity: diag.Info,
+			URN:      urn,
+			Message:  "the resource drifted from its recorded state in these properties: color",
+		}}, logs)
+	})
+
+	t.Run("in-sync", func(t *testing.T) {
+		t.Parallel()
+		resp, logs := refresh(t, true, "abc-123")
+		assert.Equal(t, resource.NewStringProperty("red"), resp.Properties["color"])
+		assert.Empty(t, logs)
+	})
+
+	t.Run("not-reported", func(t *testing.T) {
+		t.Parallel()
+		resp, logs := refresh(t, false, "ghi-789")
+		assert.Equal(t, resource.NewStringProperty("blue"), resp.Properties["color"])
+		assert.Empty(t, logs)
+	})
+}
+
+// Instance is imported with IDs of the form "{project}/{zone}/{instance}".
+type Instance struct{}
+
+func (*Instance) Annotate(a infer.Annotator) { a.SetImportIDFormat("{project}/{zone}/{instance}") }
+
+type InstanceArgs struct {
+	Project  string `pulumi:"project"`
+	Zone     string `pulumi:"zone"`
+	Instance string `pulumi:"instance"`
+}
+
+type InstanceState struct {
+	InstanceArgs
+	SelfLink string `pulumi:"selfLink"`
+}
+
+func (*Instance) Create(
+	ctx context.Context, name string, inputs InstanceArgs, preview bool,
+) (string, InstanceState, error) {
+	return name, InstanceState{InstanceArgs: inputs}, nil
+}
+
+func (*Instance) Read(
+	ctx context.Context, id string, inputs InstanceArgs, state InstanceState,
+) (string, InstanceArgs, InstanceState, error) {
+	state.SelfLink = fmt.Sprintf("projects/%s/zones/%s/instances/%s", state.Project, state.Zone, state.Instance)
+	return id, inputs, state, nil
+}
+
+func TestImportCompositeID(t *testing.T) {
+	t.Parallel()
+
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[*Instance, InstanceArgs, InstanceState]()},
+	}))
+	urn := resource.NewURN("stack", "proj", "", "test:tests:Instance", "vm")
+	str := resource.NewStringProperty
+
+	t.Run("valid", func(t *testing.T) {
+		t.Parallel()
+		resp, err := prov.Read(p.ReadRequest{Urn: urn, ID: "my-project/us-east1-b/vm-1"})
+		require.NoError(t, err)
+		assert.Equal(t, "my-project/us-east1-b/vm-1", resp.ID)
+		assert.Equal(t, resource.PropertyMap{
+			"project":  str("my-project"),
+			"zone":     str("us-east1-b"),
+			"instance": str("vm-1"),
+		}, resp.Inputs)
+		assert.Equal(t, str("projects/my-project/zones/us-east1-b/instances/vm-1"), resp.Properties["selfLink"])
+	})
+
+	for _, id := range []string{"my-project/vm-1", "my-project//vm-1", "/us-east1-b/vm-1", "my-project/us-east1-b/"} {
+		t.Run("malformed "+id, func(t *testing.T) {
+			t.Parallel()
+			_, err := prov.Read(p.ReadRequest{Urn: urn, ID: id})
+			assert.ErrorContains(t, err, fmt.Sprintf(
+				`invalid import ID %q: expected an ID of the form "{project}/{zone}/{instance}"`, id))
+		})
+	}
+}
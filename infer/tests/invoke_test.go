@@ -15,13 +15,17 @@
 package tests
 
 import (
+	"context"
 	"testing"
 
+	"github.com/blang/semver"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
 )
 
 func TestInvoke(t *testing.T) {
@@ -103,3 +107,60 @@ func TestInvoke(t *testing.T) {
 	})
 
 }
+
+type GetGreeting struct{}
+
+type GreetingArgs struct {
+	Name     string  `pulumi:"name"`
+	Greeting *string `pulumi:"greeting,optional"`
+}
+
+func (g *GreetingArgs) Annotate(a infer.Annotator) {
+	a.SetPattern(&g.Name, "^[A-Z][a-z]+$")
+	a.SetDefault(&g.Greeting, "Hello", "TEST_GREETING")
+}
+
+type GreetingResult struct {
+	Message string `pulumi:"message"`
+}
+
+func (*GetGreeting) Call(ctx context.Context, args GreetingArgs) (GreetingResult, error) {
+	return GreetingResult{*args.Greeting + ", " + args.Name}, nil
+}
+
+func TestInvokeChecksArgs(t *testing.T) {
+	t.Setenv("TEST_GREETING", "Howdy")
+
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Functions: []infer.InferredFunction{infer.Function[*GetGreeting, GreetingArgs, GreetingResult]()},
+	}))
+	invoke := func(args resource.PropertyMap) p.InvokeResponse {
+		resp, err := prov.Invoke(p.InvokeRequest{Token: "test:tests:getGreeting", Args: args})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("default-env", func(t *testing.T) {
+		resp := invoke(resource.PropertyMap{"name": resource.NewStringProperty("Alice")})
+		assert.Empty(t, resp.Failures)
+		assert.Equal(t, resource.PropertyMap{
+			"message": resource.NewStringProperty("Howdy, Alice"),
+		}, resp.Return)
+	})
+
+	t.Run("missing-required", func(t *testing.T) {
+		resp := invoke(resource.PropertyMap{})
+		assert.Equal(t, []p.CheckFailure{
+			{Property: "name", Reason: "missing required property 'name'"},
+		}, resp.Failures)
+		assert.Nil(t, resp.Return)
+	})
+
+	t.Run("pattern", func(t *testing.T) {
+		resp := invoke(resource.PropertyMap{"name": resource.NewStringProperty("alice")})
+		assert.Equal(t, []p.CheckFailure{
+			{Property: "name", Reason: `"alice" does not match the pattern "^[A-Z][a-z]+$"`},
+		}, resp.Failures)
+		assert.Nil(t, resp.Return)
+	})
+}
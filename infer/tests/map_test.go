@@ -0,0 +1,155 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/blang/semver"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
+)
+
+// Garden has maps whose values are structs.
+type Garden struct{}
+
+type Season string
+
+func (Season) Values() []infer.EnumValue[Season] {
+	return []infer.EnumValue[Season]{{Value: "spring"}, {Value: "autumn"}}
+}
+
+type GardenBed struct {
+	Crop string `pulumi:"crop"`
+	Size *int   `pulumi:"size,optional"`
+}
+
+func (b *GardenBed) Annotate(a infer.Annotator) {
+	a.Describe(&b, "A bed of a garden.")
+}
+
+type GardenArgs struct {
+	Beds     map[string]GardenBed            `pulumi:"beds"`
+	Spares   map[string]*GardenBed           `pulumi:"spares,optional"`
+	Seasonal map[Season]GardenBed            `pulumi:"seasonal,optional"`
+	Rows     map[string][]GardenBed          `pulumi:"rows,optional"`
+	Plots    map[string]map[string]GardenBed `pulumi:"plots,optional"`
+}
+
+func (*Garden) Create(ctx context.Context, name string, args GardenArgs, preview bool) (string, GardenArgs, error) {
+	return name, args, nil
+}
+
+func gardenProvider() integration.Server {
+	return integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[*Garden, GardenArgs, GardenArgs]()},
+	}))
+}
+
+func TestMapOfStructs(t *testing.T) {
+	t.Parallel()
+
+	t.Run("schema", func(t *testing.T) {
+		t.Parallel()
+		resp, err := gardenProvider().GetSchema(p.GetSchemaRequest{Version: 1})
+		require.NoError(t, err)
+		var spec pschema.PackageSpec
+		require.NoError(t, json.Unmarshal([]byte(resp.Schema), &spec))
+
+		const ref = "#/types/test:tests:GardenBed"
+		inputs := spec.Resources["test:tests:Garden"].InputProperties
+		for _, name := range []string{"beds", "spares", "seasonal"} {
+			prop := inputs[name]
+			assert.Equal(t, "object", prop.Type, name)
+			require.NotNil(t, prop.AdditionalProperties, name)
+			assert.Equal(t, ref, prop.AdditionalProperties.Ref, name)
+		}
+		require.NotNil(t, inputs["rows"].AdditionalProperties)
+		assert.Equal(t, "array", inputs["rows"].AdditionalProperties.Type)
+		assert.Equal(t, ref, inputs["rows"].AdditionalProperties.Items.Ref)
+		require.NotNil(t, inputs["plots"].AdditionalProperties)
+		assert.Equal(t, "object", inputs["plots"].AdditionalProperties.Type)
+		assert.Equal(t, ref, inputs["plots"].AdditionalProperties.AdditionalProperties.Ref)
+
+		bed, ok := spec.Types["test:tests:GardenBed"]
+		require.True(t, ok, "the value type of the maps is emitted")
+		assert.Equal(t, "object", bed.Type)
+		assert.Equal(t, "A bed of a garden.", bed.Description)
+		assert.Equal(t, []string{"crop"}, bed.Required)
+		assert.Equal(t, "string", bed.Properties["crop"].Type)
+		assert.Equal(t, "integer", bed.Properties["size"].Type)
+	})
+
+	t.Run("create", func(t *testing.T) {
+		t.Parallel()
+		bed := func(crop string) resource.PropertyValue {
+			return resource.NewObjectProperty(resource.PropertyMap{"crop": resource.NewStringProperty(crop)})
+		}
+		inputs := resource.PropertyMap{
+			"beds": resource.NewObjectProperty(resource.PropertyMap{"north": bed("kale")}),
+			"spares": resource.NewObjectProperty(resource.PropertyMap{"east": resource.NewObjectProperty(
+				resource.PropertyMap{"crop": resource.NewStringProperty("leek"), "size": resource.NewNumberProperty(3)},
+			)}),
+			"seasonal": resource.NewObjectProperty(resource.PropertyMap{"spring": bed("peas")}),
+			"rows": resource.NewObjectProperty(resource.PropertyMap{
+				"first": resource.NewArrayProperty([]resource.PropertyValue{bed("beans"), bed("corn")}),
+			}),
+			"plots": resource.NewObjectProperty(resource.PropertyMap{
+				"west": resource.NewObjectProperty(resource.PropertyMap{"a": bed("squash")}),
+			}),
+		}
+		prov := gardenProvider()
+		urn := resource.NewURN("stack", "proj", "", "test:tests:Garden", "garden")
+		check, err := prov.Check(p.CheckRequest{Urn: urn, News: inputs})
+		require.NoError(t, err)
+		require.Empty(t, check.Failures)
+		assert.Equal(t, inputs, check.Inputs)
+
+		create, err := prov.Create(p.CreateRequest{Urn: urn, Properties: check.Inputs})
+		require.NoError(t, err)
+		assert.Equal(t, inputs, create.Properties)
+	})
+
+	// Failures within the values of a map are reported once, at their property path.
+	t.Run("check", func(t *testing.T) {
+		t.Parallel()
+		check, err := gardenProvider().Check(p.CheckRequest{
+			Urn: resource.NewURN("stack", "proj", "", "test:tests:Garden", "garden"),
+			News: resource.PropertyMap{
+				"beds": resource.NewObjectProperty(resource.PropertyMap{
+					"north": resource.NewObjectProperty(resource.PropertyMap{}),
+				}),
+				"rows": resource.NewObjectProperty(resource.PropertyMap{
+					"first": resource.NewArrayProperty([]resource.PropertyValue{
+						resource.NewObjectProperty(resource.PropertyMap{}),
+					}),
+				}),
+			},
+		})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []p.CheckFailure{
+			{Property: `beds["north"].crop`, Reason: `missing required property 'beds["north"].crop'`},
+			{Property: `rows["first"][0].crop`, Reason: `missing required property 'rows["first"][0].crop'`},
+		}, check.Failures)
+	})
+}
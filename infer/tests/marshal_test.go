@@ -0,0 +1,161 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/blang/semver"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
+)
+
+// CIDR is an IP network, represented by a string such as "10.0.0.0/8".
+type CIDR struct{ net.IPNet }
+
+var (
+	_ infer.PropertyMarshaler   = CIDR{}
+	_ infer.PropertyUnmarshaler = (*CIDR)(nil)
+)
+
+func (CIDR) PropertyType() pschema.TypeSpec { return pschema.TypeSpec{Type: "string"} }
+
+func (c CIDR) MarshalProperty() (resource.PropertyValue, error) {
+	return resource.NewStringProperty(c.String()), nil
+}
+
+func (c *CIDR) UnmarshalProperty(v resource.PropertyValue) error {
+	if !v.IsString() {
+		return fmt.Errorf("expected a string, found a %s", v.TypeString())
+	}
+	_, n, err := net.ParseCIDR(v.StringValue())
+	if err != nil {
+		return err
+	}
+	c.IPNet = *n
+	return nil
+}
+
+// Subnet carves the first subnet of a given size out of a block.
+type Subnet struct{}
+
+type SubnetArgs struct {
+	Block   CIDR    `pulumi:"block"`
+	Size    int     `pulumi:"size"`
+	Exclude []*CIDR `pulumi:"exclude,optional"`
+}
+
+type SubnetState struct {
+	SubnetArgs
+	Subnet CIDR `pulumi:"subnet"`
+}
+
+func (*Subnet) Create(ctx context.Context, name string, inputs SubnetArgs, preview bool) (string, SubnetState, error) {
+	ones, _ := inputs.Block.Mask.Size()
+	if inputs.Size < ones {
+		return "", SubnetState{}, fmt.Errorf("/%d does not fit in %s", inputs.Size, inputs.Block.String())
+	}
+	subnet := CIDR{net.IPNet{
+		IP:   inputs.Block.IP,
+		Mask: net.CIDRMask(inputs.Size, len(inputs.Block.IP)*8),
+	}}
+	return name, SubnetState{SubnetArgs: inputs, Subnet: subnet}, nil
+}
+
+func TestPropertyMarshaler(t *testing.T) {
+	t.Parallel()
+
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[*Subnet, SubnetArgs, SubnetState]()},
+	}))
+	str := resource.NewStringProperty
+	urn := resource.NewURN("stack", "proj", "", "test:tests:Subnet", "subnet")
+
+	t.Run("schema", func(t *testing.T) {
+		t.Parallel()
+		resp, err := prov.GetSchema(p.GetSchemaRequest{Version: 1})
+		require.NoError(t, err)
+		var spec pschema.PackageSpec
+		require.NoError(t, json.Unmarshal([]byte(resp.Schema), &spec))
+
+		subnet := spec.Resources["test:tests:Subnet"]
+		assert.Equal(t, pschema.TypeSpec{Type: "string"}, subnet.InputProperties["block"].TypeSpec)
+		assert.Equal(t, pschema.TypeSpec{Type: "string"}, subnet.Properties["subnet"].TypeSpec)
+		assert.Equal(t, pschema.TypeSpec{
+			Type:  "array",
+			Items: &pschema.TypeSpec{Type: "string"},
+		}, subnet.InputProperties["exclude"].TypeSpec)
+		assert.Empty(t, spec.Types, "CIDR should not be described as an object type")
+	})
+
+	t.Run("check", func(t *testing.T) {
+		t.Parallel()
+		resp, err := prov.Check(p.CheckRequest{
+			Urn: urn,
+			News: resource.PropertyMap{
+				"block":   str("10.0.0.0/8"),
+				"size":    resource.NewNumberProperty(16),
+				"exclude": resource.NewArrayProperty([]resource.PropertyValue{str("10.1.0.0/16")}),
+			},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, resp.Failures)
+		assert.Equal(t, str("10.0.0.0/8"), resp.Inputs["block"])
+		assert.Equal(t, resource.NewArrayProperty([]resource.PropertyValue{str("10.1.0.0/16")}),
+			resp.Inputs["exclude"])
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		resp, err := prov.Check(p.CheckRequest{
+			Urn: urn,
+			News: resource.PropertyMap{
+				"block": str("10.0.0.0/33"),
+				"size":  resource.NewNumberProperty(16),
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.Failures, 1)
+		assert.Equal(t, "block", resp.Failures[0].Property)
+		assert.Contains(t, resp.Failures[0].Reason, "invalid CIDR address: 10.0.0.0/33")
+	})
+
+	t.Run("create", func(t *testing.T) {
+		t.Parallel()
+		resp, err := prov.Create(p.CreateRequest{
+			Urn: urn,
+			Properties: resource.PropertyMap{
+				"block": str("10.0.0.0/8"),
+				"size":  resource.NewNumberProperty(16),
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, resource.PropertyMap{
+			"block":  str("10.0.0.0/8"),
+			"size":   resource.NewNumberProperty(16),
+			"subnet": str("10.0.0.0/16"),
+		}, resp.Properties)
+	})
+}
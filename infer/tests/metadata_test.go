@@ -0,0 +1,145 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/blang/semver"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
+	"github.com/pulumi/pulumi-go-provider/middleware/schema"
+)
+
+func TestMetadata(t *testing.T) {
+	t.Parallel()
+
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Metadata: schema.Metadata{
+			DisplayName: "Test",
+			Description: "A provider for testing.",
+			Keywords:    []string{"pulumi", "test", "category/utility"},
+			Homepage:    "https://example.com",
+			Repository:  "https://github.com/example/pulumi-test",
+			Publisher:   "Example",
+			License:     "Apache-2.0",
+			LogoURL:     "https://example.com/logo.png",
+		},
+		Resources: []infer.InferredResource{infer.Resource[*Increment, IncrementArgs, IncrementOutput]()},
+	}))
+
+	resp, err := prov.GetSchema(p.GetSchemaRequest{})
+	require.NoError(t, err)
+
+	var spec pschema.PackageSpec
+	require.NoError(t, json.Unmarshal([]byte(resp.Schema), &spec))
+
+	assert.Equal(t, "test", spec.Name)
+	assert.Equal(t, "Test", spec.DisplayName)
+	assert.Equal(t, "A provider for testing.", spec.Description)
+	assert.Equal(t, []string{"pulumi", "test", "category/utility"}, spec.Keywords)
+	assert.Equal(t, "https://example.com", spec.Homepage)
+	assert.Equal(t, "https://github.com/example/pulumi-test", spec.Repository)
+	assert.Equal(t, "Example", spec.Publisher)
+	assert.Equal(t, "Apache-2.0", spec.License)
+	assert.Equal(t, "https://example.com/logo.png", spec.LogoURL)
+	assert.Empty(t, prov.Logs())
+}
+
+func TestMetadataMissingFields(t *testing.T) {
+	t.Parallel()
+
+	server := func(strict bool) integration.Server {
+		return integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+			Metadata: schema.Metadata{
+				DisplayName: "Test",
+				Homepage:    "https://example.com",
+			},
+			Resources: []infer.InferredResource{infer.Resource[*Memo, MemoArgs, MemoArgs]()},
+			Strict:    strict,
+		}))
+	}
+
+	// The missing fields are only reported on request.
+	prov := server(false)
+	_, err := prov.GetSchema(p.GetSchemaRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, prov.Logs())
+
+	prov = server(true)
+	_, err = prov.GetSchema(p.GetSchemaRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []integration.LogMessage{{
+		Severity: diag.Warning,
+		Message: "metadata is missing fields required for publishing: " +
+			"Description, Keywords, Repository, Publisher, License",
+	}}, prov.Logs())
+}
+
+func TestLanguagePackageNames(t *testing.T) {
+	t.Parallel()
+
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Metadata: schema.Metadata{
+			LogoURL: "https://example.com/logo.png",
+			LanguageMap: map[string]any{
+				"python": map[string]any{"pyproject": map[string]any{"enabled": true}},
+			},
+			LanguagePackageNames: map[string]string{
+				"csharp": "MyOrg.Pkg",
+				"go":     "github.com/myorg/pulumi-pkg/sdk/go/pkg",
+				"java":   "com.myorg.pkg",
+				"nodejs": "@myorg/pkg",
+				"python": "myorg_pkg",
+			},
+		},
+		Resources: []infer.InferredResource{infer.Resource[*Increment, IncrementArgs, IncrementOutput]()},
+	}))
+
+	resp, err := prov.GetSchema(p.GetSchemaRequest{})
+	require.NoError(t, err)
+
+	var spec pschema.PackageSpec
+	require.NoError(t, json.Unmarshal([]byte(resp.Schema), &spec))
+
+	assert.Equal(t, "https://example.com/logo.png", spec.LogoURL)
+	assert.JSONEq(t, `{"rootNamespace": "MyOrg.Pkg"}`, string(spec.Language["csharp"]))
+	assert.JSONEq(t, `{"importBasePath": "github.com/myorg/pulumi-pkg/sdk/go/pkg"}`, string(spec.Language["go"]))
+	assert.JSONEq(t, `{"basePackage": "com.myorg.pkg"}`, string(spec.Language["java"]))
+	assert.JSONEq(t, `{"packageName": "@myorg/pkg"}`, string(spec.Language["nodejs"]))
+	assert.JSONEq(t, `{"packageName": "myorg_pkg", "pyproject": {"enabled": true}}`,
+		string(spec.Language["python"]))
+}
+
+func TestLanguagePackageNamesInvalidGoPath(t *testing.T) {
+	t.Parallel()
+
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Metadata: schema.Metadata{
+			LanguagePackageNames: map[string]string{"go": "github.com/myorg/pulumi pkg"},
+		},
+		Resources: []infer.InferredResource{infer.Resource[*Increment, IncrementArgs, IncrementOutput]()},
+	}))
+
+	_, err := prov.GetSchema(p.GetSchemaRequest{})
+	assert.ErrorContains(t, err, "invalid go package name")
+}
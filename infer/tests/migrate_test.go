@@ -275,3 +275,111 @@ func (*MigrateR) Diff(_ context.Context, _ string, s MigrateStateV2, _ MigrateSt
 type viaError[T any] struct{ t T }
 
 func (viaError[T]) Error() string { panic("NOT FOR DISPLAY") }
+
+// Mailbox replaced its alias string with a list of aliases in version 2.
+type Mailbox struct{}
+
+func (*Mailbox) Annotate(a infer.Annotator) { a.SetVersion(2) }
+
+type MailboxArgs struct {
+	Address string   `pulumi:"address"`
+	Aliases []string `pulumi:"aliases,optional"`
+}
+
+func (*Mailbox) Create(ctx context.Context, name string, args MailboxArgs, preview bool) (string, MailboxArgs, error) {
+	return args.Address, args, nil
+}
+
+func (*Mailbox) Update(
+	ctx context.Context, id string, olds, news MailboxArgs, preview bool,
+) (MailboxArgs, error) {
+	// The aliases of the old state were migrated from version 1.
+	news.Aliases = append(olds.Aliases, news.Aliases...)
+	return news, nil
+}
+
+func (*Mailbox) MigrateState(
+	ctx context.Context, oldVersion int, old resource.PropertyMap,
+) (resource.PropertyMap, error) {
+	if oldVersion == 1 {
+		if alias, ok := old["alias"]; ok {
+			old["aliases"] = resource.NewArrayProperty([]resource.PropertyValue{alias})
+			delete(old, "alias")
+		}
+	}
+	return old, nil
+}
+
+func TestMigrateStateVersion(t *testing.T) {
+	t.Parallel()
+
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[*Mailbox, MailboxArgs, MailboxArgs]()},
+	}))
+	urn := resource.NewURN("stack", "proj", "", "test:tests:Mailbox", "mailbox")
+	str := resource.NewStringProperty
+	arr := func(v ...resource.PropertyValue) resource.PropertyValue { return resource.NewArrayProperty(v) }
+	v1 := resource.PropertyMap{"address": str("me@example.com"), "alias": str("info@example.com")}
+	v2 := resource.PropertyMap{
+		"address":   str("me@example.com"),
+		"aliases":   arr(str("info@example.com")),
+		"__version": resource.NewNumberProperty(2),
+	}
+
+	t.Run("create", func(t *testing.T) {
+		t.Parallel()
+		resp, err := prov.Create(p.CreateRequest{
+			Urn:        urn,
+			Properties: resource.PropertyMap{"address": str("me@example.com"), "aliases": arr(str("info@example.com"))},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, v2, resp.Properties)
+	})
+
+	t.Run("diff", func(t *testing.T) {
+		t.Parallel()
+		resp, err := prov.Diff(p.DiffRequest{
+			Urn:  urn,
+			ID:   "me@example.com",
+			Olds: v1,
+			News: resource.PropertyMap{"address": str("me@example.com"), "aliases": arr(str("info@example.com"))},
+		})
+		require.NoError(t, err)
+		assert.False(t, resp.HasChanges)
+	})
+
+	t.Run("read", func(t *testing.T) {
+		t.Parallel()
+		resp, err := prov.Read(p.ReadRequest{Urn: urn, ID: "me@example.com", Properties: v1, Inputs: v1})
+		require.NoError(t, err)
+		assert.Equal(t, v2, resp.Properties)
+		assert.Equal(t, resource.PropertyMap{
+			"address": str("me@example.com"),
+			"aliases": arr(str("info@example.com")),
+		}, resp.Inputs)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		t.Parallel()
+		resp, err := prov.Update(p.UpdateRequest{
+			Urn:  urn,
+			ID:   "me@example.com",
+			Olds: v1,
+			News: resource.PropertyMap{"address": str("me@example.com"), "aliases": arr(str("sales@example.com"))},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, arr(str("info@example.com"), str("sales@example.com")), resp.Properties["aliases"])
+		assert.Equal(t, resource.NewNumberProperty(2), resp.Properties["__version"])
+	})
+
+	t.Run("newer", func(t *testing.T) {
+		t.Parallel()
+		_, err := prov.Diff(p.DiffRequest{
+			Urn:  urn,
+			ID:   "me@example.com",
+			Olds: resource.PropertyMap{"address": str("me@example.com"), "__version": resource.NewNumberProperty(3)},
+			News: resource.PropertyMap{"address": str("me@example.com")},
+		})
+		assert.ErrorContains(t, err, "the state of the resource is at version 3, which is newer than version 2")
+	})
+}
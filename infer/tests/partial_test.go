@@ -0,0 +1,89 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
+)
+
+// Quote knows its price during a preview, but not its receipt.
+type Quote struct{}
+
+func (*Quote) Annotate(a infer.Annotator) {
+	a.SetSupportsPartialValues(true)
+}
+
+type QuoteArgs struct {
+	Size int `pulumi:"size"`
+}
+
+type QuoteState struct {
+	QuoteArgs
+	Price   int     `pulumi:"price"`
+	Receipt *string `pulumi:"receipt,optional"`
+}
+
+func (*Quote) Create(ctx context.Context, name string, args QuoteArgs, preview bool) (string, QuoteState, error) {
+	state := QuoteState{QuoteArgs: args, Price: args.Size * 10}
+	if !preview {
+		receipt := "paid"
+		state.Receipt = &receipt
+	}
+	return name, state, nil
+}
+
+func TestPartialValuesInPreview(t *testing.T) {
+	t.Parallel()
+
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[*Quote, QuoteArgs, QuoteState]()},
+	}))
+	create := func(size resource.PropertyValue, preview bool) resource.PropertyMap {
+		resp, err := prov.Create(p.CreateRequest{
+			Urn:        resource.NewURN("stack", "proj", "", "test:tests:Quote", "quote"),
+			Properties: resource.PropertyMap{"size": size},
+			Preview:    preview,
+		})
+		require.NoError(t, err)
+		return resp.Properties
+	}
+
+	assert.Equal(t, resource.PropertyMap{
+		"size":    resource.NewNumberProperty(2),
+		"price":   resource.NewNumberProperty(20),
+		"receipt": resource.MakeComputed(resource.NewNullProperty()),
+	}, create(resource.NewNumberProperty(2), true))
+
+	// An output that depends on an unknown input is unknown, even though it is set.
+	unknown := create(resource.MakeComputed(resource.NewNumberProperty(0)), true)
+	assert.True(t, unknown["price"].IsComputed())
+	assert.True(t, unknown["receipt"].IsComputed())
+
+	assert.Equal(t, resource.PropertyMap{
+		"size":    resource.NewNumberProperty(2),
+		"price":   resource.NewNumberProperty(20),
+		"receipt": resource.NewStringProperty("paid"),
+	}, create(resource.NewNumberProperty(2), false))
+}
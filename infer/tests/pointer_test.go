@@ -0,0 +1,191 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/blang/semver"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
+)
+
+// Nest has fields behind more than one pointer.
+type Nest struct{}
+
+type NestArgs struct {
+	Label  **string      `pulumi:"label,optional"`
+	Nested **NestInner   `pulumi:"nested,optional"`
+	Items  []**NestInner `pulumi:"items,optional"`
+}
+
+type NestInner struct {
+	Size **int `pulumi:"size,optional"`
+}
+
+func (n *NestInner) Annotate(a infer.Annotator) {
+	a.Describe(&n, "A nested object.")
+}
+
+func (n *NestArgs) Annotate(a infer.Annotator) {
+	a.SetDefault(&n.Label, "default")
+}
+
+func (*Nest) Create(ctx context.Context, name string, args NestArgs, preview bool) (string, NestArgs, error) {
+	return name, args, nil
+}
+
+func TestDoublePointers(t *testing.T) {
+	t.Parallel()
+
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[*Nest, NestArgs, NestArgs]()},
+	}))
+
+	t.Run("schema", func(t *testing.T) {
+		t.Parallel()
+		resp, err := prov.GetSchema(p.GetSchemaRequest{Version: 1})
+		require.NoError(t, err)
+		var spec pschema.PackageSpec
+		require.NoError(t, json.Unmarshal([]byte(resp.Schema), &spec))
+
+		res := spec.Resources["test:tests:Nest"]
+		assert.Empty(t, res.RequiredInputs)
+		assert.Equal(t, "string", res.InputProperties["label"].Type)
+		assert.Equal(t, "default", res.InputProperties["label"].Default)
+		assert.Equal(t, "#/types/test:tests:NestInner", res.InputProperties["nested"].Ref)
+		assert.Equal(t, "#/types/test:tests:NestInner", res.InputProperties["items"].Items.Ref)
+		inner := spec.Types["test:tests:NestInner"]
+		assert.Equal(t, "A nested object.", inner.Description)
+		assert.Empty(t, inner.Required)
+		assert.Equal(t, "integer", inner.Properties["size"].Type)
+	})
+
+	t.Run("set", func(t *testing.T) {
+		t.Parallel()
+		inputs := resource.PropertyMap{
+			"label":  resource.NewStringProperty("l"),
+			"nested": resource.NewObjectProperty(resource.PropertyMap{"size": resource.NewNumberProperty(2)}),
+			"items": resource.NewArrayProperty([]resource.PropertyValue{
+				resource.NewObjectProperty(resource.PropertyMap{"size": resource.NewNumberProperty(3)}),
+				resource.NewObjectProperty(resource.PropertyMap{}),
+			}),
+		}
+		integration.LifeCycleTest{
+			Resource: "test:tests:Nest",
+			Create:   integration.Operation{Inputs: inputs, ExpectedOutput: inputs},
+		}.Run(t, prov)
+	})
+
+	t.Run("unset", func(t *testing.T) {
+		t.Parallel()
+		integration.LifeCycleTest{
+			Resource: "test:tests:Nest",
+			Create: integration.Operation{
+				Inputs: resource.PropertyMap{},
+				ExpectedOutput: resource.PropertyMap{
+					"label": resource.NewStringProperty("default"),
+				},
+			},
+		}.Run(t, prov)
+	})
+}
+
+// Knob has pointer fields without the optional tag, for [infer.Options.PointersAreOptional].
+type Knob struct{}
+
+type KnobArgs struct {
+	Name  string    `pulumi:"name"`
+	Size  *int      `pulumi:"size"`
+	Label *string   `pulumi:"label" provider:"required"`
+	Range *KnobSpan `pulumi:"range"`
+}
+
+type KnobSpan struct {
+	Low  *float64 `pulumi:"low"`
+	High float64  `pulumi:"high"`
+}
+
+func (*Knob) Create(ctx context.Context, name string, args KnobArgs, preview bool) (string, KnobArgs, error) {
+	return name, args, nil
+}
+
+func TestPointersAreOptional(t *testing.T) {
+	t.Parallel()
+
+	knobProvider := func(pointersAreOptional bool) integration.Server {
+		return integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+			Resources:           []infer.InferredResource{infer.Resource[*Knob, KnobArgs, KnobArgs]()},
+			PointersAreOptional: pointersAreOptional,
+		}))
+	}
+	getSchema := func(t *testing.T, prov integration.Server) pschema.PackageSpec {
+		resp, err := prov.GetSchema(p.GetSchemaRequest{Version: 1})
+		require.NoError(t, err)
+		var spec pschema.PackageSpec
+		require.NoError(t, json.Unmarshal([]byte(resp.Schema), &spec))
+		return spec
+	}
+	urn := resource.NewURN("stack", "proj", "", "test:tests:Knob", "knob")
+
+	// A provider without the option serves the same types in the same process, and its
+	// pointer fields stay required.
+	required := knobProvider(false)
+	prov := knobProvider(true)
+
+	spec := getSchema(t, prov)
+	assert.Equal(t, []string{"label", "name"}, spec.Resources["test:tests:Knob"].RequiredInputs)
+	assert.Equal(t, []string{"high"}, spec.Types["test:tests:KnobSpan"].Required)
+
+	inputs := resource.PropertyMap{
+		"name":  resource.NewStringProperty("knob"),
+		"label": resource.NewStringProperty("volume"),
+		"range": resource.NewObjectProperty(resource.PropertyMap{"high": resource.NewNumberProperty(11)}),
+	}
+	resp, err := prov.Check(p.CheckRequest{Urn: urn, News: inputs})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Failures)
+	assert.Equal(t, inputs, resp.Inputs)
+
+	create, err := prov.Create(p.CreateRequest{Urn: urn, Properties: resp.Inputs})
+	require.NoError(t, err)
+	assert.Equal(t, inputs, create.Properties)
+
+	resp, err = prov.Check(p.CheckRequest{Urn: urn, News: resource.PropertyMap{
+		"name": resource.NewStringProperty("knob"),
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []p.CheckFailure{
+		{Property: "label", Reason: "missing required property 'label'"},
+	}, resp.Failures)
+
+	spec = getSchema(t, required)
+	assert.Equal(t, []string{"label", "name", "range", "size"}, spec.Resources["test:tests:Knob"].RequiredInputs)
+	assert.Equal(t, []string{"high", "low"}, spec.Types["test:tests:KnobSpan"].Required)
+	resp, err = required.Check(p.CheckRequest{Urn: urn, News: inputs})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []p.CheckFailure{
+		{Property: "size", Reason: "missing required property 'size'"},
+		{Property: "range.low", Reason: "missing required property 'range.low'"},
+	}, resp.Failures)
+}
@@ -17,8 +17,10 @@ package tests
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/blang/semver"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
@@ -305,10 +307,261 @@ func (w *RecursiveArgs) Annotate(a infer.Annotator) {
 	a.SetDefault(&w.Value, "default-value")
 }
 
+type Required struct{}
+type RequiredArgs struct {
+	Bucket  string           `pulumi:"bucket"`
+	Network *RequiredNetwork `pulumi:"network,optional"`
+	Rules   []RequiredRule   `pulumi:"rules,optional"`
+}
+type RequiredNetwork struct {
+	Subnet string  `pulumi:"subnet"`
+	CIDR   *string `pulumi:"cidr,optional"`
+}
+type RequiredRule struct {
+	Port int `pulumi:"port"`
+}
+
+func (w *Required) Create(
+	ctx context.Context, name string, inputs RequiredArgs, preview bool,
+) (string, RequiredArgs, error) {
+	return "required", inputs, nil
+}
+
+type Deployment struct{}
+type DeploymentArgs struct {
+	APIVersion string                `pulumi:"apiVersion"`
+	Replicas   int                   `pulumi:"replicas"`
+	Containers []DeploymentContainer `pulumi:"containers,optional"`
+}
+type DeploymentContainer struct {
+	Kind  string `pulumi:"kind"`
+	Image string `pulumi:"image"`
+}
+
+func (d *DeploymentArgs) Annotate(a infer.Annotator) {
+	a.SetConst(&d.APIVersion, "apps/v1")
+}
+
+func (c *DeploymentContainer) Annotate(a infer.Annotator) {
+	a.SetConst(&c.Kind, "Container")
+}
+
+func (*Deployment) Create(
+	ctx context.Context, name string, inputs DeploymentArgs, preview bool,
+) (string, DeploymentArgs, error) {
+	return "deployment", inputs, nil
+}
+
+type Listener struct{}
+type ListenerArgs struct {
+	Name     string            `pulumi:"name"`
+	Port     int               `pulumi:"port"`
+	Backends []ListenerBackend `pulumi:"backends,optional"`
+}
+type ListenerBackend struct {
+	Host   string   `pulumi:"host"`
+	Weight *float64 `pulumi:"weight,optional"`
+}
+
+func (l *ListenerArgs) Annotate(a infer.Annotator) {
+	a.SetPattern(&l.Name, "^[a-z0-9-]+$")
+	a.SetMinimum(&l.Port, 1)
+	a.SetMaximum(&l.Port, 65535)
+}
+
+func (b *ListenerBackend) Annotate(a infer.Annotator) {
+	a.SetPattern(&b.Host, `^[a-z.]+$`)
+	a.SetMinimum(&b.Weight, 0)
+	a.SetMaximum(&b.Weight, 1)
+}
+
+func (*Listener) Create(
+	ctx context.Context, name string, inputs ListenerArgs, preview bool,
+) (string, ListenerArgs, error) {
+	return "listener", inputs, nil
+}
+
+type Topic struct{}
+type TopicArgs struct {
+	Prefix string `pulumi:"prefix"`
+	Region string `pulumi:"region,optional"`
+	Name   string `pulumi:"name,optional"`
+}
+
+func (t *TopicArgs) Annotate(a infer.Annotator) {
+	a.SetDefault(&t.Region, "us-east-1")
+	a.SetDefaultFunc(&t.Name, func(ctx context.Context, args any) (any, error) {
+		topic := args.(TopicArgs)
+		if topic.Prefix == "" {
+			return nil, errors.New("cannot derive a name without a prefix")
+		}
+		return topic.Prefix + "-" + topic.Region, nil
+	})
+}
+
+func (*Topic) Create(
+	ctx context.Context, name string, inputs TopicArgs, preview bool,
+) (string, TopicArgs, error) {
+	return inputs.Name, inputs, nil
+}
+
+type Object struct{}
+type ObjectState struct {
+	Name string `pulumi:"name"`
+	Arn  string `pulumi:"arn,computed"`
+}
+
+func (*Object) Create(
+	ctx context.Context, name string, inputs ObjectState, preview bool,
+) (string, ObjectState, error) {
+	inputs.Arn = "arn:object:" + inputs.Name
+	return inputs.Name, inputs, nil
+}
+
+type Volume struct{}
+type VolumeArgs struct {
+	Size int    `pulumi:"size"`
+	Zone string `pulumi:"zone" provider:"replaceOnChanges"`
+}
+type VolumeState struct {
+	VolumeArgs
+	Resizes int `pulumi:"resizes"`
+}
+
+// volumeResizes records the volumes that have been resized, keyed by ID.
+var volumeResizes sync.Map
+
+func (*Volume) Create(
+	ctx context.Context, name string, inputs VolumeArgs, preview bool,
+) (string, VolumeState, error) {
+	return name, VolumeState{VolumeArgs: inputs}, nil
+}
+
+func (*Volume) Update(
+	ctx context.Context, id string, olds VolumeState, news VolumeArgs, preview bool,
+) (VolumeState, error) {
+	state := VolumeState{VolumeArgs: news, Resizes: olds.Resizes}
+	if olds.Size == news.Size {
+		return state, nil
+	}
+	state.Resizes++
+	if !preview {
+		volumeResizes.Store(id, news.Size)
+	}
+	return state, nil
+}
+
+type Certificate struct{}
+type CertificateArgs struct {
+	Domain string `pulumi:"domain"`
+}
+type CertificateState struct {
+	CertificateArgs
+	Serial string `pulumi:"serial"`
+}
+
+// issuedCertificates records the domains that certificates have been issued for.
+var issuedCertificates sync.Map
+
+// issueCertificate issues a certificate for domain, returning its serial number. Outside
+// of a preview, the serial number isn't known until the certificate is issued.
+func issueCertificate(ctx context.Context, domain string) string {
+	if infer.IsPreview(ctx) {
+		return ""
+	}
+	issuedCertificates.Store(domain, true)
+	return "serial-" + domain
+}
+
+func (*Certificate) Create(
+	ctx context.Context, name string, inputs CertificateArgs, preview bool,
+) (string, CertificateState, error) {
+	return name, CertificateState{
+		CertificateArgs: inputs,
+		Serial:          issueCertificate(ctx, inputs.Domain),
+	}, nil
+}
+
+func (*Certificate) WireDependencies(f infer.FieldSelector, args *CertificateArgs, state *CertificateState) {
+	f.OutputField(&state.Domain).DependsOn(f.InputField(&args.Domain))
+}
+
+type Cluster struct{}
+type ClusterArgs struct {
+	Nodes int `pulumi:"nodes"`
+}
+
+func (*Cluster) Create(
+	ctx context.Context, name string, inputs ClusterArgs, preview bool,
+) (string, ClusterArgs, error) {
+	if preview {
+		return name, inputs, nil
+	}
+	infer.ReportStatus(ctx, "requesting cluster")
+	infer.ReportStatusf(ctx, "starting %d nodes", inputs.Nodes)
+	infer.ReportStatus(ctx, "waiting for cluster to become ready")
+	return name, inputs, nil
+}
+
+type Invoice struct{}
+type InvoiceArgs struct {
+	Title string `pulumi:"title"`
+}
+
+func (*Invoice) Create(
+	ctx context.Context, name string, inputs InvoiceArgs, preview bool,
+) (string, InvoiceArgs, error) {
+	return "invoice-" + infer.NewID(ctx), inputs, nil
+}
+
+type Pool struct{}
+type PoolArgs struct {
+	MinSize     int    `pulumi:"minSize"`
+	MaxSize     int    `pulumi:"maxSize"`
+	DesiredSize *int   `pulumi:"desiredSize,optional"`
+	Zone        string `pulumi:"zone,optional"`
+}
+
+func (a *PoolArgs) Annotate(an infer.Annotator) {
+	an.SetDefault(&a.Zone, "us-east-1a")
+}
+
+func (*Pool) Check(
+	ctx context.Context, name string, oldInputs, newInputs resource.PropertyMap,
+) (PoolArgs, []p.CheckFailure, error) {
+	args, failures, err := infer.DefaultCheck[PoolArgs](ctx, newInputs)
+	if err != nil || len(failures) > 0 {
+		return args, failures, err
+	}
+	if args.MaxSize < args.MinSize {
+		failures = append(failures, p.CheckFailure{
+			Property: "maxSize",
+			Reason:   fmt.Sprintf("maxSize (%d) must be at least minSize (%d)", args.MaxSize, args.MinSize),
+		})
+	}
+	if args.DesiredSize == nil {
+		args.DesiredSize = &args.MinSize
+	}
+	return args, failures, nil
+}
+
+func (*Pool) Create(ctx context.Context, name string, inputs PoolArgs, preview bool) (string, PoolArgs, error) {
+	return "pool", inputs, nil
+}
+
 type Config struct {
 	Value *string `pulumi:"value,optional"`
 }
 
+type SecretConfig struct {
+	Token string `pulumi:"apiToken,optional" provider:"secret"`
+}
+
+func (c *SecretConfig) Annotate(a infer.Annotator) {
+	a.Describe(&c.Token, "The token used to authenticate with the API.")
+	a.SetDefault(&c.Token, nil, "TEST_API_TOKEN")
+}
+
 type ReadConfig struct{}
 type ReadConfigArgs struct{}
 type ReadConfigOutput struct {
@@ -386,6 +639,41 @@ func (w *ReadConfigCustom) Create(
 	return "read", ReadConfigCustomOutput{Config: string(bytes)}, err
 }
 
+// Lease takes a lease in Create, and holds it until the provider is canceled, even
+// though the context of the Create is canceled first.
+type Lease struct {
+	lease *lease
+}
+
+type LeaseArgs struct {
+	Holder string `pulumi:"holder"`
+}
+
+// lease is a handle held by the Create of a Lease.
+type lease struct {
+	ctx      context.Context
+	released chan struct{}
+}
+
+// leases maps each holder to the lease taken for it.
+var leases sync.Map
+
+func (l *Lease) Create(ctx context.Context, name string, args LeaseArgs, preview bool) (string, LeaseArgs, error) {
+	l.lease = &lease{ctx: ctx, released: make(chan struct{})}
+	leases.Store(args.Holder, l.lease)
+	<-l.lease.released
+	return "", args, ctx.Err()
+}
+
+func (l *Lease) Cancel(ctx context.Context) error {
+	// The contexts of in-flight operations are canceled before Cancel is called.
+	if l.lease.ctx.Err() == nil {
+		return fmt.Errorf("lease released while still in use")
+	}
+	close(l.lease.released)
+	return nil
+}
+
 func providerOpts(config infer.InferredConfig) infer.Options {
 	return infer.Options{
 		Config: config,
@@ -397,8 +685,19 @@ func providerOpts(config infer.InferredConfig) infer.Options {
 			infer.Resource[*WithDefaults, WithDefaultsArgs, WithDefaultsOutput](),
 			infer.Resource[*ReadEnv, ReadEnvArgs, ReadEnvOutput](),
 			infer.Resource[*Recursive, RecursiveArgs, RecursiveOutput](),
+			infer.Resource[*Required, RequiredArgs, RequiredArgs](),
+			infer.Resource[*Pool, PoolArgs, PoolArgs](),
+			infer.Resource[*Deployment, DeploymentArgs, DeploymentArgs](),
+			infer.Resource[*Listener, ListenerArgs, ListenerArgs](),
+			infer.Resource[*Topic, TopicArgs, TopicArgs](),
+			infer.Resource[*Object, ObjectState, ObjectState](),
+			infer.Resource[*Volume, VolumeArgs, VolumeState](),
+			infer.Resource[*Certificate, CertificateArgs, CertificateState](),
+			infer.Resource[*Cluster, ClusterArgs, ClusterArgs](),
+			infer.Resource[*Invoice, InvoiceArgs, InvoiceArgs](),
 			infer.Resource[*ReadConfig, ReadConfigArgs, ReadConfigOutput](),
 			infer.Resource[*ReadConfigCustom, ReadConfigCustomArgs, ReadConfigCustomOutput](),
+			infer.Resource[*Lease, LeaseArgs, LeaseArgs](),
 		},
 		Functions: []infer.InferredFunction{
 			infer.Function[*GetJoin, JoinArgs, JoinResult](),
@@ -407,12 +706,21 @@ func providerOpts(config infer.InferredConfig) infer.Options {
 	}
 }
 
-func provider() integration.Server {
+func provider(opts ...integration.ServerOption) integration.Server {
 	p := infer.Provider(providerOpts(nil))
-	return integration.NewServer("test", semver.MustParse("1.0.0"), p)
+	return integration.NewServer("test", semver.MustParse("1.0.0"), p, opts...)
 }
 
-func providerWithConfig[T any]() integration.Server {
+func providerWithConfig[T any](opts ...integration.ServerOption) integration.Server {
 	p := infer.Provider(providerOpts(infer.Config[T]()))
-	return integration.NewServer("test", semver.MustParse("1.0.0"), p)
+	return integration.NewServer("test", semver.MustParse("1.0.0"), p, opts...)
+}
+
+type ReadFileConfig struct{}
+
+func (*ReadFileConfig) Create(
+	ctx context.Context, name string, _ ReadConfigArgs, _ bool,
+) (string, ReadConfigOutput, error) {
+	c := infer.GetConfig[FileConfig](ctx)
+	return "read", ReadConfigOutput{Config: fmt.Sprintf("%s x%d", c.Endpoint, c.Retries)}, nil
 }
@@ -0,0 +1,112 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
+)
+
+// Probe records the request metadata of the context it is created or updated with.
+type Probe struct{}
+
+type ProbeArgs struct {
+	Tag string `pulumi:"tag"`
+}
+
+type ProbeState struct {
+	ProbeArgs
+	URN     string `pulumi:"urn"`
+	Project string `pulumi:"project"`
+	Stack   string `pulumi:"stack"`
+	DryRun  bool   `pulumi:"dryRun"`
+}
+
+func probe(ctx context.Context, args ProbeArgs) ProbeState {
+	return ProbeState{
+		ProbeArgs: args,
+		URN:       string(p.GetURN(ctx)),
+		Project:   p.GetProject(ctx),
+		Stack:     p.GetStack(ctx),
+		DryRun:    p.IsDryRun(ctx),
+	}
+}
+
+func (*Probe) Create(ctx context.Context, name string, args ProbeArgs, preview bool) (string, ProbeState, error) {
+	return name, probe(ctx, args), nil
+}
+
+func (*Probe) Update(
+	ctx context.Context, id string, olds ProbeState, news ProbeArgs, preview bool,
+) (ProbeState, error) {
+	return probe(ctx, news), nil
+}
+
+func TestRequestMetadata(t *testing.T) {
+	t.Parallel()
+
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[*Probe, ProbeArgs, ProbeState]()},
+	}))
+	urn := resource.NewURN("dev", "proj", "", "test:tests:Probe", "probe")
+	// The outputs of a preview are marked as computed, as they aren't known until the
+	// resource is created or updated.
+	expected := func(tag string, dryRun bool) resource.PropertyMap {
+		output := func(v resource.PropertyValue) resource.PropertyValue {
+			if dryRun {
+				return resource.MakeComputed(v)
+			}
+			return v
+		}
+		return resource.PropertyMap{
+			"tag":     resource.NewStringProperty(tag),
+			"urn":     output(resource.NewStringProperty(string(urn))),
+			"project": output(resource.NewStringProperty("proj")),
+			"stack":   output(resource.NewStringProperty("dev")),
+			"dryRun":  output(resource.NewBoolProperty(dryRun)),
+		}
+	}
+
+	for _, preview := range []bool{true, false} {
+		create, err := prov.Create(p.CreateRequest{
+			Urn:        urn,
+			Properties: resource.PropertyMap{"tag": resource.NewStringProperty("a")},
+			Preview:    preview,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, expected("a", preview), create.Properties)
+	}
+
+	for _, preview := range []bool{true, false} {
+		update, err := prov.Update(p.UpdateRequest{
+			ID:      "probe",
+			Urn:     urn,
+			Olds:    expected("a", false),
+			News:    resource.PropertyMap{"tag": resource.NewStringProperty("b")},
+			Preview: preview,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, expected("b", preview), update.Properties)
+	}
+}
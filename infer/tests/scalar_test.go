@@ -0,0 +1,116 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/blang/semver"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
+)
+
+// Port is a TCP port, which ranges from 1 to 65535.
+type Port int
+
+func init() {
+	infer.RegisterScalar[Port](pschema.TypeSpec{Type: "integer"}, func(p Port) error {
+		if p < 1 || p > 65535 {
+			return errors.New("ports range from 1 to 65535")
+		}
+		return nil
+	})
+}
+
+type Firewall struct{}
+
+type FirewallArgs struct {
+	Allow []Port `pulumi:"allow"`
+}
+
+func (*Firewall) Create(
+	ctx context.Context, name string, args FirewallArgs, preview bool,
+) (string, FirewallArgs, error) {
+	return name, args, nil
+}
+
+type Tunnel struct{}
+
+type TunnelArgs struct {
+	Local  Port  `pulumi:"local"`
+	Remote *Port `pulumi:"remote,optional"`
+}
+
+func (*Tunnel) Create(ctx context.Context, name string, args TunnelArgs, preview bool) (string, TunnelArgs, error) {
+	return name, args, nil
+}
+
+func TestRegisterScalar(t *testing.T) {
+	t.Parallel()
+
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{
+			infer.Resource[*Firewall, FirewallArgs, FirewallArgs](),
+			infer.Resource[*Tunnel, TunnelArgs, TunnelArgs](),
+		},
+	}))
+
+	resp, err := prov.GetSchema(p.GetSchemaRequest{Version: 1})
+	require.NoError(t, err)
+	var spec pschema.PackageSpec
+	require.NoError(t, json.Unmarshal([]byte(resp.Schema), &spec))
+	port := pschema.TypeSpec{Type: "integer"}
+	assert.Equal(t, pschema.TypeSpec{Type: "array", Items: &port},
+		spec.Resources["test:tests:Firewall"].InputProperties["allow"].TypeSpec)
+	assert.Equal(t, port, spec.Resources["test:tests:Tunnel"].InputProperties["local"].TypeSpec)
+	assert.Equal(t, port, spec.Resources["test:tests:Tunnel"].InputProperties["remote"].TypeSpec)
+
+	num := resource.NewNumberProperty
+	firewall := resource.NewURN("stack", "proj", "", "test:tests:Firewall", "firewall")
+	tunnel := resource.NewURN("stack", "proj", "", "test:tests:Tunnel", "tunnel")
+
+	check, err := prov.Check(p.CheckRequest{Urn: firewall, News: resource.PropertyMap{
+		"allow": resource.NewArrayProperty([]resource.PropertyValue{num(22), num(443)}),
+	}})
+	require.NoError(t, err)
+	assert.Empty(t, check.Failures)
+
+	check, err = prov.Check(p.CheckRequest{Urn: firewall, News: resource.PropertyMap{
+		"allow": resource.NewArrayProperty([]resource.PropertyValue{num(22), num(0), num(70000)}),
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []p.CheckFailure{
+		{Property: "allow[1]", Reason: "0 is not a valid Port: ports range from 1 to 65535"},
+		{Property: "allow[2]", Reason: "70000 is not a valid Port: ports range from 1 to 65535"},
+	}, check.Failures)
+
+	check, err = prov.Check(p.CheckRequest{Urn: tunnel, News: resource.PropertyMap{
+		"local":  num(8080),
+		"remote": resource.MakeSecret(num(65536)),
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []p.CheckFailure{
+		{Property: "remote", Reason: "[secret] is not a valid Port"},
+	}, check.Failures)
+}
@@ -0,0 +1,459 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/blang/semver"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
+)
+
+func TestGetSchemaConcurrent(t *testing.T) {
+	t.Parallel()
+
+	prov := provider()
+	expected, err := prov.GetSchema(p.GetSchemaRequest{Version: 1})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := prov.GetSchema(p.GetSchemaRequest{Version: 1})
+			assert.NoError(t, err)
+			assert.Equal(t, expected.Schema, resp.Schema)
+		}()
+	}
+	wg.Wait()
+}
+
+// TestGetSchemaConcurrentMatchesSerial generates the schema of many resources from
+// concurrent providers, before the annotations of their types are cached, and checks that
+// each matches the schema generated serially. Run with -race to check for data races.
+//
+//nolint:paralleltest // Runs before the parallel tests, which warm the caches.
+func TestGetSchemaConcurrentMatchesSerial(t *testing.T) {
+	opts := func() infer.Options {
+		opts := providerOpts(nil)
+		opts.Resources = append(opts.Resources,
+			infer.Resource[*Gateway, GatewayArgs, GatewayArgs](),
+			infer.Resource[*Queue, QueueArgs, QueueState](),
+			infer.Resource[*Meter, MeterArgs, MeterArgs](),
+			infer.Resource[*Instance, InstanceArgs, InstanceState](),
+			infer.Resource[*Mailbox, MailboxArgs, MailboxArgs](),
+			infer.Resource[*Firewall, FirewallArgs, FirewallArgs](),
+			infer.Resource[*Tunnel, TunnelArgs, TunnelArgs](),
+			infer.Resource[*Beacon, BeaconArgs, BeaconArgs](),
+		)
+		return opts
+	}
+	getSchema := func() (string, error) {
+		prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(opts()))
+		resp, err := prov.GetSchema(p.GetSchemaRequest{Version: 1})
+		return resp.Schema, err
+	}
+
+	concurrent := make([]string, 16)
+	var wg sync.WaitGroup
+	for i := range concurrent {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			schema, err := getSchema()
+			assert.NoError(t, err)
+			concurrent[i] = schema
+		}()
+	}
+	wg.Wait()
+
+	serial, err := getSchema()
+	require.NoError(t, err)
+	for _, schema := range concurrent {
+		assert.Equal(t, serial, schema)
+	}
+}
+
+func BenchmarkGetSchema(b *testing.B) {
+	b.Run("cold", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := provider().GetSchema(p.GetSchemaRequest{Version: 1})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("warm", func(b *testing.B) {
+		prov := provider()
+		if _, err := prov.GetSchema(p.GetSchemaRequest{Version: 1}); err != nil {
+			b.Fatal(err)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, err := prov.GetSchema(p.GetSchemaRequest{Version: 1})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+type Site struct{}
+
+type SiteArgs struct {
+	Origin *SiteOrigin `pulumi:"origin,optional"`
+}
+
+type SiteOrigin struct {
+	Host    string              `pulumi:"host"`
+	Headers []*SiteOriginHeader `pulumi:"headers,optional"`
+	TLS     SiteOriginTLS       `pulumi:"tls"`
+	Pools   map[string]SitePool `pulumi:"pools,optional"`
+}
+
+type SitePool struct {
+	Size int `pulumi:"size"`
+}
+
+func (sp *SitePool) Annotate(a infer.Annotator) {
+	a.Describe(sp, "A pool of origin servers.")
+	a.Describe(&sp.Size, "The number of servers.")
+}
+
+func (o *SiteOrigin) Annotate(a infer.Annotator) {
+	a.Describe(&o.Host, "The host to serve the site from.")
+}
+
+type SiteOriginHeader struct {
+	SiteHeaderName
+	Value string `pulumi:"value"`
+}
+
+func (h *SiteOriginHeader) Annotate(a infer.Annotator) {
+	a.Describe(&h.Value, "The value of the header.")
+	a.SetDefault(&h.Value, "none")
+}
+
+type SiteHeaderName struct {
+	Name string `pulumi:"name"`
+}
+
+func (n *SiteHeaderName) Annotate(a infer.Annotator) {
+	a.Describe(n, "The name of a header.")
+	a.Describe(&n.Name, "The name of the header.")
+}
+
+type SiteOriginTLS struct {
+	MinVersion string `pulumi:"minVersion"`
+}
+
+func (t *SiteOriginTLS) Annotate(a infer.Annotator) {
+	a.Describe(&t.MinVersion, "The minimum TLS version.")
+}
+
+func (*Site) Create(ctx context.Context, name string, args SiteArgs, preview bool) (string, SiteArgs, error) {
+	return name, args, nil
+}
+
+func TestNestedDescriptions(t *testing.T) {
+	t.Parallel()
+
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[*Site, SiteArgs, SiteArgs]()},
+	}))
+	resp, err := prov.GetSchema(p.GetSchemaRequest{Version: 1})
+	require.NoError(t, err)
+	var spec pschema.PackageSpec
+	require.NoError(t, json.Unmarshal([]byte(resp.Schema), &spec))
+
+	property := func(typ, name string) pschema.PropertySpec {
+		require.Contains(t, spec.Types, typ)
+		require.Contains(t, spec.Types[typ].Properties, name)
+		return spec.Types[typ].Properties[name]
+	}
+	assert.Equal(t, "The host to serve the site from.", property("test:tests:SiteOrigin", "host").Description)
+	assert.Equal(t, "The name of the header.", property("test:tests:SiteOriginHeader", "name").Description)
+	value := property("test:tests:SiteOriginHeader", "value")
+	assert.Equal(t, "The value of the header.", value.Description)
+	assert.Equal(t, "none", value.Default)
+	assert.Equal(t, "The minimum TLS version.", property("test:tests:SiteOriginTLS", "minVersion").Description)
+	assert.Equal(t, "The number of servers.", property("test:tests:SitePool", "size").Description)
+	assert.Equal(t, "A pool of origin servers.", spec.Types["test:tests:SitePool"].Description)
+	// The description of an embedded struct is not the description of the struct embedding it.
+	assert.Empty(t, spec.Types["test:tests:SiteOriginHeader"].Description)
+}
+
+func TestSchemaForToken(t *testing.T) {
+	t.Parallel()
+
+	opts := providerOpts(nil)
+
+	spec, err := opts.SchemaForToken("test:index:Listener")
+	require.NoError(t, err)
+	assert.Equal(t, "test", spec.Name)
+	assert.Equal(t, []string{"test:index:Listener"}, sortedKeys(spec.Resources))
+	assert.Empty(t, spec.Functions)
+	// Only the types referenced by the resource are generated.
+	assert.Equal(t, []string{"test:index:ListenerBackend"}, sortedKeys(spec.Types))
+	assert.Equal(t, "#/types/test:index:ListenerBackend",
+		spec.Resources["test:index:Listener"].InputProperties["backends"].Items.Ref)
+
+	spec, err = opts.SchemaForToken("test:index:getJoin")
+	require.NoError(t, err)
+	assert.Empty(t, spec.Resources)
+	assert.Equal(t, []string{"test:index:getJoin"}, sortedKeys(spec.Functions))
+
+	_, err = opts.SchemaForToken("test:index:Missing")
+	assert.ErrorContains(t, err, `no resources or functions in package "test" match`)
+}
+
+func TestSchemaForModule(t *testing.T) {
+	t.Parallel()
+
+	opts := infer.Options{
+		Resources: []infer.InferredResource{
+			infer.Resource[*CustomToken, TokenArgs, TokenResult](),
+		},
+		Components: []infer.InferredComponent{
+			infer.Component[*ComponentToken, TokenArgs, *TokenComponent](),
+		},
+		Functions: []infer.InferredFunction{
+			infer.Function[*FnToken, TokenArgs, TokenResult](),
+		},
+		ModuleMap: map[tokens.ModuleName]tokens.ModuleName{"overwritten": "index"},
+	}
+
+	spec, err := opts.SchemaForModule("test:cmp")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test:cmp:tK"}, sortedKeys(spec.Resources))
+	assert.Empty(t, spec.Functions)
+	assert.Equal(t, []string{"test:obj:Customized"}, sortedKeys(spec.Types))
+
+	// Modules are matched after the module map is applied.
+	spec, err = opts.SchemaForModule("test:index")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test:index:Tk"}, sortedKeys(spec.Resources))
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Peering refers to object and enum types of another package.
+type Peering struct{}
+
+type PeeringArgs struct {
+	Vpc     VpcInfo  `pulumi:"vpc" provider:"type=aws@6.0.0:ec2:Vpc"`
+	Region  string   `pulumi:"region" provider:"type=aws@6.0.0:index:Region"`
+	Regions []string `pulumi:"regions,optional" provider:"type=aws@6.0.0:index:Region"`
+	// Tier is an enum of this package, but the tag takes precedence.
+	Tier *Tier `pulumi:"tier,optional" provider:"type=aws@6.0.0:index:Tier"`
+}
+
+// VpcInfo mirrors the fields of the external type that the provider uses.
+type VpcInfo struct {
+	ID string `pulumi:"id"`
+}
+
+func (*Peering) Create(ctx context.Context, name string, args PeeringArgs, preview bool) (string, PeeringArgs, error) {
+	return name, args, nil
+}
+
+func TestExternalTypeReferences(t *testing.T) {
+	t.Parallel()
+
+	resp, err := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[*Peering, PeeringArgs, PeeringArgs]()},
+	})).GetSchema(p.GetSchemaRequest{Version: 1})
+	require.NoError(t, err)
+	var spec pschema.PackageSpec
+	require.NoError(t, json.Unmarshal([]byte(resp.Schema), &spec))
+
+	inputs := spec.Resources["test:tests:Peering"].InputProperties
+	assert.Equal(t, pschema.TypeSpec{Ref: "/aws/v6.0.0/schema.json#/types/aws:ec2:Vpc"}, inputs["vpc"].TypeSpec)
+	assert.Equal(t, pschema.TypeSpec{Ref: "/aws/v6.0.0/schema.json#/types/aws:index:Region"}, inputs["region"].TypeSpec)
+	assert.Equal(t, pschema.TypeSpec{
+		Type:  "array",
+		Items: &pschema.TypeSpec{Ref: "/aws/v6.0.0/schema.json#/types/aws:index:Region"},
+	}, inputs["regions"].TypeSpec)
+	assert.Equal(t, pschema.TypeSpec{Ref: "/aws/v6.0.0/schema.json#/types/aws:index:Tier"}, inputs["tier"].TypeSpec)
+	// Neither the mirrored object nor the enum are types of this package.
+	assert.Empty(t, spec.Types)
+}
+
+// Gateway declares its nested objects both as a named type and inline, as anonymous
+// structs.
+type Gateway struct{}
+
+type GatewayArgs struct {
+	Listener GatewayListener `pulumi:"listener"`
+	Spec     struct {
+		Name string `pulumi:"name"`
+	} `pulumi:"spec"`
+	Routes []struct {
+		Path   string `pulumi:"path"`
+		Target *struct {
+			Port int `pulumi:"port"`
+		} `pulumi:"target,optional"`
+	} `pulumi:"routes,optional"`
+}
+
+type GatewayListener struct {
+	Port int `pulumi:"port"`
+}
+
+func (*Gateway) Create(ctx context.Context, name string, args GatewayArgs, preview bool) (string, GatewayArgs, error) {
+	return name, args, nil
+}
+
+func TestNestedObjectsAreNamedTypes(t *testing.T) {
+	t.Parallel()
+
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[*Gateway, GatewayArgs, GatewayArgs]()},
+	}))
+	resp, err := prov.GetSchema(p.GetSchemaRequest{Version: 1})
+	require.NoError(t, err)
+	var spec pschema.PackageSpec
+	require.NoError(t, json.Unmarshal([]byte(resp.Schema), &spec))
+
+	gateway := spec.Resources["test:tests:Gateway"]
+	assert.Equal(t, pschema.TypeSpec{Ref: "#/types/test:tests:GatewayListener"},
+		gateway.InputProperties["listener"].TypeSpec)
+	assert.Equal(t, pschema.TypeSpec{Ref: "#/types/test:tests:GatewayArgsSpec"},
+		gateway.InputProperties["spec"].TypeSpec)
+	assert.Equal(t, pschema.TypeSpec{
+		Type:  "array",
+		Items: &pschema.TypeSpec{Ref: "#/types/test:tests:GatewayArgsRoutes"},
+	}, gateway.Properties["routes"].TypeSpec)
+
+	assert.Equal(t, []string{
+		"test:tests:GatewayArgsRoutes",
+		"test:tests:GatewayArgsRoutesTarget",
+		"test:tests:GatewayArgsSpec",
+		"test:tests:GatewayListener",
+	}, sortedKeys(spec.Types))
+	assert.Equal(t, pschema.TypeSpec{Ref: "#/types/test:tests:GatewayArgsRoutesTarget"},
+		spec.Types["test:tests:GatewayArgsRoutes"].Properties["target"].TypeSpec)
+}
+
+// Labeler holds an interface that is not a registered union, which is described as any.
+type Labeler struct{}
+
+type LabelerArgs struct {
+	Any      any          `pulumi:"any,optional"`
+	Stringer fmt.Stringer `pulumi:"stringer,optional"`
+}
+
+func (*Labeler) Create(ctx context.Context, name string, args LabelerArgs, preview bool) (string, LabelerArgs, error) {
+	return name, args, nil
+}
+
+func TestInterfaceFallbackLogged(t *testing.T) {
+	t.Parallel()
+
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[*Labeler, LabelerArgs, LabelerArgs]()},
+	}))
+	resp, err := prov.GetSchema(p.GetSchemaRequest{})
+	require.NoError(t, err)
+	var spec pschema.PackageSpec
+	require.NoError(t, json.Unmarshal([]byte(resp.Schema), &spec))
+	assert.Equal(t, "pulumi.json#/Any", spec.Resources["test:tests:Labeler"].InputProperties["stringer"].Ref)
+
+	// Only fmt.Stringer is logged, since a field of type any is meant to hold anything.
+	assert.Equal(t, []integration.LogMessage{{
+		Severity: diag.Debug,
+		Message: "tests.LabelerArgs.Stringer has type fmt.Stringer, an interface that is not a registered " +
+			"union, so it is described as any: register its cases with infer.Union to describe them",
+	}}, prov.Logs())
+}
+
+// Mislabeled has a default whose type doesn't match its property, which makes its schema
+// invalid.
+type Mislabeled struct{}
+
+type MislabeledArgs struct {
+	Count int `pulumi:"count,optional"`
+}
+
+func (m *MislabeledArgs) Annotate(a infer.Annotator) {
+	a.SetDefault(&m.Count, "three")
+}
+
+func (*Mislabeled) Create(
+	ctx context.Context, name string, args MislabeledArgs, preview bool,
+) (string, MislabeledArgs, error) {
+	return name, args, nil
+}
+
+func TestValidateSchema(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+		t.Parallel()
+		diags, err := infer.ValidateSchema("test", infer.Options{
+			Resources: []infer.InferredResource{infer.Resource[*Gateway, GatewayArgs, GatewayArgs]()},
+		})
+		require.NoError(t, err)
+		assert.False(t, diags.HasErrors(), diags.Error())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		opts := infer.Options{
+			Resources: []infer.InferredResource{infer.Resource[*Mislabeled, MislabeledArgs, MislabeledArgs]()},
+		}
+		diags, err := infer.ValidateSchema("test", opts)
+		require.NoError(t, err)
+		require.True(t, diags.HasErrors())
+		var messages []string
+		for _, d := range diags {
+			messages = append(messages, d.Summary)
+		}
+		assert.ElementsMatch(t, []string{
+			"#/resources/test:tests:Mislabeled/properties/count/default: " +
+				"invalid constant of type string for integer default",
+			"#/resources/test:tests:Mislabeled/inputProperties/count/default: " +
+				"invalid constant of type string for integer default",
+		}, messages)
+
+		// The integration helpers validate the schema served by a provider.
+		prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(opts))
+		served, err := integration.ValidateSchema(prov)
+		require.NoError(t, err)
+		assert.Equal(t, diags.Error(), served.Error())
+	})
+}
@@ -0,0 +1,244 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/blang/semver"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
+)
+
+type Login struct{}
+
+type LoginArgs struct {
+	User     string `pulumi:"user"`
+	Password string `pulumi:"password"`
+	Token    string `pulumi:"token,optional" provider:"secret"`
+}
+
+func (l *LoginArgs) Annotate(a infer.Annotator) {
+	a.SetPattern(&l.Token, "^[a-z0-9]+$")
+}
+
+type LoginState struct {
+	LoginArgs
+	Version int `pulumi:"version"`
+}
+
+func (*Login) Create(ctx context.Context, name string, args LoginArgs, preview bool) (string, LoginState, error) {
+	return args.User, LoginState{LoginArgs: args, Version: 1}, nil
+}
+
+func (*Login) Update(
+	ctx context.Context, id string, olds LoginState, news LoginArgs, preview bool,
+) (LoginState, error) {
+	return LoginState{LoginArgs: news, Version: olds.Version + 1}, nil
+}
+
+func (*Login) Read(
+	ctx context.Context, id string, inputs LoginArgs, state LoginState,
+) (string, LoginArgs, LoginState, error) {
+	return id, inputs, state, nil
+}
+
+func loginProvider() integration.Server {
+	return integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[*Login, LoginArgs, LoginState]()},
+	}))
+}
+
+// login returns the inputs of a Login. Only the password is sent as a secret: the token
+// is secret because of its tag.
+func login(password, token string) resource.PropertyMap {
+	return resource.PropertyMap{
+		"user":     resource.NewStringProperty("admin"),
+		"password": resource.MakeSecret(resource.NewStringProperty(password)),
+		"token":    resource.NewStringProperty(token),
+	}
+}
+
+func TestSecretLifeCycle(t *testing.T) {
+	t.Parallel()
+
+	state := func(password, token string, version float64) resource.PropertyMap {
+		return resource.PropertyMap{
+			"user":     resource.NewStringProperty("admin"),
+			"password": resource.MakeSecret(resource.NewStringProperty(password)),
+			"token":    resource.MakeSecret(resource.NewStringProperty(token)),
+			"version":  resource.NewNumberProperty(version),
+		}
+	}
+
+	integration.LifeCycleTest{
+		Resource: "test:tests:Login",
+		Create: integration.Operation{
+			Inputs:         login("hunter2", "t1"),
+			ExpectedOutput: state("hunter2", "t1", 1),
+		},
+		Updates: []integration.Operation{
+			{
+				Inputs:         login("hunter3", "t1"),
+				ExpectedOutput: state("hunter3", "t1", 2),
+			},
+			{
+				Inputs:         login("hunter3", "t2"),
+				ExpectedOutput: state("hunter3", "t2", 3),
+			},
+		},
+	}.Run(t, loginProvider())
+}
+
+func TestSecretOutputsWithoutCheck(t *testing.T) {
+	t.Parallel()
+
+	prov := loginProvider()
+	urn := resource.NewURN("stack", "proj", "", "test:tests:Login", "l")
+	plain := resource.PropertyMap{
+		"user":     resource.NewStringProperty("admin"),
+		"password": resource.NewStringProperty("hunter2"),
+		"token":    resource.NewStringProperty("t1"),
+	}
+
+	t.Run("create", func(t *testing.T) {
+		t.Parallel()
+		resp, err := prov.Create(p.CreateRequest{Urn: urn, Properties: plain.Copy()})
+		require.NoError(t, err)
+		assert.True(t, resp.Properties["token"].IsSecret())
+		assert.False(t, resp.Properties["password"].IsSecret())
+	})
+
+	t.Run("update", func(t *testing.T) {
+		t.Parallel()
+		olds := plain.Copy()
+		olds["version"] = resource.NewNumberProperty(1)
+		resp, err := prov.Update(p.UpdateRequest{Urn: urn, ID: "admin", Olds: olds, News: plain.Copy()})
+		require.NoError(t, err)
+		assert.True(t, resp.Properties["token"].IsSecret())
+	})
+
+	// An import reads the resource from the provider, which knows nothing about secrets.
+	t.Run("read", func(t *testing.T) {
+		t.Parallel()
+		state := plain.Copy()
+		state["version"] = resource.NewNumberProperty(1)
+		resp, err := prov.Read(p.ReadRequest{Urn: urn, ID: "admin", Properties: state, Inputs: plain.Copy()})
+		require.NoError(t, err)
+		assert.True(t, resp.Properties["token"].IsSecret())
+		assert.True(t, resp.Inputs["token"].IsSecret())
+		assert.False(t, resp.Properties["user"].IsSecret())
+	})
+}
+
+func TestSecretCheckFailuresRedacted(t *testing.T) {
+	t.Parallel()
+
+	resp, err := loginProvider().Check(p.CheckRequest{
+		Urn:  resource.NewURN("stack", "proj", "", "test:tests:Login", "l"),
+		News: login("hunter2", "Not-A-Token"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []p.CheckFailure{
+		{Property: "token", Reason: `[secret] does not match the pattern "^[a-z0-9]+$"`},
+	}, resp.Failures)
+}
+
+// Account generates a password, which is secret although none of its inputs are.
+type Account struct{}
+
+type AccountArgs struct {
+	Name string `pulumi:"name"`
+}
+
+type AccountState struct {
+	AccountArgs
+	Password string `pulumi:"password" provider:"secret"`
+}
+
+func (*Account) Create(ctx context.Context, name string, args AccountArgs, preview bool) (string, AccountState, error) {
+	state := AccountState{AccountArgs: args}
+	if !preview {
+		state.Password = "generated-" + args.Name
+	}
+	return args.Name, state, nil
+}
+
+func TestSecretGeneratedOutput(t *testing.T) {
+	t.Parallel()
+
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[*Account, AccountArgs, AccountState]()},
+	}))
+	urn := resource.NewURN("stack", "proj", "", "test:tests:Account", "a")
+	inputs := resource.PropertyMap{"name": resource.NewStringProperty("alice")}
+	state := resource.PropertyMap{
+		"name":     resource.NewStringProperty("alice"),
+		"password": resource.MakeSecret(resource.NewStringProperty("generated-alice")),
+	}
+
+	integration.LifeCycleTest{
+		Resource: "test:tests:Account",
+		Create: integration.Operation{
+			Inputs:         inputs,
+			ExpectedOutput: state,
+		},
+	}.Run(t, prov)
+
+	t.Run("preview", func(t *testing.T) {
+		t.Parallel()
+		resp, err := prov.Create(p.CreateRequest{Urn: urn, Properties: inputs.Copy(), Preview: true})
+		require.NoError(t, err)
+		password := resp.Properties["password"]
+		assert.True(t, password.ContainsSecrets(), "password should be secret, got %v", password)
+		assert.True(t, password.ContainsUnknowns(), "password should be unknown, got %v", password)
+	})
+
+	// The schema has no list of secret outputs. SDK generators derive the
+	// additionalSecretOutputs of a resource from its secret output properties.
+	t.Run("schema", func(t *testing.T) {
+		t.Parallel()
+		resp, err := prov.GetSchema(p.GetSchemaRequest{})
+		require.NoError(t, err)
+		var spec pschema.PackageSpec
+		require.NoError(t, json.Unmarshal([]byte(resp.Schema), &spec))
+		account := spec.Resources["test:tests:Account"]
+		assert.True(t, account.Properties["password"].Secret)
+		assert.False(t, account.Properties["name"].Secret)
+	})
+
+	t.Run("read", func(t *testing.T) {
+		t.Parallel()
+		resp, err := prov.Read(p.ReadRequest{
+			Urn: urn,
+			ID:  "alice",
+			Properties: resource.PropertyMap{
+				"name":     resource.NewStringProperty("alice"),
+				"password": resource.NewStringProperty("generated-alice"),
+			},
+			Inputs: inputs.Copy(),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, state, resp.Properties)
+	})
+}
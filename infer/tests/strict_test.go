@@ -0,0 +1,175 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
+)
+
+type Memo struct{}
+
+type MemoArgs struct {
+	Text   string     `pulumi:"text"`
+	Author MemoAuthor `pulumi:"author"`
+}
+
+type MemoAuthor struct {
+	Name  string  `pulumi:"name"`
+	Email *string `pulumi:"email,optional"`
+}
+
+func (m *Memo) Annotate(a infer.Annotator) {
+	a.Describe(m, "A short note.")
+}
+
+func (m *MemoArgs) Annotate(a infer.Annotator) {
+	a.Describe(&m.Text, "The text of the memo.")
+	a.Describe(&m.Author, "Who wrote the memo.")
+}
+
+func (m *MemoAuthor) Annotate(a infer.Annotator) {
+	a.Describe(&m.Name, "The name of the author.")
+	a.Describe(&m.Email, "The email address of the author.")
+}
+
+func (*Memo) Create(ctx context.Context, name string, args MemoArgs, preview bool) (string, MemoArgs, error) {
+	return name, args, nil
+}
+
+type Scrap struct{}
+
+type ScrapArgs struct {
+	Text string     `pulumi:"text"`
+	Tags []ScrapTag `pulumi:"tags,optional"`
+}
+
+type ScrapTag struct {
+	Label string `pulumi:"label"`
+}
+
+func (*Scrap) Create(ctx context.Context, name string, args ScrapArgs, preview bool) (string, ScrapArgs, error) {
+	return name, args, nil
+}
+
+func TestStrictSchema(t *testing.T) {
+	t.Parallel()
+
+	getSchema := func(strict bool, resources ...infer.InferredResource) error {
+		prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+			Resources: resources,
+			Strict:    strict,
+		}))
+		_, err := prov.GetSchema(p.GetSchemaRequest{})
+		return err
+	}
+
+	t.Run("undocumented", func(t *testing.T) {
+		t.Parallel()
+		err := getSchema(true,
+			infer.Resource[*Memo, MemoArgs, MemoArgs](),
+			infer.Resource[*Scrap, ScrapArgs, ScrapArgs]())
+		require.Error(t, err)
+		var merr *multierror.Error
+		require.ErrorAs(t, err, &merr)
+		var messages []string
+		for _, err := range merr.Errors {
+			messages = append(messages, err.Error())
+		}
+		assert.Equal(t, []string{
+			`resource "test:tests:Scrap" has no description`,
+			`property "tags" of resource "test:tests:Scrap" has no description`,
+			`property "text" of resource "test:tests:Scrap" has no description`,
+			`property "label" of type "test:tests:ScrapTag" has no description`,
+		}, messages)
+	})
+
+	t.Run("documented", func(t *testing.T) {
+		t.Parallel()
+		assert.NoError(t, getSchema(true, infer.Resource[*Memo, MemoArgs, MemoArgs]()))
+	})
+
+	t.Run("not-strict", func(t *testing.T) {
+		t.Parallel()
+		assert.NoError(t, getSchema(false, infer.Resource[*Scrap, ScrapArgs, ScrapArgs]()))
+	})
+}
+
+// Gauge declares an optional int that is not a pointer, so an unset limit reads as 0.
+type Gauge struct{}
+
+type GaugeArgs struct {
+	Limit int     `pulumi:"limit,optional"`
+	Unit  *string `pulumi:"unit,optional"`
+}
+
+func (*Gauge) Create(ctx context.Context, name string, args GaugeArgs, preview bool) (string, GaugeArgs, error) {
+	return name, args, nil
+}
+
+func TestOptionalValues(t *testing.T) {
+	t.Parallel()
+
+	const warning = "optional field tests.GaugeArgs.Limit has type int, so an unset value " +
+		"can't be told apart from 0: use *int instead"
+	opts := func(check, strict bool) infer.Options {
+		return infer.Options{
+			Resources:           []infer.InferredResource{infer.Resource[*Gauge, GaugeArgs, GaugeArgs]()},
+			CheckOptionalValues: check,
+			Strict:              strict,
+		}
+	}
+
+	t.Run("unchecked", func(t *testing.T) {
+		t.Parallel()
+		prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(opts(false, false)))
+		require.NoError(t, prov.Configure(p.ConfigureRequest{}))
+		assert.Empty(t, prov.Logs())
+		_, err := prov.GetSchema(p.GetSchemaRequest{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("warning", func(t *testing.T) {
+		t.Parallel()
+		prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(opts(true, false)))
+		require.NoError(t, prov.Configure(p.ConfigureRequest{}))
+		require.NoError(t, prov.Configure(p.ConfigureRequest{}))
+		assert.Equal(t, []integration.LogMessage{
+			{Severity: "warning", Message: warning},
+		}, prov.Logs())
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		t.Parallel()
+		// The provider starts, and only fails to describe itself.
+		require.NoError(t, opts(true, true).Validate())
+		prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(opts(true, true)))
+		_, err := prov.GetSchema(p.GetSchemaRequest{})
+		var merr *multierror.Error
+		require.ErrorAs(t, err, &merr)
+		assert.Contains(t, merr.Errors, errors.New(warning))
+		assert.Contains(t, merr.Errors, errors.New(`resource "test:tests:Gauge" has no description`))
+	})
+}
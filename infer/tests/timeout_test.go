@@ -0,0 +1,105 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
+)
+
+// Slow allocates an ID and then waits until it is cancelled.
+type Slow struct{}
+
+func (*Slow) Annotate(a infer.Annotator) {
+	a.SetCreateTimeout(10 * time.Millisecond)
+}
+
+type SlowArgs struct {
+	// If set, Slow times out before allocating an ID.
+	Early bool `pulumi:"early,optional"`
+}
+
+type SlowState struct {
+	SlowArgs
+	Phase string `pulumi:"phase"`
+}
+
+func (*Slow) Create(ctx context.Context, name string, inputs SlowArgs, preview bool) (string, SlowState, error) {
+	if inputs.Early {
+		<-ctx.Done()
+		return "", SlowState{}, ctx.Err()
+	}
+	state := SlowState{SlowArgs: inputs, Phase: "allocated"}
+	<-ctx.Done()
+	return "slow-id", state, ctx.Err()
+}
+
+func TestCreateTimeout(t *testing.T) {
+	t.Parallel()
+
+	prov := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[*Slow, SlowArgs, SlowState]()},
+	}))
+	urn := resource.NewURN("stack", "proj", "", "test:tests:Slow", "slow")
+
+	t.Run("partial", func(t *testing.T) {
+		t.Parallel()
+		resp, err := prov.Create(p.CreateRequest{
+			Urn:        urn,
+			Properties: resource.PropertyMap{},
+		})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, "slow-id", resp.ID)
+		assert.Equal(t, resource.PropertyMap{
+			"early": resource.NewBoolProperty(false),
+			"phase": resource.NewStringProperty("allocated"),
+		}, resp.Properties)
+		require.NotNil(t, resp.PartialState)
+		assert.Equal(t, []string{"timed out while creating the resource"}, resp.PartialState.Reasons)
+	})
+
+	t.Run("before-id", func(t *testing.T) {
+		t.Parallel()
+		resp, err := prov.Create(p.CreateRequest{
+			Urn:        urn,
+			Properties: resource.PropertyMap{"early": resource.NewBoolProperty(true)},
+		})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Nil(t, resp.PartialState)
+	})
+
+	t.Run("requested-timeout", func(t *testing.T) {
+		t.Parallel()
+		start := time.Now()
+		_, err := prov.Create(p.CreateRequest{
+			Urn:        urn,
+			Properties: resource.PropertyMap{"early": resource.NewBoolProperty(true)},
+			Timeout:    0.2,
+		})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		// The timeout requested by the engine wins over the resource default.
+		assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+	})
+}
@@ -16,9 +16,12 @@ package tests
 
 import (
 	"context"
+	"encoding/json"
+	"reflect"
 	"testing"
 
 	"github.com/blang/semver"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 	"github.com/stretchr/testify/assert"
@@ -27,6 +30,7 @@ import (
 	p "github.com/pulumi/pulumi-go-provider"
 	"github.com/pulumi/pulumi-go-provider/infer"
 	"github.com/pulumi/pulumi-go-provider/integration"
+	"github.com/pulumi/pulumi-go-provider/middleware/schema"
 )
 
 type CustomToken struct{}
@@ -197,3 +201,230 @@ func TestTokens(t *testing.T) {
   }
 }`, schema.Schema)
 }
+
+func TestLanguageModuleNames(t *testing.T) {
+	t.Parallel()
+
+	provider := infer.Provider(infer.Options{
+		Metadata: schema.Metadata{
+			LanguageMap: map[string]any{
+				"csharp": map[string]any{
+					"namespaces":           map[string]string{"index": "Provider"},
+					"respectSchemaVersion": true,
+				},
+			},
+			LanguageModuleNames: map[tokens.ModuleName]map[string]string{
+				"compute": {"csharp": "Compute", "python": "compute"},
+			},
+		},
+		Resources: []infer.InferredResource{
+			infer.Resource[*CustomToken, TokenArgs, TokenResult](),
+		},
+		ModuleMap: map[tokens.ModuleName]tokens.ModuleName{"overwritten": "compute"},
+	})
+	server := integration.NewServer("test", semver.MustParse("1.0.0"), provider)
+
+	resp, err := server.GetSchema(p.GetSchemaRequest{})
+	require.NoError(t, err)
+
+	var spec struct {
+		Resources map[string]json.RawMessage `json:"resources"`
+		Language  map[string]json.RawMessage `json:"language"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(resp.Schema), &spec))
+
+	assert.Contains(t, spec.Resources, "test:compute:Tk")
+	assert.JSONEq(t, `{
+  "namespaces": {"index": "Provider", "compute": "Compute"},
+  "respectSchemaVersion": true
+}`, string(spec.Language["csharp"]))
+	assert.JSONEq(t, `{"moduleNameOverrides": {"compute": "compute"}}`, string(spec.Language["python"]))
+}
+
+func TestLanguageModuleNamesUnsupportedLanguage(t *testing.T) {
+	t.Parallel()
+
+	provider := infer.Provider(infer.Options{
+		Metadata: schema.Metadata{
+			LanguageModuleNames: map[tokens.ModuleName]map[string]string{
+				"compute": {"cobol": "COMPUTE"},
+			},
+		},
+		Resources: []infer.InferredResource{
+			infer.Resource[*CustomToken, TokenArgs, TokenResult](),
+		},
+	})
+	server := integration.NewServer("test", semver.MustParse("1.0.0"), provider)
+
+	_, err := server.GetSchema(p.GetSchemaRequest{})
+	assert.ErrorContains(t, err, `unsupported language "cobol"`)
+}
+
+// gadget has no token of its own, so its token is derived by the provider's
+// [infer.TokenStrategy].
+type gadget struct{}
+
+type gadgetArgs struct {
+	Spec  gadgetSpec  `pulumi:"spec"`
+	Owner ObjectToken `pulumi:"owner"`
+}
+
+type gadgetSpec struct {
+	Size int `pulumi:"size"`
+}
+
+func (*gadget) Create(ctx context.Context, name string, args gadgetArgs, preview bool) (string, gadgetArgs, error) {
+	return name, args, nil
+}
+
+type getGadget struct{}
+
+func (*getGadget) Call(ctx context.Context, args gadgetSpec) (gadgetSpec, error) {
+	return args, nil
+}
+
+func TestTokenStrategy(t *testing.T) {
+	t.Parallel()
+
+	server := func(strategy infer.TokenStrategy) integration.Server {
+		return integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+			Resources: []infer.InferredResource{
+				infer.Resource[*gadget, gadgetArgs, gadgetArgs](),
+				infer.Resource[*CustomToken, TokenArgs, TokenResult](),
+			},
+			Functions: []infer.InferredFunction{
+				infer.Function[*getGadget, gadgetSpec, gadgetSpec](),
+			},
+			TokenStrategy: strategy,
+		}))
+	}
+
+	type spec struct {
+		Resources map[string]struct {
+			InputProperties map[string]struct {
+				Ref string `json:"$ref"`
+			} `json:"inputProperties"`
+		} `json:"resources"`
+		Functions map[string]json.RawMessage `json:"functions"`
+		Types     map[string]json.RawMessage `json:"types"`
+	}
+	getSchema := func(t *testing.T, s integration.Server) spec {
+		resp, err := s.GetSchema(p.GetSchemaRequest{})
+		require.NoError(t, err)
+		var spec spec
+		require.NoError(t, json.Unmarshal([]byte(resp.Schema), &spec))
+		return spec
+	}
+
+	t.Run("pascal case", func(t *testing.T) {
+		t.Parallel()
+		s := server(infer.PascalCaseTokenStrategy)
+		spec := getSchema(t, s)
+
+		// Explicit tokens set with SetToken win over the strategy.
+		assert.Equal(t, []string{"test:overwritten:Tk", "test:tests:Gadget"}, sortedKeys(spec.Resources))
+		assert.Equal(t, []string{"test:tests:getGadget"}, sortedKeys(spec.Functions))
+		assert.Equal(t, []string{"test:obj:Customized", "test:tests:GadgetSpec"}, sortedKeys(spec.Types))
+		inputs := spec.Resources["test:tests:Gadget"].InputProperties
+		assert.Equal(t, "#/types/test:tests:GadgetSpec", inputs["spec"].Ref)
+		assert.Equal(t, "#/types/test:obj:Customized", inputs["owner"].Ref)
+
+		// Requests are dispatched with the derived tokens.
+		resp, err := s.Create(p.CreateRequest{
+			Urn: resource.NewURN("stack", "proj", "", "test:tests:Gadget", "g"),
+			Properties: resource.PropertyMap{
+				"spec":  resource.NewObjectProperty(resource.PropertyMap{"size": resource.NewNumberProperty(3)}),
+				"owner": resource.NewObjectProperty(resource.PropertyMap{"value": resource.NewStringProperty("me")}),
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "g", resp.ID)
+
+		_, err = s.Invoke(p.InvokeRequest{
+			Token: "test:tests:getGadget",
+			Args:  resource.PropertyMap{"size": resource.NewNumberProperty(3)},
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		t.Parallel()
+		spec := getSchema(t, server(func(t reflect.Type) (tokens.ModuleName, tokens.TypeName) {
+			_, name := infer.PascalCaseTokenStrategy(t)
+			return "gadgets", name + "V2"
+		}))
+
+		assert.Equal(t, []string{"test:gadgets:GadgetV2", "test:overwritten:Tk"}, sortedKeys(spec.Resources))
+		assert.Equal(t, []string{"test:gadgets:getGadgetV2"}, sortedKeys(spec.Functions))
+		assert.Equal(t, []string{"test:gadgets:GadgetSpecV2", "test:obj:Customized"}, sortedKeys(spec.Types))
+	})
+
+	t.Run("default", func(t *testing.T) {
+		t.Parallel()
+		spec := getSchema(t, server(nil))
+
+		assert.Equal(t, []string{"test:overwritten:Tk", "test:tests:gadget"}, sortedKeys(spec.Resources))
+		assert.Equal(t, []string{"test:obj:Customized", "test:tests:gadgetSpec"}, sortedKeys(spec.Types))
+	})
+}
+
+// DupA and DupB set the same token, as do ObjectToken and otherObjectToken.
+type DupA struct{}
+
+func (*DupA) Annotate(a infer.Annotator) { a.SetToken("index", "Dup") }
+
+func (*DupA) Create(ctx context.Context, name string, args TokenArgs, preview bool) (string, TokenResult, error) {
+	return name, TokenResult{}, nil
+}
+
+type DupB struct{}
+
+func (*DupB) Annotate(a infer.Annotator) { a.SetToken("index", "Dup") }
+
+func (*DupB) Create(ctx context.Context, name string, args dupArgs, preview bool) (string, dupArgs, error) {
+	return name, args, nil
+}
+
+type dupArgs struct {
+	Object otherObjectToken `pulumi:"object"`
+}
+
+type otherObjectToken struct {
+	Value int `pulumi:"value"`
+}
+
+func (*otherObjectToken) Annotate(a infer.Annotator) { a.SetToken("obj", "Customized") }
+
+func TestDuplicateTokens(t *testing.T) {
+	t.Parallel()
+
+	opts := infer.Options{
+		Resources: []infer.InferredResource{
+			infer.Resource[*DupA, TokenArgs, TokenResult](),
+			infer.Resource[*DupB, dupArgs, dupArgs](),
+		},
+	}
+
+	err := opts.Validate()
+	require.Error(t, err)
+	pkg := "github.com/pulumi/pulumi-go-provider/infer/tests"
+	assert.ErrorContains(t, err, `resource token "index:Dup" is used by more than one type: `+
+		pkg+".DupA, "+pkg+".DupB")
+	assert.ErrorContains(t, err, `type token "obj:Customized" is used by more than one type: `+
+		pkg+".ObjectToken, "+pkg+".otherObjectToken")
+
+	// The provider is still created, but it fails to describe or configure itself.
+	server := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(opts))
+	_, schemaErr := server.GetSchema(p.GetSchemaRequest{})
+	assert.ErrorContains(t, schemaErr, `invalid provider options: `)
+	assert.ErrorContains(t, schemaErr, `resource token "index:Dup" is used by more than one type`)
+	assert.ErrorContains(t, server.Configure(p.ConfigureRequest{}), `resource token "index:Dup"`)
+
+	// A type shared by several resources is not a collision.
+	assert.NoError(t, infer.Options{
+		Resources: []infer.InferredResource{
+			infer.Resource[*DupA, TokenArgs, TokenResult](),
+			infer.Resource[*CustomToken, TokenArgs, TokenResult](),
+		},
+	}.Validate())
+}
@@ -17,6 +17,7 @@ package tests
 import (
 	"context"
 	"encoding/json"
+	"reflect"
 	"testing"
 
 	"github.com/blang/semver"
@@ -74,3 +75,35 @@ func TestOmittingAssetTypes(t *testing.T) {
 	require.Contains(t, spec.Types, "test:tests:RandomType")
 	// That's all - does not contain any asset types.
 }
+
+// BadInput implements pulumi.Input, but has no ToBadOutput method.
+type BadInput struct{}
+
+func (BadInput) ElementType() reflect.Type { return reflect.TypeOf("") }
+
+type HasBadInput struct{}
+
+type HasBadInputArgs struct {
+	Bad BadInput `pulumi:"bad"`
+}
+
+func (*HasBadInput) Create(
+	ctx context.Context, name string, inputs HasBadInputArgs, preview bool,
+) (string, HasBadInputArgs, error) {
+	return name, inputs, nil
+}
+
+func TestInputTypeWithoutOutputMethod(t *testing.T) {
+	t.Parallel()
+
+	server := integration.NewServer("test", semver.MustParse("1.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{
+			infer.Resource[*HasBadInput, HasBadInputArgs, HasBadInputArgs](),
+		},
+	}))
+
+	_, err := server.GetSchema(pgp.GetSchemaRequest{Version: 1})
+	require.ErrorContains(t, err, "invalid type 'tests.BadInput' on 'tests.HasBadInputArgs.Bad': "+
+		"BadInput is an input type, but does not have a ToBadOutput method: "+
+		"add a ToBadOutput method returning a pulumi.Output, or use a type that is not a pulumi.Input")
+}
@@ -0,0 +1,148 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/blang/semver"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pgp "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
+)
+
+type Source interface{ isSource() }
+
+type GitSource struct {
+	Kind string `pulumi:"kind"`
+	URL  string `pulumi:"url"`
+}
+
+func (GitSource) isSource() {}
+
+func (s *GitSource) Annotate(a infer.Annotator) { a.SetDiscriminator(&s.Kind, "git") }
+
+type S3Source struct {
+	Kind   string `pulumi:"kind"`
+	Bucket string `pulumi:"bucket"`
+}
+
+func (*S3Source) isSource() {}
+
+func (s *S3Source) Annotate(a infer.Annotator) { a.SetDiscriminator(&s.Kind, "s3") }
+
+type Build struct{}
+
+type BuildArgs struct {
+	Source Source `pulumi:"source"`
+}
+
+type BuildState struct {
+	BuildArgs
+	Mirror Source `pulumi:"mirror"`
+}
+
+func (*Build) Create(ctx context.Context, name string, inputs BuildArgs, preview bool) (string, BuildState, error) {
+	// Mirror git sources to S3, without setting the discriminator of the mirror.
+	state := BuildState{BuildArgs: inputs, Mirror: inputs.Source}
+	if _, ok := inputs.Source.(GitSource); ok {
+		state.Mirror = &S3Source{Bucket: "mirror"}
+	}
+	return "id", state, nil
+}
+
+func unionProvider() integration.Server {
+	p := infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[*Build, BuildArgs, BuildState]()},
+		Unions:    []infer.InferredUnion{infer.Union[Source](GitSource{}, &S3Source{})},
+	})
+	return integration.NewServer("test", semver.MustParse("1.0.0"), p)
+}
+
+func TestUnionSchema(t *testing.T) {
+	t.Parallel()
+
+	resp, err := unionProvider().GetSchema(pgp.GetSchemaRequest{Version: 1})
+	require.NoError(t, err)
+
+	var spec pschema.PackageSpec
+	require.NoError(t, json.Unmarshal([]byte(resp.Schema), &spec))
+
+	source := spec.Resources["test:tests:Build"].InputProperties["source"]
+	assert.Equal(t, []pschema.TypeSpec{
+		{Ref: "#/types/test:tests:GitSource"},
+		{Ref: "#/types/test:tests:S3Source"},
+	}, source.OneOf)
+	assert.Equal(t, &pschema.DiscriminatorSpec{
+		PropertyName: "kind",
+		Mapping: map[string]string{
+			"git": "#/types/test:tests:GitSource",
+			"s3":  "#/types/test:tests:S3Source",
+		},
+	}, source.Discriminator)
+
+	require.Contains(t, spec.Types, "test:tests:GitSource")
+	require.Contains(t, spec.Types, "test:tests:S3Source")
+	assert.Equal(t, "git", spec.Types["test:tests:GitSource"].Properties["kind"].Const)
+}
+
+func TestUnionCreate(t *testing.T) {
+	t.Parallel()
+
+	resp, err := unionProvider().Create(pgp.CreateRequest{
+		Urn: resource.NewURN("stack", "proj", "", "test:tests:Build", "build"),
+		Properties: resource.PropertyMap{
+			"source": resource.NewObjectProperty(resource.PropertyMap{
+				"kind": resource.NewStringProperty("git"),
+				"url":  resource.NewStringProperty("https://example.com/repo.git"),
+			}),
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, resource.PropertyMap{
+		"source": resource.NewObjectProperty(resource.PropertyMap{
+			"kind": resource.NewStringProperty("git"),
+			"url":  resource.NewStringProperty("https://example.com/repo.git"),
+		}),
+		"mirror": resource.NewObjectProperty(resource.PropertyMap{
+			"kind":   resource.NewStringProperty("s3"),
+			"bucket": resource.NewStringProperty("mirror"),
+		}),
+	}, resp.Properties)
+}
+
+func TestUnionUnknownCase(t *testing.T) {
+	t.Parallel()
+
+	resp, err := unionProvider().Check(pgp.CheckRequest{
+		Urn: resource.NewURN("stack", "proj", "", "test:tests:Build", "build"),
+		News: resource.PropertyMap{
+			"source": resource.NewObjectProperty(resource.PropertyMap{
+				"kind": resource.NewStringProperty("ftp"),
+			}),
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Failures, 1)
+	assert.Equal(t, "source", resp.Failures[0].Property)
+	assert.Contains(t, resp.Failures[0].Reason, `"ftp" is not a valid kind, expected one of ["git" "s3"]`)
+}
@@ -19,6 +19,7 @@ import (
 
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	p "github.com/pulumi/pulumi-go-provider"
 )
@@ -226,3 +227,54 @@ func TestUpdateDefaultDeps(t *testing.T) {
 		)
 	})
 }
+
+func TestUpdatePreview(t *testing.T) {
+	t.Parallel()
+	type m = resource.PropertyMap
+	s := resource.NewStringProperty
+	n := resource.NewNumberProperty
+
+	olds := m{"size": n(10), "zone": s("a"), "resizes": n(0)}
+	news := m{"size": n(20), "zone": s("a")}
+
+	prov := provider()
+	diff, err := prov.Diff(p.DiffRequest{
+		ID:   "preview-vol",
+		Urn:  urn("Volume", "preview"),
+		Olds: olds, News: news,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]p.PropertyDiff{"size": {Kind: p.Update}}, diff.DetailedDiff)
+
+	resp, err := prov.Update(p.UpdateRequest{
+		ID:   "preview-vol",
+		Urn:  urn("Volume", "preview"),
+		Olds: olds, News: news,
+		Preview: true,
+	})
+	require.NoError(t, err)
+	// resizes depends on the changed inputs, so its predicted value is marked unknown.
+	assert.Equal(t, m{"size": n(20), "zone": s("a"), "resizes": resource.MakeComputed(n(1))}, resp.Properties)
+	_, resized := volumeResizes.Load("preview-vol")
+	assert.False(t, resized, "a preview must not resize the volume")
+
+	resp, err = prov.Update(p.UpdateRequest{
+		ID:   "preview-vol",
+		Urn:  urn("Volume", "preview"),
+		Olds: olds, News: news,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, m{"size": n(20), "zone": s("a"), "resizes": n(1)}, resp.Properties)
+	size, resized := volumeResizes.Load("preview-vol")
+	assert.True(t, resized)
+	assert.Equal(t, 20, size)
+
+	// Changing the zone can't be done in place.
+	diff, err = prov.Diff(p.DiffRequest{
+		ID:   "preview-vol",
+		Urn:  urn("Volume", "preview"),
+		Olds: olds, News: m{"size": n(10), "zone": s("b")},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]p.PropertyDiff{"zone": {Kind: p.UpdateReplace}}, diff.DetailedDiff)
+}
@@ -0,0 +1,189 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infer
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+
+	"github.com/pulumi/pulumi-go-provider/internal/introspect"
+)
+
+// TokenStrategy derives the module and name of the token of a resource, component,
+// function or type from its Go type t. The package of the token is the name of the
+// provider.
+//
+// A TokenStrategy is only used for types that don't set their token with
+// [Annotator.SetToken]. The names of functions are then uncapitalized, as they are
+// without a TokenStrategy.
+type TokenStrategy func(t reflect.Type) (tokens.ModuleName, tokens.TypeName)
+
+// DefaultTokenStrategy derives the module from the last element of the package path of t,
+// with "main" becoming "index", and the name from the name of t. A type Widget in package
+// main of the provider "mypkg" has the token "mypkg:index:Widget".
+//
+// This is how tokens are derived when [Options.TokenStrategy] is nil.
+func DefaultTokenStrategy(t reflect.Type) (tokens.ModuleName, tokens.TypeName) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	mod := t.PkgPath()
+	mod = mod[strings.LastIndex(mod, "/")+1:]
+	if mod == "main" {
+		mod = "index"
+	}
+	return tokens.ModuleName(mod), tokens.TypeName(t.Name())
+}
+
+// PascalCaseTokenStrategy derives tokens like [DefaultTokenStrategy], but converts the
+// name of t to PascalCase: a type widget_pool is named "WidgetPool".
+func PascalCaseTokenStrategy(t reflect.Type) (tokens.ModuleName, tokens.TypeName) {
+	mod, name := DefaultTokenStrategy(t)
+	var b strings.Builder
+	upper := true
+	for _, r := range string(name) {
+		switch {
+		case r == '_':
+			upper = true
+		case upper:
+			b.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return mod, tokens.TypeName(b.String())
+}
+
+// SnakeCaseModuleTokenStrategy derives tokens like [DefaultTokenStrategy], but converts
+// the module to snake_case: a type in package "example.com/widgetPools" is in the module
+// "widget_pools".
+func SnakeCaseModuleTokenStrategy(t reflect.Type) (tokens.ModuleName, tokens.TypeName) {
+	mod, name := DefaultTokenStrategy(t)
+	return tokens.ModuleName(snakeCase(string(mod))), name
+}
+
+// snakeCase converts s to snake_case. A word starts at an upper case letter after a lower
+// case letter or a digit, and at the last upper case letter of an acronym: "HTTPServer"
+// becomes "http_server".
+func snakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		switch {
+		case r == '-' || r == '.':
+			b.WriteRune('_')
+		case unicode.IsUpper(r):
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				b.WriteRune('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// derivedTokens records the types whose token was derived from their Go type instead of
+// being set with [Annotator.SetToken], so that [Options.TokenStrategy] can rename them.
+var derivedTokens sync.Map // map[tokens.Type]derivedToken
+
+type derivedToken struct {
+	t         reflect.Type
+	transform func(tokens.Type) tokens.Type
+}
+
+// deriveToken computes the default token of t, recording it for [Options.TokenStrategy].
+func deriveToken(t reflect.Type, transform func(tokens.Type) tokens.Type) (tokens.Type, error) {
+	tk, err := introspect.GetToken("pkg", t)
+	if err != nil {
+		return "", err
+	}
+	if transform != nil {
+		tk = transform(tk)
+	}
+	derivedTokens.Store(tk, derivedToken{t, transform})
+	return tk, nil
+}
+
+// token returns the token tk after o.TokenStrategy is applied. Tokens that were not
+// derived from a Go type are returned as is.
+func (o Options) token(tk tokens.Type) tokens.Type {
+	if o.TokenStrategy == nil {
+		return tk
+	}
+	v, ok := derivedTokens.Load(tk)
+	if !ok {
+		return tk
+	}
+	d := v.(derivedToken)
+	mod, name := o.TokenStrategy(d.t)
+	renamed := tokens.NewTypeToken(tokens.NewModuleToken(tk.Package(), mod), name)
+	if d.transform != nil {
+		renamed = d.transform(renamed)
+	}
+	return renamed
+}
+
+// anonymousStructs names the anonymous struct types of fields, which have no name to
+// derive a token from. It maps each type to the first field it was found in.
+var anonymousStructs sync.Map // map[reflect.Type]anonymousStruct
+
+type anonymousStruct struct {
+	owner reflect.Type
+	field string
+}
+
+// nameAnonymousStruct records that the type of the field f of owner, or the type of the
+// elements it holds, is named after owner and f if it is an anonymous struct. A field
+// Spec of type struct{...} in a type Widget declares the type WidgetSpec, so that nested
+// objects are always described as named types.
+//
+// Nested objects can't be described inline instead: the type of a property in a Pulumi
+// schema has no properties of its own, so every object type is declared in the types of
+// the schema and referenced by its token.
+func nameAnonymousStruct(owner reflect.Type, f reflect.StructField) {
+	t := f.Type
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice ||
+		t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct && t.Name() == "" {
+		anonymousStructs.LoadOrStore(t, anonymousStruct{owner, f.Name})
+	}
+}
+
+// anonymousStructToken returns the token of the anonymous struct t, named after the type
+// and field it was found in by nameAnonymousStruct.
+func anonymousStructToken(
+	t reflect.Type, transform func(tokens.Type) tokens.Type,
+) (tokens.Type, bool, error) {
+	v, ok := anonymousStructs.Load(t)
+	if !ok {
+		return "", false, nil
+	}
+	a := v.(anonymousStruct)
+	owner, err := getTokenOf(a.owner, transform)
+	if err != nil {
+		return "", true, err
+	}
+	return tokens.NewTypeToken(owner.Module(), owner.Name()+tokens.TypeName(a.field)), true, nil
+}
@@ -0,0 +1,36 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnakeCase(t *testing.T) {
+	t.Parallel()
+
+	for input, expected := range map[string]string{
+		"widgets":     "widgets",
+		"widgetPools": "widget_pools",
+		"go-widgets":  "go_widgets",
+		"HTTPServer":  "http_server",
+		"apiV2":       "api_v2",
+		"v2Api":       "v2_api",
+	} {
+		assert.Equal(t, expected, snakeCase(input), input)
+	}
+}
@@ -17,21 +17,29 @@ package infer
 import (
 	"errors"
 	"fmt"
+	"math/big"
 	"reflect"
+	"strings"
+	"time"
 
 	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
 
+	p "github.com/pulumi/pulumi-go-provider"
 	"github.com/pulumi/pulumi-go-provider/infer/types"
 	"github.com/pulumi/pulumi-go-provider/internal/introspect"
+	"github.com/pulumi/pulumi-go-provider/internal/putil"
 	"github.com/pulumi/pulumi-go-provider/middleware/schema"
 )
 
 // EnumKind is the set of allowed underlying values for [Enum].
 type EnumKind interface {
-	~string | ~float64 | ~bool | ~int
+	~string | ~bool |
+		~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64
 }
 
 // Enum is an enum in the Pulumi type system.
@@ -57,6 +65,8 @@ func (EnumValue[T]) isEnumValue() {}
 type enum struct {
 	token  string
 	values []EnumValue[any]
+	// If values are matched regardless of case, as set by [Annotator.SetEnumCaseInsensitive].
+	caseInsensitive bool
 }
 
 // isEnum detects if a type implements Enum[T] without naming T. There is no function to
@@ -119,8 +129,9 @@ func isEnum(t reflect.Type) (enum, bool) {
 	contract.AssertNoErrorf(err, "failed to get token for enum: %s", t)
 
 	return enum{
-		token:  tk.String(),
-		values: values,
+		token:           tk.String(),
+		values:          values,
+		caseInsensitive: getAnnotated(t).EnumCaseInsensitive,
 	}, true
 }
 
@@ -138,15 +149,351 @@ func coerceToBase(v reflect.Value) any {
 		return v.String()
 	case reflect.Bool:
 		return v.Bool()
-	case reflect.Float64:
+	case reflect.Float32, reflect.Float64:
 		return v.Float()
-	case reflect.Int:
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		return int(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(v.Uint())
 	default:
 		panic("Unexpected value")
 	}
 }
 
+// valueCheckFailures returns a check failure for each enum in v whose value is not one of
+// its allowed values, for each value of a type registered with [RegisterScalar] that its
+// validators reject, and for each field that violates a constraint set with
+// [Annotator.SetMinimum], [Annotator.SetMaximum] or [Annotator.SetPattern]. pv is the
+// property value that v was decoded from, and path is its property path. Values that are
+// unknown are not checked.
+func valueCheckFailures(
+	v reflect.Value, pv resource.PropertyValue, path string, tags introspect.TagOptions,
+) []p.CheckFailure {
+	secret, pv := unwrapKnown(pv)
+	if pv.IsComputed() || pv.IsOutput() {
+		return nil
+	}
+	// The elements of a secret are secret too.
+	element := func(pv resource.PropertyValue) resource.PropertyValue {
+		if secret {
+			return putil.MakeSecret(pv)
+		}
+		return pv
+	}
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if s, ok := registeredScalar(v.Type()); ok {
+		return s.checkFailures(v, secret, path)
+	}
+	if e, ok := isEnum(v.Type()); ok {
+		return enumCheckFailures(e, v, secret, "value", path)
+	}
+
+	var failures []p.CheckFailure
+	switch v.Kind() {
+	case reflect.Struct:
+		if !pv.IsObject() {
+			return nil
+		}
+		obj := pv.ObjectValue()
+		annotations := getAnnotated(v.Type())
+		for _, field := range reflect.VisibleFields(v.Type()) {
+			tag, err := introspect.ParseTag(field, tags)
+			if err != nil || tag.Internal {
+				continue
+			}
+			f, err := v.FieldByIndexErr(field.Index)
+			if err != nil {
+				continue
+			}
+			fieldPath := tag.Name
+			if path != "" {
+				fieldPath = path + "." + tag.Name
+			}
+			fieldPV := element(obj[resource.PropertyKey(tag.Name)])
+			if tag.Secret {
+				fieldPV = putil.MakeSecret(fieldPV)
+			}
+			failures = append(failures, constraintCheckFailures(annotations, tag.Name, f, fieldPV, fieldPath)...)
+			failures = append(failures, valueCheckFailures(f, fieldPV, fieldPath, tags)...)
+		}
+	case reflect.Slice, reflect.Array:
+		if !pv.IsArray() {
+			return nil
+		}
+		arr := pv.ArrayValue()
+		for i := 0; i < v.Len() && i < len(arr); i++ {
+			failures = append(failures,
+				valueCheckFailures(v.Index(i), element(arr[i]), fmt.Sprintf("%s[%d]", path, i), tags)...)
+		}
+	case reflect.Map:
+		if !pv.IsObject() {
+			return nil
+		}
+		obj := pv.ObjectValue()
+		keyEnum, keyIsEnum := isEnum(v.Type().Key())
+		iter := v.MapRange()
+		for iter.Next() {
+			k := iter.Key().String()
+			if keyIsEnum {
+				// Property keys are never secret.
+				failures = append(failures,
+					enumCheckFailures(keyEnum, iter.Key(), false, "key", fmt.Sprintf("%s[%q]", path, k))...)
+			}
+			elemPath := fmt.Sprintf("%s[%q]", path, k)
+			failures = append(failures,
+				valueCheckFailures(iter.Value(), element(obj[resource.PropertyKey(k)]), elemPath, tags)...)
+		}
+	}
+	return failures
+}
+
+// enumCheckFailures returns a check failure if v is not one of the allowed values of e.
+// what is the role of v in the failure's reason, such as "value" or "key".
+func enumCheckFailures(e enum, v reflect.Value, secret bool, what, path string) []p.CheckFailure {
+	value := coerceToBase(v)
+	allowed := make([]string, len(e.values))
+	for i, ev := range e.values {
+		if ev.Value == value {
+			return nil
+		}
+		allowed[i] = fmt.Sprintf("%#v", ev.Value)
+	}
+	return []p.CheckFailure{{
+		Property: path,
+		Reason: fmt.Sprintf("%s is not a valid %s for %s, expected one of %s",
+			redact(secret, "%#v", value), what, v.Type().Name(), strings.Join(allowed, ", ")),
+	}}
+}
+
+// constraintCheckFailures returns a check failure for each constraint that annotations
+// sets on the field name which its value v violates. pv is the property value that v was
+// decoded from, and path is its property path. Fields that are missing or unknown are not
+// checked.
+func constraintCheckFailures(
+	annotations introspect.Annotator, name string, v reflect.Value, pv resource.PropertyValue, path string,
+) []p.CheckFailure {
+	minimum, hasMin := annotations.Minimums[name]
+	maximum, hasMax := annotations.Maximums[name]
+	pattern, hasPattern := annotations.Patterns[name]
+	if !hasMin && !hasMax && !hasPattern {
+		return nil
+	}
+	secret, pv := unwrapKnown(pv)
+	if pv.IsNull() || pv.IsComputed() || pv.IsOutput() {
+		return nil
+	}
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	var failures []p.CheckFailure
+	fail := func(format string, a ...any) {
+		failures = append(failures, p.CheckFailure{Property: path, Reason: fmt.Sprintf(format, a...)})
+	}
+	checkRange := func(number float64) {
+		if hasMin && number < minimum {
+			fail("%s is less than the minimum of %v", redact(secret, "%v", number), minimum)
+		}
+		if hasMax && number > maximum {
+			fail("%s is greater than the maximum of %v", redact(secret, "%v", number), maximum)
+		}
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		checkRange(float64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		checkRange(float64(v.Uint()))
+	case reflect.Float32, reflect.Float64:
+		checkRange(v.Float())
+	case reflect.String:
+		if hasPattern && !pattern.MatchString(v.String()) {
+			fail("%s does not match the pattern %q", redact(secret, "%q", v.String()), pattern.String())
+		}
+	}
+	return failures
+}
+
+// unwrapKnown removes the secret and known output wrappers around pv, reporting if any of
+// them were secret.
+func unwrapKnown(pv resource.PropertyValue) (secret bool, _ resource.PropertyValue) {
+	for pv.IsSecret() || pv.IsOutput() && pv.OutputValue().Known {
+		if pv.IsSecret() {
+			secret = true
+			pv = pv.SecretValue().Element
+		} else {
+			secret = secret || pv.OutputValue().Secret
+			pv = pv.OutputValue().Element
+		}
+	}
+	return secret, pv
+}
+
+// redact formats value with format, unless it is secret, so that check failures don't
+// reveal secret values.
+func redact(secret bool, format string, value any) string {
+	if secret {
+		return "[secret]"
+	}
+	return fmt.Sprintf(format, value)
+}
+
+// requiredCheckFailures returns a failure for each required property of t that is missing
+// from pv, including required properties of nested objects. path is the property path of
+// pv.
+func requiredCheckFailures(
+	t reflect.Type, pv resource.PropertyValue, path string, tags introspect.TagOptions,
+) []p.CheckFailure {
+	for pv.IsSecret() || pv.IsOutput() && pv.OutputValue().Known {
+		if pv.IsSecret() {
+			pv = pv.SecretValue().Element
+		} else {
+			pv = pv.OutputValue().Element
+		}
+	}
+	if pv.IsComputed() || pv.IsOutput() {
+		return nil
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var failures []p.CheckFailure
+	switch t.Kind() {
+	case reflect.Struct:
+		if !pv.IsObject() {
+			return nil
+		}
+		obj := pv.ObjectValue()
+		for _, field := range reflect.VisibleFields(t) {
+			tag, err := introspect.ParseTag(field, tags)
+			if err != nil || tag.Internal {
+				continue
+			}
+			fieldPath := tag.Name
+			if path != "" {
+				fieldPath = path + "." + tag.Name
+			}
+			v, ok := obj[resource.PropertyKey(tag.Name)]
+			if !ok || v.IsNull() {
+				if !tag.Optional && !tag.Computed {
+					failures = append(failures, p.CheckFailure{
+						Property: fieldPath,
+						Reason:   fmt.Sprintf("missing required property '%s'", fieldPath),
+					})
+				}
+				continue
+			}
+			failures = append(failures, requiredCheckFailures(field.Type, v, fieldPath, tags)...)
+		}
+	case reflect.Slice, reflect.Array:
+		if !pv.IsArray() {
+			return nil
+		}
+		for i, v := range pv.ArrayValue() {
+			failures = append(failures,
+				requiredCheckFailures(t.Elem(), v, fmt.Sprintf("%s[%d]", path, i), tags)...)
+		}
+	case reflect.Map:
+		if !pv.IsObject() {
+			return nil
+		}
+		obj := pv.ObjectValue()
+		for _, k := range obj.StableKeys() {
+			failures = append(failures,
+				requiredCheckFailures(t.Elem(), obj[k], fmt.Sprintf("%s[%q]", path, k), tags)...)
+		}
+	}
+	return failures
+}
+
+// computedCheckFailures returns a failure for each field of t marked `computed` that is
+// set in inputs. Computed fields are set by the provider, so users cannot set them.
+func computedCheckFailures(t reflect.Type, inputs resource.PropertyMap, tags introspect.TagOptions) []p.CheckFailure {
+	props, err := introspect.FindProperties(t, tags)
+	if err != nil {
+		return nil
+	}
+	var failures []p.CheckFailure
+	for _, k := range inputs.StableKeys() {
+		if tag, ok := props[string(k)]; ok && tag.Computed && !inputs[k].IsNull() {
+			failures = append(failures, p.CheckFailure{
+				Property: string(k),
+				Reason:   fmt.Sprintf("'%s' is computed by the provider and cannot be set", k),
+			})
+		}
+	}
+	return failures
+}
+
+// withoutComputedInputs returns inputs without the fields of t marked `computed`.
+func withoutComputedInputs(
+	t reflect.Type, inputs resource.PropertyMap, tags introspect.TagOptions,
+) resource.PropertyMap {
+	props, err := introspect.FindProperties(t, tags)
+	if err != nil {
+		return inputs
+	}
+	for name, tag := range props {
+		if _, ok := inputs[resource.PropertyKey(name)]; ok && tag.Computed {
+			inputs = inputs.Copy()
+			delete(inputs, resource.PropertyKey(name))
+		}
+	}
+	return inputs
+}
+
+// withRequiredCheckFailures replaces the failures reported by the mapper for missing
+// required properties of t with the failures of [requiredCheckFailures], which point at
+// the missing property itself instead of its closest ancestor.
+func withRequiredCheckFailures(
+	t reflect.Type, inputs resource.PropertyMap, failures []p.CheckFailure, tags introspect.TagOptions,
+) []p.CheckFailure {
+	if len(failures) == 0 {
+		return failures
+	}
+	required := requiredCheckFailures(t, resource.NewObjectProperty(inputs), "", tags)
+	if len(required) == 0 {
+		return failures
+	}
+	contains := func(ancestor, path string) bool {
+		return path == ancestor ||
+			strings.HasPrefix(path, ancestor+".") || strings.HasPrefix(path, ancestor+"[")
+	}
+
+	used := make([]bool, len(required))
+	result := make([]p.CheckFailure, 0, len(failures))
+	for _, f := range failures {
+		replaced := false
+		for i, r := range required {
+			if !contains(f.Property, r.Property) {
+				continue
+			}
+			replaced = true
+			if !used[i] {
+				used[i] = true
+				result = append(result, r)
+			}
+		}
+		if !replaced {
+			result = append(result, f)
+		}
+	}
+	for i, r := range required {
+		if !used[i] {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
 type Crawler func(
 	t reflect.Type, isReference bool,
 	fieldInfo *introspect.FieldTag,
@@ -154,14 +501,14 @@ type Crawler func(
 ) (drill bool, err error)
 
 // crawlTypes recursively crawls T, calling the crawler on each new type it finds.
-func crawlTypes[T any](crawler Crawler) error {
+func crawlTypes[T any](tags introspect.TagOptions, crawler Crawler) error {
 	var i T
 	t := reflect.TypeOf(i)
 
 	// Prohibit top-level "id" or "urn" fields.
 	if t.Kind() == reflect.Struct {
 		for _, f := range reflect.VisibleFields(t) {
-			info, err := introspect.ParseTag(f)
+			info, err := introspect.ParseTag(f, tags)
 			if err != nil {
 				continue
 			}
@@ -171,6 +518,10 @@ func crawlTypes[T any](crawler Crawler) error {
 		}
 	}
 
+	// visited holds the struct types that have already been drilled into, so recursive
+	// types don't recurse forever. Fields of a visited type are serialized as references.
+	visited := map[reflect.Type]struct{}{}
+
 	// Drill will walk the types, calling crawl on types it finds.
 	var drill func(reflect.Type, bool, *introspect.FieldTag) error
 	drill = func(t reflect.Type, isReference bool, fieldInfo *introspect.FieldTag) error {
@@ -182,18 +533,40 @@ func crawlTypes[T any](crawler Crawler) error {
 			t = nT
 		}
 		switch t.Kind() {
-		case reflect.String, reflect.Float64, reflect.Int, reflect.Bool:
+		case reflect.String, reflect.Bool,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
 			// Primitive types could be enums
 			_, err := crawler(t, isReference, fieldInfo, "", "")
 			return err
 		case reflect.Pointer, reflect.Array, reflect.Map, reflect.Slice:
 			// Holds a reference to other types
 			return drill(t.Elem(), false, fieldInfo)
+		case reflect.Interface:
+			u, ok := introspect.GetUnion(t)
+			if !ok {
+				return nil
+			}
+			// A union holds a reference to each of its cases
+			var errs []error
+			for _, v := range u.Values() {
+				further, err := crawler(u.Cases[v], true, fieldInfo, "", "")
+				if err == nil && further {
+					err = drill(u.Cases[v], true, fieldInfo)
+				}
+				errs = append(errs, err)
+			}
+			return errors.Join(errs...)
 		case reflect.Struct:
+			if _, ok := visited[t]; ok {
+				return nil
+			}
+			visited[t] = struct{}{}
 			var errs []error
 		field:
 			for _, f := range reflect.VisibleFields(t) {
-				info, err := introspect.ParseTag(f)
+				info, err := introspect.ParseTag(f, tags)
 				if err != nil {
 					return err
 				}
@@ -201,20 +574,26 @@ func crawlTypes[T any](crawler Crawler) error {
 				if info.Internal || (info.ExplicitRef != nil && info.ExplicitRef.Pkg != "") {
 					continue
 				}
+				nameAnonymousStruct(t, f)
 
 				fieldIsReference := false
 
 				typ := f.Type
 				for done := false; !done; {
-					switch typ.Kind() {
-					case reflect.Pointer, reflect.Array, reflect.Map, reflect.Slice:
+					_, marshaled := propertyMarshaler(typ)
+					switch {
+					case marshaled:
+						// A PropertyMarshaler is not made of the types it holds.
+						done = true
+					case typ.Kind() == reflect.Pointer, typ.Kind() == reflect.Array,
+						typ.Kind() == reflect.Map, typ.Kind() == reflect.Slice:
 						// Could hold a reference to other types
 						typ = typ.Elem()
 						fieldIsReference = true
 					default:
 						nT, inputty, err := underlyingType(typ)
 						if err != nil {
-							errs = append(errs, err)
+							errs = append(errs, fmt.Errorf("invalid type '%s' on '%s.%s': %w", f.Type, t, f.Name, err))
 							continue field
 						}
 						if inputty {
@@ -245,8 +624,30 @@ func crawlTypes[T any](crawler Crawler) error {
 	return drill(t, false, nil)
 }
 
+// isBuiltinType reports whether t is serialized without a type of its own in the schema.
+func isBuiltinType(t reflect.Type) bool {
+	switch t {
+	// The pulumi/pulumi core Asset types are defined there, don't repeat them here.
+	case reflect.TypeOf(resource.Asset{}), reflect.TypeOf(resource.Archive{}):
+		return true
+	// AssetOrArchive is only for provider authors and shouldn't be in the provider schema.
+	case reflect.TypeOf(types.AssetOrArchive{}):
+		return true
+	// time.Time is serialized as a string, so it has no object type.
+	case reflect.TypeOf(time.Time{}):
+		return true
+	// big.Int and big.Float are serialized as decimal strings.
+	case reflect.TypeOf(big.Int{}), reflect.TypeOf(big.Float{}):
+		return true
+	default:
+		// A PropertyMarshaler declares its own type.
+		_, ok := propertyMarshaler(t)
+		return ok
+	}
+}
+
 // registerTypes recursively examines fields of T, calling reg on the schematized type when appropriate.
-func registerTypes[T any](reg schema.RegisterDerivativeType) error {
+func registerTypes[T any](reg schema.RegisterDerivativeType, tags introspect.TagOptions) error {
 	crawler := func(
 		t reflect.Type, isReference bool, info *introspect.FieldTag,
 		parent, field string,
@@ -256,12 +657,7 @@ func registerTypes[T any](reg schema.RegisterDerivativeType) error {
 		} else if inputty {
 			t = nT
 		}
-		// The pulumi/pulumi core Asset types are defined there, don't repeat them here.
-		if t == reflect.TypeOf(resource.Asset{}) || t == reflect.TypeOf(resource.Archive{}) {
-			return false, nil
-		}
-		// AssetOrArchive is only for provider authors and shouldn't be in the provider schema.
-		if t == reflect.TypeOf(types.AssetOrArchive{}) {
+		if isBuiltinType(t) {
 			return false, nil
 		}
 		if enum, ok := isEnum(t); ok {
@@ -291,7 +687,7 @@ func registerTypes[T any](reg schema.RegisterDerivativeType) error {
 			return false, err
 		}
 		if t.Kind() == reflect.Struct {
-			spec, err := objectSchema(t)
+			spec, err := objectSchema(t, tags)
 			if err != nil {
 				return false, err
 			}
@@ -313,7 +709,7 @@ func registerTypes[T any](reg schema.RegisterDerivativeType) error {
 		}
 		return true, nil
 	}
-	return crawlTypes[T](crawler)
+	return crawlTypes[T](tags, crawler)
 }
 
 type optionalNeedsPointerError struct {
@@ -0,0 +1,54 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// ResourceReference is a reference to a resource managed by this provider, where R is the
+// resource's controller type, as passed to infer.Resource or infer.Component.
+//
+// A ResourceReference field is typed as a reference to R in the schema, so it can be used
+// by functions that take or return resources.
+type ResourceReference[R any] struct {
+	// The URN of the referenced resource.
+	URN resource.URN `pulumi:"urn"`
+	// The ID of the referenced resource. ID is empty for component resources, and when
+	// the ID is not yet known.
+	ID string `pulumi:"id,optional"`
+}
+
+// ResourceType returns the controller type of the referenced resource, R.
+func (ResourceReference[R]) ResourceType() reflect.Type {
+	return reflect.TypeOf((*R)(nil)).Elem()
+}
+
+// Reference returns r as a [resource.ResourceReference].
+func (r ResourceReference[R]) Reference() resource.ResourceReference {
+	ref := resource.ResourceReference{URN: r.URN}
+	if r.ID != "" {
+		ref.ID = resource.NewStringProperty(r.ID)
+	}
+	return ref
+}
+
+// AnyResourceReference is implemented by every [ResourceReference].
+type AnyResourceReference interface {
+	ResourceType() reflect.Type
+	Reference() resource.ResourceReference
+}
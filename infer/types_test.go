@@ -18,10 +18,12 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/pulumi/pulumi-go-provider/internal/introspect"
 	"github.com/pulumi/pulumi-go-provider/middleware/schema"
 	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type MyEnum string
@@ -162,7 +164,7 @@ func TestCrawlTypes(t *testing.T) {
 		m[typ.String()] = spec
 		return true
 	}
-	err := registerTypes[Foo](reg)
+	err := registerTypes[Foo](reg, introspect.TagOptions{})
 	assert.NoError(t, err)
 
 	assert.Equal(t,
@@ -178,7 +180,7 @@ func TestCrawlTypes(t *testing.T) {
 						"other": {
 							TypeSpec: pschema.TypeSpec{
 								Ref: "#/types/pkg:infer:EnumByRef"}}},
-					Required: []string{"other", "foo"}}},
+					Required: []string{"foo", "other"}}},
 			"pkg:infer:EnumByRef": {
 				ObjectTypeSpec: pschema.ObjectTypeSpec{
 					Type: "number"},
@@ -228,13 +230,44 @@ func TestReservedFields(t *testing.T) {
 	reg := func(tokens.Type, pschema.ComplexTypeSpec) bool {
 		return true
 	}
-	err := registerTypes[outer](reg)
+	err := registerTypes[outer](reg, introspect.TagOptions{})
 	assert.NoError(t, err, "id isn't reserved on nested fields")
 
-	err = registerTypes[inner](reg)
+	err = registerTypes[inner](reg, introspect.TagOptions{})
 	assert.ErrorContains(t, err, `"id" is a reserved field name`)
 }
 
+type treeNode struct {
+	Name     string     `pulumi:"name"`
+	Children []treeNode `pulumi:"children,optional"`
+	Rule     *rule      `pulumi:"rule,optional"`
+}
+
+type rule struct {
+	Match string    `pulumi:"match"`
+	Then  *treeNode `pulumi:"then,optional"`
+}
+
+func TestRecursiveTypes(t *testing.T) {
+	t.Parallel()
+
+	m := map[string]pschema.ComplexTypeSpec{}
+	// Always request recursion, so termination depends on registerTypes alone.
+	reg := func(typ tokens.Type, spec pschema.ComplexTypeSpec) bool {
+		m[typ.String()] = spec
+		return true
+	}
+	err := registerTypes[treeNode](reg, introspect.TagOptions{})
+	require.NoError(t, err)
+
+	require.Contains(t, m, "pkg:infer:treeNode")
+	require.Contains(t, m, "pkg:infer:rule")
+	assert.Equal(t, "#/types/pkg:infer:treeNode",
+		m["pkg:infer:treeNode"].Properties["children"].Items.Ref)
+	assert.Equal(t, "#/types/pkg:infer:rule", m["pkg:infer:treeNode"].Properties["rule"].Ref)
+	assert.Equal(t, "#/types/pkg:infer:treeNode", m["pkg:infer:rule"].Properties["then"].Ref)
+}
+
 func noOpRegister() schema.RegisterDerivativeType {
 	m := map[tokens.Type]struct{}{}
 	return func(tk tokens.Type, _ pschema.ComplexTypeSpec) bool {
@@ -247,7 +280,7 @@ func noOpRegister() schema.RegisterDerivativeType {
 func registerOk[T any]() func(t *testing.T) {
 	return func(t *testing.T) {
 		t.Parallel()
-		err := registerTypes[T](noOpRegister())
+		err := registerTypes[T](noOpRegister(), introspect.TagOptions{})
 		assert.NoError(t, err)
 	}
 }
@@ -268,7 +301,7 @@ func TestInvalidOptionalProperty(t *testing.T) {
 
 	t.Run("invalid optional enum", func(t *testing.T) {
 		t.Parallel()
-		err := registerTypes[invalidContainsOptionalEnum](noOpRegister())
+		err := registerTypes[invalidContainsOptionalEnum](noOpRegister(), introspect.TagOptions{})
 
 		var actual optionalNeedsPointerError
 		if assert.ErrorAs(t, err, &actual) {
@@ -290,7 +323,7 @@ func TestInvalidOptionalProperty(t *testing.T) {
 
 	t.Run("invalid optional struct", func(t *testing.T) {
 		t.Parallel()
-		err := registerTypes[invalidContainsOptionalStruct](noOpRegister())
+		err := registerTypes[invalidContainsOptionalStruct](noOpRegister(), introspect.TagOptions{})
 
 		var actual optionalNeedsPointerError
 		if assert.ErrorAs(t, err, &actual) {
@@ -318,3 +351,50 @@ func TestInvalidOptionalProperty(t *testing.T) {
 		Arr []testInner `pulumi:"arr,optional"`
 	}]())
 }
+
+type shape interface{ isShape() }
+
+type circle struct {
+	Kind   string  `pulumi:"kind"`
+	Radius float64 `pulumi:"radius"`
+}
+
+func (circle) isShape() {}
+
+func (c *circle) Annotate(a Annotator) { a.SetDiscriminator(&c.Kind, "circle") }
+
+type square struct {
+	Type string  `pulumi:"type"`
+	Side float64 `pulumi:"side"`
+}
+
+func (square) isShape() {}
+
+func (s *square) Annotate(a Annotator) { a.SetDiscriminator(&s.Type, "square") }
+
+type point struct{}
+
+func (point) isShape() {}
+
+func TestUnionValidation(t *testing.T) {
+	t.Parallel()
+
+	assert.PanicsWithValue(t, "Union[infer.circle]: infer.circle is not an interface", func() {
+		Union[circle](circle{}, circle{})
+	})
+	assert.PanicsWithValue(t, "Union[infer.shape]: a union must have at least two cases", func() {
+		Union[shape](circle{})
+	})
+	assert.PanicsWithValue(t, "Union[infer.shape]: case infer.point does not set a discriminator", func() {
+		Union[shape](circle{}, point{})
+	})
+	assert.PanicsWithValue(t,
+		`Union[infer.shape]: case infer.square uses discriminator "type", expected "kind"`, func() {
+			Union[shape](circle{}, square{})
+		})
+	assert.PanicsWithValue(t,
+		`Union[infer.shape]: cases infer.circle and infer.circle have the same discriminator value "circle"`,
+		func() {
+			Union[shape](circle{}, &circle{})
+		})
+}
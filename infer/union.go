@@ -0,0 +1,112 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infer
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/pulumi/pulumi-go-provider/internal/introspect"
+)
+
+// InferredUnion is a union type that can be served by a provider.
+//
+// To create an InferredUnion, call [Union].
+type InferredUnion interface {
+	register()
+}
+
+type derivedUnion struct {
+	iface reflect.Type
+	union introspect.Union
+}
+
+func (u derivedUnion) register() { introspect.RegisterUnion(u.iface, u.union) }
+
+// Union describes T, an interface type, as a union of the struct types of cases.
+//
+// Each case must identify itself by calling [Annotator.SetDiscriminator] on the same
+// property. Fields of type T are then described in the schema as a oneOf of the cases,
+// and are decoded into the case named by the discriminator property.
+//
+// For example:
+//
+//	type Source interface{ isSource() }
+//
+//	type GitSource struct {
+//		Kind string `pulumi:"kind"`
+//		URL  string `pulumi:"url"`
+//	}
+//
+//	func (GitSource) isSource() {}
+//
+//	func (s *GitSource) Annotate(a infer.Annotator) { a.SetDiscriminator(&s.Kind, "git") }
+//
+//	type S3Source struct {
+//		Kind   string `pulumi:"kind"`
+//		Bucket string `pulumi:"bucket"`
+//	}
+//
+//	func (S3Source) isSource() {}
+//
+//	func (s *S3Source) Annotate(a infer.Annotator) { a.SetDiscriminator(&s.Kind, "s3") }
+//
+// The union is then registered with the provider:
+//
+//	infer.Provider(infer.Options{
+//		Resources: []infer.InferredResource{infer.Resource[*Build, BuildArgs, BuildState]()},
+//		Unions:    []infer.InferredUnion{infer.Union[Source](GitSource{}, S3Source{})},
+//	})
+//
+// Union panics if T is not an interface, if there are less than two cases or if the cases
+// don't have distinct values for a shared discriminator.
+func Union[T any](cases ...T) InferredUnion {
+	iface := typeFor[T]()
+	if iface.Kind() != reflect.Interface {
+		panic(fmt.Sprintf("Union[%s]: %s is not an interface", iface, iface))
+	}
+	if len(cases) < 2 {
+		panic(fmt.Sprintf("Union[%s]: a union must have at least two cases", iface))
+	}
+
+	union := introspect.Union{Cases: map[string]reflect.Type{}}
+	for _, c := range cases {
+		t := reflect.TypeOf(c)
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			panic(fmt.Sprintf("Union[%s]: case %s is not a struct", iface, t))
+		}
+
+		annotations := getAnnotated(t)
+		switch {
+		case annotations.Discriminator == "":
+			panic(fmt.Sprintf("Union[%s]: case %s does not set a discriminator", iface, t))
+		case union.Discriminator != "" && union.Discriminator != annotations.Discriminator:
+			panic(fmt.Sprintf("Union[%s]: case %s uses discriminator %q, expected %q",
+				iface, t, annotations.Discriminator, union.Discriminator))
+		}
+		union.Discriminator = annotations.Discriminator
+
+		if other, ok := union.Cases[annotations.DiscriminatorValue]; ok {
+			panic(fmt.Sprintf("Union[%s]: cases %s and %s have the same discriminator value %q",
+				iface, other, t, annotations.DiscriminatorValue))
+		}
+		union.Cases[annotations.DiscriminatorValue] = t
+	}
+
+	return derivedUnion{iface, union}
+}
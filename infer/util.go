@@ -14,7 +14,14 @@
 
 package infer
 
-import "reflect"
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/internal/introspect"
+)
 
 // typeFor returns the [reflect.Type] that represents the type argument T.
 //
@@ -22,3 +29,67 @@ import "reflect"
 func typeFor[T any]() reflect.Type {
 	return reflect.TypeOf((*T)(nil)).Elem()
 }
+
+// maxSafeInteger is the largest integer that can be exactly represented as a JSON
+// number (an IEEE 754 double).
+const maxSafeInteger = 1<<53 - 1
+
+// warnUnsafeIntegers warns the user about each integer in v that cannot be exactly
+// represented as a JSON number.
+//
+// Pulumi transports all numbers as float64, so integers outside of ±(2^53-1) silently
+// lose precision when they are sent to the engine.
+func warnUnsafeIntegers(ctx context.Context, v any) {
+	for _, path := range unsafeIntegerPaths(reflect.ValueOf(v), "", tagOptions(ctx)) {
+		p.GetLogger(ctx).Warningf("%q holds an integer that cannot be represented "+
+			"exactly as a JSON number; its value may lose precision", path)
+	}
+}
+
+// unsafeIntegerPaths returns the property paths of each integer in v that is outside of
+// the range ±(2^53-1).
+func unsafeIntegerPaths(v reflect.Value, path string, tags introspect.TagOptions) []string {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return unsafeIntegerPaths(v.Elem(), path, tags)
+	case reflect.Struct:
+		var paths []string
+		for _, f := range reflect.VisibleFields(v.Type()) {
+			tag, err := introspect.ParseTag(f, tags)
+			if err != nil || tag.Internal {
+				continue
+			}
+			fieldPath := tag.Name
+			if path != "" {
+				fieldPath = path + "." + tag.Name
+			}
+			paths = append(paths, unsafeIntegerPaths(v.FieldByIndex(f.Index), fieldPath, tags)...)
+		}
+		return paths
+	case reflect.Array, reflect.Slice:
+		var paths []string
+		for i := 0; i < v.Len(); i++ {
+			paths = append(paths, unsafeIntegerPaths(v.Index(i), fmt.Sprintf("%s[%d]", path, i), tags)...)
+		}
+		return paths
+	case reflect.Map:
+		var paths []string
+		for iter := v.MapRange(); iter.Next(); {
+			elemPath := fmt.Sprintf("%s[%q]", path, fmt.Sprint(iter.Key().Interface()))
+			paths = append(paths, unsafeIntegerPaths(iter.Value(), elemPath, tags)...)
+		}
+		return paths
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i := v.Int(); i > maxSafeInteger || i < -maxSafeInteger {
+			return []string{path}
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() > maxSafeInteger {
+			return []string{path}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,306 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/internal/introspect"
+)
+
+// tokenKind is the part of the schema a token belongs to. Tokens of different kinds don't
+// collide with each other.
+type tokenKind string
+
+const (
+	resourceToken tokenKind = "resource"
+	functionToken tokenKind = "function"
+	typeToken     tokenKind = "type"
+)
+
+// addToken records that the Go type t has the token tk.
+type addToken func(kind tokenKind, tk tokens.Type, t reflect.Type)
+
+// Validate checks that o describes a consistent provider, reporting each problem it
+// finds. When their options are not valid, [Provider] and [Wrap] return a provider whose
+// GetSchema and Configure fail with the error of Validate, so that a misconfigured
+// provider fails before it serves any resource or function.
+//
+// Validate reports the resources, components, functions and types that resolve to the
+// same token, after [Options.TokenStrategy] and [Options.ModuleMap] are applied, since
+// only one of them would be served and described in the schema.
+func (o Options) Validate() error {
+	used := map[tokenKind]map[tokens.Type]map[reflect.Type]struct{}{}
+	add := func(kind tokenKind, tk tokens.Type, t reflect.Type) {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		tk = o.token(tk)
+		if mod, ok := o.ModuleMap[tk.Module().Name()]; ok {
+			tk = tokens.NewTypeToken(tokens.NewModuleToken(tk.Package(), mod), tk.Name())
+		}
+		if used[kind] == nil {
+			used[kind] = map[tokens.Type]map[reflect.Type]struct{}{}
+		}
+		if used[kind][tk] == nil {
+			used[kind][tk] = map[reflect.Type]struct{}{}
+		}
+		used[kind][tk][t] = struct{}{}
+	}
+
+	o.collectTokens(add)
+
+	var errs multierror.Error
+	for _, kind := range []tokenKind{resourceToken, functionToken, typeToken} {
+		toks := make([]tokens.Type, 0, len(used[kind]))
+		for tk := range used[kind] {
+			toks = append(toks, tk)
+		}
+		sort.Slice(toks, func(i, j int) bool { return toks[i] < toks[j] })
+		for _, tk := range toks {
+			if len(used[kind][tk]) < 2 {
+				continue
+			}
+			names := make([]string, 0, len(used[kind][tk]))
+			for t := range used[kind][tk] {
+				names = append(names, t.PkgPath()+"."+t.Name())
+			}
+			sort.Strings(names)
+			errs.Errors = append(errs.Errors, fmt.Errorf("%s token %q is used by more than one type: %s",
+				kind, tk.Module().Name().String()+tokens.TokenDelimiter+tk.Name().String(), strings.Join(names, ", ")))
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// invalidOptions makes GetSchema and Configure of provider fail with err, the error that
+// [Options.Validate] reported for the options of provider.
+func invalidOptions(provider p.Provider, err error) p.Provider {
+	err = fmt.Errorf("invalid provider options: %w", err)
+	provider.GetSchema = func(context.Context, p.GetSchemaRequest) (p.GetSchemaResponse, error) {
+		return p.GetSchemaResponse{}, err
+	}
+	provider.Configure = func(context.Context, p.ConfigureRequest) error { return err }
+	return provider
+}
+
+// optionalValues describes each optional field of the resources and config of o that is
+// not a pointer, in a stable order.
+func (o Options) optionalValues() []string {
+	seen := map[string]struct{}{}
+	for _, r := range o.Resources {
+		for _, v := range r.optionalValues(o.tagOptions()) {
+			seen[v] = struct{}{}
+		}
+	}
+	if o.Config != nil {
+		for _, v := range o.Config.optionalValues(o.tagOptions()) {
+			seen[v] = struct{}{}
+		}
+	}
+	values := make([]string, 0, len(seen))
+	for v := range seen {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	return values
+}
+
+// anyFields describes the fields of the resources, components, functions and config of o
+// that are described as any, sorted. See [anyFields].
+func (o Options) anyFields() []string {
+	seen := map[string]struct{}{}
+	add := func(fields []string) {
+		for _, f := range fields {
+			seen[f] = struct{}{}
+		}
+	}
+	for _, r := range o.Resources {
+		add(r.anyFields(o.tagOptions()))
+	}
+	for _, c := range o.Components {
+		add(c.anyFields(o.tagOptions()))
+	}
+	for _, f := range o.Functions {
+		add(f.anyFields(o.tagOptions()))
+	}
+	if o.Config != nil {
+		add(o.Config.anyFields(o.tagOptions()))
+	}
+	fields := make([]string, 0, len(seen))
+	for f := range seen {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// logAnyFields logs a debug message for each of fields each time the schema is requested,
+// before calling getSchema.
+func logAnyFields(
+	getSchema func(context.Context, p.GetSchemaRequest) (p.GetSchemaResponse, error), fields []string,
+) func(context.Context, p.GetSchemaRequest) (p.GetSchemaResponse, error) {
+	return func(ctx context.Context, req p.GetSchemaRequest) (p.GetSchemaResponse, error) {
+		for _, f := range fields {
+			p.GetLogger(ctx).Debug(f)
+		}
+		return getSchema(ctx, req)
+	}
+}
+
+// rejectOptionalValues fails each request for the schema with an error for each of values,
+// along with the errors of getSchema, as described by [Options.CheckOptionalValues].
+func rejectOptionalValues(
+	getSchema func(context.Context, p.GetSchemaRequest) (p.GetSchemaResponse, error), values []string,
+) func(context.Context, p.GetSchemaRequest) (p.GetSchemaResponse, error) {
+	return func(ctx context.Context, req p.GetSchemaRequest) (p.GetSchemaResponse, error) {
+		_, err := getSchema(ctx, req)
+		for _, v := range values {
+			err = multierror.Append(err, errors.New(v))
+		}
+		return p.GetSchemaResponse{}, err
+	}
+}
+
+// warnOptionalValues logs warnings the first time the provider is configured, before
+// calling configure.
+func warnOptionalValues(configure func(context.Context, p.ConfigureRequest) error, warnings []string,
+) func(context.Context, p.ConfigureRequest) error {
+	var once sync.Once
+	return func(ctx context.Context, req p.ConfigureRequest) error {
+		once.Do(func() {
+			for _, w := range warnings {
+				p.GetLogger(ctx).Warning(w)
+			}
+		})
+		if configure == nil {
+			return nil
+		}
+		return configure(ctx, req)
+	}
+}
+
+// optionalValues describes each optional field of T, and of the types it refers to, whose
+// type is a scalar rather than a pointer to one.
+func optionalValues[T any](tags introspect.TagOptions) []string {
+	var values []string
+	check := func(t reflect.Type) {
+		for _, f := range reflect.VisibleFields(t) {
+			tag, err := introspect.ParseTag(f, tags)
+			if err != nil || tag.Internal || !tag.Optional || !isScalar(f.Type) {
+				continue
+			}
+			if _, ok := propertyMarshaler(f.Type); ok {
+				continue
+			}
+			values = append(values, fmt.Sprintf("optional field %s.%s has type %s, so an unset value "+
+				"can't be told apart from %#v: use *%s instead", t, f.Name, f.Type,
+				reflect.Zero(f.Type).Interface(), f.Type))
+		}
+	}
+
+	t := typeFor[T]()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	check(t)
+	// Invalid types are reported when the schema is generated, so errors are ignored here.
+	_ = crawlTypes[T](tags, func(t reflect.Type, _ bool, _ *introspect.FieldTag, _, _ string) (bool, error) {
+		if t.Kind() == reflect.Struct {
+			check(t)
+		}
+		return true, nil
+	})
+	return values
+}
+
+// isScalar reports whether values of t are booleans, numbers or strings.
+func isScalar(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
+
+// collectElementToken records the token of the resource, component or function T.
+// collectTokens records the tokens used by each element of o, in the order the schema
+// lists them.
+func (o Options) collectTokens(add addToken) {
+	for _, r := range o.Resources {
+		r.collectTokens(add, o.tagOptions())
+	}
+	for _, c := range o.Components {
+		c.collectTokens(add, o.tagOptions())
+	}
+	for _, f := range o.Functions {
+		f.collectTokens(add, o.tagOptions())
+	}
+	if o.Config != nil {
+		o.Config.collectTokens(add, o.tagOptions())
+	}
+}
+
+func collectElementToken[T any](add addToken, kind tokenKind, transform func(tokens.Type) tokens.Type) {
+	var t T
+	// The errors of tokens that can't be computed are reported when they are served.
+	if tk, err := getToken[T](transform); err == nil {
+		add(kind, tk, reflect.TypeOf(t))
+	}
+}
+
+// collectTypeTokens records the token of each enum and object type that T refers to.
+func collectTypeTokens[T any](add addToken, tags introspect.TagOptions) {
+	// Invalid types are reported when the schema is generated, so errors are ignored here.
+	_ = crawlTypes[T](tags, func(t reflect.Type, _ bool, _ *introspect.FieldTag, _, _ string) (bool, error) {
+		if nT, inputty, err := underlyingType(t); err != nil {
+			return false, err
+		} else if inputty {
+			t = nT
+		}
+		if isBuiltinType(t) {
+			return false, nil
+		}
+		if enum, ok := isEnum(t); ok {
+			add(typeToken, tokens.Type(enum.token), t)
+			return false, nil
+		}
+		if _, ok, _ := resourceReferenceToken(t, nil, true); ok {
+			return false, nil
+		}
+		if t.Kind() == reflect.Struct {
+			if tk, err := getTokenOf(t, nil); err == nil {
+				add(typeToken, tk, t)
+			}
+		}
+		return true, nil
+	})
+}
@@ -20,16 +20,31 @@
 package integration
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/blang/semver"
+	"github.com/hashicorp/hcl/v2"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
 	presource "github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
 	"github.com/stretchr/testify/assert"
 
 	p "github.com/pulumi/pulumi-go-provider"
 	"github.com/pulumi/pulumi-go-provider/internal/key"
+	"github.com/pulumi/pulumi-go-provider/middleware/schema"
 )
 
 type Server interface {
@@ -46,27 +61,127 @@ type Server interface {
 	Update(p.UpdateRequest) (p.UpdateResponse, error)
 	Delete(p.DeleteRequest) error
 	Construct(p.ConstructRequest) (p.ConstructResponse, error)
+	Parameterize(p.ParameterizeRequest) (p.ParameterizeResponse, error)
+
+	// Logs returns the messages that the provider has logged with [p.GetLogger], in the
+	// order they were logged.
+	Logs() []LogMessage
+}
+
+// LogMessage is a message logged by a provider with [p.GetLogger].
+type LogMessage struct {
+	Severity diag.Severity
+	// The URN of the resource the message is associated with, if any.
+	URN     presource.URN
+	Message string
+	// If the message was logged as a status message, such as with [p.Logger.InfoStatus].
+	Status bool
 }
 
-func NewServer(pkg string, version semver.Version, provider p.Provider) Server {
-	return NewServerWithContext(context.Background(), pkg, version, provider)
+func NewServer(pkg string, version semver.Version, provider p.Provider, opts ...ServerOption) Server {
+	return NewServerWithContext(context.Background(), pkg, version, provider, opts...)
 }
 
-func NewServerWithContext(ctx context.Context, pkg string, version semver.Version, provider p.Provider) Server {
-	return &server{p.RunInfo{
+func NewServerWithContext(
+	ctx context.Context, pkg string, version semver.Version, provider p.Provider, opts ...ServerOption,
+) Server {
+	var options serverOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.idGenerator != nil {
+		ctx = context.WithValue(ctx, key.IDGenerator, options.idGenerator)
+	}
+
+	s := &server{p.RunInfo{
 		PackageName: pkg,
 		Version:     version.String(),
-	}, provider.WithDefaults(), ctx}
+	}, provider.WithDefaults(), ctx, new(logSink)}
+	if options.config != nil {
+		s.mustConfigure(options.config)
+	}
+	return s
+}
+
+// A ServerOption customizes a [Server] when it is created.
+type ServerOption func(*serverOptions)
+
+type serverOptions struct {
+	config      presource.PropertyMap
+	idGenerator func() string
+}
+
+// WithConfig configures the provider with config when the server is created, as the
+// engine does before sending any other request. config is checked with CheckConfig and
+// the checked inputs are passed to Configure.
+//
+// The server panics if config fails its check or the provider fails to configure.
+func WithConfig(config presource.PropertyMap) ServerOption {
+	return func(opts *serverOptions) { opts.config = config }
+}
+
+// WithIDGenerator makes [github.com/pulumi/pulumi-go-provider/infer.NewID] return the
+// result of gen for every request sent to the server, so that tests see the same IDs on
+// every run.
+func WithIDGenerator(gen func() string) ServerOption {
+	return func(opts *serverOptions) { opts.idGenerator = gen }
+}
+
+func (s *server) mustConfigure(config presource.PropertyMap) {
+	resp, err := s.CheckConfig(p.CheckRequest{
+		Urn:  presource.NewURN("test", "provider", "", tokens.Type("pulumi:providers:"+s.runInfo.PackageName), "test"),
+		News: config,
+	})
+	if err != nil {
+		panic(fmt.Sprintf("checking provider config: %v", err))
+	}
+	if len(resp.Failures) > 0 {
+		panic(fmt.Sprintf("invalid provider config: %v", resp.Failures))
+	}
+	if err := s.Configure(p.ConfigureRequest{Args: resp.Inputs}); err != nil {
+		panic(fmt.Sprintf("configuring provider: %v", err))
+	}
 }
 
 type server struct {
 	runInfo p.RunInfo
 	p       p.Provider
 	context context.Context
+	logs    *logSink
+}
+
+func (s *server) ctx(urn presource.URN) context.Context {
+	ctx := context.WithValue(s.context, key.Logger, s.logs)
+	if urn != "" {
+		ctx = context.WithValue(ctx, key.URN, urn)
+	}
+	return context.WithValue(ctx, key.RuntimeInfo, s.runInfo)
+}
+
+func (s *server) Logs() []LogMessage {
+	s.logs.m.Lock()
+	defer s.logs.m.Unlock()
+	return slices.Clone(s.logs.messages)
+}
+
+// logSink records the messages logged by the provider, in place of the engine.
+type logSink struct {
+	m        sync.Mutex
+	messages []LogMessage
+}
+
+func (l *logSink) Log(_ context.Context, urn presource.URN, severity diag.Severity, msg string) {
+	l.record(LogMessage{Severity: severity, URN: urn, Message: msg})
 }
 
-func (s *server) ctx(presource.URN) context.Context {
-	return context.WithValue(s.context, key.RuntimeInfo, s.runInfo)
+func (l *logSink) LogStatus(_ context.Context, urn presource.URN, severity diag.Severity, msg string) {
+	l.record(LogMessage{Severity: severity, URN: urn, Message: msg, Status: true})
+}
+
+func (l *logSink) record(msg LogMessage) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	l.messages = append(l.messages, msg)
 }
 
 func (s *server) GetSchema(req p.GetSchemaRequest) (p.GetSchemaResponse, error) {
@@ -90,7 +205,7 @@ func (s *server) Configure(req p.ConfigureRequest) error {
 }
 
 func (s *server) Invoke(req p.InvokeRequest) (p.InvokeResponse, error) {
-	return s.p.Invoke(s.ctx(presource.URN(req.Token)), req)
+	return s.p.Invoke(s.ctx(""), req)
 }
 
 func (s *server) Check(req p.CheckRequest) (p.CheckResponse, error) {
@@ -102,7 +217,8 @@ func (s *server) Diff(req p.DiffRequest) (p.DiffResponse, error) {
 }
 
 func (s *server) Create(req p.CreateRequest) (p.CreateResponse, error) {
-	return s.p.Create(s.ctx(req.Urn), req)
+	ctx := context.WithValue(s.ctx(req.Urn), key.DryRun, req.Preview)
+	return s.p.Create(ctx, req)
 }
 
 func (s *server) Read(req p.ReadRequest) (p.ReadResponse, error) {
@@ -110,7 +226,8 @@ func (s *server) Read(req p.ReadRequest) (p.ReadResponse, error) {
 }
 
 func (s *server) Update(req p.UpdateRequest) (p.UpdateResponse, error) {
-	return s.p.Update(s.ctx(req.Urn), req)
+	ctx := context.WithValue(s.ctx(req.Urn), key.DryRun, req.Preview)
+	return s.p.Update(ctx, req)
 }
 
 func (s *server) Delete(req p.DeleteRequest) error {
@@ -118,12 +235,15 @@ func (s *server) Delete(req p.DeleteRequest) error {
 }
 
 func (s *server) Construct(req p.ConstructRequest) (p.ConstructResponse, error) {
-	return s.p.Construct(s.ctx(req.URN), req)
+	ctx := context.WithValue(s.ctx(req.URN), key.DryRun, req.Preview)
+	return s.p.Construct(ctx, req)
+}
+
+func (s *server) Parameterize(req p.ParameterizeRequest) (p.ParameterizeResponse, error) {
+	return s.p.Parameterize(s.ctx(""), req)
 }
 
 // Operation describes a step in a [LifeCycleTest].
-//
-// TODO: Add support for diff verification.
 type Operation struct {
 	// The inputs for the operation
 	Inputs presource.PropertyMap
@@ -135,6 +255,86 @@ type Operation struct {
 	ExpectFailure bool
 	// If CheckFailures is non-nil, expect the check step to fail with the provided output.
 	CheckFailures []p.CheckFailure
+	// If ExpectedDiff is non-nil, expect the diff step of an update to report these
+	// property diffs. The Kind of each property diff tells if the update will be done in
+	// place or by replacing the resource. A non-nil empty map expects no changes.
+	//
+	// ExpectedDiff is ignored for the initial create.
+	ExpectedDiff map[string]p.PropertyDiff
+}
+
+// ValidateSchema gets the schema of server and returns the diagnostics that Pulumi's schema
+// validator reports for it. See [schema.Validate]. No plugins are loaded, so it fails on
+// references to the types and resources of other packages.
+func ValidateSchema(server Server) (hcl.Diagnostics, error) {
+	resp, err := server.GetSchema(p.GetSchemaRequest{})
+	if err != nil {
+		return nil, err
+	}
+	var spec pschema.PackageSpec
+	if err := json.Unmarshal([]byte(resp.Schema), &spec); err != nil {
+		return nil, err
+	}
+	return schema.Validate(spec, nil)
+}
+
+// acceptEnvVar is the environment variable that accepts the current schema as the golden
+// file of [AssertSchema]. It follows the convention of the Pulumi CLI's own tests.
+const acceptEnvVar = "PULUMI_ACCEPT"
+
+// AssertSchema asserts that the schema of server matches the golden file at path, which is
+// relative to the directory of the test. Running the test with PULUMI_ACCEPT set to a
+// truthy value writes the schema to path instead:
+//
+//	PULUMI_ACCEPT=true go test ./... -run TestSchema
+//
+// The schema is compared as indented JSON with sorted object keys, so that the golden
+// file can be reviewed and its diffs only show changes to the schema.
+func AssertSchema(t *testing.T, server Server, path string) bool {
+	t.Helper()
+	resp, err := server.GetSchema(p.GetSchemaRequest{})
+	if !assert.NoError(t, err, "failed to get the schema") {
+		return false
+	}
+	actual, err := normalizeSchema(resp.Schema)
+	if !assert.NoError(t, err, "the schema is not valid JSON") {
+		return false
+	}
+
+	if cmdutil.IsTruthy(os.Getenv(acceptEnvVar)) {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); !assert.NoError(t, err) {
+			return false
+		}
+		return assert.NoError(t, os.WriteFile(path, actual, 0o600), "failed to update %s", path)
+	}
+
+	expected, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return assert.Fail(t, fmt.Sprintf("%s does not exist: run the test with %s=true to create it", path, acceptEnvVar))
+	}
+	if !assert.NoError(t, err) {
+		return false
+	}
+	return assert.Equal(t, string(expected), string(actual),
+		"the schema does not match %s: run the test with %s=true to update it", path, acceptEnvVar)
+}
+
+// normalizeSchema indents schema with its object keys sorted.
+func normalizeSchema(schema string) ([]byte, error) {
+	d := json.NewDecoder(strings.NewReader(schema))
+	d.UseNumber()
+	var v any
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	e := json.NewEncoder(&b)
+	e.SetEscapeHTML(false)
+	e.SetIndent("", "  ")
+	if err := e.Encode(v); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
 }
 
 // LifeCycleTest describing the lifecycle of a resource test.
@@ -233,6 +433,13 @@ func (l LifeCycleTest) Run(t *testing.T, server Server) {
 		if err != nil {
 			return
 		}
+		if update.ExpectedDiff != nil {
+			if len(update.ExpectedDiff) == 0 {
+				assert.Falsef(t, diff.HasChanges, "expected no changes on update %d", i)
+			} else {
+				assert.Equalf(t, update.ExpectedDiff, diff.DetailedDiff, "diff mismatch on update %d", i)
+			}
+		}
 		if !diff.HasChanges {
 			// We don't have any changes, so we can just do nothing
 			continue
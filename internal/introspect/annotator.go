@@ -15,30 +15,104 @@
 package introspect
 
 import (
+	"context"
 	"fmt"
 	"reflect"
+	"regexp"
+	"time"
 
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
 )
 
 func NewAnnotator(resource any) Annotator {
 	return Annotator{
-		Descriptions: map[string]string{},
-		Defaults:     map[string]any{},
-		DefaultEnvs:  map[string][]string{},
-		matcher:      NewFieldMatcher(resource),
+		Descriptions:     map[string]string{},
+		Defaults:         map[string]any{},
+		DefaultEnvs:      map[string][]string{},
+		DefaultFuncs:     map[string]DefaultFunc{},
+		Formats:          map[string]string{},
+		Deprecations:     map[string]string{},
+		PropertyAliases:  map[string][]string{},
+		Consts:           map[string]any{},
+		Minimums:         map[string]float64{},
+		Maximums:         map[string]float64{},
+		Patterns:         map[string]*regexp.Regexp{},
+		LanguageNames:    map[string]map[string]string{},
+		ReplaceOnChanges: map[string]bool{},
+		AutoNames:        map[string]int{},
+		matcher:          newAnnotatorMatcher(resource),
 	}
 }
 
+// newAnnotatorMatcher returns a FieldMatcher for resource. Types other than structs, such
+// as enums, may be annotated too, but they have no fields to match. Annotations are keyed
+// by field name, which no TagOptions change.
+func newAnnotatorMatcher(resource any) FieldMatcher {
+	v := reflect.ValueOf(resource)
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return FieldMatcher{value: v}
+	}
+	return NewFieldMatcher(resource, TagOptions{})
+}
+
 // Annotator implements the Annotator interface as defined in resource/resource.go.
+//
+// An Annotator is not safe for concurrent use. Each call to Annotate is given a new
+// Annotator, which is only read once Annotate returns.
 type Annotator struct {
 	Descriptions       map[string]string
 	Defaults           map[string]any
 	DefaultEnvs        map[string][]string
+	DefaultFuncs       map[string]DefaultFunc
+	Formats            map[string]string
+	Deprecations       map[string]string
+	PropertyAliases    map[string][]string
+	Consts             map[string]any
+	Minimums           map[string]float64
+	Maximums           map[string]float64
+	Patterns           map[string]*regexp.Regexp
+	LanguageNames      map[string]map[string]string // field -> language -> name
+	ReplaceOnChanges   map[string]bool
+	AutoNames          map[string]int // field -> maximum length
 	Token              string
 	Aliases            []string
 	DeprecationMessage string
 
+	// The name and value of the discriminator property, when the annotated type is a
+	// case of a union.
+	Discriminator      string
+	DiscriminatorValue string
+
+	// Default timeouts for resource operations. A zero value means no timeout.
+	CreateTimeout time.Duration
+	UpdateTimeout time.Duration
+	DeleteTimeout time.Duration
+
+	// If replacements of the resource must delete the old resource before creating the
+	// new one.
+	DeleteBeforeReplace bool
+
+	// If the outputs that the resource sets during a preview are returned as known values.
+	SupportsPartialValues bool
+
+	// If the values of the annotated enum are matched regardless of case.
+	EnumCaseInsensitive bool
+
+	// The name of the property that holds the ID of the resource, when the annotated type
+	// is the output type of a resource.
+	IDField string
+
+	// The format of the IDs that the annotated resource is imported with, such as
+	// "{project}/{zone}/{instance}".
+	ImportIDFormat string
+
+	// The version of the state of the annotated resource. A zero value means that the
+	// state is not versioned.
+	Version int
+
 	matcher FieldMatcher
 }
 
@@ -53,7 +127,9 @@ func (a *Annotator) mustGetField(i any) FieldTag {
 	return field
 }
 
-func (a *Annotator) Describe(i any, description string) {
+// getFieldOrSelf returns the field that i points to. If i points to the annotated struct
+// itself, then isSelf is true.
+func (a *Annotator) getFieldOrSelf(i any) (field FieldTag, isSelf bool) {
 	field, ok, err := a.matcher.GetField(i)
 	if err != nil {
 		panic(fmt.Sprintf("Could not parse field tags: %s", err.Error()))
@@ -73,14 +149,33 @@ func (a *Annotator) Describe(i any, description string) {
 			i = reflect.ValueOf(i).Elem().Interface()
 		}
 		if a.matcher.value.Addr().Interface() == i {
-			a.Descriptions[""] = description
-			return
+			return FieldTag{}, true
 		}
 		panic("Could not annotate field: could not find field")
 	}
+	return field, false
+}
+
+func (a *Annotator) Describe(i any, description string) {
+	field, isSelf := a.getFieldOrSelf(i)
+	if isSelf {
+		a.Descriptions[""] = description
+		return
+	}
 	a.Descriptions[field.Name] = description
 }
 
+// Deprecate marks a struct field as deprecated with message. Deprecating the annotated
+// struct itself sets the deprecation message of the resource.
+func (a *Annotator) Deprecate(i any, message string) {
+	field, isSelf := a.getFieldOrSelf(i)
+	if isSelf {
+		a.DeprecationMessage = message
+		return
+	}
+	a.Deprecations[field.Name] = message
+}
+
 // SetDefault annotates a struct field with a default value. The default value must be a
 // primitive type in the pulumi type system.
 func (a *Annotator) SetDefault(i any, defaultValue any, env ...string) {
@@ -89,6 +184,69 @@ func (a *Annotator) SetDefault(i any, defaultValue any, env ...string) {
 	a.DefaultEnvs[field.Name] = append(a.DefaultEnvs[field.Name], env...)
 }
 
+// DefaultFunc computes the default value of a field from args, the struct that holds it.
+type DefaultFunc func(ctx context.Context, args any) (any, error)
+
+func (a *Annotator) SetDefaultFunc(i any, f func(ctx context.Context, args any) (any, error)) {
+	field := a.mustGetField(i)
+	a.DefaultFuncs[field.Name] = f
+}
+
+// SetFieldFormat annotates a struct field with the format of its serialized value, such
+// as "date-time".
+func (a *Annotator) SetFieldFormat(i any, format string) {
+	field := a.mustGetField(i)
+	a.Formats[field.Name] = format
+}
+
+// SetDiscriminator marks the annotated type as a case of a union, identified when the
+// field i holds value.
+func (a *Annotator) SetDiscriminator(i any, value string) {
+	field := a.mustGetField(i)
+	a.Discriminator = field.Name
+	a.DiscriminatorValue = value
+}
+
+// AddPropertyAlias records alias as a previous name of a struct field.
+func (a *Annotator) AddPropertyAlias(i any, alias string) {
+	field := a.mustGetField(i)
+	a.PropertyAliases[field.Name] = append(a.PropertyAliases[field.Name], alias)
+}
+
+// SetConst annotates a struct field with the only value it may hold.
+func (a *Annotator) SetConst(i any, value any) {
+	field := a.mustGetField(i)
+	a.Consts[field.Name] = value
+}
+
+// SetMinimum annotates a numeric struct field with the smallest value it may hold.
+func (a *Annotator) SetMinimum(i any, minimum float64) {
+	field := a.mustGetField(i)
+	a.Minimums[field.Name] = minimum
+}
+
+// SetMaximum annotates a numeric struct field with the largest value it may hold.
+func (a *Annotator) SetMaximum(i any, maximum float64) {
+	field := a.mustGetField(i)
+	a.Maximums[field.Name] = maximum
+}
+
+// SetPattern annotates a string struct field with a regular expression its value must
+// match. SetPattern panics if pattern is not a valid regular expression.
+func (a *Annotator) SetPattern(i any, pattern string) {
+	field := a.mustGetField(i)
+	a.Patterns[field.Name] = regexp.MustCompile(pattern)
+}
+
+// SetLanguageName overrides the name of a struct field in the SDK generated for language.
+func (a *Annotator) SetLanguageName(i any, language, name string) {
+	field := a.mustGetField(i)
+	if a.LanguageNames[field.Name] == nil {
+		a.LanguageNames[field.Name] = map[string]string{}
+	}
+	a.LanguageNames[field.Name][language] = name
+}
+
 func (a *Annotator) SetToken(module tokens.ModuleName, token tokens.TypeName) {
 	a.Token = formatToken(module, token)
 }
@@ -101,6 +259,62 @@ func (a *Annotator) SetResourceDeprecationMessage(message string) {
 	a.DeprecationMessage = message
 }
 
+func (a *Annotator) SetReplaceOnChanges(i any) {
+	field := a.mustGetField(i)
+	a.ReplaceOnChanges[field.Name] = true
+}
+
+// AutoNameSuffixLen is the length of the random suffix of the names set by AutoName.
+const AutoNameSuffixLen = 7
+
+// AutoName annotates a string struct field to be named after the resource when unset. The
+// names are at most maxLen characters long.
+func (a *Annotator) AutoName(i any, maxLen int) {
+	field := a.mustGetField(i)
+	if maxLen <= AutoNameSuffixLen+1 {
+		panic(fmt.Sprintf("AutoName: the maximum length (%d) of %q must be at least %d",
+			maxLen, field.Name, AutoNameSuffixLen+2))
+	}
+	a.AutoNames[field.Name] = maxLen
+}
+
+func (a *Annotator) SetID(i any) {
+	field := a.mustGetField(i)
+	a.IDField = field.Name
+}
+
+func (a *Annotator) SetImportIDFormat(format string) {
+	if _, err := ParseImportIDFormat(format); err != nil {
+		panic(err.Error())
+	}
+	a.ImportIDFormat = format
+}
+
+func (a *Annotator) SetVersion(version int) {
+	if version < 1 {
+		panic(fmt.Sprintf("Version (%d) must be at least 1", version))
+	}
+	a.Version = version
+}
+
+func (a *Annotator) SetEnumCaseInsensitive(caseInsensitive bool) {
+	a.EnumCaseInsensitive = caseInsensitive
+}
+
+func (a *Annotator) SetCreateTimeout(timeout time.Duration) { a.CreateTimeout = timeout }
+
+func (a *Annotator) SetUpdateTimeout(timeout time.Duration) { a.UpdateTimeout = timeout }
+
+func (a *Annotator) SetDeleteTimeout(timeout time.Duration) { a.DeleteTimeout = timeout }
+
+func (a *Annotator) SetDeleteBeforeReplace(deleteBeforeReplace bool) {
+	a.DeleteBeforeReplace = deleteBeforeReplace
+}
+
+func (a *Annotator) SetSupportsPartialValues(supportsPartialValues bool) {
+	a.SupportsPartialValues = supportsPartialValues
+}
+
 // formatToken formats a (module, token) pair into a valid token string.
 //
 // Panics when module or token are invalid.
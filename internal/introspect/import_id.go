@@ -0,0 +1,90 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package introspect
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ImportIDPart is a part of an import ID format: either literal text, or the name of the
+// property whose value takes its place.
+type ImportIDPart struct {
+	Literal  string
+	Property string
+}
+
+// ParseImportIDFormat parses a format such as "{project}/{zone}/{instance}" into its
+// parts. Properties must be separated by literal text, so that IDs can be split.
+func ParseImportIDFormat(format string) ([]ImportIDPart, error) {
+	var parts []ImportIDPart
+	rest := format
+	for rest != "" {
+		start := strings.IndexByte(rest, '{')
+		if end := strings.IndexByte(rest, '}'); end >= 0 && (start < 0 || end < start) {
+			return nil, fmt.Errorf("import ID format %q has an unmatched '}'", format)
+		}
+		if start < 0 {
+			parts = append(parts, ImportIDPart{Literal: rest})
+			break
+		}
+		if start > 0 {
+			parts = append(parts, ImportIDPart{Literal: rest[:start]})
+		}
+		rest = rest[start+1:]
+		end := strings.IndexByte(rest, '}')
+		if end < 0 {
+			return nil, fmt.Errorf("import ID format %q has an unmatched '{'", format)
+		}
+		name := rest[:end]
+		if name == "" || strings.ContainsRune(name, '{') {
+			return nil, fmt.Errorf("import ID format %q has an invalid property name %q", format, name)
+		}
+		if len(parts) > 0 && parts[len(parts)-1].Property != "" {
+			return nil, fmt.Errorf("import ID format %q must separate {%s} from the property before it",
+				format, name)
+		}
+		parts = append(parts, ImportIDPart{Property: name})
+		rest = rest[end+1:]
+	}
+	return parts, nil
+}
+
+// ParseImportID splits id into the values of the properties of format, as parsed by
+// [ParseImportIDFormat]. Each property takes the text up to the first occurrence of the
+// literal that follows it, and values may not be empty.
+func ParseImportID(format []ImportIDPart, id string) (map[string]string, bool) {
+	values := map[string]string{}
+	rest := id
+	for i, part := range format {
+		if part.Property == "" {
+			var ok bool
+			if rest, ok = strings.CutPrefix(rest, part.Literal); !ok {
+				return nil, false
+			}
+			continue
+		}
+		end := len(rest)
+		if i+1 < len(format) {
+			end = strings.Index(rest, format[i+1].Literal)
+		}
+		if end <= 0 {
+			return nil, false
+		}
+		values[part.Property] = rest[:end]
+		rest = rest[end:]
+	}
+	return values, rest == ""
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/blang/semver"
 	"github.com/hashicorp/go-multierror"
@@ -28,46 +29,18 @@ import (
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
 )
 
-func StructToMap(i any) map[string]interface{} {
-	typ := reflect.TypeOf(i)
-	for typ.Kind() == reflect.Pointer {
-		typ = typ.Elem()
-	}
-
-	contract.Assertf(typ.Kind() == reflect.Struct, "Expected a struct. Instead got %s (%v)", typ.Kind(), i)
-
-	m := map[string]interface{}{}
-	value := reflect.ValueOf(i)
-	for value.Type().Kind() == reflect.Pointer {
-		value = value.Elem()
-	}
-	for _, field := range reflect.VisibleFields(typ) {
-		if !field.IsExported() {
-			continue
-		}
-
-		tag, has := field.Tag.Lookup("pulumi")
-		if !has {
-			continue
-		}
-
-		m[tag] = value.FieldByIndex(field.Index).Interface()
-	}
-	return m
-}
-
 type ToPropertiesOptions struct {
 	ComputedKeys []string
 }
 
-func FindProperties(typ reflect.Type) (map[string]FieldTag, error) {
+func FindProperties(typ reflect.Type, opts TagOptions) (map[string]FieldTag, error) {
 	for typ.Kind() == reflect.Pointer {
 		typ = typ.Elem()
 	}
 	contract.Assertf(typ.Kind() == reflect.Struct, "Expected struct, found %s (%s)", typ.Kind(), typ.String())
 	m := map[string]FieldTag{}
 	for _, f := range reflect.VisibleFields(typ) {
-		info, err := ParseTag(f)
+		info, err := ParseTag(f, opts)
 		if err != nil {
 			return nil, err
 		}
@@ -79,6 +52,13 @@ func FindProperties(typ reflect.Type) (map[string]FieldTag, error) {
 	return m, nil
 }
 
+// TagOptions are the options of a provider that change what the tags of its fields mean.
+type TagOptions struct {
+	// PointersOptional makes pointer fields optional, as if they were tagged
+	// `pulumi:"name,optional"`, unless they are tagged `provider:"required"`.
+	PointersOptional bool
+}
+
 // GetToken calculates the Pulumi token that typ would be projected into.
 func GetToken(pkg tokens.Package, typ reflect.Type) (tokens.Type, error) {
 	if typ == nil {
@@ -110,7 +90,7 @@ func GetToken(pkg tokens.Package, typ reflect.Type) (tokens.Type, error) {
 
 // ParseTag gets tag information out of struct tags. It looks under the `pulumi` and
 // `provider` tag namespaces.
-func ParseTag(field reflect.StructField) (FieldTag, error) {
+func ParseTag(field reflect.StructField, opts TagOptions) (FieldTag, error) {
 	pulumiTag, hasPulumiTag := field.Tag.Lookup("pulumi")
 	providerTag, hasProviderTag := field.Tag.Lookup("provider")
 	if hasProviderTag && !hasPulumiTag {
@@ -160,17 +140,40 @@ func ParseTag(field reflect.StructField) (FieldTag, error) {
 				default:
 					return FieldTag{}, fmt.Errorf(typeErrMsg, extType)
 				}
+				if err := explRef.validate(); err != nil {
+					return FieldTag{}, fmt.Errorf(`invalid "type=" value "%s": %w`, extType, err)
+				}
 				continue
 			}
 			provider[item] = true
 		}
 	}
 
+	if pulumi["optional"] && provider["required"] {
+		return FieldTag{}, fmt.Errorf("a field can't be both `optional` and `required`")
+	}
+
+	if provider["duration"] {
+		typ := field.Type
+		for typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice ||
+			typ.Kind() == reflect.Array || typ.Kind() == reflect.Map {
+			typ = typ.Elem()
+		}
+		if typ != reflect.TypeOf(time.Duration(0)) {
+			return FieldTag{}, fmt.Errorf("`duration` can only be applied to time.Duration fields, found %s", typ)
+		}
+	}
+
+	optionalPointer := opts.PointersOptional && !provider["required"] && field.Type.Kind() == reflect.Pointer
+
 	return FieldTag{
 		Name:             name,
-		Optional:         pulumi["optional"],
+		Optional:         pulumi["optional"] || optionalPointer,
+		Required:         provider["required"],
+		Computed:         pulumi["computed"],
 		Secret:           provider["secret"],
 		ReplaceOnChanges: provider["replaceOnChanges"],
+		Duration:         provider["duration"],
 		ExplicitRef:      explRef,
 	}, nil
 }
@@ -183,17 +186,36 @@ type ExplicitType struct {
 	Name    string
 }
 
+// validate checks that the segments of t form a valid token.
+func (t *ExplicitType) validate() error {
+	if t.Pkg != "" || t.Version != "" {
+		if !tokens.IsName(t.Pkg) {
+			return fmt.Errorf("package %q is not a valid name", t.Pkg)
+		}
+	}
+	if !tokens.IsQName(t.Module) {
+		return fmt.Errorf("module %q is not a valid module name", t.Module)
+	}
+	if !tokens.IsName(t.Name) {
+		return fmt.Errorf("type name %q is not a valid name", t.Name)
+	}
+	return nil
+}
+
 type FieldTag struct {
 	Name        string        // The name of the field in the Pulumi type system.
 	Optional    bool          // If the field is optional in the Pulumi type system.
+	Required    bool          // If the field is required, even when its pointers are optional.
+	Computed    bool          // If the field is an output that users cannot set as an input.
 	Internal    bool          // If the field should exist in the Pulumi type system.
 	Secret      bool          // If the field is secret.
 	ExplicitRef *ExplicitType // The name and version of the external type consumed in the field.
 	// NOTE: ReplaceOnChanges will only be obeyed when the default diff implementation is used.
 	ReplaceOnChanges bool // If changes in the field should force a replacement.
+	Duration         bool // If the field holds time.Durations serialized as duration strings, such as "5m30s".
 }
 
-func NewFieldMatcher(i any) FieldMatcher {
+func NewFieldMatcher(i any, opts TagOptions) FieldMatcher {
 	v := reflect.ValueOf(i)
 	for v.Kind() == reflect.Pointer {
 		v = v.Elem()
@@ -201,23 +223,28 @@ func NewFieldMatcher(i any) FieldMatcher {
 	contract.Assertf(v.Kind() == reflect.Struct, "FieldMatcher must contain a struct, found a %s.", v.Type())
 	return FieldMatcher{
 		value: v,
+		opts:  opts,
 	}
 }
 
 type FieldMatcher struct {
 	value reflect.Value
+	opts  TagOptions
 }
 
 func (f *FieldMatcher) GetField(field any) (FieldTag, bool, error) {
+	if f.value.Kind() != reflect.Struct {
+		return FieldTag{}, false, nil
+	}
 	hostType := f.value.Type()
 	for _, i := range reflect.VisibleFields(hostType) {
-		f := f.value.FieldByIndex(i.Index)
+		v := f.value.FieldByIndex(i.Index)
 		fType := hostType.FieldByIndex(i.Index)
 		if !fType.IsExported() {
 			continue
 		}
-		if f.Addr().Interface() == field {
-			f, err := ParseTag(fType)
+		if v.Addr().Interface() == field {
+			f, err := ParseTag(fType, f.opts)
 			return f, true, err
 		}
 	}
@@ -246,7 +273,7 @@ func (f *FieldMatcher) TargetStructFields(t any) ([]FieldTag, bool, error) {
 		if !fType.IsExported() {
 			continue
 		}
-		tag, err := ParseTag(fType)
+		tag, err := ParseTag(fType, f.opts)
 		if err != nil {
 			errs.Errors = append(errs.Errors, err)
 			continue
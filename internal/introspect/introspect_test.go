@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -33,6 +34,9 @@ type MyStruct struct {
 	Bar     int    `provider:"secret"`
 	Fizz    *int   `pulumi:"fizz"`
 	ExtType string `pulumi:"typ" provider:"type=example@1.2.3:m1:m2"`
+
+	Timeout     *time.Duration `pulumi:"timeout,optional" provider:"duration"`
+	NotDuration int64          `pulumi:"notDuration" provider:"duration"`
 }
 
 func (m *MyStruct) Annotate(a infer.Annotator) {
@@ -42,6 +46,9 @@ func (m *MyStruct) Annotate(a infer.Annotator) {
 	a.SetToken("myMod", "MyToken")
 	a.SetResourceDeprecationMessage("This resource is deprecated.")
 	a.AddAlias("myMod", "MyAlias")
+	a.Deprecate(&m.Foo, "Use Fizz instead.")
+	a.AddPropertyAlias(&m.Fizz, "buzz")
+	a.SetCreateTimeout(time.Minute)
 }
 
 func TestParseTag(t *testing.T) {
@@ -83,6 +90,18 @@ func TestParseTag(t *testing.T) {
 				},
 			},
 		},
+		{
+			Field: "Timeout",
+			Expected: introspect.FieldTag{
+				Name:     "timeout",
+				Optional: true,
+				Duration: true,
+			},
+		},
+		{
+			Field: "NotDuration",
+			Error: "`duration` can only be applied to time.Duration fields, found int64",
+		},
 	}
 
 	for _, c := range cases {
@@ -91,7 +110,7 @@ func TestParseTag(t *testing.T) {
 			t.Parallel()
 			field, ok := typ.FieldByName(c.Field)
 			assert.True(t, ok)
-			tag, err := introspect.ParseTag(field)
+			tag, err := introspect.ParseTag(field, introspect.TagOptions{})
 			if c.Error != "" {
 				assert.ErrorContains(t, err, c.Error)
 			} else {
@@ -101,6 +120,34 @@ func TestParseTag(t *testing.T) {
 	}
 }
 
+func TestParseTagExplicitType(t *testing.T) {
+	t.Parallel()
+
+	for tag, expected := range map[string]string{
+		"type=aws@6.0.0:ec2/vpc:Vpc": "",
+		"type=index:Local":           "",
+		"type=aws:ec2:Vpc":           `expected "type=" value of "[pkg@version:]module:name", found "aws:ec2:Vpc"`,
+		"type=aws@6.0.0:ec2":         `invalid "type=" value "aws@6.0.0:ec2": module "aws@6.0.0" is not a valid module name`,
+		"type=@6.0.0:ec2:Vpc":        `invalid "type=" value "@6.0.0:ec2:Vpc": package "" is not a valid name`,
+		"type=aws@6.0.0::Vpc":        `invalid "type=" value "aws@6.0.0::Vpc": module "" is not a valid module name`,
+		"type=aws@6.0.0:ec2:":        `invalid "type=" value "aws@6.0.0:ec2:": type name "" is not a valid name`,
+		"type=aws@6.0.0:ec2:My Vpc":  `invalid "type=" value "aws@6.0.0:ec2:My Vpc": type name "My Vpc" is not a valid name`,
+		"type=aws@six:ec2:Vpc":       `"type=" version must be valid semver`,
+	} {
+		field := reflect.StructField{
+			Name: "Field",
+			Type: reflect.TypeOf(""),
+			Tag:  reflect.StructTag(`pulumi:"field" provider:"` + tag + `"`),
+		}
+		_, err := introspect.ParseTag(field, introspect.TagOptions{})
+		if expected == "" {
+			assert.NoError(t, err, tag)
+		} else {
+			assert.ErrorContains(t, err, expected, tag)
+		}
+	}
+}
+
 func TestAnnotate(t *testing.T) {
 	t.Parallel()
 
@@ -116,6 +163,33 @@ func TestAnnotate(t *testing.T) {
 	assert.Equal(t, "pkg:myMod:MyToken", a.Token)
 	assert.Equal(t, "This resource is deprecated.", a.DeprecationMessage)
 	assert.Equal(t, []string{"pkg:myMod:MyAlias"}, a.Aliases)
+	assert.Equal(t, map[string]string{"foo": "Use Fizz instead."}, a.Deprecations)
+	assert.Equal(t, map[string][]string{"fizz": {"buzz"}}, a.PropertyAliases)
+	assert.Equal(t, time.Minute, a.CreateTimeout)
+	assert.Zero(t, a.UpdateTimeout)
+}
+
+func TestDeprecateResource(t *testing.T) {
+	t.Parallel()
+
+	s := &MyStruct{}
+	a := introspect.NewAnnotator(s)
+	a.Deprecate(&s, "Use OtherStruct instead.")
+
+	assert.Equal(t, "Use OtherStruct instead.", a.DeprecationMessage)
+	assert.Empty(t, a.Deprecations)
+}
+
+func TestSetPattern(t *testing.T) {
+	t.Parallel()
+
+	s := &MyStruct{}
+	a := introspect.NewAnnotator(s)
+	a.SetPattern(&s.Foo, "^[a-z]+$")
+	require.Contains(t, a.Patterns, "foo")
+	assert.Equal(t, "^[a-z]+$", a.Patterns["foo"].String())
+
+	assert.Panics(t, func() { a.SetPattern(&s.Foo, "[a-z") })
 }
 
 func TestSetTokenValidation(t *testing.T) {
@@ -164,6 +238,36 @@ func TestSetTokenValidation(t *testing.T) {
 	}
 }
 
+func TestParseImportIDFormat(t *testing.T) {
+	t.Parallel()
+
+	parts, err := introspect.ParseImportIDFormat("projects/{project}:{zone}/{instance}")
+	require.NoError(t, err)
+	assert.Equal(t, []introspect.ImportIDPart{
+		{Literal: "projects/"},
+		{Property: "project"},
+		{Literal: ":"},
+		{Property: "zone"},
+		{Literal: "/"},
+		{Property: "instance"},
+	}, parts)
+
+	values, ok := introspect.ParseImportID(parts, "projects/p:z/i")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"project": "p", "zone": "z", "instance": "i"}, values)
+	_, ok = introspect.ParseImportID(parts, "p:z/i")
+	assert.False(t, ok)
+
+	for _, format := range []string{"{a}{b}", "{a", "a}", "{}", "{a{b}}"} {
+		_, err := introspect.ParseImportIDFormat(format)
+		assert.Error(t, err, format)
+		assert.Panics(t, func() {
+			a := introspect.NewAnnotator(&MyStruct{})
+			a.SetImportIDFormat(format)
+		}, format)
+	}
+}
+
 func TestAllFields(t *testing.T) {
 	t.Parallel()
 
@@ -173,7 +277,7 @@ func TestAllFields(t *testing.T) {
 		ExtType string
 	}
 	s := &MyStruct{}
-	fm := introspect.NewFieldMatcher(s)
+	fm := introspect.NewFieldMatcher(s, introspect.TagOptions{})
 
 	fields, ok, err := fm.TargetStructFields(s)
 	require.True(t, ok)
@@ -190,7 +294,7 @@ func TestAllFieldsMiss(t *testing.T) {
 		ExtType string
 	}
 	s := &MyStruct{}
-	fm := introspect.NewFieldMatcher(s)
+	fm := introspect.NewFieldMatcher(s, introspect.TagOptions{})
 
 	_, ok, err := fm.TargetStructFields(&s.Fizz)
 	require.False(t, ok)
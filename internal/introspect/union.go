@@ -0,0 +1,75 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package introspect
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// Union describes an interface type whose values are one of a fixed set of structs. The
+// struct held by a value is identified by a discriminator property shared by all cases.
+type Union struct {
+	Discriminator string                  // The name of the discriminator property.
+	Cases         map[string]reflect.Type // The struct type of each case, by discriminator value.
+}
+
+// Values returns the discriminator values of u in sorted order.
+func (u Union) Values() []string {
+	values := make([]string, 0, len(u.Cases))
+	for v := range u.Cases {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	return values
+}
+
+// ValueOf returns the discriminator value of the case of type t.
+func (u Union) ValueOf(t reflect.Type) (string, bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	for v, c := range u.Cases {
+		if c == t {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+var unions sync.Map // map[reflect.Type]Union
+
+// RegisterUnion registers iface as a union type.
+func RegisterUnion(iface reflect.Type, u Union) {
+	unions.Store(iface, u)
+}
+
+// GetUnion returns the union registered for t, if any.
+func GetUnion(t reflect.Type) (Union, bool) {
+	u, ok := unions.Load(t)
+	if !ok {
+		return Union{}, false
+	}
+	return u.(Union), true
+}
+
+// RangeUnions calls f for each registered union type.
+func RangeUnions(f func(iface reflect.Type, u Union)) {
+	unions.Range(func(k, v any) bool {
+		f(k.(reflect.Type), v.(Union))
+		return true
+	})
+}
@@ -22,6 +22,11 @@ type (
 	runtimeInfoType struct{}
 	logType         struct{}
 	urnType         struct{}
+	idGeneratorType struct{}
+	dryRunType      struct{}
+	projectType     struct{}
+	stackType       struct{}
+	orgType         struct{}
 )
 
 var (
@@ -31,6 +36,16 @@ var (
 	Logger = logType{}
 	// URN is used to retrieve an URN from ctx.
 	URN = urnType{}
+	// IDGenerator is used to retrieve the func() string that [infer.NewID] uses from ctx.
+	IDGenerator = idGeneratorType{}
+	// DryRun is used to retrieve if the request of ctx is part of a preview.
+	DryRun = dryRunType{}
+	// Project is used to retrieve the name of the Pulumi project from ctx.
+	Project = projectType{}
+	// Stack is used to retrieve the name of the Pulumi stack from ctx.
+	Stack = stackType{}
+	// Organization is used to retrieve the name of the Pulumi organization from ctx.
+	Organization = orgType{}
 )
 
 // ForceNoDetailedDiff acts as a side-channel in
@@ -99,6 +99,36 @@ func MakeKnown(v resource.PropertyValue) resource.PropertyValue {
 	}
 }
 
+// DeepCopy returns a copy of v that shares no mutable state with v, so that changes to
+// the objects and arrays of one are not observed by the other.
+func DeepCopy(v resource.PropertyValue) resource.PropertyValue {
+	switch {
+	case v.IsObject():
+		obj := make(resource.PropertyMap, len(v.ObjectValue()))
+		for k, e := range v.ObjectValue() {
+			obj[k] = DeepCopy(e)
+		}
+		return resource.NewObjectProperty(obj)
+	case v.IsArray():
+		arr := make([]resource.PropertyValue, len(v.ArrayValue()))
+		for i, e := range v.ArrayValue() {
+			arr[i] = DeepCopy(e)
+		}
+		return resource.NewArrayProperty(arr)
+	case v.IsSecret():
+		return resource.MakeSecret(DeepCopy(v.SecretValue().Element))
+	case v.IsComputed():
+		return resource.MakeComputed(DeepCopy(v.Input().Element))
+	case v.IsOutput():
+		o := v.OutputValue()
+		o.Element = DeepCopy(o.Element)
+		o.Dependencies = append([]resource.URN(nil), o.Dependencies...)
+		return resource.NewOutputProperty(o)
+	default:
+		return v
+	}
+}
+
 // DeepEquals checks if a and b are equal.
 //
 // DeepEquals is different from a.DeepEquals(b) in two ways:
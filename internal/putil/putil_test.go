@@ -98,6 +98,33 @@ func TestRapidDeepEqual(t *testing.T) {
 	})
 }
 
+func TestDeepCopy(t *testing.T) {
+	t.Parallel()
+
+	t.Run("equal", rapid.MakeCheck(func(t *rapid.T) { //nolint:paralleltest
+		value := rresource.PropertyValue(5).Draw(t, "value")
+		assert.True(t, putil.DeepEquals(value, putil.DeepCopy(value)))
+	}))
+
+	t.Run("independent", func(t *testing.T) {
+		t.Parallel()
+
+		original := r.NewObjectProperty(r.PropertyMap{
+			"spec": r.MakeSecret(r.NewObjectProperty(r.PropertyMap{
+				"replicas": r.NewNumberProperty(1),
+			})),
+			"tags": r.NewArrayProperty([]r.PropertyValue{r.NewStringProperty("a")}),
+		})
+		c := putil.DeepCopy(original)
+		c.ObjectValue()["spec"].SecretValue().Element.ObjectValue()["replicas"] = r.NewNumberProperty(2)
+		c.ObjectValue()["tags"].ArrayValue()[0] = r.NewStringProperty("b")
+
+		assert.Equal(t, 1.0,
+			original.ObjectValue()["spec"].SecretValue().Element.ObjectValue()["replicas"].NumberValue())
+		assert.Equal(t, "a", original.ObjectValue()["tags"].ArrayValue()[0].StringValue())
+	})
+}
+
 func normalize(p r.PropertyValue) r.PropertyValue {
 	return r.ToResourcePropertyValue(r.FromResourcePropertyValue(p))
 }
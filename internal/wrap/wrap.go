@@ -0,0 +1,82 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package wrap runs code around the methods of a provider, for middleware that treat
+// each method the same way, such as by tracing or throttling it.
+package wrap
+
+import (
+	"context"
+	"errors"
+)
+
+// Around is called before each call to a wrapped method. It returns the context to call
+// the method with, and a function that is called with the method's error once the method
+// returns.
+//
+// If Around returns an error, the method is not called and the error is returned
+// instead.
+type Around[Req any] func(ctx context.Context, req Req) (context.Context, func(error), error)
+
+// ErrPanicked is the error that the function returned by an [Around] is called with when
+// the method panics.
+var ErrPanicked = errors.New("the operation panicked")
+
+// Method wraps f, a method with a response such as Create, in around.
+//
+// A nil f stays nil, so that a wrapped provider implements the same methods as the
+// provider it wraps.
+func Method[Req, Resp any](
+	f func(context.Context, Req) (Resp, error), around Around[Req],
+) func(context.Context, Req) (Resp, error) {
+	if f == nil {
+		return nil
+	}
+	return func(ctx context.Context, req Req) (resp Resp, err error) {
+		ctx, done, err := around(ctx, req)
+		if err != nil {
+			return resp, err
+		}
+		// done is deferred so that it is called even if f panics. The panic isn't
+		// recovered, and keeps unwinding once done returns.
+		returned := false
+		defer func() {
+			if !returned {
+				done(ErrPanicked)
+				return
+			}
+			done(err)
+		}()
+		resp, err = f(ctx, req)
+		returned = true
+		return resp, err
+	}
+}
+
+// ErrMethod wraps f, a method without a response such as Delete, in around.
+//
+// A nil f stays nil, so that a wrapped provider implements the same methods as the
+// provider it wraps.
+func ErrMethod[Req any](f func(context.Context, Req) error, around Around[Req]) func(context.Context, Req) error {
+	if f == nil {
+		return nil
+	}
+	method := Method(func(ctx context.Context, req Req) (struct{}, error) {
+		return struct{}{}, f(ctx, req)
+	}, around)
+	return func(ctx context.Context, req Req) error {
+		_, err := method(ctx, req)
+		return err
+	}
+}
@@ -74,6 +74,12 @@ type logSink interface {
 	LogStatus(context.Context, resource.URN, diag.Severity, string)
 }
 
+// GetLogger returns a Logger that sends messages to the Pulumi engine, to be displayed in
+// the output of `pulumi preview` and `pulumi up`.
+//
+// Within a request that targets a resource, such as Check, Create, Update or Delete,
+// messages are associated with the URN of that resource. Otherwise they are global.
+// When no engine is available, messages are logged with [log/slog].
 func GetLogger(ctx context.Context) Logger {
 	var (
 		sink logSink = slogSink{}
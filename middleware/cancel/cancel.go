@@ -46,7 +46,7 @@ func Wrap(provider p.Provider) p.Provider {
 		if timeout == noTimeout {
 			ctx, cancel = context.WithCancel(ctx)
 		} else {
-			ctx, cancel = context.WithTimeout(ctx, time.Second*time.Duration(timeout))
+			ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout*float64(time.Second)))
 		}
 
 		handle := cancelFuncs.Insert(cancel)
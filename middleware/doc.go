@@ -13,4 +13,29 @@
 // limitations under the License.
 
 // Package middleware defines common interfaces multiple middleware components use.
+//
+// A middleware is a function that takes a [p.Provider] and returns a new [p.Provider],
+// such as [github.com/pulumi/pulumi-go-provider/middleware/cancel.Wrap]. Because
+// [github.com/pulumi/pulumi-go-provider/infer.Provider] returns a plain [p.Provider],
+// middleware compose with inferred providers the same way they compose with each other.
+// The last middleware applied is the first to see each request from the host.
+//
+// To write a middleware, copy the provider and replace the methods to intercept, calling
+// into the original method. Methods that are nil are not implemented, and should stay
+// nil:
+//
+//	func countCreates(provider p.Provider, count *atomic.Int64) p.Provider {
+//		create := provider.Create
+//		if create != nil {
+//			provider.Create = func(ctx context.Context, req p.CreateRequest) (p.CreateResponse, error) {
+//				count.Add(1)
+//				return create(ctx, req)
+//			}
+//		}
+//		return provider
+//	}
+//
+// To insert it between the host and an inferred provider:
+//
+//	p.RunProvider("my-provider", version, cancel.Wrap(countCreates(infer.Provider(opts), &count)))
 package middleware
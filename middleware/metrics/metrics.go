@@ -0,0 +1,167 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics provides a middleware that records Prometheus metrics for the
+// operations of a provider. See [Wrap].
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	presource "github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+
+	p "github.com/pulumi/pulumi-go-provider"
+)
+
+// OtherToken is the value of the token label for operations whose token is not
+// recorded, as decided by [Options.AggregateTokens] and [Options.MaxTokens].
+const OtherToken = "other"
+
+// Options configures the middleware returned by [Wrap].
+type Options struct {
+	// Registerer is where the metrics are registered. It is required.
+	Registerer prometheus.Registerer
+
+	// Namespace prefixes the name of each metric. It defaults to "pulumi_provider".
+	Namespace string
+
+	// AggregateTokens records every operation with the token label [OtherToken], instead
+	// of the token of the resource or function it targets.
+	AggregateTokens bool
+
+	// MaxTokens bounds the number of distinct values of the token label. Once MaxTokens
+	// tokens have been recorded, operations on other tokens are recorded with the token
+	// label [OtherToken]. A zero value means no bound.
+	MaxTokens int
+}
+
+// Wrap records metrics for the Check, Diff, Create, Read, Update, Delete, Invoke and
+// Construct methods of provider:
+//
+//   - <namespace>_operations_total counts operations by method, token and result,
+//     which is either "success" or "error".
+//   - <namespace>_operation_duration_seconds is a histogram of the latency of
+//     operations by method and token.
+//
+// Wrap panics if the metrics cannot be registered with opts.Registerer.
+func Wrap(provider p.Provider, opts Options) p.Provider {
+	namespace := opts.Namespace
+	if namespace == "" {
+		namespace = "pulumi_provider"
+	}
+	r := &recorder{
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "operations_total",
+			Help:      "The number of provider operations, by method, token and result.",
+		}, []string{"method", "token", "result"}),
+		durations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "operation_duration_seconds",
+			Help:      "The latency of provider operations, by method and token.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "token"}),
+		aggregate: opts.AggregateTokens,
+		maxTokens: opts.MaxTokens,
+		tokens:    map[string]struct{}{},
+	}
+	opts.Registerer.MustRegister(r.operations, r.durations)
+
+	wrapper := provider
+	wrapper.Check = method(provider.Check, record(r, "Check", func(req p.CheckRequest) string {
+		return urnToken(req.Urn)
+	}))
+	wrapper.Diff = method(provider.Diff, record(r, "Diff", func(req p.DiffRequest) string {
+		return urnToken(req.Urn)
+	}))
+	wrapper.Create = method(provider.Create, record(r, "Create", func(req p.CreateRequest) string {
+		return urnToken(req.Urn)
+	}))
+	wrapper.Read = method(provider.Read, record(r, "Read", func(req p.ReadRequest) string {
+		return urnToken(req.Urn)
+	}))
+	wrapper.Update = method(provider.Update, record(r, "Update", func(req p.UpdateRequest) string {
+		return urnToken(req.Urn)
+	}))
+	wrapper.Delete = errMethod(provider.Delete, record(r, "Delete", func(req p.DeleteRequest) string {
+		return urnToken(req.Urn)
+	}))
+	wrapper.Invoke = method(provider.Invoke, record(r, "Invoke", func(req p.InvokeRequest) string {
+		return req.Token.String()
+	}))
+	wrapper.Construct = method(provider.Construct, record(r, "Construct", func(req p.ConstructRequest) string {
+		return urnToken(req.URN)
+	}))
+	return wrapper
+}
+
+func urnToken(urn presource.URN) string {
+	if !urn.IsValid() {
+		return ""
+	}
+	return string(urn.Type())
+}
+
+// recorder records the metrics of operations.
+type recorder struct {
+	operations *prometheus.CounterVec
+	durations  *prometheus.HistogramVec
+
+	aggregate bool
+	maxTokens int
+
+	m      sync.Mutex
+	tokens map[string]struct{}
+}
+
+// tokenLabel returns the value of the token label to record for token.
+func (r *recorder) tokenLabel(token string) string {
+	if r.aggregate {
+		return OtherToken
+	}
+	if r.maxTokens <= 0 {
+		return token
+	}
+	r.m.Lock()
+	defer r.m.Unlock()
+	if _, ok := r.tokens[token]; ok {
+		return token
+	}
+	if len(r.tokens) < r.maxTokens {
+		r.tokens[token] = struct{}{}
+		return token
+	}
+	return OtherToken
+}
+
+func (r *recorder) observe(method, token string, start time.Time, err error) {
+	token = r.tokenLabel(token)
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	r.operations.WithLabelValues(method, token, result).Inc()
+	r.durations.WithLabelValues(method, token).Observe(time.Since(start).Seconds())
+}
+
+// record observes each operation once it returns.
+func record[Req any](r *recorder, method string, token func(Req) string) around[Req] {
+	return func(ctx context.Context, req Req) (context.Context, func(error), error) {
+		start := time.Now()
+		return ctx, func(err error) { r.observe(method, token(req), start, err) }, nil
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"context"
+	"errors"
+)
+
+// around is called before each call to a measured method. It returns the context to call
+// the method with, and a function that is called with the method's error once the method
+// returns.
+type around[Req any] func(ctx context.Context, req Req) (context.Context, func(error), error)
+
+// errPanicked is the error that the function returned by an around is called with when
+// the method panics.
+var errPanicked = errors.New("the operation panicked")
+
+// method wraps f, a method with a response such as Create, in a.
+//
+// A nil f stays nil, so that a wrapped provider implements the same methods as the
+// provider it wraps.
+func method[Req, Resp any](
+	f func(context.Context, Req) (Resp, error), a around[Req],
+) func(context.Context, Req) (Resp, error) {
+	if f == nil {
+		return nil
+	}
+	return func(ctx context.Context, req Req) (resp Resp, err error) {
+		ctx, done, err := a(ctx, req)
+		if err != nil {
+			return resp, err
+		}
+		// done is deferred so that it is called even if f panics. The panic isn't
+		// recovered, and keeps unwinding once done returns.
+		returned := false
+		defer func() {
+			if !returned {
+				done(errPanicked)
+				return
+			}
+			done(err)
+		}()
+		resp, err = f(ctx, req)
+		returned = true
+		return resp, err
+	}
+}
+
+// errMethod wraps f, a method without a response such as Delete, in a.
+func errMethod[Req any](f func(context.Context, Req) error, a around[Req]) func(context.Context, Req) error {
+	if f == nil {
+		return nil
+	}
+	m := method(func(ctx context.Context, req Req) (struct{}, error) {
+		return struct{}{}, f(ctx, req)
+	}, a)
+	return func(ctx context.Context, req Req) error {
+		_, err := m(ctx, req)
+		return err
+	}
+}
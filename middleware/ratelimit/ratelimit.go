@@ -0,0 +1,195 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ratelimit provides a middleware that throttles the resource operations of a
+// provider, protecting rate limited upstream APIs. See [Wrap].
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/internal/wrap"
+)
+
+// Limits describes how resource operations are throttled. A zero value for a limit
+// means that it is not enforced.
+type Limits struct {
+	// MaxConcurrent is the maximum number of resource operations in flight at once.
+	MaxConcurrent int
+	// Rate is the maximum number of resource operations started per second, on average.
+	Rate float64
+	// Burst is the number of resource operations that may start at once before Rate is
+	// enforced. Burst defaults to 1 when Rate is set.
+	Burst int
+}
+
+// Options configures the middleware returned by [Wrap].
+type Options struct {
+	// The limits to enforce until the provider is configured.
+	Limits
+
+	// FromConfig derives the limits to enforce from the provider's configuration. When
+	// set, FromConfig is called after each successful Configure, and the limits it returns
+	// replace the current limits.
+	FromConfig func(ctx context.Context, args resource.PropertyMap) (Limits, error)
+}
+
+// Wrap throttles the Create, Read, Update and Delete methods of provider.
+//
+// When a limit is reached, the operation blocks until it may proceed or its context is
+// canceled, in which case the operation fails with the context's error. Throttled
+// operations log a debug message.
+func Wrap(provider p.Provider, opts Options) p.Provider {
+	var current atomic.Pointer[limiter]
+	current.Store(newLimiter(opts.Limits))
+
+	wrapper := provider
+	if configure := provider.Configure; configure != nil && opts.FromConfig != nil {
+		wrapper.Configure = func(ctx context.Context, req p.ConfigureRequest) error {
+			if err := configure(ctx, req); err != nil {
+				return err
+			}
+			limits, err := opts.FromConfig(ctx, req.Args)
+			if err != nil {
+				return err
+			}
+			current.Store(newLimiter(limits))
+			return nil
+		}
+	}
+
+	wrapper.Create = wrap.Method(provider.Create, limit[p.CreateRequest](&current))
+	wrapper.Read = wrap.Method(provider.Read, limit[p.ReadRequest](&current))
+	wrapper.Update = wrap.Method(provider.Update, limit[p.UpdateRequest](&current))
+	wrapper.Delete = wrap.ErrMethod(provider.Delete, limit[p.DeleteRequest](&current))
+	return wrapper
+}
+
+// limit holds each operation until the current limiter allows it to proceed.
+func limit[Req any](current *atomic.Pointer[limiter]) wrap.Around[Req] {
+	return func(ctx context.Context, _ Req) (context.Context, func(error), error) {
+		release, err := current.Load().acquire(ctx)
+		if err != nil {
+			return ctx, nil, err
+		}
+		return ctx, func(error) { release() }, nil
+	}
+}
+
+// limiter enforces a set of [Limits].
+type limiter struct {
+	// slots holds a value for each operation in flight. It is nil when the number of
+	// operations in flight is not limited.
+	slots chan struct{}
+
+	// bucket is nil when the rate of operations is not limited.
+	bucket *tokenBucket
+}
+
+func newLimiter(limits Limits) *limiter {
+	l := &limiter{}
+	if limits.MaxConcurrent > 0 {
+		l.slots = make(chan struct{}, limits.MaxConcurrent)
+	}
+	if limits.Rate > 0 {
+		burst := limits.Burst
+		if burst < 1 {
+			burst = 1
+		}
+		l.bucket = &tokenBucket{
+			rate:   limits.Rate,
+			burst:  float64(burst),
+			tokens: float64(burst),
+			last:   time.Now(),
+		}
+	}
+	return l
+}
+
+// acquire blocks until an operation may start. The caller must call release when the
+// operation is done.
+func (l *limiter) acquire(ctx context.Context) (release func(), err error) {
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		default:
+			p.GetLogger(ctx).Debugf("Waiting for one of %d concurrent operations to finish", cap(l.slots))
+			select {
+			case l.slots <- struct{}{}:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+	}
+	release = func() {
+		if l.slots != nil {
+			<-l.slots
+		}
+	}
+
+	if l.bucket != nil {
+		if err := l.bucket.wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+	return release, nil
+}
+
+// tokenBucket limits the rate of operations. Each operation takes a token from the
+// bucket, which is refilled at rate tokens per second up to burst tokens.
+type tokenBucket struct {
+	m      sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// wait takes a token from the bucket, blocking until one is available.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	b.m.Lock()
+	now := time.Now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	// Reserve a token, even if the bucket is empty. Later callers will wait for the
+	// reserved token to be refilled before their own.
+	b.tokens--
+	delay := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	b.m.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	p.GetLogger(ctx).Debugf("Rate limit reached, waiting %s", delay)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Return the reserved token, since it was not used.
+		b.m.Lock()
+		b.tokens++
+		b.m.Unlock()
+		return ctx.Err()
+	}
+}
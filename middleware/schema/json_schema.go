@@ -0,0 +1,185 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// JSONSchemaDraft07 is the URI of the JSON Schema dialect of [JSONSchema] documents.
+const JSONSchemaDraft07 = "http://json-schema.org/draft-07/schema#"
+
+// JSONSchema is a JSON Schema (draft-07) document, or a schema nested in one. It holds
+// the subset of JSON Schema that Pulumi schema types convert to.
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+	Enum                 []any                  `json:"enum,omitempty"`
+	OneOf                []*JSONSchema          `json:"oneOf,omitempty"`
+	Default              any                    `json:"default,omitempty"`
+	Definitions          map[string]*JSONSchema `json:"definitions,omitempty"`
+}
+
+// JSONSchemas converts the input properties of each resource of spec into a JSON Schema
+// document, keyed by the token of the resource, for tools that consume JSON Schema
+// instead of the Pulumi schema.
+//
+// The object and enum types that a resource refers to are converted into the definitions
+// of its document. Properties of types from other packages, and of assets, archives and
+// the Any type, accept any value.
+func JSONSchemas(spec schema.PackageSpec) (map[string]*JSONSchema, error) {
+	docs := make(map[string]*JSONSchema, len(spec.Resources))
+	for tk, r := range spec.Resources {
+		c := jsonSchemaConverter{spec: spec, definitions: map[string]*JSONSchema{}}
+		doc, err := c.object(r.Description, r.InputProperties, r.RequiredInputs)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", tk, err)
+		}
+		doc.Schema = JSONSchemaDraft07
+		doc.Title = tk
+		if len(c.definitions) > 0 {
+			doc.Definitions = c.definitions
+		}
+		docs[tk] = doc
+	}
+	return docs, nil
+}
+
+// jsonSchemaConverter converts the types of a Pulumi schema into JSON Schema, collecting
+// the definitions of the types it refers to.
+type jsonSchemaConverter struct {
+	spec        schema.PackageSpec
+	definitions map[string]*JSONSchema
+}
+
+func (c *jsonSchemaConverter) object(
+	description string, props map[string]schema.PropertySpec, required []string,
+) (*JSONSchema, error) {
+	s := &JSONSchema{
+		Description: description,
+		Type:        "object",
+		Properties:  make(map[string]*JSONSchema, len(props)),
+	}
+	for name, prop := range props {
+		p, err := c.typ(prop.TypeSpec)
+		if err != nil {
+			return nil, fmt.Errorf("property %q: %w", name, err)
+		}
+		if prop.Description != "" || prop.Default != nil {
+			// A $ref can't have siblings in draft-07, so the reference is wrapped.
+			if p.Ref != "" {
+				p = &JSONSchema{OneOf: []*JSONSchema{p}}
+			}
+			p.Description = prop.Description
+			p.Default = prop.Default
+		}
+		s.Properties[name] = p
+	}
+	if len(required) > 0 {
+		s.Required = append([]string(nil), required...)
+		sort.Strings(s.Required)
+	}
+	return s, nil
+}
+
+func (c *jsonSchemaConverter) typ(t schema.TypeSpec) (*JSONSchema, error) {
+	switch {
+	case t.Ref != "":
+		return c.ref(t.Ref)
+	case len(t.OneOf) > 0:
+		s := &JSONSchema{OneOf: make([]*JSONSchema, len(t.OneOf))}
+		for i, o := range t.OneOf {
+			var err error
+			if s.OneOf[i], err = c.typ(o); err != nil {
+				return nil, err
+			}
+		}
+		return s, nil
+	case t.Type == "array":
+		s := &JSONSchema{Type: "array"}
+		if t.Items != nil {
+			items, err := c.typ(*t.Items)
+			if err != nil {
+				return nil, err
+			}
+			s.Items = items
+		}
+		return s, nil
+	case t.Type == "object":
+		s := &JSONSchema{Type: "object"}
+		if t.AdditionalProperties != nil {
+			values, err := c.typ(*t.AdditionalProperties)
+			if err != nil {
+				return nil, err
+			}
+			s.AdditionalProperties = values
+		}
+		return s, nil
+	case t.Type == "boolean", t.Type == "integer", t.Type == "number", t.Type == "string":
+		return &JSONSchema{Type: t.Type}, nil
+	default:
+		return nil, fmt.Errorf("unknown type %q", t.Type)
+	}
+}
+
+// ref returns a reference to the definition of the type that ref refers to, adding the
+// definition when it is a type of the package.
+func (c *jsonSchemaConverter) ref(ref string) (*JSONSchema, error) {
+	tk, ok := strings.CutPrefix(ref, "#/types/")
+	if !ok {
+		// Assets, archives, the Any type, resources and types of other packages.
+		return &JSONSchema{}, nil
+	}
+	s := &JSONSchema{Ref: "#/definitions/" + jsonPointerEscaper.Replace(tk)}
+	if _, ok := c.definitions[tk]; ok {
+		return s, nil
+	}
+	t, ok := c.spec.Types[tk]
+	if !ok {
+		return nil, fmt.Errorf("reference to unknown type %q", tk)
+	}
+
+	if len(t.Enum) > 0 {
+		def := &JSONSchema{Description: t.Description, Type: t.Type, Enum: make([]any, len(t.Enum))}
+		for i, e := range t.Enum {
+			def.Enum[i] = e.Value
+		}
+		c.definitions[tk] = def
+		return s, nil
+	}
+	// The definition is added before its properties are converted, so that recursive
+	// types refer to it instead of being converted again.
+	c.definitions[tk] = &JSONSchema{}
+	def, err := c.object(t.Description, t.Properties, t.Required)
+	if err != nil {
+		return nil, fmt.Errorf("type %q: %w", tk, err)
+	}
+	c.definitions[tk] = def
+	return s, nil
+}
+
+// jsonPointerEscaper escapes a token for use in a JSON pointer.
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
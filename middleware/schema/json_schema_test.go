@@ -0,0 +1,141 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSchemas(t *testing.T) {
+	t.Parallel()
+
+	str := schema.TypeSpec{Type: "string"}
+	spec := schema.PackageSpec{
+		Name: "pkg",
+		Resources: map[string]schema.ResourceSpec{
+			"pkg:index:Cluster": {
+				ObjectTypeSpec: schema.ObjectTypeSpec{Description: "A cluster."},
+				InputProperties: map[string]schema.PropertySpec{
+					"name": {TypeSpec: str, Description: "The name of the cluster."},
+					"tier": {TypeSpec: schema.TypeSpec{Ref: "#/types/pkg:index:Tier"}},
+					"pools": {TypeSpec: schema.TypeSpec{
+						Type:  "array",
+						Items: &schema.TypeSpec{Ref: "#/types/pkg:index:Pool"},
+					}},
+					"labels": {TypeSpec: schema.TypeSpec{Type: "object", AdditionalProperties: &str}},
+					"extra":  {TypeSpec: schema.TypeSpec{Ref: "pulumi.json#/Any"}},
+				},
+				RequiredInputs: []string{"pools", "name"},
+			},
+		},
+		Types: map[string]schema.ComplexTypeSpec{
+			"pkg:index:Tier": {
+				ObjectTypeSpec: schema.ObjectTypeSpec{Type: "string"},
+				Enum:           []schema.EnumValueSpec{{Value: "basic"}, {Value: "premium"}},
+			},
+			"pkg:index:Pool": {ObjectTypeSpec: schema.ObjectTypeSpec{
+				Type: "object",
+				Properties: map[string]schema.PropertySpec{
+					"size": {TypeSpec: schema.TypeSpec{Type: "integer"}, Default: 3, Description: "The size."},
+					"node": {TypeSpec: schema.TypeSpec{Ref: "#/types/pkg:index:Node"}},
+				},
+				Required: []string{"node"},
+			}},
+			"pkg:index:Node": {ObjectTypeSpec: schema.ObjectTypeSpec{
+				Type: "object",
+				Properties: map[string]schema.PropertySpec{
+					"machine": {TypeSpec: str},
+					// Types may refer to themselves.
+					"next": {TypeSpec: schema.TypeSpec{Ref: "#/types/pkg:index:Node"}},
+				},
+			}},
+			"pkg:index:Unused": {ObjectTypeSpec: schema.ObjectTypeSpec{Type: "object"}},
+		},
+	}
+
+	docs, err := JSONSchemas(spec)
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	b, err := json.MarshalIndent(docs["pkg:index:Cluster"], "", "  ")
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "title": "pkg:index:Cluster",
+  "description": "A cluster.",
+  "type": "object",
+  "properties": {
+    "name": {"type": "string", "description": "The name of the cluster."},
+    "tier": {"$ref": "#/definitions/pkg:index:Tier"},
+    "pools": {"type": "array", "items": {"$ref": "#/definitions/pkg:index:Pool"}},
+    "labels": {"type": "object", "additionalProperties": {"type": "string"}},
+    "extra": {}
+  },
+  "required": ["name", "pools"],
+  "definitions": {
+    "pkg:index:Tier": {"type": "string", "enum": ["basic", "premium"]},
+    "pkg:index:Pool": {
+      "type": "object",
+      "properties": {
+        "size": {"type": "integer", "description": "The size.", "default": 3},
+        "node": {"$ref": "#/definitions/pkg:index:Node"}
+      },
+      "required": ["node"]
+    },
+    "pkg:index:Node": {
+      "type": "object",
+      "properties": {
+        "machine": {"type": "string"},
+        "next": {"$ref": "#/definitions/pkg:index:Node"}
+      }
+    }
+  }
+}`, string(b))
+
+	// The document is valid JSON Schema, and validates inputs of the resource.
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft7
+	require.NoError(t, compiler.AddResource("cluster.json", strings.NewReader(string(b))))
+	s, err := compiler.Compile("cluster.json")
+	require.NoError(t, err)
+
+	validate := func(instance string) error {
+		var v any
+		require.NoError(t, json.Unmarshal([]byte(instance), &v))
+		return s.Validate(v)
+	}
+	assert.NoError(t, validate(`{
+  "name": "prod",
+  "tier": "premium",
+  "pools": [{"size": 2, "node": {"next": {"machine": "m1"}}}],
+  "labels": {"team": "infra"}
+}`))
+	for _, invalid := range []string{
+		`{"pools": []}`,
+		`{"name": "prod", "pools": [], "tier": "gold"}`,
+		`{"name": "prod", "pools": [{"size": 2}]}`,
+		`{"name": "prod", "pools": [], "labels": {"team": 1}}`,
+		`{"name": "prod", "pools": [{"node": {"next": {"machine": 1}}}]}`,
+	} {
+		assert.Error(t, validate(invalid), invalid)
+	}
+}
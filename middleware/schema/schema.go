@@ -22,12 +22,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 
+	"github.com/blang/semver"
 	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/hcl/v2"
 	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"golang.org/x/mod/module"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -86,6 +92,8 @@ func (c *cache) isEmpty() bool {
 
 type state struct {
 	Options
+	// m guards the caches below, since GetSchema may be called concurrently.
+	m sync.Mutex
 	// The cached schema. All With* methods should set schema to "", so we regenerate it
 	// on the next request.
 	schema         *cache
@@ -109,6 +117,19 @@ type Options struct {
 	// For example, with the map {"foo": "bar"}, the token "pkg:foo:Name" would be present in
 	// the schema as "pkg:bar:Name".
 	ModuleMap map[tokens.ModuleName]tokens.ModuleName
+
+	// RenameToken, when set, maps the token of each resource, function and type, and the
+	// references to them, before ModuleMap is applied. Alias tokens are not renamed, since
+	// they refer to tokens that were already published.
+	RenameToken func(tokens.Type) tokens.Type
+
+	// Strict fails schema generation when a resource, function or property has no
+	// description, reporting each of them. This is useful to check in CI that a provider
+	// is fully documented before it is published.
+	//
+	// Strict also logs a warning when the metadata is missing fields that publishing
+	// requires. See [Metadata.Validate].
+	Strict bool
 }
 
 // Metadata describes additional metadata to embed in the generated Pulumi Schema.
@@ -148,6 +169,29 @@ type Metadata struct {
 	//
 	// Before embedding, each field is marshaled via [json.Marshal].
 	LanguageMap map[string]any
+	// LanguageModuleNames maps modules of the schema to their names in the SDK of each
+	// language, keyed by module and then by language. The names are merged into the
+	// language section of the schema, alongside LanguageMap.
+	//
+	// Example:
+	//
+	//	Metadata{
+	//		LanguageModuleNames: map[tokens.ModuleName]map[string]string{
+	//			"compute": {"csharp": "Compute", "python": "compute"},
+	//		},
+	//	}
+	//
+	// The supported languages are "csharp", "go", "java", "nodejs" and "python".
+	LanguageModuleNames map[tokens.ModuleName]map[string]string
+	// LanguagePackageNames maps languages to the name of the SDK generated for them. The
+	// names are merged into the language section of the schema, alongside LanguageMap:
+	//
+	//	- "csharp" sets the root namespace, and with it the NuGet package ID.
+	//	- "go" sets the import path, which must be a valid Go module path.
+	//	- "java" sets the base package.
+	//	- "nodejs" sets the npm package name, such as "@myorg/pkg".
+	//	- "python" sets the PyPI package name, such as "myorg_pkg".
+	LanguagePackageNames map[string]string
 	// Description sets the [schema.PackageSpec.Description] field.
 	Description string
 	// DisplayName sets the [schema.PackageSpec.DisplayName] field.
@@ -168,9 +212,40 @@ type Metadata struct {
 	PluginDownloadURL string
 }
 
+// Validate checks that m holds the fields that the Pulumi Registry requires to publish
+// a package: DisplayName, Description, Keywords, Homepage, Repository, Publisher and
+// License.
+//
+// When [Options.Strict] is set, schema generation logs the error returned by Validate as a
+// warning, but does not fail.
+func (m Metadata) Validate() error {
+	var missing []string
+	for _, f := range []struct {
+		name string
+		set  bool
+	}{
+		{"DisplayName", m.DisplayName != ""},
+		{"Description", m.Description != ""},
+		{"Keywords", len(m.Keywords) > 0},
+		{"Homepage", m.Homepage != ""},
+		{"Repository", m.Repository != ""},
+		{"Publisher", m.Publisher != ""},
+		{"License", m.License != ""},
+	} {
+		if !f.set {
+			missing = append(missing, f.name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("metadata is missing fields required for publishing: %s",
+			strings.Join(missing, ", "))
+	}
+	return nil
+}
+
 // Wrap a provider with the facilities to serve GetSchema.
 func Wrap(provider p.Provider, opts Options) p.Provider {
-	state := state{
+	state := &state{
 		Options:        opts,
 		innerGetSchema: provider.GetSchema,
 	}
@@ -179,6 +254,8 @@ func Wrap(provider p.Provider, opts Options) p.Provider {
 }
 
 func (s *state) GetSchema(ctx context.Context, req p.GetSchemaRequest) (p.GetSchemaResponse, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
 	if s.schema.isEmpty() {
 		spec, err := s.generateSchema(ctx)
 		if err != nil {
@@ -276,9 +353,184 @@ func (s *state) mergeSchemas() error {
 	return err
 }
 
+// languageModuleNameKeys holds the key of the language section of the schema where each
+// language expects the names of modules.
+var languageModuleNameKeys = map[string]string{
+	"csharp": "namespaces",
+	"go":     "moduleToPackage",
+	"java":   "packages",
+	"nodejs": "moduleToPackage",
+	"python": "moduleNameOverrides",
+}
+
+// addLanguageModuleNames merges names into the language section of a schema. Names that
+// are already present in the language section take precedence.
+func addLanguageModuleNames(
+	language map[string]schema.RawMessage, names map[tokens.ModuleName]map[string]string,
+) error {
+	byLanguage := map[string]map[string]string{}
+	for mod, langs := range names {
+		for lang, name := range langs {
+			if _, ok := languageModuleNameKeys[lang]; !ok {
+				return fmt.Errorf("module %q: unsupported language %q for module names", mod, lang)
+			}
+			if byLanguage[lang] == nil {
+				byLanguage[lang] = map[string]string{}
+			}
+			byLanguage[lang][string(mod)] = name
+		}
+	}
+	for lang, modules := range byLanguage {
+		err := updateLanguageInfo(language, lang, func(info map[string]any) {
+			key := languageModuleNameKeys[lang]
+			merged, _ := info[key].(map[string]any)
+			if merged == nil {
+				merged = map[string]any{}
+			}
+			for mod, name := range modules {
+				if _, ok := merged[mod]; !ok {
+					merged[mod] = name
+				}
+			}
+			info[key] = merged
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// languagePackageNameKeys holds the key of the language section of the schema where each
+// language expects the name of the generated package.
+var languagePackageNameKeys = map[string]string{
+	"csharp": "rootNamespace",
+	"go":     "importBasePath",
+	"java":   "basePackage",
+	"nodejs": "packageName",
+	"python": "packageName",
+}
+
+// addLanguagePackageNames merges names into the language section of a schema. Names that
+// are already present in the language section take precedence.
+func addLanguagePackageNames(language map[string]schema.RawMessage, names map[string]string) error {
+	for lang, name := range names {
+		key, ok := languagePackageNameKeys[lang]
+		if !ok {
+			return fmt.Errorf("unsupported language %q for package names", lang)
+		}
+		if lang == "go" {
+			if err := module.CheckImportPath(name); err != nil {
+				return fmt.Errorf("invalid go package name: %w", err)
+			}
+		}
+		err := updateLanguageInfo(language, lang, func(info map[string]any) {
+			if _, ok := info[key]; !ok {
+				info[key] = name
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateLanguageInfo applies update to the decoded language section of a schema for lang.
+func updateLanguageInfo(
+	language map[string]schema.RawMessage, lang string, update func(info map[string]any),
+) error {
+	info := map[string]any{}
+	if existing, ok := language[lang]; ok {
+		if err := json.Unmarshal(existing, &info); err != nil {
+			return fmt.Errorf("language %q: %w", lang, err)
+		}
+	}
+	update(info)
+	bytes, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	language[lang] = bytes
+	return nil
+}
+
 // Generate a schema string from the currently present schema types.
 func (s *state) generateSchema(ctx context.Context) (schema.PackageSpec, error) {
-	info := p.GetRunInfo(ctx)
+	if s.Strict {
+		if err := s.Metadata.Validate(); err != nil {
+			p.GetLogger(ctx).Warning(err.Error())
+		}
+	}
+	return generate(s.Options, p.GetRunInfo(ctx))
+}
+
+// Subset generates the part of the schema of the package pkgName that holds the resources
+// and functions of opts whose token satisfies include, along with the types they
+// reference. Tokens are matched after opts.RenameToken and opts.ModuleMap are applied.
+// The provider and its config are left out.
+//
+// Generating a subset is faster than generating the whole schema of a large provider.
+func Subset(pkgName string, opts Options, include func(tokens.Type) bool) (schema.PackageSpec, error) {
+	opts.Resources = filterElements(opts.Resources, pkgName, opts, include)
+	opts.Invokes = filterElements(opts.Invokes, pkgName, opts, include)
+	opts.Provider = nil
+	if len(opts.Resources) == 0 && len(opts.Invokes) == 0 {
+		return schema.PackageSpec{}, fmt.Errorf("no resources or functions in package %q match", pkgName)
+	}
+	return generate(opts, p.RunInfo{PackageName: pkgName})
+}
+
+// filterElements returns the elements of els whose token satisfies include. Elements
+// whose token can't be computed are kept, so that schema generation reports the error.
+func filterElements[T interface{ GetToken() (tokens.Type, error) }](
+	els []T, pkgName string, opts Options, include func(tokens.Type) bool,
+) []T {
+	var filtered []T
+	for _, el := range els {
+		tk, err := el.GetToken()
+		if err != nil || include(assignTo(tk, pkgName, opts)) {
+			filtered = append(filtered, el)
+		}
+	}
+	return filtered
+}
+
+// Generate generates the whole schema of the package described by opts, as GetSchema
+// returns it for the package pkgName.
+func Generate(pkgName string, opts Options) (schema.PackageSpec, error) {
+	return generate(opts, p.RunInfo{PackageName: pkgName})
+}
+
+// Validate binds spec with Pulumi's schema validator, as SDK generation does, and returns
+// the diagnostics it reports. It catches structural errors, such as defaults whose type
+// doesn't match their property, before the schema is published. The error is not nil
+// when spec could not be bound at all.
+//
+// loader loads the packages that spec references types and resources of. When loader is
+// nil, nothing is loaded and a reference to another package makes Validate fail.
+// Pass [schema.NewPluginLoader] to resolve references through plugins, which may download
+// the plugins that are not installed.
+func Validate(spec schema.PackageSpec, loader schema.Loader) (hcl.Diagnostics, error) {
+	if loader == nil {
+		loader = offlineLoader{}
+	}
+	_, diags, err := schema.BindSpec(spec, loader)
+	return diags, err
+}
+
+// offlineLoader is a [schema.Loader] that doesn't load any package.
+type offlineLoader struct{}
+
+func (offlineLoader) LoadPackage(pkg string, _ *semver.Version) (*schema.Package, error) {
+	return nil, fmt.Errorf("package %q is not loaded while validating offline", pkg)
+}
+
+func (l offlineLoader) LoadPackageV2(_ context.Context, desc *schema.PackageDescriptor) (*schema.Package, error) {
+	return l.LoadPackage(desc.Name, desc.Version)
+}
+
+func generate(s Options, info p.RunInfo) (schema.PackageSpec, error) {
 	pkg := schema.PackageSpec{
 		Name:              info.PackageName,
 		Version:           info.Version,
@@ -303,22 +555,28 @@ func (s *state) generateSchema(ctx context.Context) (schema.PackageSpec, error)
 		}
 		pkg.Language[k] = bytes
 	}
+	if err := addLanguageModuleNames(pkg.Language, s.LanguageModuleNames); err != nil {
+		return schema.PackageSpec{}, err
+	}
+	if err := addLanguagePackageNames(pkg.Language, s.LanguagePackageNames); err != nil {
+		return schema.PackageSpec{}, err
+	}
 	registerDerivative := func(tk tokens.Type, t schema.ComplexTypeSpec) bool {
-		tkString := assignTo(tk, info.PackageName, s.ModuleMap).String()
+		tkString := assignTo(tk, info.PackageName, s).String()
 		_, ok := pkg.Types[tkString]
 		if ok {
 			return false
 		}
-		pkg.Types[tkString] = renamePackage(t, info.PackageName, s.ModuleMap)
+		pkg.Types[tkString] = renamePackage(t, info.PackageName, s)
 		return true
 	}
-	errs := addElements(s.Resources, pkg.Resources, info.PackageName, registerDerivative, s.ModuleMap)
-	e := addElements(s.Invokes, pkg.Functions, info.PackageName, registerDerivative, s.ModuleMap)
+	errs := addElements(s.Resources, pkg.Resources, pkg.Types, info.PackageName, s)
+	e := addElements(s.Invokes, pkg.Functions, pkg.Types, info.PackageName, s)
 	errs.Errors = append(errs.Errors, e.Errors...)
 
 	if s.Provider != nil {
 		_, prov, err := addElement[Resource, schema.ResourceSpec](
-			info.PackageName, registerDerivative, s.ModuleMap, s.Provider)
+			info.PackageName, registerDerivative, s, s.Provider)
 		if err != nil {
 			errs.Errors = append(errs.Errors, err)
 		}
@@ -331,53 +589,178 @@ func (s *state) generateSchema(ctx context.Context) (schema.PackageSpec, error)
 	if err := errs.ErrorOrNil(); err != nil {
 		return schema.PackageSpec{}, err
 	}
+	if s.Strict {
+		if err := undocumented(pkg); err != nil {
+			return schema.PackageSpec{}, err
+		}
+	}
 	return pkg, nil
 }
 
+// undocumented returns an error listing each resource, function and property of pkg that
+// has no description.
+func undocumented(pkg schema.PackageSpec) error {
+	var errs multierror.Error
+	missing := func(format string, a ...any) {
+		errs.Errors = append(errs.Errors, fmt.Errorf(format+" has no description", a...))
+	}
+	properties := func(kind, tk string, props ...map[string]schema.PropertySpec) {
+		names := map[string]struct{}{}
+		for _, m := range props {
+			for name, prop := range m {
+				if prop.Description == "" {
+					names[name] = struct{}{}
+				}
+			}
+		}
+		for _, name := range sortedKeys(names) {
+			missing("property %q of %s %q", name, kind, tk)
+		}
+	}
+
+	for _, tk := range sortedKeys(pkg.Resources) {
+		r := pkg.Resources[tk]
+		if r.Description == "" {
+			missing("resource %q", tk)
+		}
+		properties("resource", tk, r.InputProperties, r.Properties)
+	}
+	for _, tk := range sortedKeys(pkg.Functions) {
+		f := pkg.Functions[tk]
+		if f.Description == "" {
+			missing("function %q", tk)
+		}
+		var props []map[string]schema.PropertySpec
+		if f.Inputs != nil {
+			props = append(props, f.Inputs.Properties)
+		}
+		if f.Outputs != nil {
+			props = append(props, f.Outputs.Properties)
+		}
+		properties("function", tk, props...)
+	}
+	for _, tk := range sortedKeys(pkg.Types) {
+		properties("type", tk, pkg.Types[tk].Properties)
+	}
+	for _, name := range sortedKeys(pkg.Config.Variables) {
+		if pkg.Config.Variables[name].Description == "" {
+			missing("config variable %q", name)
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 type canGetSchema[T any] interface {
 	GetToken() (tokens.Type, error)
 	GetSchema(RegisterDerivativeType) (T, error)
 }
 
+// registration is a type registered by an element, under its assigned token.
+type registration struct {
+	tk  string
+	typ schema.ComplexTypeSpec
+}
+
 func addElements[T canGetSchema[S], S any](els []T, m map[string]S,
-	pkgName string, reg RegisterDerivativeType,
-	modMap map[tokens.ModuleName]tokens.ModuleName) multierror.Error {
+	types map[string]schema.ComplexTypeSpec, pkgName string,
+	opts Options) multierror.Error {
+	type result struct {
+		tk      tokens.Type
+		element S
+		types   []registration
+		err     error
+	}
+
+	// Elements are independent of each other, so we generate their schemas on a bounded
+	// pool of workers. Each element records the types it registers instead of adding them
+	// to types, and the results are merged in the order the elements were given, so the
+	// first element to register a type still defines it and the schema doesn't depend on
+	// scheduling.
+	results := make([]result, len(els))
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(runtime.GOMAXPROCS(0), len(els)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				r := &results[i]
+				seen := map[string]struct{}{}
+				reg := func(tk tokens.Type, t schema.ComplexTypeSpec) bool {
+					tkString := assignTo(tk, pkgName, opts).String()
+					// types is only written once every worker is done.
+					if _, ok := types[tkString]; ok {
+						return false
+					}
+					if _, ok := seen[tkString]; ok {
+						return false
+					}
+					seen[tkString] = struct{}{}
+					r.types = append(r.types, registration{tkString, renamePackage(t, pkgName, opts)})
+					return true
+				}
+				r.tk, r.element, r.err = addElement[T, S](pkgName, reg, opts, els[i])
+			}
+		}()
+	}
+	for i := range els {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
 	errs := multierror.Error{}
-	for _, f := range els {
-		tk, element, err := addElement[T, S](pkgName, reg, modMap, f)
-		if err != nil {
-			errs.Errors = append(errs.Errors, err)
+	for _, r := range results {
+		for _, t := range r.types {
+			if _, ok := types[t.tk]; !ok {
+				types[t.tk] = t.typ
+			}
+		}
+		if r.err != nil {
+			errs.Errors = append(errs.Errors, r.err)
 			continue
 		}
-		m[tk.String()] = element
+		m[r.tk.String()] = r.element
 	}
 	return errs
 }
 
 func addElement[T canGetSchema[S], S any](pkgName string, reg RegisterDerivativeType,
-	modMap map[tokens.ModuleName]tokens.ModuleName, f T) (tokens.Type, S, error) {
+	opts Options, f T) (tokens.Type, S, error) {
 	var s S
 	tk, err := f.GetToken()
 	if err != nil {
 		return "", s, err
 	}
-	tk = assignTo(tk, pkgName, modMap)
+	tk = assignTo(tk, pkgName, opts)
 	fun, err := f.GetSchema(reg)
 	if err != nil {
 		return "", s, fmt.Errorf("failed to get schema for '%s': %w", tk, err)
 	}
-	return tk, renamePackage(fun, pkgName, modMap), nil
+	return tk, renamePackage(fun, pkgName, opts), nil
 }
 
-func assignTo(tk tokens.Type, pkg string, modMap map[tokens.ModuleName]tokens.ModuleName) tokens.Type {
+func assignTo(tk tokens.Type, pkg string, opts Options) tokens.Type {
+	if opts.RenameToken != nil {
+		tk = opts.RenameToken(tk)
+	}
 	mod := tk.Module().Name()
-	if m, ok := modMap[mod]; ok {
+	if m, ok := opts.ModuleMap[mod]; ok {
 		mod = m
 	}
 	return tokens.NewTypeToken(tokens.NewModuleToken(tokens.Package(pkg), mod), tk.Name())
 }
 
-func fixReference(ref, pkg string, modMap map[tokens.ModuleName]tokens.ModuleName) string {
+func fixReference(ref, pkg string, opts Options) string {
 	if !strings.HasPrefix(ref, "#/") {
 		// Not an internal reference, so we don't rewrite
 		return ref
@@ -389,17 +772,17 @@ func fixReference(ref, pkg string, modMap map[tokens.ModuleName]tokens.ModuleNam
 		return ref
 	}
 	kind := ref[:i+3]
-	tk, err := tokens.ParseTypeToken(s[i+2:])
+	tk, err := tokens.ParseTypeToken(s[i+1:])
 	if err != nil {
 		// Not a valid token, so again we just leave it
 		return ref
 	}
-	return kind + string(assignTo(tk, pkg, modMap))
+	return kind + string(assignTo(tk, pkg, opts))
 }
 
 // renamePackage sets internal package references to point to the package with the name
 // `pkg`.
-func renamePackage[T any](typ T, pkg string, modMap map[tokens.ModuleName]tokens.ModuleName) T {
+func renamePackage[T any](typ T, pkg string, opts Options) T {
 	var rename func(reflect.Value)
 	rename = func(v reflect.Value) {
 		switch v.Kind() {
@@ -411,9 +794,25 @@ func renamePackage[T any](typ T, pkg string, modMap map[tokens.ModuleName]tokens
 		case reflect.Struct:
 			if v.Type() == reflect.TypeOf(schema.TypeSpec{}) {
 				field := v.FieldByName("Ref")
-				rewritten := fixReference(field.String(), pkg, modMap)
+				rewritten := fixReference(field.String(), pkg, opts)
 				field.SetString(rewritten)
 			}
+			if v.Type() == reflect.TypeOf(schema.DiscriminatorSpec{}) {
+				mapping := v.FieldByName("Mapping")
+				for iter := mapping.MapRange(); iter.Next(); {
+					rewritten := fixReference(iter.Value().String(), pkg, opts)
+					mapping.SetMapIndex(iter.Key(), reflect.ValueOf(rewritten))
+				}
+			}
+			if v.Type() == reflect.TypeOf(schema.AliasSpec{}) {
+				if alias, ok := v.Interface().(schema.AliasSpec); ok && alias.Type != nil {
+					if tk, err := tokens.ParseTypeToken(*alias.Type); err == nil {
+						// Aliases name previously published tokens, so they are not renamed.
+						rewritten := string(assignTo(tk, pkg, Options{ModuleMap: opts.ModuleMap}))
+						v.FieldByName("Type").Set(reflect.ValueOf(&rewritten))
+					}
+				}
+			}
 			for _, f := range reflect.VisibleFields(v.Type()) {
 				f := v.FieldByIndex(f.Index)
 				rename(f)
@@ -15,11 +15,21 @@
 package schema
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
 	"testing"
 
+	"github.com/hashicorp/go-multierror"
 	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/internal/key"
 )
 
 func TestRenamePacakge(t *testing.T) {
@@ -36,7 +46,7 @@ func TestRenamePacakge(t *testing.T) {
 		},
 	}
 
-	p = renamePackage(p, "fizz", map[tokens.ModuleName]tokens.ModuleName{})
+	p = renamePackage(p, "fizz", Options{})
 	assert.Equal(t, "#/types/fizz:bar:Buzz", p.ObjectTypeSpec.Properties["foo"].Ref)
 
 	arr := []schema.PropertySpec{
@@ -51,6 +61,167 @@ func TestRenamePacakge(t *testing.T) {
 			},
 		},
 	}
-	arr = renamePackage(arr, "buzz", map[tokens.ModuleName]tokens.ModuleName{})
+	arr = renamePackage(arr, "buzz", Options{})
 	assert.Equal(t, "#/resources/buzz:fizz:Buzz", arr[1].Ref)
+
+	union := schema.TypeSpec{
+		OneOf: []schema.TypeSpec{{Ref: "#/types/pkg:mod:A"}, {Ref: "#/types/pkg:mod:B"}},
+		Discriminator: &schema.DiscriminatorSpec{
+			PropertyName: "kind",
+			Mapping: map[string]string{
+				"a": "#/types/pkg:mod:A",
+				"b": "#/types/pkg:mod:B",
+			},
+		},
+	}
+	union = renamePackage(union, "fizz", Options{})
+	assert.Equal(t, "#/types/fizz:mod:A", union.OneOf[0].Ref)
+	assert.Equal(t, map[string]string{
+		"a": "#/types/fizz:mod:A",
+		"b": "#/types/fizz:mod:B",
+	}, union.Discriminator.Mapping)
+
+	alias := "pkg:old:Buzz"
+	res := schema.ResourceSpec{Aliases: []schema.AliasSpec{{Type: &alias}}}
+	res = renamePackage(res, "fizz", Options{ModuleMap: map[tokens.ModuleName]tokens.ModuleName{"old": "new"}})
+	assert.Equal(t, "fizz:new:Buzz", *res.Aliases[0].Type)
+	assert.Equal(t, "pkg:old:Buzz", alias)
+}
+
+// synthetic is a resource that registers a type of its own and a type shared with every
+// other synthetic resource.
+type synthetic struct {
+	name string
+	err  error
+}
+
+func (r synthetic) GetToken() (tokens.Type, error) {
+	return tokens.Type("pkg:index:" + r.name), nil
+}
+
+func (r synthetic) GetSchema(reg RegisterDerivativeType) (schema.ResourceSpec, error) {
+	if r.err != nil {
+		return schema.ResourceSpec{}, r.err
+	}
+	props := func(extra map[string]schema.PropertySpec) map[string]schema.PropertySpec {
+		m := map[string]schema.PropertySpec{}
+		for i := 0; i < 20; i++ {
+			m[fmt.Sprintf("field%d", i)] = schema.PropertySpec{TypeSpec: schema.TypeSpec{Type: "string"}}
+		}
+		for k, v := range extra {
+			m[k] = v
+		}
+		return m
+	}
+	// Every resource registers Shared, and the first one given defines it.
+	reg("pkg:index:Shared", schema.ComplexTypeSpec{
+		ObjectTypeSpec: schema.ObjectTypeSpec{
+			Description: "Registered by " + r.name + ".",
+			Type:        "object",
+			Properties:  props(nil),
+		},
+	})
+	reg(tokens.Type("pkg:index:"+r.name+"Nested"), schema.ComplexTypeSpec{
+		ObjectTypeSpec: schema.ObjectTypeSpec{Type: "object", Properties: props(nil)},
+	})
+	refs := map[string]schema.PropertySpec{
+		"shared": {TypeSpec: schema.TypeSpec{Ref: "#/types/pkg:index:Shared"}},
+		"nested": {TypeSpec: schema.TypeSpec{Ref: "#/types/pkg:index:" + r.name + "Nested"}},
+	}
+	return schema.ResourceSpec{
+		ObjectTypeSpec:  schema.ObjectTypeSpec{Type: "object", Properties: props(refs)},
+		InputProperties: props(refs),
+	}, nil
+}
+
+func syntheticProvider(n int, failing ...int) *state {
+	resources := make([]Resource, n)
+	for i := range resources {
+		resources[i] = synthetic{name: fmt.Sprintf("Res%d", i)}
+	}
+	for _, i := range failing {
+		resources[i] = synthetic{name: fmt.Sprintf("Res%d", i), err: fmt.Errorf("broken %d", i)}
+	}
+	return &state{Options: Options{Resources: resources}}
+}
+
+func schemaContext() context.Context {
+	return context.WithValue(context.Background(), key.RuntimeInfo, p.RunInfo{
+		PackageName: "test",
+		Version:     "1.0.0",
+	})
+}
+
+func TestGenerateSchemaDeterministic(t *testing.T) {
+	t.Parallel()
+
+	spec, err := syntheticProvider(200).generateSchema(schemaContext())
+	require.NoError(t, err)
+	assert.Len(t, spec.Resources, 200)
+	assert.Len(t, spec.Types, 201)
+	assert.Equal(t, "Registered by Res0.", spec.Types["test:index:Shared"].Description)
+	expected, err := json.Marshal(spec)
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		spec, err := syntheticProvider(200).generateSchema(schemaContext())
+		require.NoError(t, err)
+		actual, err := json.Marshal(spec)
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), string(actual))
+	}
+
+	// Errors are reported in the order the resources were given, regardless of which
+	// finished first.
+	for i := 0; i < 10; i++ {
+		_, err := syntheticProvider(200, 150, 3, 42).generateSchema(schemaContext())
+		var merr *multierror.Error
+		require.True(t, errors.As(err, &merr))
+		require.Len(t, merr.Errors, 3)
+		assert.ErrorContains(t, merr.Errors[0], "broken 3")
+		assert.ErrorContains(t, merr.Errors[1], "broken 42")
+		assert.ErrorContains(t, merr.Errors[2], "broken 150")
+	}
+}
+
+func TestValidateOffline(t *testing.T) {
+	t.Parallel()
+
+	spec := schema.PackageSpec{
+		Name: "test",
+		Resources: map[string]schema.ResourceSpec{
+			"test:index:Bucket": {
+				InputProperties: map[string]schema.PropertySpec{
+					"policy": {TypeSpec: schema.TypeSpec{
+						Ref: "/other/v1.0.0/schema.json#/types/other:index:Policy",
+					}},
+				},
+			},
+		},
+	}
+	_, err := Validate(spec, nil)
+	assert.ErrorContains(t, err, `package "other" is not loaded while validating offline`)
+}
+
+// BenchmarkGenerateSchema compares generating the schemas of resources on a single
+// worker against generating them on a worker per CPU.
+func BenchmarkGenerateSchema(b *testing.B) {
+	ctx := schemaContext()
+	for _, bench := range []struct {
+		name    string
+		workers int
+	}{
+		{"serial", 1},
+		{"concurrent", runtime.GOMAXPROCS(0)},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(bench.workers))
+			for i := 0; i < b.N; i++ {
+				_, err := syntheticProvider(200).generateSchema(ctx)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
 }
@@ -0,0 +1,107 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracing provides a middleware that traces the RPCs of a provider with
+// OpenTelemetry. See [Wrap].
+package tracing
+
+import (
+	"context"
+
+	presource "github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	p "github.com/pulumi/pulumi-go-provider"
+)
+
+// TracerName is the name of the tracer that [Wrap] obtains from its
+// [trace.TracerProvider].
+const TracerName = "github.com/pulumi/pulumi-go-provider/middleware/tracing"
+
+// The attributes set on spans by [Wrap].
+const (
+	// URNKey is the URN of the resource targeted by the operation.
+	URNKey = attribute.Key("pulumi.urn")
+	// TokenKey is the token of the resource or function targeted by the operation.
+	TokenKey = attribute.Key("pulumi.token")
+	// IDKey is the ID of the resource targeted by the operation, when it is known.
+	IDKey = attribute.Key("pulumi.id")
+)
+
+// Wrap starts a span for each Create, Read, Update, Delete and Invoke call to provider,
+// using a tracer from tracerProvider. The duration of each operation is the duration of
+// its span.
+//
+// The span is stored in the context passed to provider, so spans started by provider
+// (such as from an inferred resource) are children of the span for the operation. When
+// the operation fails, its error is recorded on the span.
+func Wrap(provider p.Provider, tracerProvider trace.TracerProvider) p.Provider {
+	tracer := tracerProvider.Tracer(TracerName)
+
+	wrapper := provider
+	wrapper.Create = method(provider.Create, span(tracer, "Create", func(req p.CreateRequest) []attribute.KeyValue {
+		return resourceAttributes(req.Urn, "")
+	}))
+	wrapper.Read = method(provider.Read, span(tracer, "Read", func(req p.ReadRequest) []attribute.KeyValue {
+		return resourceAttributes(req.Urn, req.ID)
+	}))
+	wrapper.Update = method(provider.Update, span(tracer, "Update", func(req p.UpdateRequest) []attribute.KeyValue {
+		return resourceAttributes(req.Urn, req.ID)
+	}))
+	wrapper.Delete = errMethod(provider.Delete,
+		span(tracer, "Delete", func(req p.DeleteRequest) []attribute.KeyValue {
+			return resourceAttributes(req.Urn, req.ID)
+		}))
+	wrapper.Invoke = method(provider.Invoke, span(tracer, "Invoke", func(req p.InvokeRequest) []attribute.KeyValue {
+		return []attribute.KeyValue{TokenKey.String(req.Token.String())}
+	}))
+	return wrapper
+}
+
+func resourceAttributes(urn presource.URN, id string) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{URNKey.String(string(urn))}
+	if urn.IsValid() {
+		attrs = append(attrs, TokenKey.String(string(urn.Type())))
+	}
+	if id != "" {
+		attrs = append(attrs, IDKey.String(id))
+	}
+	return attrs
+}
+
+// span starts a span named name for each operation, which ends when the operation
+// returns.
+func span[Req any](
+	tracer trace.Tracer, name string, attributes func(Req) []attribute.KeyValue,
+) around[Req] {
+	return func(ctx context.Context, req Req) (context.Context, func(error), error) {
+		ctx, span := tracer.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attributes(req)...))
+		return ctx, func(err error) {
+			recordError(span, err)
+			span.End()
+		}, nil
+	}
+}
+
+func recordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -756,6 +756,11 @@ func (p *provider) StreamInvoke(*rpc.InvokeRequest, rpc.ResourceProvider_StreamI
 }
 
 func (p *provider) Call(ctx context.Context, req *rpc.CallRequest) (*rpc.CallResponse, error) {
+	ctx = p.ctx(ctx, "")
+	ctx = context.WithValue(ctx, key.Project, req.GetProject())
+	ctx = context.WithValue(ctx, key.Stack, req.GetStack())
+	ctx = context.WithValue(ctx, key.Organization, req.GetOrganization())
+	ctx = context.WithValue(ctx, key.DryRun, req.GetDryRun())
 
 	configPropertyMap := make(presource.PropertyMap, len(req.GetConfig()))
 	for k, v := range req.GetConfig() {
@@ -909,7 +914,7 @@ func (p *provider) Diff(ctx context.Context, req *rpc.DiffRequest) (*rpc.DiffRes
 }
 
 func (p *provider) Create(ctx context.Context, req *rpc.CreateRequest) (*rpc.CreateResponse, error) {
-	ctx = p.ctx(ctx, presource.URN(req.GetUrn()))
+	ctx = context.WithValue(p.ctx(ctx, presource.URN(req.GetUrn())), key.DryRun, req.GetPreview())
 	props, err := p.getMap(req.GetProperties())
 	if err != nil {
 		return nil, err
@@ -992,7 +997,7 @@ func (p *provider) Read(ctx context.Context, req *rpc.ReadRequest) (*rpc.ReadRes
 }
 
 func (p *provider) Update(ctx context.Context, req *rpc.UpdateRequest) (*rpc.UpdateResponse, error) {
-	ctx = p.ctx(ctx, presource.URN(req.GetUrn()))
+	ctx = context.WithValue(p.ctx(ctx, presource.URN(req.GetUrn())), key.DryRun, req.GetPreview())
 	oldsMap, err := p.getMap(req.GetOlds())
 	if err != nil {
 		return nil, err
@@ -1079,6 +1084,8 @@ func (p *provider) Construct(ctx context.Context, req *rpc.ConstructRequest) (*r
 		req.GetName(),
 	)
 	ctx = p.ctx(ctx, urn)
+	ctx = context.WithValue(ctx, key.Organization, req.GetOrganization())
+	ctx = context.WithValue(ctx, key.DryRun, req.GetDryRun())
 	f := func(ctx context.Context, construct ConstructFunc) (ConstructResponse, error) {
 		r, err := comProvider.Construct(ctx, req, p.host.EngineConn(),
 			func(
@@ -0,0 +1,70 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+
+	presource "github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+
+	"github.com/pulumi/pulumi-go-provider/internal/key"
+)
+
+// GetURN returns the URN of the resource that the request of ctx targets, such as the
+// resource of Check, Create or Delete. It returns "" for requests that don't target a
+// resource, such as Configure or Invoke.
+func GetURN(ctx context.Context) presource.URN {
+	urn, _ := ctx.Value(key.URN).(presource.URN)
+	return urn
+}
+
+// GetProject returns the name of the Pulumi project of the request of ctx. It is known
+// for requests that target a resource, and for Call. Otherwise it returns "".
+func GetProject(ctx context.Context) string {
+	if project, ok := ctx.Value(key.Project).(string); ok {
+		return project
+	}
+	if urn := GetURN(ctx); urn.IsValid() {
+		return urn.Project().String()
+	}
+	return ""
+}
+
+// GetStack returns the name of the Pulumi stack of the request of ctx. It is known for
+// requests that target a resource, and for Call. Otherwise it returns "".
+func GetStack(ctx context.Context) string {
+	if stack, ok := ctx.Value(key.Stack).(string); ok {
+		return stack
+	}
+	if urn := GetURN(ctx); urn.IsValid() {
+		return urn.Stack().String()
+	}
+	return ""
+}
+
+// GetOrganization returns the name of the Pulumi organization of the request of ctx. The
+// engine only sends it with Construct and Call. Otherwise it returns "".
+func GetOrganization(ctx context.Context) string {
+	org, _ := ctx.Value(key.Organization).(string)
+	return org
+}
+
+// IsDryRun reports if the request of ctx is part of a preview, when the provider should not
+// make any changes. The engine only sends it with Create, Update, Construct and Call; for
+// other requests IsDryRun returns false.
+func IsDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(key.DryRun).(bool)
+	return dryRun
+}
@@ -35,8 +35,10 @@ func (*Foo) Construct(ctx *pulumi.Context, name string, typ string, inputs FooAr
 }
 
 type FooArgs struct {
-	Foo    pulumi.StringInput `pulumi:"foo"`
-	Bundle Bundle             `pulumi:"bundle"`
+	Foo    pulumi.StringInput      `pulumi:"foo"`
+	Bundle Bundle                  `pulumi:"bundle"`
+	Tags   pulumi.StringArrayInput `pulumi:"tags"`
+	Labels pulumi.StringMapInput   `pulumi:"labels"`
 }
 
 type Bundle struct {
@@ -94,11 +96,25 @@ const componentSchema = `{
                 },
                 "foo": {
                     "type": "string"
+                },
+                "labels": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             },
             "requiredInputs": [
+                "bundle",
                 "foo",
-                "bundle"
+                "labels",
+                "tags"
             ],
             "isComponent": true
         }
@@ -0,0 +1,310 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	pprovider "github.com/pulumi/pulumi/pkg/v3/resource/provider"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+)
+
+type Greeter struct {
+	pulumi.ResourceState
+
+	Message  pulumi.StringOutput `pulumi:"message"`
+	Token    pulumi.StringOutput `pulumi:"token" provider:"secret"`
+	Greeting string              `pulumi:"greeting,optional"`
+	Salt     string              `pulumi:"salt,optional" provider:"secret"`
+}
+
+type GreeterArgs struct {
+	Name  pulumi.StringInput `pulumi:"name"`
+	Count pulumi.IntInput    `pulumi:"count"`
+}
+
+func (*Greeter) Construct(
+	ctx *pulumi.Context, name, typ string, args GreeterArgs, opts pulumi.ResourceOption,
+) (*Greeter, error) {
+	g := &Greeter{}
+	if err := ctx.RegisterComponentResource(typ, name, g, opts); err != nil {
+		return nil, err
+	}
+	g.Message = pulumi.Sprintf("hello %s x%d", args.Name, args.Count)
+	g.Token = pulumi.Sprintf("token-%s", args.Name)
+	g.Greeting = "hello"
+	g.Salt = "pepper"
+	return g, nil
+}
+
+// monitor is a resource monitor and engine that accepts every registration. It records the
+// outputs registered for each resource.
+type monitor struct {
+	pulumirpc.UnimplementedResourceMonitorServer
+	pulumirpc.UnimplementedEngineServer
+
+	m       sync.Mutex
+	outputs map[string]*structpb.Struct
+}
+
+func (*monitor) SupportsFeature(
+	context.Context, *pulumirpc.SupportsFeatureRequest,
+) (*pulumirpc.SupportsFeatureResponse, error) {
+	return &pulumirpc.SupportsFeatureResponse{HasSupport: true}, nil
+}
+
+func (*monitor) RegisterResource(
+	_ context.Context, req *pulumirpc.RegisterResourceRequest,
+) (*pulumirpc.RegisterResourceResponse, error) {
+	return &pulumirpc.RegisterResourceResponse{
+		Urn:    string(resource.NewURN("stack", "proj", "", tokens.Type(req.GetType()), req.GetName())),
+		Object: req.GetObject(),
+	}, nil
+}
+
+func (m *monitor) RegisterResourceOutputs(
+	_ context.Context, req *pulumirpc.RegisterResourceOutputsRequest,
+) (*emptypb.Empty, error) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	m.outputs[req.GetUrn()] = req.GetOutputs()
+	return &emptypb.Empty{}, nil
+}
+
+// registeredOutputs returns the outputs registered for urn.
+func (m *monitor) registeredOutputs(t *testing.T, urn string) resource.PropertyMap {
+	m.m.Lock()
+	defer m.m.Unlock()
+	outputs, ok := m.outputs[urn]
+	require.True(t, ok, "no outputs were registered for %s", urn)
+	props, err := plugin.UnmarshalProperties(outputs, plugin.MarshalOptions{
+		KeepUnknowns:     true,
+		KeepSecrets:      true,
+		KeepOutputValues: true,
+	})
+	require.NoError(t, err)
+	return props
+}
+
+func (*monitor) Log(context.Context, *pulumirpc.LogRequest) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, nil
+}
+
+// greeterServer serves a provider with the Greeter component, and a monitor for it to
+// register resources with. It returns the provider, the monitor and its address.
+func greeterServer(t *testing.T) (pulumirpc.ResourceProviderServer, *monitor, string) {
+	return componentServer(t, infer.Component[*Greeter, GreeterArgs, *Greeter]())
+}
+
+// componentServer serves a provider with component, and a monitor for it to register
+// resources with. It returns the provider, the monitor and its address.
+func componentServer(
+	t *testing.T, component infer.InferredComponent,
+) (pulumirpc.ResourceProviderServer, *monitor, string) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	mon := &monitor{outputs: map[string]*structpb.Struct{}}
+	pulumirpc.RegisterResourceMonitorServer(srv, mon)
+	pulumirpc.RegisterEngineServer(srv, mon)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	host, err := pprovider.NewHostClient(lis.Addr().String())
+	require.NoError(t, err)
+	s, err := p.RawServer("test", "1.0.0", infer.Provider(infer.Options{
+		Components: []infer.InferredComponent{component},
+	}))(host)
+	require.NoError(t, err)
+	return s, mon, lis.Addr().String()
+}
+
+// constructGreeter constructs a Greeter with the name input and returns its state and the
+// outputs registered for it.
+func constructGreeter(
+	t *testing.T, name resource.PropertyValue, deps []string, preview bool,
+) (*pulumirpc.ConstructResponse, resource.PropertyMap, resource.PropertyMap) {
+	s, mon, addr := greeterServer(t)
+	inputs, err := plugin.MarshalProperties(resource.PropertyMap{
+		"name":  name,
+		"count": resource.NewNumberProperty(2),
+	}, plugin.MarshalOptions{KeepUnknowns: true, KeepOutputValues: true})
+	require.NoError(t, err)
+
+	resp, err := s.Construct(context.Background(), &pulumirpc.ConstructRequest{
+		Project:         "proj",
+		Stack:           "stack",
+		Type:            "test:grpc:Greeter",
+		Name:            "greeter",
+		DryRun:          preview,
+		MonitorEndpoint: addr,
+		Inputs:          inputs,
+		InputDependencies: map[string]*pulumirpc.ConstructRequest_PropertyDependencies{
+			"name": {Urns: deps},
+		},
+	})
+	require.NoError(t, err)
+
+	state, err := plugin.UnmarshalProperties(resp.GetState(), plugin.MarshalOptions{
+		KeepUnknowns:     true,
+		KeepSecrets:      true,
+		KeepOutputValues: true,
+	})
+	require.NoError(t, err)
+	return resp, state, mon.registeredOutputs(t, resp.GetUrn())
+}
+
+// TestConstructInputOutputs checks that an output of an upstream resource, passed to an
+// input field of a component, keeps its dependencies and unknownness.
+func TestConstructInputOutputs(t *testing.T) {
+	t.Parallel()
+
+	const upstream = "urn:pulumi:stack::proj::test:index:Upstream::up"
+
+	t.Run("preview", func(t *testing.T) {
+		t.Parallel()
+		resp, state, _ := constructGreeter(t, resource.MakeComputed(resource.NewStringProperty("")),
+			[]string{upstream}, true)
+		assert.Equal(t, []string{upstream}, resp.GetStateDependencies()["message"].GetUrns())
+		assert.True(t, state["message"].ContainsUnknowns(), "message should be unknown, got %v", state["message"])
+	})
+
+	t.Run("update", func(t *testing.T) {
+		t.Parallel()
+		resp, state, _ := constructGreeter(t, resource.NewStringProperty("world"), []string{upstream}, false)
+		assert.Equal(t, []string{upstream}, resp.GetStateDependencies()["message"].GetUrns())
+		assert.Equal(t, resource.NewStringProperty("hello world x2"), state["message"])
+	})
+}
+
+// TestConstructRegistersOutputs checks that the fields of a component are registered as its
+// outputs, with secret fields registered as secrets.
+func TestConstructRegistersOutputs(t *testing.T) {
+	t.Parallel()
+
+	resp, state, outputs := constructGreeter(t, resource.NewStringProperty("world"), nil, false)
+	assert.Equal(t, "urn:pulumi:stack::proj::test:grpc:Greeter::greeter", resp.GetUrn())
+	assert.Equal(t, resource.PropertyMap{
+		"message":  resource.NewStringProperty("hello world x2"),
+		"token":    resource.MakeSecret(resource.NewStringProperty("token-world")),
+		"greeting": resource.NewStringProperty("hello"),
+		"salt":     resource.MakeSecret(resource.NewStringProperty("pepper")),
+	}, outputs)
+
+	// The outputs in the response to Construct are secret too.
+	assert.True(t, state["token"].ContainsSecrets(), "token should be secret, got %v", state["token"])
+}
+
+type Roster struct {
+	pulumi.ResourceState
+
+	Members pulumi.StringArrayOutput `pulumi:"members"`
+	Labels  pulumi.StringMapOutput   `pulumi:"labels"`
+	Summary pulumi.StringOutput      `pulumi:"summary"`
+}
+
+type RosterArgs struct {
+	Members pulumi.StringArrayInput `pulumi:"members"`
+	Labels  pulumi.StringMapInput   `pulumi:"labels"`
+}
+
+func (*Roster) Construct(
+	ctx *pulumi.Context, name, typ string, args RosterArgs, opts pulumi.ResourceOption,
+) (*Roster, error) {
+	r := &Roster{}
+	if err := ctx.RegisterComponentResource(typ, name, r, opts); err != nil {
+		return nil, err
+	}
+	r.Members = args.Members.ToStringArrayOutput()
+	r.Labels = args.Labels.ToStringMapOutput()
+	r.Summary = pulumi.All(args.Members, args.Labels).ApplyT(func(v []any) string {
+		return fmt.Sprintf("%s (%s)", strings.Join(v[0].([]string), ", "), v[1].(map[string]string)["team"])
+	}).(pulumi.StringOutput)
+	return r, nil
+}
+
+// TestConstructContainerInputs checks that array and map inputs of a component, such as
+// pulumi.StringArrayInput and pulumi.StringMapInput, are passed to Construct as inputs.
+func TestConstructContainerInputs(t *testing.T) {
+	t.Parallel()
+
+	construct := func(t *testing.T, members resource.PropertyValue, preview bool) resource.PropertyMap {
+		s, _, addr := componentServer(t, infer.Component[*Roster, RosterArgs, *Roster]())
+		inputs, err := plugin.MarshalProperties(resource.PropertyMap{
+			"members": members,
+			"labels":  resource.NewObjectProperty(resource.PropertyMap{"team": resource.NewStringProperty("core")}),
+		}, plugin.MarshalOptions{KeepUnknowns: true, KeepOutputValues: true})
+		require.NoError(t, err)
+
+		resp, err := s.Construct(context.Background(), &pulumirpc.ConstructRequest{
+			Project:         "proj",
+			Stack:           "stack",
+			Type:            "test:grpc:Roster",
+			Name:            "roster",
+			DryRun:          preview,
+			MonitorEndpoint: addr,
+			Inputs:          inputs,
+		})
+		require.NoError(t, err)
+		state, err := plugin.UnmarshalProperties(resp.GetState(), plugin.MarshalOptions{
+			KeepUnknowns:     true,
+			KeepSecrets:      true,
+			KeepOutputValues: true,
+		})
+		require.NoError(t, err)
+		return state
+	}
+
+	t.Run("update", func(t *testing.T) {
+		t.Parallel()
+		state := construct(t, resource.NewArrayProperty([]resource.PropertyValue{
+			resource.NewStringProperty("ann"), resource.NewStringProperty("bob"),
+		}), false)
+		assert.Equal(t, resource.PropertyMap{
+			"members": resource.NewArrayProperty([]resource.PropertyValue{
+				resource.NewStringProperty("ann"), resource.NewStringProperty("bob"),
+			}),
+			"labels":  resource.NewObjectProperty(resource.PropertyMap{"team": resource.NewStringProperty("core")}),
+			"summary": resource.NewStringProperty("ann, bob (core)"),
+		}, state)
+	})
+
+	t.Run("preview", func(t *testing.T) {
+		t.Parallel()
+		state := construct(t, resource.NewArrayProperty([]resource.PropertyValue{
+			resource.NewStringProperty("ann"), resource.MakeComputed(resource.NewStringProperty("")),
+		}), true)
+		assert.True(t, state["summary"].ContainsUnknowns(), "summary should be unknown, got %v", state["summary"])
+		assert.Equal(t, resource.NewObjectProperty(resource.PropertyMap{"team": resource.NewStringProperty("core")}),
+			state["labels"])
+	})
+}
@@ -16,14 +16,17 @@ package tests
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 
 	"github.com/blang/semver"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	p "github.com/pulumi/pulumi-go-provider"
 	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/infer/types"
 	"github.com/pulumi/pulumi-go-provider/integration"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 )
@@ -67,3 +70,129 @@ func TestInferInvokeSecrets(t *testing.T) {
 		"out": resource.MakeSecret(resource.NewProperty("value-secret")),
 	}, resp.Return)
 }
+
+type listCredentials struct{}
+
+type listCredentialsInput struct {
+	Users []string `pulumi:"users"`
+}
+
+type credential struct {
+	Username string `pulumi:"username"`
+	Password string `pulumi:"password" provider:"secret"`
+}
+
+type listCredentialsOutput struct {
+	Out    []credential          `pulumi:"out"`
+	ByUser map[string]credential `pulumi:"byUser"`
+}
+
+func (listCredentials) Call(ctx context.Context, args listCredentialsInput) (listCredentialsOutput, error) {
+	out := listCredentialsOutput{ByUser: map[string]credential{}}
+	for _, user := range args.Users {
+		c := credential{Username: user, Password: user + "-password"}
+		out.Out = append(out.Out, c)
+		out.ByUser[user] = c
+	}
+	return out, nil
+}
+
+func (listCredentials) Annotate(a infer.Annotator) { a.SetToken("index", "listCredentials") }
+
+func TestInferInvokeNestedSecrets(t *testing.T) {
+	t.Parallel()
+
+	resp, err := integration.NewServer("test", semver.MustParse("0.0.0"), infer.Provider(infer.Options{
+		Functions: []infer.InferredFunction{
+			infer.Function[listCredentials, listCredentialsInput, listCredentialsOutput](),
+		},
+	})).Invoke(p.InvokeRequest{
+		Token: "test:index:listCredentials",
+		Args: resource.PropertyMap{
+			"users": resource.NewProperty([]resource.PropertyValue{
+				resource.NewProperty("alice"),
+				resource.NewProperty("bob"),
+			}),
+		},
+	})
+	require.NoError(t, err)
+	require.Empty(t, resp.Failures)
+
+	cred := func(user string) resource.PropertyValue {
+		return resource.NewProperty(resource.PropertyMap{
+			"username": resource.NewProperty(user),
+			"password": resource.MakeSecret(resource.NewProperty(user + "-password")),
+		})
+	}
+	assert.Equal(t, resource.PropertyMap{
+		"out": resource.NewProperty([]resource.PropertyValue{cred("alice"), cred("bob")}),
+		"byUser": resource.NewProperty(resource.PropertyMap{
+			"alice": cred("alice"),
+			"bob":   cred("bob"),
+		}),
+	}, resp.Return)
+}
+
+type refBucket struct{}
+
+type refBucketArgs struct {
+	Name string `pulumi:"name"`
+}
+
+func (refBucket) Create(
+	ctx context.Context, name string, args refBucketArgs, preview bool,
+) (string, refBucketArgs, error) {
+	return args.Name, args, nil
+}
+
+type describeBucket struct{}
+
+type describeBucketInput struct {
+	Bucket types.ResourceReference[refBucket] `pulumi:"bucket"`
+}
+
+type describeBucketOutput struct {
+	Bucket types.ResourceReference[refBucket] `pulumi:"bucket"`
+	Region string                             `pulumi:"region"`
+}
+
+func (describeBucket) Call(ctx context.Context, args describeBucketInput) (describeBucketOutput, error) {
+	return describeBucketOutput{
+		Bucket: args.Bucket,
+		Region: "region-of-" + args.Bucket.ID,
+	}, nil
+}
+
+func TestInferInvokeResourceReference(t *testing.T) {
+	t.Parallel()
+
+	s := integration.NewServer("test", semver.MustParse("0.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[refBucket, refBucketArgs, refBucketArgs]()},
+		Functions: []infer.InferredFunction{
+			infer.Function[describeBucket, describeBucketInput, describeBucketOutput](),
+		},
+	}))
+
+	schema, err := s.GetSchema(p.GetSchemaRequest{})
+	require.NoError(t, err)
+	var spec pschema.PackageSpec
+	require.NoError(t, json.Unmarshal([]byte(schema.Schema), &spec))
+	fn := spec.Functions["test:tests:describeBucket"]
+	assert.Equal(t, "#/resources/test:tests:refBucket", fn.Inputs.Properties["bucket"].Ref)
+	assert.Equal(t, "#/resources/test:tests:refBucket", fn.ReturnType.ObjectTypeSpec.Properties["bucket"].Ref)
+
+	ref := resource.ResourceReference{
+		URN: resource.NewURN("stack", "proj", "", "test:tests:refBucket", "my-bucket"),
+		ID:  resource.NewProperty("bucket-id"),
+	}
+	resp, err := s.Invoke(p.InvokeRequest{
+		Token: "test:tests:describeBucket",
+		Args:  resource.PropertyMap{"bucket": resource.NewResourceReferenceProperty(ref)},
+	})
+	require.NoError(t, err)
+	require.Empty(t, resp.Failures)
+	assert.Equal(t, resource.PropertyMap{
+		"bucket": resource.NewResourceReferenceProperty(ref),
+		"region": resource.NewProperty("region-of-bucket-id"),
+	}, resp.Return)
+}
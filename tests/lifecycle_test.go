@@ -0,0 +1,133 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
+)
+
+// kvStore is a trivial in-memory backend for the kvEntry resource.
+type kvStore struct {
+	m       sync.Mutex
+	entries map[string]string
+}
+
+func (s *kvStore) set(key, value string) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.entries[key] = value
+}
+
+func (s *kvStore) delete(key string) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	delete(s.entries, key)
+}
+
+type kvStoreKey struct{}
+
+type kvEntry struct{}
+
+type kvEntryArgs struct {
+	Key   string `pulumi:"key" provider:"replaceOnChanges"`
+	Value string `pulumi:"value"`
+}
+
+func store(ctx context.Context) *kvStore { return ctx.Value(kvStoreKey{}).(*kvStore) }
+
+func (kvEntry) Create(ctx context.Context, name string, args kvEntryArgs, preview bool) (string, kvEntryArgs, error) {
+	if !preview {
+		store(ctx).set(args.Key, args.Value)
+	}
+	return args.Key, args, nil
+}
+
+func (kvEntry) Update(
+	ctx context.Context, id string, olds kvEntryArgs, news kvEntryArgs, preview bool,
+) (kvEntryArgs, error) {
+	if !preview {
+		store(ctx).set(news.Key, news.Value)
+	}
+	return news, nil
+}
+
+func (kvEntry) Delete(ctx context.Context, id string, props kvEntryArgs) error {
+	store(ctx).delete(props.Key)
+	return nil
+}
+
+func TestLifeCycle(t *testing.T) {
+	t.Parallel()
+
+	kv := &kvStore{entries: map[string]string{}}
+	server := integration.NewServerWithContext(
+		context.WithValue(context.Background(), kvStoreKey{}, kv),
+		"test", semver.MustParse("1.0.0"),
+		infer.Provider(infer.Options{
+			Resources: []infer.InferredResource{infer.Resource[kvEntry, kvEntryArgs, kvEntryArgs]()},
+		}),
+	)
+
+	entry := func(key, value string) resource.PropertyMap {
+		return resource.PropertyMap{
+			"key":   resource.NewStringProperty(key),
+			"value": resource.NewStringProperty(value),
+		}
+	}
+
+	integration.LifeCycleTest{
+		Resource: "test:tests:kvEntry",
+		Create: integration.Operation{
+			Inputs:         entry("a", "1"),
+			ExpectedOutput: entry("a", "1"),
+			Hook: func(_, _ resource.PropertyMap) {
+				assert.Equal(t, map[string]string{"a": "1"}, kv.entries)
+			},
+		},
+		Updates: []integration.Operation{
+			{
+				// Changing the value is done in place.
+				Inputs:         entry("a", "2"),
+				ExpectedOutput: entry("a", "2"),
+				ExpectedDiff:   map[string]p.PropertyDiff{"value": {Kind: p.Update}},
+				Hook: func(_, _ resource.PropertyMap) {
+					assert.Equal(t, map[string]string{"a": "2"}, kv.entries)
+				},
+			},
+			{
+				Inputs:       entry("a", "2"),
+				ExpectedDiff: map[string]p.PropertyDiff{},
+			},
+			{
+				// Changing the key replaces the entry.
+				Inputs:         entry("b", "2"),
+				ExpectedOutput: entry("b", "2"),
+				ExpectedDiff:   map[string]p.PropertyDiff{"key": {Kind: p.UpdateReplace}},
+			},
+		},
+	}.Run(t, server)
+
+	assert.Empty(t, kv.entries, "every entry should be deleted at the end of the lifecycle")
+}
@@ -0,0 +1,80 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
+)
+
+type logged struct{}
+
+type loggedArgs struct {
+	Name string `pulumi:"name"`
+}
+
+func (logged) Create(ctx context.Context, name string, args loggedArgs, preview bool) (string, loggedArgs, error) {
+	log := p.GetLogger(ctx)
+	log.InfoStatus("creating " + args.Name)
+	log.Debugf("using name %q", name)
+	log.Warning("this resource is deprecated")
+	return name, args, nil
+}
+
+type loggedFn struct{}
+
+func (loggedFn) Call(ctx context.Context, args loggedArgs) (loggedArgs, error) {
+	p.GetLogger(ctx).Errorf("could not find %s", args.Name)
+	return args, nil
+}
+
+func TestLogging(t *testing.T) {
+	t.Parallel()
+
+	s := integration.NewServer("test", semver.MustParse("0.0.0"), infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[logged, loggedArgs, loggedArgs]()},
+		Functions: []infer.InferredFunction{infer.Function[loggedFn, loggedArgs, loggedArgs]()},
+	}))
+
+	urn := resource.NewURN("stack", "proj", "", "test:tests:logged", "res")
+	_, err := s.Create(p.CreateRequest{
+		Urn:        urn,
+		Properties: resource.PropertyMap{"name": resource.NewStringProperty("bucket")},
+	})
+	require.NoError(t, err)
+
+	_, err = s.Invoke(p.InvokeRequest{
+		Token: "test:tests:loggedFn",
+		Args:  resource.PropertyMap{"name": resource.NewStringProperty("key")},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []integration.LogMessage{
+		{Severity: diag.Info, URN: urn, Message: "creating bucket", Status: true},
+		{Severity: diag.Debug, URN: urn, Message: `using name "res"`},
+		{Severity: diag.Warning, URN: urn, Message: "this resource is deprecated"},
+		{Severity: diag.Error, Message: "could not find key"},
+	}, s.Logs())
+}
@@ -0,0 +1,167 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/integration"
+	"github.com/pulumi/pulumi-go-provider/middleware/metrics"
+)
+
+func meteredProvider(opts metrics.Options) integration.Server {
+	return integration.NewServer("test", semver.MustParse("1.0.0"), metrics.Wrap(p.Provider{
+		Create: func(_ context.Context, req p.CreateRequest) (p.CreateResponse, error) {
+			if req.Properties["fail"].IsBool() && req.Properties["fail"].BoolValue() {
+				return p.CreateResponse{}, fmt.Errorf("failed")
+			}
+			return p.CreateResponse{ID: "id"}, nil
+		},
+		Delete: func(context.Context, p.DeleteRequest) error { return nil },
+		Invoke: func(context.Context, p.InvokeRequest) (p.InvokeResponse, error) {
+			return p.InvokeResponse{}, nil
+		},
+	}, opts))
+}
+
+func meteredURN(typ string) resource.URN {
+	return resource.NewURN("stack", "proj", "", tokens.Type(typ), "r")
+}
+
+// histogramCounts returns the number of observations of each series of a histogram,
+// keyed by the values of its labels.
+func histogramCounts(t *testing.T, reg prometheus.Gatherer, name string) map[string]uint64 {
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	counts := map[string]uint64{}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			var labels []string
+			for _, l := range m.GetLabel() {
+				labels = append(labels, l.GetName()+"="+l.GetValue())
+			}
+			counts[strings.Join(labels, ",")] = m.GetHistogram().GetSampleCount()
+		}
+	}
+	return counts
+}
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewRegistry()
+	s := meteredProvider(metrics.Options{Registerer: reg})
+
+	for i := 0; i < 3; i++ {
+		_, err := s.Create(p.CreateRequest{Urn: meteredURN("test:index:Widget")})
+		require.NoError(t, err)
+	}
+	_, err := s.Create(p.CreateRequest{
+		Urn:        meteredURN("test:index:Widget"),
+		Properties: resource.PropertyMap{"fail": resource.NewBoolProperty(true)},
+	})
+	require.Error(t, err)
+	require.NoError(t, s.Delete(p.DeleteRequest{ID: "id", Urn: meteredURN("test:index:Gadget")}))
+	_, err = s.Invoke(p.InvokeRequest{Token: "test:index:lookup"})
+	require.NoError(t, err)
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP pulumi_provider_operations_total The number of provider operations, by method, token and result.
+# TYPE pulumi_provider_operations_total counter
+pulumi_provider_operations_total{method="Create",result="error",token="test:index:Widget"} 1
+pulumi_provider_operations_total{method="Create",result="success",token="test:index:Widget"} 3
+pulumi_provider_operations_total{method="Delete",result="success",token="test:index:Gadget"} 1
+pulumi_provider_operations_total{method="Invoke",result="success",token="test:index:lookup"} 1
+`), "pulumi_provider_operations_total"))
+
+	assert.Equal(t, map[string]uint64{
+		"method=Create,token=test:index:Widget": 4,
+		"method=Delete,token=test:index:Gadget": 1,
+		"method=Invoke,token=test:index:lookup": 1,
+	}, histogramCounts(t, reg, "pulumi_provider_operation_duration_seconds"))
+}
+
+func TestMetricsMaxTokens(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewRegistry()
+	s := meteredProvider(metrics.Options{Registerer: reg, Namespace: "bounded", MaxTokens: 2})
+
+	for _, typ := range []string{"test:index:A", "test:index:B", "test:index:C", "test:index:A", "test:index:D"} {
+		_, err := s.Create(p.CreateRequest{Urn: meteredURN(typ)})
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP bounded_operations_total The number of provider operations, by method, token and result.
+# TYPE bounded_operations_total counter
+bounded_operations_total{method="Create",result="success",token="other"} 2
+bounded_operations_total{method="Create",result="success",token="test:index:A"} 2
+bounded_operations_total{method="Create",result="success",token="test:index:B"} 1
+`), "bounded_operations_total"))
+}
+
+func TestMetricsAggregateTokens(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewRegistry()
+	s := meteredProvider(metrics.Options{Registerer: reg, AggregateTokens: true})
+
+	for _, typ := range []string{"test:index:A", "test:index:B"} {
+		_, err := s.Create(p.CreateRequest{Urn: meteredURN(typ)})
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, map[string]uint64{
+		"method=Create,token=other": 2,
+	}, histogramCounts(t, reg, "pulumi_provider_operation_duration_seconds"))
+}
+
+func TestMetricsPanic(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewRegistry()
+	prov := metrics.Wrap(p.Provider{
+		Create: func(context.Context, p.CreateRequest) (p.CreateResponse, error) {
+			panic("boom")
+		},
+	}, metrics.Options{Registerer: reg})
+
+	assert.PanicsWithValue(t, "boom", func() {
+		_, _ = prov.Create(context.Background(), p.CreateRequest{Urn: meteredURN("test:index:Widget")})
+	})
+
+	// An operation that panics is recorded as an error.
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP pulumi_provider_operations_total The number of provider operations, by method, token and result.
+# TYPE pulumi_provider_operations_total counter
+pulumi_provider_operations_total{method="Create",result="error",token="test:index:Widget"} 1
+`), "pulumi_provider_operations_total"))
+}
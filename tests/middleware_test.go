@@ -0,0 +1,122 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
+	"github.com/pulumi/pulumi-go-provider/middleware/cancel"
+)
+
+// rpcCounter is a user defined middleware that counts the RPCs that reach the provider
+// it wraps.
+type rpcCounter struct {
+	m      sync.Mutex
+	counts map[string]int
+}
+
+func (c *rpcCounter) observe(method string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.counts[method]++
+}
+
+func (c *rpcCounter) wrap(provider p.Provider) p.Provider {
+	provider.Check = count2(c, "Check", provider.Check)
+	provider.Diff = count2(c, "Diff", provider.Diff)
+	provider.Create = count2(c, "Create", provider.Create)
+	provider.Update = count2(c, "Update", provider.Update)
+	provider.Delete = count1(c, "Delete", provider.Delete)
+	return provider
+}
+
+func count1[Req any](
+	c *rpcCounter, method string, f func(context.Context, Req) error,
+) func(context.Context, Req) error {
+	if f == nil {
+		return nil
+	}
+	return func(ctx context.Context, req Req) error {
+		c.observe(method)
+		return f(ctx, req)
+	}
+}
+
+func count2[Req, Resp any](
+	c *rpcCounter, method string, f func(context.Context, Req) (Resp, error),
+) func(context.Context, Req) (Resp, error) {
+	if f == nil {
+		return nil
+	}
+	return func(ctx context.Context, req Req) (Resp, error) {
+		c.observe(method)
+		return f(ctx, req)
+	}
+}
+
+func TestMiddlewareComposition(t *testing.T) {
+	t.Parallel()
+
+	kv := &kvStore{entries: map[string]string{}}
+	counter := &rpcCounter{counts: map[string]int{}}
+
+	// The user middleware sits between the host, represented by the cancel middleware, and
+	// the inferred provider.
+	provider := cancel.Wrap(counter.wrap(infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[kvEntry, kvEntryArgs, kvEntryArgs]()},
+	})))
+	server := integration.NewServerWithContext(
+		context.WithValue(context.Background(), kvStoreKey{}, kv),
+		"test", semver.MustParse("1.0.0"), provider,
+	)
+
+	entry := func(key, value string) resource.PropertyMap {
+		return resource.PropertyMap{
+			"key":   resource.NewStringProperty(key),
+			"value": resource.NewStringProperty(value),
+		}
+	}
+
+	integration.LifeCycleTest{
+		Resource: "test:tests:kvEntry",
+		Create: integration.Operation{
+			Inputs:         entry("a", "1"),
+			ExpectedOutput: entry("a", "1"),
+		},
+		Updates: []integration.Operation{{
+			Inputs:         entry("a", "2"),
+			ExpectedOutput: entry("a", "2"),
+		}},
+	}.Run(t, server)
+
+	// Create and Update are each called once as a preview and once for real.
+	assert.Equal(t, map[string]int{
+		"Check":  2,
+		"Diff":   1,
+		"Create": 2,
+		"Update": 2,
+		"Delete": 1,
+	}, counter.counts)
+	assert.Empty(t, kv.entries)
+}
@@ -0,0 +1,160 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
+)
+
+const syntheticItemCount = 10_000
+
+type item struct {
+	Name string `pulumi:"name"`
+	Size int    `pulumi:"size"`
+}
+
+// fetchItems returns a page of synthetic items, standing in for a paginated upstream API.
+func fetchItems(_ context.Context, cursor *string, pageSize int) (infer.PageResult[item], error) {
+	start := 0
+	if cursor != nil {
+		var err error
+		if start, err = strconv.Atoi(*cursor); err != nil {
+			return infer.PageResult[item]{}, fmt.Errorf("invalid cursor %q", *cursor)
+		}
+	}
+	end := min(start+pageSize, syntheticItemCount)
+	page := infer.PageResult[item]{Items: make([]item, 0, end-start)}
+	for i := start; i < end; i++ {
+		page.Items = append(page.Items, item{Name: fmt.Sprintf("item-%05d", i), Size: i})
+	}
+	if end < syntheticItemCount {
+		next := strconv.Itoa(end)
+		page.NextCursor = &next
+	}
+	return page, nil
+}
+
+type listItems struct{}
+
+type listItemsArgs struct {
+	infer.PageArgs
+}
+
+type listItemsResult struct {
+	infer.PageResult[item]
+}
+
+func (listItems) Call(ctx context.Context, args listItemsArgs) (listItemsResult, error) {
+	pageSize := 100
+	if args.PageSize != nil {
+		pageSize = *args.PageSize
+	}
+	page, err := fetchItems(ctx, args.Cursor, pageSize)
+	return listItemsResult{page}, err
+}
+
+type listAllItems struct{}
+
+type listAllItemsResult struct {
+	Items []item `pulumi:"items"`
+}
+
+func (listAllItems) Call(ctx context.Context, _ struct{}) (listAllItemsResult, error) {
+	items, err := infer.Paginate(ctx, func(ctx context.Context, cursor *string) (infer.PageResult[item], error) {
+		return fetchItems(ctx, cursor, 1000)
+	})
+	return listAllItemsResult{items}, err
+}
+
+func paginationServer() integration.Server {
+	return integration.NewServer("test", semver.MustParse("0.0.0"), infer.Provider(infer.Options{
+		Functions: []infer.InferredFunction{
+			infer.Function[listItems, listItemsArgs, listItemsResult](),
+			infer.Function[listAllItems, struct{}, listAllItemsResult](),
+		},
+	}))
+}
+
+func TestPaginatedInvoke(t *testing.T) {
+	t.Parallel()
+	s := paginationServer()
+
+	var names []string
+	args := resource.PropertyMap{"pageSize": resource.NewProperty(2500.0)}
+	for {
+		resp, err := s.Invoke(p.InvokeRequest{Token: "test:tests:listItems", Args: args})
+		require.NoError(t, err)
+		require.Empty(t, resp.Failures)
+		for _, v := range resp.Return["items"].ArrayValue() {
+			names = append(names, v.ObjectValue()["name"].StringValue())
+		}
+		next, ok := resp.Return["nextCursor"]
+		if !ok || next.IsNull() {
+			break
+		}
+		args["cursor"] = next
+	}
+	require.Len(t, names, syntheticItemCount)
+	assert.Equal(t, "item-00000", names[0])
+	assert.Equal(t, "item-09999", names[syntheticItemCount-1])
+}
+
+func TestPaginateAll(t *testing.T) {
+	t.Parallel()
+
+	resp, err := paginationServer().Invoke(p.InvokeRequest{Token: "test:tests:listAllItems"})
+	require.NoError(t, err)
+	require.Empty(t, resp.Failures)
+	items := resp.Return["items"].ArrayValue()
+	require.Len(t, items, syntheticItemCount)
+	assert.Equal(t, resource.NewProperty(resource.PropertyMap{
+		"name": resource.NewProperty("item-09999"),
+		"size": resource.NewProperty(9999.0),
+	}), items[syntheticItemCount-1])
+}
+
+func TestPaginateStuckCursor(t *testing.T) {
+	t.Parallel()
+
+	stuck := "same"
+	_, err := infer.Paginate(context.Background(), func(context.Context, *string) (infer.PageResult[item], error) {
+		return infer.PageResult[item]{NextCursor: &stuck}, nil
+	})
+	assert.ErrorContains(t, err, `pagination returned cursor "same" more than once`)
+
+	// Cursors that cycle back to an earlier page are caught too.
+	next := map[string]string{"": "a", "a": "b", "b": "a"}
+	_, err = infer.Paginate(context.Background(), func(_ context.Context, cursor *string) (infer.PageResult[item], error) {
+		var c string
+		if cursor != nil {
+			c = *cursor
+		}
+		n := next[c]
+		return infer.PageResult[item]{NextCursor: &n}, nil
+	})
+	assert.ErrorContains(t, err, `pagination returned cursor "a" more than once`)
+}
@@ -16,10 +16,19 @@ package tests
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/blang/semver"
 	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -113,3 +122,181 @@ func TestParameterize(t *testing.T) {
 		inputError: `invalid version "not-a-version"`,
 	}))
 }
+
+type baseResource struct{}
+
+func (baseResource) Create(ctx context.Context, name string, args struct{}, preview bool) (string, struct{}, error) {
+	return name, args, nil
+}
+
+type widget struct{}
+
+type widgetArgs struct {
+	Color string `pulumi:"color"`
+}
+
+func (widget) Create(ctx context.Context, name string, args widgetArgs, preview bool) (string, widgetArgs, error) {
+	return name, args, nil
+}
+
+type gadget struct{}
+
+func (gadget) Create(ctx context.Context, name string, args struct{}, preview bool) (string, struct{}, error) {
+	return name, args, nil
+}
+
+// parameterizedProvider serves a package named by its first argument, with the resources
+// named by the other arguments. The other options of the provider are taken from opts.
+func parameterizedProvider(opts infer.Options) p.Provider {
+	available := map[string]infer.InferredResource{
+		"widget": infer.Resource[widget, widgetArgs, widgetArgs](),
+		"gadget": infer.Resource[gadget, struct{}, struct{}](),
+	}
+	opts.Resources = []infer.InferredResource{infer.Resource[baseResource, struct{}, struct{}]()}
+	opts.Parameterize = func(_ context.Context, req p.ParameterizeRequest) (infer.Parameterization, error) {
+		var args []string
+		if req.Args != nil {
+			args = req.Args.Args
+		} else {
+			args = strings.Split(string(req.Value.Value), ",")
+		}
+		if len(args) < 2 {
+			return infer.Parameterization{}, fmt.Errorf("expected a package name and resources, got %q", args)
+		}
+		param := infer.Parameterization{
+			Name:    args[0],
+			Version: semver.MustParse("2.0.0"),
+			Value:   []byte(strings.Join(args, ",")),
+		}
+		for _, name := range args[1:] {
+			r, ok := available[name]
+			if !ok {
+				return infer.Parameterization{}, fmt.Errorf("unknown resource %q", name)
+			}
+			param.Resources = append(param.Resources, r)
+		}
+		return param, nil
+	}
+	return infer.Provider(opts)
+}
+
+func TestInferParameterize(t *testing.T) {
+	t.Parallel()
+
+	getSchema := func(t *testing.T, s integration.Server) pschema.PackageSpec {
+		resp, err := s.GetSchema(p.GetSchemaRequest{})
+		require.NoError(t, err)
+		var spec pschema.PackageSpec
+		require.NoError(t, json.Unmarshal([]byte(resp.Schema), &spec))
+		return spec
+	}
+
+	check := func(t *testing.T, s integration.Server) {
+		spec := getSchema(t, s)
+		assert.Equal(t, "widgets", spec.Name)
+		assert.Equal(t, "2.0.0", spec.Version)
+		assert.Equal(t, &pschema.ParameterizationSpec{
+			BaseProvider: pschema.BaseProviderSpec{Name: "test", Version: "1.0.0"},
+			Parameter:    []byte("widgets,widget"),
+		}, spec.Parameterization)
+		assert.Equal(t, []string{"widgets:tests:widget"}, keys(spec.Resources))
+
+		resp, err := s.Create(p.CreateRequest{
+			Urn:        resource.NewURN("stack", "proj", "", "widgets:tests:widget", "w"),
+			Properties: resource.PropertyMap{"color": resource.NewProperty("red")},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, resource.PropertyMap{"color": resource.NewProperty("red")}, resp.Properties)
+	}
+
+	t.Run("before parameterization", func(t *testing.T) {
+		t.Parallel()
+		s := integration.NewServer("test", semver.MustParse("1.0.0"), parameterizedProvider(infer.Options{}))
+		spec := getSchema(t, s)
+		assert.Equal(t, "test", spec.Name)
+		assert.Nil(t, spec.Parameterization)
+		assert.Equal(t, []string{"test:tests:baseResource"}, keys(spec.Resources))
+	})
+
+	t.Run("args", func(t *testing.T) {
+		t.Parallel()
+		s := integration.NewServer("test", semver.MustParse("1.0.0"), parameterizedProvider(infer.Options{}))
+		resp, err := s.Parameterize(p.ParameterizeRequest{
+			Args: &p.ParameterizeRequestArgs{Args: []string{"widgets", "widget"}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, p.ParameterizeResponse{Name: "widgets", Version: semver.MustParse("2.0.0")}, resp)
+		check(t, s)
+	})
+
+	t.Run("value", func(t *testing.T) {
+		t.Parallel()
+		s := integration.NewServer("test", semver.MustParse("1.0.0"), parameterizedProvider(infer.Options{}))
+		_, err := s.Parameterize(p.ParameterizeRequest{
+			Value: &p.ParameterizeRequestValue{
+				Name:    "widgets",
+				Version: semver.MustParse("2.0.0"),
+				Value:   []byte("widgets,widget"),
+			},
+		})
+		require.NoError(t, err)
+		check(t, s)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		t.Parallel()
+		s := integration.NewServer("test", semver.MustParse("1.0.0"), parameterizedProvider(infer.Options{}))
+		_, err := s.Parameterize(p.ParameterizeRequest{
+			Args: &p.ParameterizeRequestArgs{Args: []string{"widgets", "sprocket"}},
+		})
+		assert.ErrorContains(t, err, `unknown resource "sprocket"`)
+		assert.Equal(t, "test", getSchema(t, s).Name)
+	})
+}
+
+func TestInferParameterizeOptions(t *testing.T) {
+	t.Parallel()
+
+	parameterize := func(t *testing.T, opts infer.Options) integration.Server {
+		s := integration.NewServer("test", semver.MustParse("1.0.0"), parameterizedProvider(opts))
+		_, err := s.Parameterize(p.ParameterizeRequest{
+			Args: &p.ParameterizeRequestArgs{Args: []string{"widgets", "widget"}},
+		})
+		require.NoError(t, err)
+		return s
+	}
+
+	t.Run("explain diffs", func(t *testing.T) {
+		t.Parallel()
+		s := parameterize(t, infer.Options{ExplainDiffs: true})
+		urn := resource.NewURN("stack", "proj", "", "widgets:tests:widget", "w")
+		_, err := s.Diff(p.DiffRequest{
+			Urn:  urn,
+			ID:   "w",
+			Olds: resource.PropertyMap{"color": resource.NewProperty("red")},
+			News: resource.PropertyMap{"color": resource.NewProperty("blue")},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []integration.LogMessage{{
+			Severity: diag.Info,
+			URN:      urn,
+			Message:  "the resource will be replaced because these properties changed:\n  color (update, forces replacement)",
+		}}, s.Logs())
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		t.Parallel()
+		s := parameterize(t, infer.Options{Strict: true})
+		_, err := s.GetSchema(p.GetSchemaRequest{})
+		assert.ErrorContains(t, err, "widgets:tests:widget")
+	})
+}
+
+func keys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
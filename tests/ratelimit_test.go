@@ -0,0 +1,137 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/integration"
+	"github.com/pulumi/pulumi-go-provider/middleware/ratelimit"
+)
+
+var rateLimitedURN = resource.NewURN("stack", "proj", "", "ratelimit:index:Res", "r")
+
+func TestRateLimitConcurrency(t *testing.T) {
+	t.Parallel()
+
+	const limit = 3
+	var inFlight, maxInFlight atomic.Int32
+	s := integration.NewServer("ratelimit", semver.MustParse("1.0.0"), ratelimit.Wrap(p.Provider{
+		Configure: func(context.Context, p.ConfigureRequest) error { return nil },
+		Create: func(ctx context.Context, req p.CreateRequest) (p.CreateResponse, error) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				prev := maxInFlight.Load()
+				if n <= prev || maxInFlight.CompareAndSwap(prev, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			return p.CreateResponse{ID: "id"}, nil
+		},
+	}, ratelimit.Options{
+		FromConfig: func(_ context.Context, args resource.PropertyMap) (ratelimit.Limits, error) {
+			return ratelimit.Limits{MaxConcurrent: int(args["maxConcurrent"].NumberValue())}, nil
+		},
+	}))
+
+	require.NoError(t, s.Configure(p.ConfigureRequest{
+		Args: resource.PropertyMap{"maxConcurrent": resource.NewNumberProperty(limit)},
+	}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.Create(p.CreateRequest{Urn: rateLimitedURN})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(limit))
+	assert.Positive(t, maxInFlight.Load())
+
+	var throttled bool
+	for _, msg := range s.Logs() {
+		if msg.Severity == diag.Debug {
+			throttled = true
+		}
+	}
+	assert.True(t, throttled, "expected a debug message when throttling")
+}
+
+func TestRateLimitRate(t *testing.T) {
+	t.Parallel()
+
+	s := integration.NewServer("ratelimit", semver.MustParse("1.0.0"), ratelimit.Wrap(p.Provider{
+		Delete: func(context.Context, p.DeleteRequest) error { return nil },
+	}, ratelimit.Options{
+		Limits: ratelimit.Limits{Rate: 200, Burst: 1},
+	}))
+
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		require.NoError(t, s.Delete(p.DeleteRequest{ID: "id", Urn: rateLimitedURN}))
+	}
+	// The first delete uses the burst, and each of the 9 others waits 1/200th of a second.
+	assert.GreaterOrEqual(t, time.Since(start), 45*time.Millisecond)
+}
+
+func TestRateLimitCancel(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	s := integration.NewServerWithContext(ctx, "ratelimit", semver.MustParse("1.0.0"), ratelimit.Wrap(p.Provider{
+		Update: func(context.Context, p.UpdateRequest) (p.UpdateResponse, error) {
+			close(started)
+			<-release
+			return p.UpdateResponse{}, nil
+		},
+	}, ratelimit.Options{
+		Limits: ratelimit.Limits{MaxConcurrent: 1},
+	}))
+
+	done := make(chan error)
+	go func() {
+		_, err := s.Update(p.UpdateRequest{ID: "id", Urn: rateLimitedURN})
+		done <- err
+	}()
+	<-started
+
+	// The second update blocks until its context is canceled.
+	go cancel()
+	_, err := s.Update(p.UpdateRequest{ID: "id", Urn: rateLimitedURN})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	close(release)
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,176 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi-go-provider/integration"
+	"github.com/pulumi/pulumi-go-provider/middleware/tracing"
+)
+
+type tracedWidget struct{}
+
+type tracedWidgetArgs struct {
+	Name string `pulumi:"name"`
+	Fail bool   `pulumi:"fail,optional"`
+}
+
+// requireSpan fails unless ctx holds the span started by the tracing middleware.
+func requireSpan(ctx context.Context) error {
+	if !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+		return fmt.Errorf("missing span")
+	}
+	return nil
+}
+
+func (tracedWidget) Create(
+	ctx context.Context, name string, args tracedWidgetArgs, preview bool,
+) (string, tracedWidgetArgs, error) {
+	if args.Fail {
+		return "", args, fmt.Errorf("failed to create %s", args.Name)
+	}
+	return args.Name, args, requireSpan(ctx)
+}
+
+func (tracedWidget) Read(
+	ctx context.Context, id string, inputs tracedWidgetArgs, state tracedWidgetArgs,
+) (string, tracedWidgetArgs, tracedWidgetArgs, error) {
+	return id, inputs, state, requireSpan(ctx)
+}
+
+func (tracedWidget) Update(
+	ctx context.Context, id string, olds tracedWidgetArgs, news tracedWidgetArgs, preview bool,
+) (tracedWidgetArgs, error) {
+	return news, requireSpan(ctx)
+}
+
+func (tracedWidget) Delete(ctx context.Context, id string, props tracedWidgetArgs) error {
+	return requireSpan(ctx)
+}
+
+type tracedLookup struct{}
+
+func (tracedLookup) Call(ctx context.Context, args tracedWidgetArgs) (tracedWidgetArgs, error) {
+	return args, requireSpan(ctx)
+}
+
+func TestTracing(t *testing.T) {
+	t.Parallel()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	s := integration.NewServer("test", semver.MustParse("1.0.0"), tracing.Wrap(infer.Provider(infer.Options{
+		Resources: []infer.InferredResource{infer.Resource[tracedWidget, tracedWidgetArgs, tracedWidgetArgs]()},
+		Functions: []infer.InferredFunction{infer.Function[tracedLookup, tracedWidgetArgs, tracedWidgetArgs]()},
+	}), tracerProvider))
+
+	urn := resource.NewURN("stack", "proj", "", "test:tests:tracedWidget", "w")
+	props := func(name string, fail bool) resource.PropertyMap {
+		return resource.PropertyMap{
+			"name": resource.NewStringProperty(name),
+			"fail": resource.NewBoolProperty(fail),
+		}
+	}
+
+	_, err := s.Create(p.CreateRequest{Urn: urn, Properties: props("a", false)})
+	require.NoError(t, err)
+	_, err = s.Read(p.ReadRequest{ID: "a", Urn: urn, Properties: props("a", false), Inputs: props("a", false)})
+	require.NoError(t, err)
+	_, err = s.Update(p.UpdateRequest{ID: "a", Urn: urn, Olds: props("a", false), News: props("b", false)})
+	require.NoError(t, err)
+	require.NoError(t, s.Delete(p.DeleteRequest{ID: "a", Urn: urn, Properties: props("b", false)}))
+	_, err = s.Invoke(p.InvokeRequest{Token: "test:tests:tracedLookup", Args: props("c", false)})
+	require.NoError(t, err)
+	_, err = s.Create(p.CreateRequest{Urn: urn, Properties: props("a", true)})
+	require.Error(t, err)
+
+	resourceAttrs := func(id string) []attribute.KeyValue {
+		attrs := []attribute.KeyValue{
+			tracing.URNKey.String(string(urn)),
+			tracing.TokenKey.String("test:tests:tracedWidget"),
+		}
+		if id != "" {
+			attrs = append(attrs, tracing.IDKey.String(id))
+		}
+		return attrs
+	}
+	expected := []struct {
+		name  string
+		attrs []attribute.KeyValue
+		code  codes.Code
+	}{
+		{"Create", resourceAttrs(""), codes.Unset},
+		{"Read", resourceAttrs("a"), codes.Unset},
+		{"Update", resourceAttrs("a"), codes.Unset},
+		{"Delete", resourceAttrs("a"), codes.Unset},
+		{"Invoke", []attribute.KeyValue{tracing.TokenKey.String("test:tests:tracedLookup")}, codes.Unset},
+		{"Create", resourceAttrs(""), codes.Error},
+	}
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, len(expected))
+	for i, e := range expected {
+		span := spans[i]
+		assert.Equal(t, e.name, span.Name)
+		assert.Equal(t, trace.SpanKindServer, span.SpanKind)
+		assert.ElementsMatch(t, e.attrs, span.Attributes, "attributes of %s", e.name)
+		assert.Equal(t, e.code, span.Status.Code, "status of %s", e.name)
+		assert.False(t, span.EndTime.IsZero() || span.EndTime.Before(span.StartTime), "duration of %s", e.name)
+	}
+
+	failed := spans[len(spans)-1]
+	assert.Equal(t, "failed to create a", failed.Status.Description)
+	require.Len(t, failed.Events, 1)
+	assert.Equal(t, "exception", failed.Events[0].Name)
+}
+
+func TestTracingPanic(t *testing.T) {
+	t.Parallel()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	prov := tracing.Wrap(p.Provider{
+		Create: func(context.Context, p.CreateRequest) (p.CreateResponse, error) {
+			panic("boom")
+		},
+	}, tracerProvider)
+
+	urn := resource.NewURN("stack", "proj", "", "test:tests:tracedWidget", "w")
+	assert.PanicsWithValue(t, "boom", func() {
+		_, _ = prov.Create(context.Background(), p.CreateRequest{Urn: urn})
+	})
+
+	// The span of the operation is ended, and records that it panicked.
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "Create", spans[0].Name)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Equal(t, "the operation panicked", spans[0].Status.Description)
+}